@@ -3,22 +3,26 @@ package cmd
 
 import (
     "context"
+    "errors"
     "fmt"
     "os"
     "path/filepath"
-    "regexp"
+    "strconv"
     "strings"
+    "text/template"
     "time"
 
+    "github.com/cerberussg/tagger/pkg/audiotag"
     "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/cerberussg/tagger/pkg/enricher/deezer"
     "github.com/cerberussg/tagger/pkg/enricher/musicbrainz"
-    "github.com/dhowden/tag"
+    "github.com/cerberussg/tagger/pkg/tagger"
     "github.com/spf13/cobra"
     "github.com/spf13/viper"
 )
 
 var batchCmd = &cobra.Command{
-    Use:   "batch <folder>",
+    Use:   "batch <folder> | --from-file <list>",
     Short: "Process all AIFF files in a folder",
     Long: `Batch process all AIFF files in the specified folder, enriching
 metadata with record label, release date, and genre information.
@@ -26,16 +30,33 @@ metadata with record label, release date, and genre information.
 Examples:
   tagger batch ~/Music/DnB
   tagger batch ~/Downloads/new-releases --genre house --dry-run
-  tagger batch . --verbose`,
-    Args: cobra.ExactArgs(1),
+  tagger batch . --verbose
+  find ~/Music -name '*.aiff' -newer last-run | tagger batch --from-file -`,
+    Args: func(cmd *cobra.Command, args []string) error {
+        if fromFile != "" {
+            return cobra.MaximumNArgs(1)(cmd, args)
+        }
+        return cobra.ExactArgs(1)(cmd, args)
+    },
     Run:  runBatch,
 }
 
 var (
-    genreHint   string
-    recursive   bool
-    htmlReport  string
-    enrichData  bool
+    genreHint        string
+    recursive        bool
+    htmlReport       string
+    enrichData       bool
+    fetchArtwork     bool
+    forceArtwork     bool
+    jsonlOutput      bool
+    summaryLine      bool
+    overwriteGenre   bool
+    genreOverride    bool
+    tagGenreFromHint bool
+    noBatchTimeout   bool
+    labelOnly        bool
+    minConfidence    float64
+    writeMinConf     float64
 )
 
 func init() {
@@ -44,68 +65,224 @@ func init() {
     batchCmd.Flags().StringVarP(&genreHint, "genre", "g", "", "genre hint for better API matching (dnb, house, breakbeat, etc.)")
     batchCmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "process subdirectories recursively")
     batchCmd.Flags().StringVar(&htmlReport, "html-report", "", "generate HTML report of edge cases (e.g., --html-report edge-cases.html)")
+    batchCmd.Flags().BoolVar(&findDuplicates, "find-duplicates", false, "report files that look like the same track (same normalized artist and title), with their formats and sizes")
+    batchCmd.Flags().StringVar(&dedupeReport, "dedupe-report", "", "instead of enriching, write groups of duplicate files (same artist and title, or MusicBrainz recording ID) as HTML, or JSON/CSV by extension")
+    batchCmd.Flags().StringVar(&renameScript, "rename-script", "", "write suggested renames for edge-case files as a shell script, or a CSV mapping if the name ends in .csv")
+    batchCmd.Flags().StringVar(&templateReport, "template-report", "", "render every file's result through a Go template, as template:output (e.g., --template-report report.tmpl:report.md)")
+    batchCmd.Flags().StringVar(&reviewReport, "review-report", "", "generate HTML report of borderline matches, least confident first (e.g., --review-report review.html)")
+    batchCmd.Flags().Float64Var(&reviewMin, "review-min-confidence", 0.4, "lowest match confidence listed in the review report")
+    batchCmd.Flags().Float64Var(&reviewMax, "review-max-confidence", 0.7, "matches at or above this confidence are left out of the review report")
+    batchCmd.Flags().BoolVar(&confidenceReport, "confidence-report", false, "print a histogram of match confidences, to help pick --min-confidence")
+    batchCmd.Flags().StringVar(&confidenceJSON, "confidence-json", "", "write the match confidence histogram as JSON to this file (e.g., --confidence-json confidence.json)")
     batchCmd.Flags().BoolVar(&enrichData, "enrich", false, "enable metadata enrichment via API (respects --dry-run)")
+    batchCmd.Flags().BoolVar(&fetchArtwork, "artwork", false, "embed front cover art from the Cover Art Archive for enriched files")
+    batchCmd.Flags().BoolVar(&forceArtwork, "force-artwork", false, "replace existing embedded artwork (requires --artwork)")
+    batchCmd.Flags().BoolVar(&overwriteGenre, "overwrite-genre", false, "replace an existing genre tag with the enriched genre; labelled files are looked up for it too")
+    batchCmd.Flags().BoolVar(&overwriteGenre, "force-genre", false, "same as --overwrite-genre")
+    batchCmd.Flags().BoolVar(&appendGenreFlag, "append-genre", false, "add the enriched genre to a file's existing genres (deduplicated) instead of replacing them; existing genres no longer block it")
+    batchCmd.Flags().BoolVar(&replaceGenreFlag, "replace-genre", false, "write the enriched genre as the only genre (the default, unless write.genre_policy is append)")
+    batchCmd.Flags().StringSlice("overwrite", nil, "fields a match replaces even when the file has them: label, catalog, album, year, genre or all (default: only fill empty fields)")
+    batchCmd.Flags().BoolVar(&genreOverride, "genre-override", false, "write the --genre hint even when the provider supplies a genre")
+    batchCmd.Flags().BoolVar(&tagGenreFromHint, "tag-genre-from-hint", false, "write the --genre hint to files with no genre that enrichment doesn't tag (no match, already labelled, or --enrich off)")
+    batchCmd.Flags().StringVar(&fromFile, "from-file", "", "process the files listed in this file, one path per line (- for stdin), instead of walking a folder")
+    batchCmd.Flags().StringVar(&since, "since", "", "only process files modified after this (a duration like 24h, 7d or 2w, or a date like 2024-01-01)")
+    batchCmd.Flags().BoolVar(&labelOnly, "label-only", false, "only write label and catalog number; never touch any other tag")
+    batchCmd.Flags().Float64Var(&minConfidence, "min-confidence", 0.7, "minimum match confidence (0.0-1.0) for a lookup to succeed")
+    batchCmd.Flags().Float64Var(&writeMinConf, "write-min-confidence", 0, "minimum confidence required to write a match to the file (default: same as --min-confidence)")
+    batchCmd.Flags().IntVar(&maxAPICalls, "max-api-calls", 0, "stop making provider requests after this many (0 = use api.max_calls_per_run); remaining files are skipped")
+    batchCmd.Flags().StringVar(&yearRange, "year-range", "", "prefer releases from these years, e.g. 1993-1997, 1993- or 90s, for tracks reissued many times")
+    batchCmd.Flags().BoolVar(&allowBootleg, "allow-bootleg", false, "let bootleg, promo and other unofficial releases compete with official ones when choosing label and catalog number")
+    batchCmd.Flags().StringVar(&preferredFormat, "prefer-format", "", "prefer releases in this media format when dates tie (e.g. vinyl, cd, digital)")
+    batchCmd.Flags().BoolVar(&useSidecars, "sidecar", false, "use .nfo/.txt release info in each folder as lookup hints")
+    batchCmd.Flags().BoolVar(&offlineMode, "offline", false, "serve lookups only from the cache populated by 'tagger warm' (implies --enrich)")
+    batchCmd.Flags().BoolVar(&noBatchTimeout, "no-batch-timeout", false, "don't limit the total enrichment time of the run")
+    batchCmd.Flags().StringVar(&outputDir, "output-dir", "", "write enriched copies under this directory (keeping relative paths) and leave originals untouched")
+    batchCmd.Flags().BoolVar(&summaryLine, "summary-line", false, "end with a one-line summary of the run, printed even with --quiet")
+    batchCmd.Flags().BoolVar(&jsonlOutput, "jsonl", false, "stream one JSON object per file to stdout (human-readable output goes to stderr)")
+    batchCmd.Flags().Int("min-score", 50, "discard MusicBrainz recordings scoring below this (0-100) before matching")
+    batchCmd.Flags().Bool("parentheses-hints", false, "use a label, catalog number, year or album in the title's parentheses as a lookup hint")
+    
+    viper.BindPFlag("api.musicbrainz.min_score", batchCmd.Flags().Lookup("min-score"))
+    viper.BindPFlag("parsing.parentheses.hints", batchCmd.Flags().Lookup("parentheses-hints"))
+    viper.BindPFlag("write.overwrite", batchCmd.Flags().Lookup("overwrite"))
 }
 
 func runBatch(cmd *cobra.Command, args []string) {
-    folder := args[0]
+    // With --from-file the folder is optional; it only roots --output-dir
+    folder := "."
+    if len(args) > 0 {
+        folder = args[0]
+    }
+    
+    // In JSON-lines mode stdout is reserved for records, so route all
+    // human-readable output to stderr for the duration of the run
+    var jsonl *jsonlWriter
+    if jsonlOutput {
+        jsonl = newJSONLWriter(os.Stdout)
+        stdout := os.Stdout
+        os.Stdout = os.Stderr
+        defer func() { os.Stdout = stdout }()
+    }
+    if viper.GetBool("quiet") && viper.GetBool("verbose") {
+        fatalf("--quiet and --verbose can't be used together")
+        return
+    }
+    
+    // The one-line summary is printed last, after --quiet restores stdout
+    var summary *jsonlSummary
+    if summaryLine {
+        defer func() {
+            if summary != nil {
+                fmt.Fprintln(console, summary.line())
+            }
+        }()
+    }
+    defer quietOutput()()
     
     // Validate folder exists
     if !isValidDirectory(folder) {
-        fmt.Printf("Error: Directory '%s' does not exist or is not accessible\n", folder)
+        fatalf("Directory '%s' does not exist or is not accessible", folder)
         return
     }
 
     // Get absolute path
     absPath, err := filepath.Abs(folder)
     if err != nil {
-        fmt.Printf("Error: Could not resolve path '%s': %v\n", folder, err)
+        fatalf("Could not resolve path '%s': %v", folder, err)
         return
     }
 
-    fmt.Printf("Processing folder: %s\n", absPath)
+    if fromFile != "" {
+        fmt.Fprintf(console, "Processing files listed in: %s\n", fromFile)
+    } else {
+        fmt.Fprintf(console, "Processing folder: %s\n", absPath)
+    }
+    if outputDir != "" {
+        dir, err := validateOutputDir(absPath)
+        if err != nil {
+            fatalf("%v", err)
+            return
+        }
+        outputDir, outputRoot = dir, absPath
+        fmt.Fprintf(console, "Output directory: %s (originals will not be modified)\n", outputDir)
+    }
+    loadGenreMap()
     if genreHint != "" {
-        fmt.Printf("Genre hint: %s\n", genreHint)
+        fmt.Fprintf(console, "Genre hint: %s (written as %q)\n", genreHint, canonicalGenre(genreHint))
+    } else if genreOverride || tagGenreFromHint {
+        fmt.Fprintln(console, "Warning: --genre-override and --tag-genre-from-hint have no effect without --genre")
+    }
+    if labelOnly {
+        fmt.Fprintln(console, "LABEL ONLY: Only label and catalog number will be written")
+        if fetchArtwork || overwriteGenre || genreOverride || tagGenreFromHint || appendGenreFlag {
+            fmt.Fprintln(console, "Warning: --artwork, --force-genre, --append-genre, --genre-override and --tag-genre-from-hint have no effect with --label-only")
+        }
+    }
+    var reportTemplate *template.Template
+    var reportOutput string
+    if templateReport != "" {
+        reportTemplate, reportOutput, err = parseTemplateReport(templateReport)
+        if err != nil {
+            fatalf("%v", err)
+            return
+        }
+    }
+    if reviewReport != "" {
+        if reviewMin >= reviewMax {
+            fatalf("--review-min-confidence (%.2f) must be below --review-max-confidence (%.2f)", reviewMin, reviewMax)
+            return
+        }
+        if !enrichData && !offlineMode {
+            fmt.Fprintln(console, "Warning: --review-report has no effect without --enrich")
+        }
+    }
+    if wantConfidenceReport() && !enrichData && !offlineMode {
+        fmt.Fprintln(console, "Warning: --confidence-report and --confidence-json have no effect without --enrich")
+    }
+    loadHyphenLayouts()
+    loadOverwritePolicy()
+    if err := loadGenrePolicy(); err != nil {
+        fatalf("%v", err)
+        return
+    }
+    if !loadYearRange() {
+        return
+    }
+    if dedupeReport != "" {
+        if enrichData || offlineMode {
+            fmt.Fprintln(console, "Warning: --enrich and --offline have no effect with --dedupe-report; files are grouped, not enriched")
+            enrichData, offlineMode = false, false
+        }
+        fmt.Fprintf(console, "DEDUPE REPORT: Grouping duplicate files into %s\n", dedupeReport)
+    }
+    // Duplicate reports are read-only: overrides and hint genres aren't
+    // written either
+    if (findDuplicates || dedupeReport != "") && !viper.GetBool("dry-run") {
+        viper.Set("dry-run", true)
     }
     if viper.GetBool("dry-run") {
-        fmt.Println("DRY RUN: No files will be modified")
+        fmt.Fprintln(console, "DRY RUN: No files will be modified")
     }
-    if enrichData {
-        fmt.Println("ENRICHMENT: Enabled - will lookup missing metadata via MusicBrainz")
+    if offlineMode {
+        enrichData = true
+        fmt.Fprintln(console, "OFFLINE: Lookups will be served from the cache only")
+    } else if enrichData {
+        fmt.Fprintln(console, "ENRICHMENT: Enabled - will lookup missing metadata via MusicBrainz")
+    }
+    if enrichData && writeMinConf > 0 {
+        if writeMinConf < minConfidence {
+            fmt.Fprintf(console, "Warning: --write-min-confidence (%.2f) is below --min-confidence (%.2f) and has no effect; weaker matches are never found\n", writeMinConf, minConfidence)
+        } else if writeMinConf > minConfidence {
+            fmt.Fprintf(console, "WRITE THRESHOLD: Matches below %.2f confidence are reported but not written\n", writeMinConf)
+        }
     }
     
     // Initialize enricher if needed
     var metadataEnricher *enricher.Enricher
     if enrichData {
-        provider := musicbrainz.NewMusicBrainzProvider()
-        defer provider.Close()
-        
-        config := &enricher.EnricherConfig{
-            Strategy:       enricher.StrategyFirst,
-            MinConfidence:  0.7,
-            RequireLabel:   false,
-            RequestTimeout: 30 * time.Second,
-        }
-        
-        metadataEnricher = enricher.NewEnricher([]enricher.MetadataProvider{provider}, config)
+        metadataEnricher = newBatchEnricher()
         defer metadataEnricher.Close()
         
-        fmt.Printf("Enricher initialized with strategy: %s\n", config.Strategy)
+        lookups = newLookupDeduper()
+        lookupCache = openLookupCache()
+        defer saveCacheStats()
+        if offlineMode && lookupCache == nil {
+            fatalf("--offline requires a usable cache directory (see 'tagger doctor')")
+            return
+        }
     }
     
     // Find audio files
-    files, err := findAudioFiles(absPath, recursive, getSupportedExtensions())
-    if err != nil {
-        fmt.Printf("Error scanning directory: %v\n", err)
+    var files []string
+    if fromFile != "" {
+        files, err = readFileList(fromFile)
+        if err != nil {
+            fatalf("reading file list: %v", err)
+            return
+        }
+    } else {
+        files, err = findAudioFiles(absPath, recursive, getSupportedExtensions())
+        if err != nil {
+            fatalf("scanning directory: %v", err)
+            return
+        }
+    }
+
+    files, ok := applySince(files)
+    if !ok {
         return
     }
 
     if len(files) == 0 {
-        fmt.Println("No supported audio files found in the specified directory")
+        if fromFile != "" {
+            fmt.Fprintln(console, "No files listed")
+        } else {
+            fmt.Fprintln(console, "No supported audio files found in the specified directory")
+        }
         return
     }
 
-    fmt.Printf("Found %d audio files\n\n", len(files))
+    fmt.Fprintf(console, "Found %d audio files\n\n", len(files))
     
     // Track what needs enrichment and edge cases
     var needsEnrichment int
@@ -113,62 +290,175 @@ func runBatch(cmd *cobra.Command, args []string) {
     var errorCount int
     var enrichmentSuccess int
     var enrichmentFailed int
+    var writeFailed int
+    failureKinds := make(map[string]int)
+    var enrichmentUnwritten int
+    var genrePresent int
+    var budgetSkipped int
+    var offlineMisses int
+    var timeoutSkipped int
     
     // Edge case tracking - store full paths instead of just filenames
-    edgeCases := make(map[string][]string)
+    edgeLog := newEdgeCaseLog(htmlReport, len(files))
+    var reviewQueue []reviewEntry
+    var results []*fileResult
+    var renames []renameEntry
+    var heldBack []*fileResult
+    var duplicates *duplicateFinder
+    if findDuplicates || dedupeReport != "" {
+        duplicates = newDuplicateFinder()
+    }
+    var outcome runOutcome
+    var confidence confidenceHistogram
     
     // Context for API calls
     ctx := context.Background()
-    if enrichData {
-        // Add timeout for the entire batch process
+    var timeout time.Duration
+    if enrichData && !noBatchTimeout {
+        // Add timeout for the entire batch process, scaled to its size
+        timeout = batchTimeout(len(files))
         var cancel context.CancelFunc
-        ctx, cancel = context.WithTimeout(ctx, 10*time.Minute)
+        ctx, cancel = context.WithTimeout(ctx, timeout)
         defer cancel()
+        fmt.Fprintf(console, "Batch timeout: %s (disable with --no-batch-timeout)\n\n", timeout)
+    }
+    timeoutReported := false
+    
+    // Verbose runs already print a line per file
+    var bar *progress
+    if !viper.GetBool("verbose") && !viper.GetBool("quiet") {
+        bar = newProgress(os.Stderr, len(files), jsonl == nil && isTerminal(os.Stdout) && isTerminal(os.Stderr))
+        if enrichData {
+            bar.withRateFloor(apiBudget.Used, musicBrainzCallInterval)
+        }
     }
     
     // Process each file
     for i, file := range files {
         if viper.GetBool("verbose") {
-            fmt.Printf("[%d/%d] %s\n", i+1, len(files), file)
+            fmt.Fprintf(console, "[%d/%d] %s\n", i+1, len(files), file)
         }
         
-        status, edgeCase := processFileWithEdgeCase(file, metadataEnricher, ctx)
-        switch status {
+        var result *fileResult
+        if fromFile != "" {
+            result = checkListedFile(file)
+        }
+        if result == nil {
+            result = processFileWithEdgeCase(file, metadataEnricher, ctx)
+        }
+        if jsonl != nil {
+            jsonl.emitFile(result)
+        }
+        outcome.add(result)
+        
+        switch result.Status {
         case "needs_enrichment":
             needsEnrichment++
+        case "needs_enrichment_offline":
+            needsEnrichment++
+            offlineMisses++
         case "has_label":
             hasLabel++
         case "error":
             errorCount++
         case "enriched":
             enrichmentSuccess++
+            if result.GenreKept {
+                genrePresent++
+            }
+        case "enriched_low_confidence":
+            enrichmentSuccess++
+            enrichmentUnwritten++
+            heldBack = append(heldBack, result)
         case "enrichment_failed":
             enrichmentFailed++
+            failureKinds[result.FailureKind]++
+        case "write_failed":
+            writeFailed++
+        case "skipped_budget_exhausted":
+            budgetSkipped++
+        case "skipped_batch_timeout":
+            timeoutSkipped++
+        }
+        
+        bar.update(i + 1)
+        
+        if errors.Is(ctx.Err(), context.DeadlineExceeded) && !timeoutReported && i+1 < len(files) {
+            bar.clear()
+            timeoutReported = true
+            fmt.Fprintf(console, "\n⏱️  Batch timeout of %s reached after %d of %d files.\n", timeout, i+1, len(files))
+            fmt.Fprintf(console, "   Remaining files will only be enriched from the cache. Rerun to continue,\n")
+            fmt.Fprintf(console, "   or use --no-batch-timeout for large libraries.\n\n")
+        }
+        
+        if reportTemplate != nil {
+            results = append(results, result)
         }
         
         // Collect edge cases with full file paths
-        if edgeCase != "" {
-            edgeCases[edgeCase] = append(edgeCases[edgeCase], file)
+        if result.EdgeCase != "" {
+            edgeLog.add(result.EdgeCase, file)
+        }
+        edgeLog.progress(i + 1)
+        duplicates.add(result)
+        if rename, ok := renameCandidate(result); ok {
+            renames = append(renames, rename)
+        }
+        confidence.add(result)
+        if match := reviewMatch(result); match != nil {
+            reviewQueue = append(reviewQueue, reviewEntry{Result: result, Match: match})
         }
     }
     
+    bar.finish()
+    edgeCases := edgeLog.finish()
+    
     // Summary
-    fmt.Printf("\n=== SUMMARY ===\n")
-    fmt.Printf("Total files found: %d\n", len(files))
-    fmt.Printf("Files with label info: %d\n", hasLabel)
-    fmt.Printf("Files needing enrichment: %d\n", needsEnrichment)
+    fmt.Fprintf(console, "\n=== SUMMARY ===\n")
+    fmt.Fprintf(console, "Total files found: %d\n", len(files))
+    fmt.Fprintf(console, "Files with label info: %d\n", hasLabel)
+    fmt.Fprintf(console, "Files needing enrichment: %d\n", needsEnrichment)
+    if offlineMisses > 0 {
+        fmt.Fprintf(console, "  of which not in cache (offline): %d\n", offlineMisses)
+    }
     if errorCount > 0 {
-        fmt.Printf("Files with read errors: %d\n", errorCount)
+        fmt.Fprintf(console, "Files with read errors: %d\n", errorCount)
     }
     
     // Enrichment summary
     if enrichData {
-        fmt.Printf("\n=== ENRICHMENT RESULTS ===\n")
-        fmt.Printf("Successfully enriched: %d\n", enrichmentSuccess)
-        fmt.Printf("Enrichment failed: %d\n", enrichmentFailed)
+        fmt.Fprintf(console, "\n=== ENRICHMENT RESULTS ===\n")
+        fmt.Fprintf(console, "Successfully enriched: %d\n", enrichmentSuccess)
+        if enrichmentUnwritten > 0 {
+            fmt.Fprintf(console, "Enriched (not written, low confidence): %d\n", enrichmentUnwritten)
+            if !viper.GetBool("verbose") {
+                for _, result := range heldBack {
+                    fmt.Fprintf(console, "  %s\n", heldBackLine(result))
+                }
+            }
+        }
+        if genrePresent > 0 {
+            fmt.Fprintf(console, "Genre present (kept): %d\n", genrePresent)
+        }
+        fmt.Fprintf(console, "Enrichment failed: %d%s\n", enrichmentFailed, failureBreakdown(failureKinds))
+        if writeFailed > 0 {
+            fmt.Fprintf(console, "Matched but not written (write failed): %d\n", writeFailed)
+        }
+        if budgetSkipped > 0 {
+            fmt.Fprintf(console, "Skipped (budget exhausted): %d\n", budgetSkipped)
+        }
+        if timeoutSkipped > 0 {
+            fmt.Fprintf(console, "Skipped (batch timeout of %s reached): %d\n", timeout, timeoutSkipped)
+        }
+        if shared := sharedLookups(); shared > 0 {
+            total, unique := lookups.stats()
+            fmt.Fprintf(console, "Duplicate tracks sharing a lookup: %d (%d lookups for %d files, %.1f%% saved)\n",
+                shared, unique, total, float64(shared)/float64(total)*100)
+        }
+        printAPICallsUsed()
         if enrichmentSuccess > 0 {
-            successRate := float64(enrichmentSuccess) / float64(enrichmentSuccess+enrichmentFailed) * 100
-            fmt.Printf("Success rate: %.1f%%\n", successRate)
+            successRate := float64(enrichmentSuccess) / float64(enrichmentSuccess+enrichmentFailed+writeFailed) * 100
+            fmt.Fprintf(console, "Success rate: %.1f%%\n", successRate)
         }
     }
     
@@ -178,37 +468,185 @@ func runBatch(cmd *cobra.Command, args []string) {
         totalEdgeCases += len(files)
     }
     
+    summary = &jsonlSummary{
+        Total:            len(files),
+        HasLabel:         hasLabel,
+        NeedsEnrichment:  needsEnrichment,
+        Errors:           errorCount,
+        Enriched:         enrichmentSuccess,
+        NotWritten:       enrichmentUnwritten,
+        GenrePresent:     genrePresent,
+        EnrichmentFailed: enrichmentFailed,
+        WriteFailed:      writeFailed,
+        BudgetSkipped:    budgetSkipped,
+        TimeoutSkipped:   timeoutSkipped,
+        SharedLookups:    sharedLookups(),
+        OfflineMisses:    offlineMisses,
+        APICalls:         apiBudget.Used(),
+        EdgeCases:        totalEdgeCases,
+    }
+    if jsonl != nil {
+        jsonl.emitSummary(*summary)
+    }
+    
     if totalEdgeCases > 0 {
-        fmt.Printf("Files with parsing edge cases: %d\n", totalEdgeCases)
+        fmt.Fprintf(console, "Files with parsing edge cases: %d\n", totalEdgeCases)
         
-        fmt.Printf("\n=== EDGE CASES ===\n")
+        fmt.Fprintf(console, "\n=== EDGE CASES ===\n")
         for caseType, filePaths := range edgeCases {
-            fmt.Printf("\n%s (%d files):\n", strings.ToUpper(strings.Replace(caseType, "_", " ", -1)), len(filePaths))
+            fmt.Fprintf(console, "\n%s (%d files):\n", strings.ToUpper(strings.Replace(caseType, "_", " ", -1)), len(filePaths))
             for _, filePath := range filePaths {
                 filename := filepath.Base(filePath)
-                fmt.Printf("%s\n", filename)
+                fmt.Fprintf(console, "%s\n", filename)
             }
         }
     }
     
+    if findDuplicates {
+        if clusters := duplicates.clusters(); len(clusters) > 0 {
+            printDuplicates(clusters)
+        } else {
+            fmt.Fprintf(console, "\nNo likely duplicates found\n")
+        }
+    }
+    
+    if dedupeReport != "" {
+        clusters := duplicates.clusters()
+        if err := writeDuplicateReport(clusters, dedupeReport); err != nil {
+            fatalf("writing duplicate report: %v", err)
+        } else {
+            fmt.Fprintf(console, "\nDuplicate report written: %s (%d tracks with more than one file)\n", dedupeReport, len(clusters))
+        }
+    }
+    
     // Generate HTML report if requested
     if htmlReport != "" && totalEdgeCases > 0 {
         err := generateHTMLReport(edgeCases, htmlReport)
         if err != nil {
-            fmt.Printf("Error generating HTML report: %v\n", err)
+            fatalf("generating HTML report: %v", err)
+        } else {
+            fmt.Fprintf(console, "\nHTML report generated: %s\n", htmlReport)
+        }
+    }
+    
+    if renameScript != "" && len(renames) > 0 {
+        err := writeRenameScript(renames, renameScript)
+        if err != nil {
+            fatalf("writing rename script: %v", err)
+        } else {
+            fmt.Fprintf(console, "\nRename script written: %s (%d files)\n", renameScript, len(renames))
+        }
+    }
+    
+    if reviewReport != "" && enrichData {
+        err := generateReviewReport(reviewQueue, reviewReport)
+        if err != nil {
+            fatalf("generating review report: %v", err)
+        } else {
+            fmt.Fprintf(console, "\nReview report generated: %s (%d borderline matches)\n", reviewReport, len(reviewQueue))
+        }
+    }
+    
+    if confidenceReport && enrichData {
+        confidence.print(console)
+    }
+    if confidenceJSON != "" && enrichData {
+        err := confidence.writeJSON(confidenceJSON)
+        if err != nil {
+            fatalf("writing confidence histogram: %v", err)
         } else {
-            fmt.Printf("\nHTML report generated: %s\n", htmlReport)
+            fmt.Fprintf(console, "\nConfidence histogram written: %s (%d matches)\n", confidenceJSON, confidence.Matched)
+        }
+    }
+    
+    if reportTemplate != nil {
+        data := templateReportData{Folder: absPath, Generated: time.Now(), Results: results, Summary: *summary}
+        err := writeTemplateReport(reportTemplate, data, reportOutput)
+        if err != nil {
+            fatalf("generating template report: %v", err)
+        } else {
+            fmt.Fprintf(console, "\nTemplate report generated: %s (%d files)\n", reportOutput, len(results))
         }
     }
     
     if needsEnrichment > 0 {
         percentage := float64(needsEnrichment) / float64(len(files)) * 100
-        fmt.Printf("\nRecommendation: %.1f%% of your collection could benefit from metadata enrichment\n", percentage)
+        fmt.Fprintf(console, "\nRecommendation: %.1f%% of your collection could benefit from metadata enrichment\n", percentage)
+    } else {
+        fmt.Fprintln(console, "\nYour collection looks well-tagged! 🎉")
+    }
+    
+    if exitCode == exitOK {
+        exitCode = outcome.exitStatus()
+    }
+}
+
+// sharedLookups returns how many files reused another file's lookup
+func sharedLookups() int {
+    if lookups == nil {
+        return 0
+    }
+    total, unique := lookups.stats()
+    return total - unique
+}
+
+// Batch timeout scaling. At MusicBrainz's 1 request/second a file needs a
+// search, often a release lookup and maybe artwork, plus the odd retry.
+const (
+    minBatchTimeout     = 10 * time.Minute
+    batchTimeoutPerFile = 6 * time.Second
+)
+
+// batchTimeout returns the time allowed for enriching fileCount files
+func batchTimeout(fileCount int) time.Duration {
+    timeout := time.Duration(fileCount) * batchTimeoutPerFile
+    if timeout < minBatchTimeout {
+        return minBatchTimeout
+    }
+    return timeout
+}
+
+// printAPICallsUsed reports provider requests made, against the budget if
+// one is set
+func printAPICallsUsed() {
+    if apiBudget.Max() > 0 {
+        fmt.Fprintf(console, "API calls used: %d/%d\n", apiBudget.Used(), apiBudget.Max())
     } else {
-        fmt.Println("\nYour collection looks well-tagged! 🎉")
+        fmt.Fprintf(console, "API calls used: %d\n", apiBudget.Used())
     }
 }
 
+// newBatchEnricher builds the MusicBrainz-backed enricher from the batch
+// flags and config
+func newBatchEnricher() *enricher.Enricher {
+    apiBudget = enricher.NewCallBudget(apiCallLimit())
+    if apiBudget.Max() > 0 {
+        fmt.Fprintf(console, "API budget: %d calls for this run\n", apiBudget.Max())
+    }
+    provider := newMusicBrainzProvider(musicbrainz.WithCallBudget(apiBudget))
+    
+    config := &enricher.EnricherConfig{
+        Strategy:          enricher.StrategyFirst,
+        MinConfidence:     lookupMinConfidence(),
+        RequireLabel:      false,
+        MinRecordingScore: viper.GetInt("api.musicbrainz.min_score"),
+        RequestTimeout:    requestTimeout(),
+    }
+    
+    providers := []enricher.MetadataProvider{provider}
+    if dz := newDeezerProvider(deezer.WithCallBudget(apiBudget)); dz != nil {
+        providers = append(providers, dz)
+        fmt.Fprintf(console, "Supplementary provider: %s\n", dz.Name())
+    }
+    if external := newExternalProvider(); external != nil {
+        providers = append(providers, external)
+        fmt.Fprintf(console, "External provider: %s (%s)\n", external.Name(), viper.GetString("api.external.command"))
+    }
+    
+    fmt.Fprintf(console, "Enricher initialized with strategy: %s\n", config.Strategy)
+    return enricher.NewEnricher(providers, config)
+}
+
 func isValidDirectory(path string) bool {
     info, err := os.Stat(path)
     if err != nil {
@@ -220,7 +658,8 @@ func isValidDirectory(path string) bool {
 
 // getSupportedExtensions returns the currently supported audio file extensions
 func getSupportedExtensions() []string {
-    return []string{".aiff", ".aif"} // TODO: Add .mp3, .flac, .wav when implemented
+    // MP4 files are read and looked up but their tags aren't written yet
+    return []string{".aiff", ".aif", ".aifc", ".wav", ".mp3", ".flac", ".m4a", ".mp4"}
 }
 
 // findAudioFiles finds all supported audio files in a directory
@@ -275,470 +714,518 @@ func findAudioFiles(root string, recursive bool, extensions []string) ([]string,
 
 // Legacy function for backward compatibility - can be removed later
 func findAIFFFiles(root string, recursive bool) ([]string, error) {
-    return findAudioFiles(root, recursive, []string{".aiff", ".aif"})
+    return findAudioFiles(root, recursive, []string{".aiff", ".aif", ".aifc"})
+}
+
+// fileResult captures the outcome of processing a single file
+type fileResult struct {
+    Path     string                  `json:"path"`
+    Status   string                  `json:"status"`
+    EdgeCase string                  `json:"edge_case,omitempty"`
+    Artist   string                  `json:"artist,omitempty"`
+    Title    string                  `json:"title,omitempty"`
+    Album    string                  `json:"album,omitempty"`
+    Genre    string                  `json:"genre,omitempty"`
+    Year     int                     `json:"year,omitempty"`
+    Label    string                  `json:"label,omitempty"`
+    Enriched *enricher.TrackMetadata `json:"enriched,omitempty"`
+    Error    string                  `json:"error,omitempty"`
+    
+    // GenreKept is set when a match was written without touching the
+    // file's existing genre tag
+    GenreKept bool `json:"genre_kept,omitempty"`
+    
+    // FailureKind classifies a failed lookup: not_found, rate_limited,
+    // network, bad_response, auth or unknown
+    FailureKind string `json:"failure_kind,omitempty"`
+    
+    // NotFoundReason says why a lookup that ran matched nothing, e.g. "3
+    // candidates, best scored 0.45 (below 0.70)"
+    NotFoundReason string `json:"not_found_reason,omitempty"`
+    
+    // Candidate is the best match when it fell below --min-confidence; it
+    // is only found when --review-report or --confidence-report lowers the
+    // lookup threshold
+    Candidate *enricher.TrackMetadata `json:"candidate,omitempty"`
+    
+    // Swapped is set when the file only matched with artist and title
+    // swapped, i.e. it is named "Title - Artist"
+    Swapped bool `json:"swapped,omitempty"`
+    
+    // OutputPath is the enriched copy written with --output-dir
+    OutputPath string `json:"output_path,omitempty"`
+    
+    // RecordingID is the MusicBrainz recording ID already in the file
+    RecordingID string `json:"recording_id,omitempty"`
+    
+    // ISRC is the recording code already in the file (TSRC)
+    ISRC string `json:"isrc,omitempty"`
+    
+    // CatalogNumber is the catalog number already in the file
+    CatalogNumber string `json:"catalog_number,omitempty"`
+    
+    // Override is set when a .tagger.yaml rule corrected the file
+    Override bool `json:"override,omitempty"`
+    
+    // notFound is set when the lookup ran but matched nothing, which
+    // isn't a failure for the exit status
+    notFound bool
 }
 
-func processFileWithEdgeCase(filePath string, metadataEnricher *enricher.Enricher, ctx context.Context) (status, edgeCase string) {
+// trackInfo is what can be read about a file before any enrichment
+type trackInfo = tagger.TrackInfo
+
+// readTrackInfo reads embedded tags, falling back to parsing the filename
+// when the file has none
+func readTrackInfo(filePath string) (*trackInfo, error) {
     if viper.GetBool("verbose") {
-        fmt.Printf("  Reading metadata: %s\n", filePath)
+        fmt.Fprintf(console, "  Reading metadata: %s\n", filePath)
     }
     
-    // Try to read metadata using dhowden/tag
-    file, err := os.Open(filePath)
-    if err != nil {
-        if viper.GetBool("verbose") {
-            fmt.Printf("  ❌ Error opening file: %v\n", err)
+    info, err := tagger.ReadTrackInfo(filePath, filenameParser())
+    if err != nil && viper.GetBool("verbose") {
+        if errors.Is(err, audiotag.ErrUnreadable) {
+            // A damaged container, not just a missing tag - don't guess from the filename
+            fmt.Fprintf(console, "  ❌ Unreadable file: %v\n", err)
+        } else {
+            fmt.Fprintf(console, "  ❌ Error opening file: %v\n", err)
         }
-        return "error", ""
     }
-    defer file.Close()
-    
-    metadata, err := tag.ReadFrom(file)
-    
-    var title, artist, album, genre, labelInfo string
-    var hasLabel bool
-    var year int
-    var parseEdgeCase string
+    return info, err
+}
+
+func processFileWithEdgeCase(filePath string, metadataEnricher *enricher.Enricher, ctx context.Context) *fileResult {
+    result := &fileResult{Path: filePath}
     
+    info, err := readTrackInfo(filePath)
     if err != nil {
-        // No embedded tags - try filename parsing
-        if viper.GetBool("verbose") {
-            fmt.Printf("  ⚠️  No embedded tags found - parsing filename\n")
+        result.Status = "error"
+        result.Error = err.Error()
+        if errors.Is(err, audiotag.ErrUnreadable) {
+            result.EdgeCase = "unreadable_file"
         }
-        
-        parsedArtist, parsedTitle, edgeType := parseFilenameWithEdgeCase(filePath)
-        artist = parsedArtist
-        title = parsedTitle
-        parseEdgeCase = edgeType
-        
-    } else {
-        // Has embedded tags - use those
-        title = strings.TrimSpace(metadata.Title())
-        artist = strings.TrimSpace(metadata.Artist())
-        album = strings.TrimSpace(metadata.Album())
-        genre = strings.TrimSpace(metadata.Genre())
-        year = metadata.Year()
-        
-        // Check for label info in raw tags
-        if rawTags := metadata.Raw(); rawTags != nil {
-            if pub, ok := rawTags["TPUB"]; ok {
-                labelInfo = fmt.Sprintf("%v", pub)
-                hasLabel = labelInfo != ""
-            }
-            if txxx, ok := rawTags["TXXX"]; ok {
-                labelInfo = fmt.Sprintf("%v", txxx)
-                hasLabel = labelInfo != ""
+        return result
+    }
+    
+    pinned := applyOverride(filePath, info)
+    if pinned != nil {
+        result.Override = true
+        defer func() {
+            // An enriched file gets the pinned tags with the match, and
+            // one whose write failed won't take them on a second try
+            if result.Status != "enriched" && result.Status != "write_failed" {
+                writeOverride(result, pinned)
             }
-        }
+        }()
     }
     
+    req := prepareLookup(filePath, info)
+    
+    title, artist, album, genre, labelInfo := info.Title, info.Artist, info.Album, info.Genre, info.Label
+    year := info.Year
+    hasArtwork := info.HasArtwork
+    hasLabel := labelInfo != ""
+    parseEdgeCase := info.EdgeCase
+    
     hasBasicInfo := title != "" && artist != ""
     
+    result.EdgeCase = parseEdgeCase
+    result.Artist = artist
+    result.Title = title
+    result.Album = album
+    result.Genre = genre
+    result.Year = year
+    result.Label = labelInfo
+    result.CatalogNumber = info.CatalogNumber
+    result.RecordingID = info.RecordingID
+    result.ISRC = info.ISRC
+    
     if viper.GetBool("verbose") {
-        fmt.Printf("  Artist: %s\n", artist)
-        fmt.Printf("  Title: %s\n", title)
+        fmt.Fprintf(console, "  Artist: %s\n", artist)
+        fmt.Fprintf(console, "  Title: %s\n", title)
         if album != "" {
-            fmt.Printf("  Album: %s\n", album)
+            fmt.Fprintf(console, "  Album: %s\n", album)
         }
         if labelInfo != "" {
-            fmt.Printf("  Label: %s\n", labelInfo)
+            fmt.Fprintf(console, "  Label: %s\n", labelInfo)
         }
         if year > 0 {
-            fmt.Printf("  Year: %d\n", year)
+            fmt.Fprintf(console, "  Year: %d\n", year)
         }
         if genre != "" {
-            fmt.Printf("  Genre: %s\n", genre)
+            fmt.Fprintf(console, "  Genre: %s\n", genre)
+        }
+        if info.ISRC != "" {
+            fmt.Fprintf(console, "  ISRC: %s\n", info.ISRC)
         }
         
         if !hasBasicInfo {
             filename := filepath.Base(filePath)
-            fmt.Printf("  💡 Filename: %s\n", filename)
-            fmt.Printf("  ⚠️  Could not parse artist/title from filename\n")
+            fmt.Fprintf(console, "  💡 Filename: %s\n", filename)
+            fmt.Fprintf(console, "  ⚠️  Could not parse artist/title from filename\n")
         }
     }
     
     if !hasBasicInfo {
         if viper.GetBool("verbose") {
-            fmt.Printf("  📝 Unable to extract basic info - needs manual review\n")
+            fmt.Fprintf(console, "  📝 Unable to extract basic info - needs manual review\n")
         }
-        return "needs_enrichment", parseEdgeCase
+        result.Status = "needs_enrichment"
+        return result
     }
     
-    if hasLabel {
+    // Labelled files are only looked up when a match may replace something,
+    // so an existing genre alone never sends a file to the providers
+    if hasLabel && (metadataEnricher == nil || (len(overwritePolicy) == 0 && !overwriteGenre)) {
         if viper.GetBool("verbose") {
-            fmt.Printf("  ✅ Has label info\n")
+            fmt.Fprintf(console, "  ✅ Has label info\n")
         }
-        return "has_label", parseEdgeCase
+        writeHintGenre(result)
+        result.Status = "has_label"
+        return result
     } else {
         // Try enrichment if enabled and we have basic info
         if metadataEnricher != nil && hasBasicInfo {
             if viper.GetBool("verbose") {
-                fmt.Printf("  🔍 Attempting enrichment for: %s - %s\n", artist, title)
+                fmt.Fprintf(console, "  🔍 Attempting enrichment for: %s - %s\n", artist, title)
+            }
+            
+            enrichedData, shared, err := lookups.lookup(ctx, metadataEnricher, req)
+            if shared && viper.GetBool("verbose") {
+                fmt.Fprintf(console, "  ♻️  Reusing lookup from an identical track in this run\n")
             }
             
-            enrichedData, err := metadataEnricher.Lookup(ctx, artist, title)
+            // Some sources name files "Title - Artist"; if the lookup missed
+            // or is too weak to write, see whether the reverse does better
+            weak := err == nil && enrichedData != nil && enrichedData.Confidence < effectiveWriteMinConfidence()
+            if errors.Is(err, enricher.ErrNotFound) || weak {
+                if swappedReq, swapped := swappedLookup(ctx, metadataEnricher, req, enrichedData); swapped != nil {
+                    if viper.GetBool("verbose") {
+                        fmt.Fprintf(console, "  🔀 Artist and title look swapped - matched as: %s - %s\n", swappedReq.Artist, swappedReq.Title)
+                    }
+                    enrichedData, err = swapped, nil
+                    result.Artist, result.Title = swappedReq.Artist, swappedReq.Title
+                    result.Swapped = true
+                    if result.EdgeCase == "" {
+                        result.EdgeCase = "swapped_artist_title"
+                    }
+                }
+            }
+            var lowConfidence *lowConfidenceError
+            if errors.As(err, &lowConfidence) {
+                result.Candidate = lowConfidence.candidate
+            }
+            if errors.Is(err, enricher.ErrBudgetExhausted) {
+                if viper.GetBool("verbose") {
+                    fmt.Fprintf(console, "  ⏭️  Skipped (budget exhausted)\n")
+                }
+                result.Status = "skipped_budget_exhausted"
+                return result
+            }
+            if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+                if viper.GetBool("verbose") {
+                    fmt.Fprintf(console, "  ⏱️  Skipped (batch timeout reached)\n")
+                }
+                result.Status = "skipped_batch_timeout"
+                return result
+            }
+            if errors.Is(err, errCacheMiss) {
+                if viper.GetBool("verbose") {
+                    fmt.Fprintf(console, "  📴 Not in cache - needs enrichment (offline)\n")
+                }
+                result.Status = "needs_enrichment_offline"
+                return result
+            }
             if err != nil {
                 if viper.GetBool("verbose") {
-                    fmt.Printf("  ❌ Enrichment failed: %v\n", err)
+                    fmt.Fprintf(console, "  ❌ Enrichment failed: %v\n", err)
+                }
+                result.Status = "enrichment_failed"
+                result.Error = err.Error()
+                result.FailureKind = enricher.KindOf(err).String()
+                if errors.Is(err, enricher.ErrNotFound) {
+                    result.notFound = true
+                    result.NotFoundReason = notFoundReason(err)
+                    if viper.GetBool("verbose") {
+                        fmt.Fprintf(console, "  🔍 %s\n", result.NotFoundReason)
+                    }
+                    writeHintGenre(result)
                 }
-                return "enrichment_failed", parseEdgeCase
+                return result
             }
             
             if enrichedData != nil {
                 if viper.GetBool("verbose") {
-                    fmt.Printf("  🎉 Enrichment successful!\n")
-                    fmt.Printf("    Label: %s\n", enrichedData.Label)
-                    fmt.Printf("    Release Date: %s\n", enrichedData.ReleaseDate)
-                    fmt.Printf("    Confidence: %.2f\n", enrichedData.Confidence)
-                    if viper.GetBool("dry-run") {
-                        fmt.Printf("    📝 Would write metadata (dry-run mode)\n")
-                    } else {
-                        fmt.Printf("    📝 Writing metadata to file\n")
-                        // TODO: Implement actual metadata writing here
+                    fmt.Fprintf(console, "  🎉 Enrichment successful!\n")
+                    fmt.Fprintf(console, "    Label: %s\n", enrichedData.Label)
+                    fmt.Fprintf(console, "    Release Date: %s\n", enrichedData.ReleaseDate)
+                    fmt.Fprintf(console, "    Confidence: %.2f\n", enrichedData.Confidence)
+                }
+                result.Enriched = enrichedData
+                
+                // Matches below the write threshold are reported but not applied
+                if enrichedData.Confidence < effectiveWriteMinConfidence() {
+                    if viper.GetBool("verbose") {
+                        fmt.Fprintf(console, "    ⚠️  Not writing: confidence %.2f below write threshold %.2f\n", enrichedData.Confidence, effectiveWriteMinConfidence())
+                    }
+                    result.Status = "enriched_low_confidence"
+                    return result
+                }
+                
+                update := buildTagUpdate(result, enrichedData)
+                if pinned != nil {
+                    mergeOverride(update, pinned)
+                }
+                if genreKept(result, update) {
+                    if viper.GetBool("verbose") && appendGenre {
+                        fmt.Fprintf(console, "    🎼 Genre present (%s) - nothing new to append\n", result.Genre)
+                    } else if viper.GetBool("verbose") {
+                        fmt.Fprintf(console, "    🎼 Genre present (%s) - keeping it (use --force-genre to replace)\n", result.Genre)
                     }
+                    result.GenreKept = true
+                }
+                if fetchArtwork && !offlineMode && !labelOnly {
+                    update.Artwork = artworkForFile(ctx, metadataEnricher, enrichedData, hasArtwork)
                 }
-                return "enriched", parseEdgeCase
+                written, err := writeTagUpdate(filePath, update)
+                if written != filePath {
+                    result.OutputPath = written
+                }
+                if err != nil {
+                    if viper.GetBool("verbose") {
+                        fmt.Fprintf(console, "    ❌ Failed to write metadata: %v\n", err)
+                    }
+                    result.Error = err.Error()
+                    result.Status = "write_failed"
+                    return result
+                }
+                result.Status = "enriched"
+                return result
             }
         }
         
         if viper.GetBool("verbose") {
-            fmt.Printf("  📝 Ready for label enrichment via API\n")
+            fmt.Fprintf(console, "  📝 Ready for label enrichment via API\n")
         }
-        return "needs_enrichment", parseEdgeCase
+        writeHintGenre(result)
+        result.Status = "needs_enrichment"
+        return result
     }
 }
 
-// cleanFilename performs comprehensive cleanup of parsed artist and title
-func cleanFilename(text string) string {
-    if text == "" {
-        return text
+// effectiveWriteMinConfidence returns the confidence a match needs before it
+// is written, defaulting to the lookup threshold
+func effectiveWriteMinConfidence() float64 {
+    if writeMinConf > 0 {
+        return writeMinConf
     }
-    
-    // Replace underscores with spaces
-    text = strings.ReplaceAll(text, "_", " ")
-    
-    // Replace + with & (common in artist collaborations)
-    text = strings.ReplaceAll(text, "+", " & ")
-    
-    // Remove track numbers from titles/artists
-    // Patterns like "01 Title", "1. Title", "A1 Title", "Title 01", "Title (01)"
-    patterns := []string{
-        `^\d+\.?\s+`,              // "01 " or "1. " at start
-        `^\d+\s*-\s*`,             // "01-" or "1 - " at start  
-        `^[A-Z]\d+\s+`,            // "A1 " or "B2 " at start
-        `^[A-Z]\d+\s*-\s*`,        // "A1-" or "B2 - " at start
-        `\s+\d+$`,                 // " 01" at end
-        `\s*\(\d+\)$`,             // " (01)" at end
-        `\s*-\s*\d+$`,             // " - 01" at end
-    }
-    
-    for _, pattern := range patterns {
-        re := regexp.MustCompile(pattern)
-        text = re.ReplaceAllString(text, "")
+    return minConfidence
+}
+
+// heldBackLine describes a match that was found but not written because it
+// fell below the write threshold
+func heldBackLine(result *fileResult) string {
+    match := result.Enriched
+    line := fmt.Sprintf("%.2f  %s -> %s - %s", match.Confidence, filepath.Base(result.Path), match.Artist, match.Title)
+    if match.Label != "" {
+        line += fmt.Sprintf(" [%s]", match.Label)
     }
-    
-    // Clean up multiple spaces and trim
-    re := regexp.MustCompile(`\s+`)
-    text = re.ReplaceAllString(text, " ")
-    text = strings.TrimSpace(text)
-    
-    return text
+    return line
 }
 
-// parseFilenameWithEdgeCase attempts to extract artist and title from filename
-// Also returns edge case type if encountered
-func parseFilenameWithEdgeCase(filePath string) (artist, title, edgeCase string) {
-    filename := filepath.Base(filePath)
-    
-    // Remove file extension
-    name := strings.TrimSuffix(filename, filepath.Ext(filename))
-    
-    // Clean up common prefixes first (track numbers, etc.)
-    name = cleanTrackPrefix(name)
-    
-    // Count hyphens to determine parsing strategy
-    hyphenCount := strings.Count(name, "-")
-    
-    if viper.GetBool("verbose") {
-        fmt.Printf("  🔍 Parsing filename: %s (hyphens: %d)\n", name, hyphenCount)
+// failureKindOrder is the order failure kinds are listed in the summary
+var failureKindOrder = []enricher.ErrorKind{
+    enricher.KindNotFound,
+    enricher.KindRateLimited,
+    enricher.KindNetwork,
+    enricher.KindBadResponse,
+    enricher.KindAuth,
+    enricher.KindUnknown,
+}
+
+// failureBreakdown summarizes failed lookups by kind, e.g.
+// " (12 not found, 2 network)", or "" when there were none
+func failureBreakdown(kinds map[string]int) string {
+    var parts []string
+    for _, kind := range failureKindOrder {
+        if n := kinds[kind.String()]; n > 0 {
+            parts = append(parts, fmt.Sprintf("%d %s", n, strings.Replace(kind.String(), "_", " ", -1)))
+        }
     }
-    
-    switch hyphenCount {
-    case 0:
-        // No hyphens - can't reliably parse
-        artist, title = handleEdgeCase(name, "no_hyphens")
-        edgeCase = "no_hyphens"
-        
-    case 1:
-        // Artist - Title
-        artist, title = parseOneHyphen(name)
-        edgeCase = ""
-        
-    case 2:
-        // Artist - Album - Title
-        artist, title = parseTwoHyphens(name)
-        edgeCase = ""
-        
-    case 3:
-        // Edge case - needs manual review or special handling
-        artist, title = handleEdgeCase(name, "three_hyphens")
-        edgeCase = "three_hyphens"
-        
-    case 4:
-        // Artist/Part - Album/Part - Title
-        // Replace 1st and 3rd hyphens with slashes
-        artist, title = parseFourHyphens(name)
-        edgeCase = ""
-        
-    default:
-        // 5+ hyphens - likely very complex, needs edge case handling
-        artist, title = handleEdgeCase(name, "many_hyphens")
-        edgeCase = "many_hyphens"
+    if len(parts) == 0 {
+        return ""
     }
-    
-    return artist, title, edgeCase
+    return " (" + strings.Join(parts, ", ") + ")"
 }
 
-// parseFilename attempts to extract artist and title from filename
-// Handles complex hyphen patterns common in D&B collections
-func parseFilename(filePath string) (artist, title string) {
-    artist, title, _ = parseFilenameWithEdgeCase(filePath)
-    return artist, title
+// buildTagUpdate maps enriched metadata onto tag frames, filling only the
+// fields the file doesn't already have unless --overwrite names them
+func buildTagUpdate(existing *fileResult, enrichedData *enricher.TrackMetadata) *audiotag.Update {
+    info := &trackInfo{
+        Album:         existing.Album,
+        Genre:         existing.Genre,
+        Label:         existing.Label,
+        Year:          existing.Year,
+        RecordingID:   existing.RecordingID,
+        CatalogNumber: existing.CatalogNumber,
+    }
+    return tagger.BuildUpdate(info, enrichedData, tagger.Options{
+        OverwriteGenre: overwriteGenre,
+        AppendGenre:    appendGenre,
+        Overwrite:      overwritePolicy,
+        LabelOnly:      labelOnly,
+        Genre:          enrichedGenre,
+    })
 }
 
-func parseOneHyphen(name string) (artist, title string) {
-    parts := strings.SplitN(name, "-", 2)
-    if len(parts) == 2 {
-        artist = cleanFilename(strings.TrimSpace(parts[0]))
-        title = cleanFilename(strings.TrimSpace(parts[1]))
-        return artist, title
-    }
-    return "", ""
+// genreKept reports whether update leaves the file's existing genre tag as
+// it is. --label-only never writes genres, so nothing is kept there.
+func genreKept(existing *fileResult, update *audiotag.Update) bool {
+    return existing.Genre != "" && update.Genre == "" && !labelOnly
 }
 
-func parseTwoHyphens(name string) (artist, title string) {
-    parts := strings.SplitN(name, "-", 3)
-    if len(parts) == 3 {
-        artist = cleanFilename(strings.TrimSpace(parts[0]))
-        // Skip album (parts[1]) for now - we just want artist/title
-        title = cleanFilename(strings.TrimSpace(parts[2]))
-        return artist, title
+// enrichedGenre picks the genre to write for a match: the provider's, unless
+// it has none or --genre-override is set, in which case the --genre hint is
+// used. Either way it is written in canonical form.
+func enrichedGenre(enrichedData *enricher.TrackMetadata) string {
+    if genreHint != "" && (genreOverride || enrichedData.Genre == "") {
+        return canonicalGenre(genreHint)
     }
-    return "", ""
+    return canonicalGenre(enrichedData.Genre)
 }
 
-func parseFourHyphens(name string) (artist, title string) {
-    parts := strings.SplitN(name, "-", 5)
-    if len(parts) == 5 {
-        // Reconstruct artist: parts[0] / parts[1]
-        artistPart1 := cleanFilename(strings.TrimSpace(parts[0]))
-        artistPart2 := cleanFilename(strings.TrimSpace(parts[1]))
-        artist = artistPart1 + "/" + artistPart2
-        
-        // Skip album parts[2]/parts[3] 
-        title = cleanFilename(strings.TrimSpace(parts[4]))
-        
+// writeHintGenre writes the --genre hint to a file that gets no enriched
+// metadata, when --tag-genre-from-hint is set and the file has no genre.
+// Enriched files already fall back to the hint in enrichedGenre.
+func writeHintGenre(result *fileResult) {
+    if !tagGenreFromHint || genreHint == "" || labelOnly || result.Genre != "" {
+        return
+    }
+    
+    update := &audiotag.Update{Genre: canonicalGenre(genreHint)}
+    if viper.GetBool("verbose") {
+        fmt.Fprintf(console, "  🏷️  No genre - tagging with hint: %s\n", update.Genre)
+    }
+    written, err := writeTagUpdate(result.Path, update)
+    if written != "" && written != result.Path {
+        result.OutputPath = written
+    }
+    if err != nil {
         if viper.GetBool("verbose") {
-            albumPart1 := cleanFilename(strings.TrimSpace(parts[2]))
-            albumPart2 := cleanFilename(strings.TrimSpace(parts[3]))
-            album := albumPart1 + "/" + albumPart2
-            fmt.Printf("  📀 Detected album: %s\n", album)
+            fmt.Fprintf(console, "    ❌ Failed to write genre: %v\n", err)
         }
-        
-        return artist, title
+        result.Error = err.Error()
     }
-    return "", ""
 }
 
-func handleEdgeCase(name string, caseType string) (artist, title string) {
-    if viper.GetBool("verbose") {
-        fmt.Printf("  ⚠️  Edge case (%s): %s\n", caseType, name)
-        fmt.Printf("  💡 Consider manual review or custom parsing rule\n")
+// writeTagUpdate writes the update to the file, or to a copy of it under
+// --output-dir, and returns the path written. In dry-run mode it only
+// describes what would be written. Formats that can't be written yet (MP4)
+// are left alone.
+func writeTagUpdate(filePath string, update *audiotag.Update) (string, error) {
+    if update.IsEmpty() {
+        return "", nil
     }
-    
-    // For edge cases, try some fallback strategies
-    switch caseType {
-    case "no_hyphens":
-        // Maybe it's "Artist Title" with spaces?
-        artist, title = trySpaceSeparated(name)
-        
-    case "three_hyphens":
-        // Try treating as Artist - Album - Extra - Title
-        artist, title = tryThreeHyphenFallback(name)
-        
-    case "many_hyphens":
-        // Try to find the most likely artist-title split
-        artist, title = tryManyHyphenFallback(name)
-        
-    default:
-        return "", ""
+    if !audiotag.CanWrite(filePath) {
+        if viper.GetBool("verbose") {
+            fmt.Fprintf(console, "    ℹ️  Writing %s tags isn't supported yet - leaving the file unchanged\n", strings.ToLower(filepath.Ext(filePath)))
+        }
+        return "", nil
     }
     
-    // Apply final cleaning to results from edge case handling
-    artist = cleanFilename(artist)
-    title = cleanFilename(title)
+    if viper.GetBool("dry-run") {
+        if viper.GetBool("verbose") {
+            if outputDir != "" {
+                fmt.Fprintf(console, "    📝 Would copy to %s and write metadata (dry-run mode)\n", outputDir)
+            } else {
+                fmt.Fprintf(console, "    📝 Would write metadata (dry-run mode)\n")
+            }
+            for _, change := range describeUpdate(update) {
+                fmt.Fprintf(console, "       %s\n", change)
+            }
+        }
+        return "", nil
+    }
     
-    return artist, title
-}
-
-func trySpaceSeparated(name string) (artist, title string) {
-    // Look for patterns like "ArtistName SongTitle"
-    // This is tricky without more context, so be conservative
-    words := strings.Fields(name)
-    if len(words) >= 2 {
-        // Simple heuristic: first word(s) as artist, rest as title
-        // But this is unreliable, so only do it if we're confident
-        if len(words) == 2 {
-            return words[0], words[1]
+    target := filePath
+    if outputDir != "" {
+        copied, err := copyForWriting(filePath)
+        if err != nil {
+            return "", err
         }
+        target = copied
     }
-    return "", ""
-}
-
-func tryThreeHyphenFallback(name string) (artist, title string) {
-    parts := strings.SplitN(name, "-", 4)
-    if len(parts) == 4 {
-        // Try different interpretations:
-        // 1. Artist - Album Part 1 - Album Part 2 - Title
-        artist = strings.TrimSpace(parts[0])
-        title = strings.TrimSpace(parts[3])
-        
-        if viper.GetBool("verbose") {
-            fmt.Printf("  🤔 Guessing: Artist=%s, Title=%s\n", artist, title)
+    
+    if viper.GetBool("verbose") {
+        if target != filePath {
+            fmt.Fprintf(console, "    📝 Writing metadata to copy %s\n", target)
+        } else {
+            fmt.Fprintf(console, "    📝 Writing metadata to file\n")
         }
-        
-        return artist, title
     }
-    return "", ""
+    return target, audiotag.WriteFile(target, update)
 }
 
-func tryManyHyphenFallback(name string) (artist, title string) {
-    // For 5+ hyphens, try to find the longest reasonable artist name
-    // and assume everything after the last 1-2 hyphens is the title
-    parts := strings.Split(name, "-")
-    if len(parts) >= 3 {
-        // Take first part as artist, last part as title
-        artist = strings.TrimSpace(parts[0])
-        title = strings.TrimSpace(parts[len(parts)-1])
-        
-        if viper.GetBool("verbose") {
-            fmt.Printf("  🎯 Best guess: Artist=%s, Title=%s\n", artist, title)
+// describeUpdate lists the fields an update would write, one per line, for
+// dry-run output
+func describeUpdate(update *audiotag.Update) []string {
+    var changes []string
+    add := func(field, value string) {
+        if value != "" {
+            changes = append(changes, fmt.Sprintf("%s: %s", field, value))
         }
-        
-        return artist, title
     }
-    return "", ""
+    
+    add("Artist", update.Artist)
+    add("Title", update.Title)
+    add("Label", update.Label)
+    add("Catalog number", update.CatalogNumber)
+    add("Album", update.Album)
+    if update.Year > 0 {
+        add("Year", strconv.Itoa(update.Year))
+    }
+    add("Genre", update.Genre)
+    if update.Artwork != nil {
+        add("Artwork", fmt.Sprintf("%s, %d bytes", update.Artwork.MIMEType, len(update.Artwork.Data)))
+    }
+    add("MusicBrainz recording ID", update.RecordingID)
+    return changes
 }
 
-// cleanTrackPrefix removes common prefixes from the entire filename (updated)
-func cleanTrackPrefix(name string) string {
-    // First replace underscores with spaces for better pattern matching
-    name = strings.ReplaceAll(name, "_", " ")
+// artworkForFile fetches cover art for an enriched file. It returns nil when
+// the file already has artwork (unless --force-artwork), none is available,
+// or in dry-run mode, where nothing is downloaded.
+func artworkForFile(ctx context.Context, metadataEnricher *enricher.Enricher, enrichedData *enricher.TrackMetadata, hasArtwork bool) *audiotag.Picture {
+    verbose := viper.GetBool("verbose")
     
-    // Remove track numbers like "01 ", "1. ", "A1 ", "B2 ", etc.
-    patterns := []string{
-        `^\d+\.?\s+`,        // "01 " or "1. "
-        `^\d+\s*-\s*`,       // "01-" or "1 - "
-        `^[A-Z]\d+\s+`,      // "A1 " or "B2 "
-        `^[A-Z]\d+\s*-\s*`,  // "A1-" or "B2 - "
+    if hasArtwork && !forceArtwork {
+        if verbose {
+            fmt.Fprintf(console, "    🖼️  Artwork already embedded - skipping (use --force-artwork to replace)\n")
+        }
+        return nil
     }
     
-    for _, pattern := range patterns {
-        re := regexp.MustCompile(pattern)
-        name = re.ReplaceAllString(name, "")
+    if viper.GetBool("dry-run") {
+        if verbose {
+            fmt.Fprintf(console, "    🖼️  Would fetch artwork (dry-run mode)\n")
+        }
+        return nil
     }
     
-    return strings.TrimSpace(name)
-}
-
-// generateHTMLReport creates an HTML file showing edge cases with links to file locations
-func generateHTMLReport(edgeCases map[string][]string, outputPath string) error {
-    file, err := os.Create(outputPath)
+    artwork, err := metadataEnricher.FetchArtwork(ctx, enrichedData)
     if err != nil {
-        return err
-    }
-    defer file.Close()
-    
-    // HTML header
-    html := `<!DOCTYPE html>
-<html>
-<head>
-    <title>Library Edge Cases</title>
-    <style>
-        body { font-family: Arial, sans-serif; margin: 40px; }
-        h1 { color: #333; }
-        h2 { color: #666; margin-top: 30px; }
-        table { border-collapse: collapse; width: 100%; margin-bottom: 20px; }
-        th, td { border: 1px solid #ddd; padding: 12px; text-align: left; }
-        th { background-color: #f2f2f2; }
-        tr:nth-child(even) { background-color: #f9f9f9; }
-        .description { background-color: #f0f8ff; padding: 15px; border-radius: 5px; margin-bottom: 20px; }
-        .path { font-family: monospace; font-size: 0.9em; color: #666; word-break: break-all; cursor: pointer; }
-        .path:hover { background-color: #f0f0f0; }
-        .copy-hint { font-size: 0.8em; color: #888; font-style: italic; }
-    </style>
-    <script>
-        function copyToClipboard(text) {
-            navigator.clipboard.writeText(text).then(function() {
-                alert('Path copied to clipboard!');
-            }).catch(function() {
-                // Fallback for older browsers
-                prompt('Copy this path:', text);
-            });
-        }
-    </script>
-</head>
-<body>
-    <h1>Library Edge Cases</h1>
-    <div class="description">
-        <p>These files have naming patterns that couldn't be automatically parsed for artist and title extraction. 
-        They may need manual review or custom parsing rules.</p>
-        <p><strong>💡 Tip:</strong> Click on any path to copy it to your clipboard, then use ⌘+Shift+G in Finder to navigate there.</p>
-        <p><strong>Edge Case Types:</strong></p>
-        <ul>
-            <li><strong>No Hyphens:</strong> Files without hyphen separators</li>
-            <li><strong>Three Hyphens:</strong> Ambiguous patterns requiring manual review</li>
-            <li><strong>Many Hyphens:</strong> Complex patterns with 5+ hyphens</li>
-        </ul>
-    </div>
-`
-    
-    // Add each edge case category
-    for caseType, filePaths := range edgeCases {
-        categoryTitle := strings.ToUpper(strings.Replace(caseType, "_", " ", -1))
-        html += fmt.Sprintf(`
-    <h2>%s (%d files)</h2>
-    <table>
-        <thead>
-            <tr>
-                <th>File Name</th>
-                <th>Path (Click to Copy)</th>
-            </tr>
-        </thead>
-        <tbody>
-`, categoryTitle, len(filePaths))
-        
-        for _, filePath := range filePaths {
-            filename := filepath.Base(filePath)
-            directory := filepath.Dir(filePath)
-            
-            html += fmt.Sprintf(`            <tr>
-                <td>%s</td>
-                <td class="path" onclick="copyToClipboard('%s')" title="Click to copy path">%s<br><span class="copy-hint">📋 Click to copy</span></td>
-            </tr>
-`, filename, directory, directory)
+        if verbose {
+            if errors.Is(err, enricher.ErrNotFound) {
+                fmt.Fprintf(console, "    🖼️  No artwork available\n")
+            } else {
+                fmt.Fprintf(console, "    ❌ Artwork fetch failed: %v\n", err)
+            }
         }
-        
-        html += `        </tbody>
-    </table>
-`
+        return nil
     }
     
-    // HTML footer
-    html += `
-</body>
-</html>`
-    
-    _, err = file.WriteString(html)
-    return err
-}
\ No newline at end of file
+    if verbose {
+        fmt.Fprintf(console, "    🖼️  Found artwork (%s, %d bytes)\n", artwork.MIMEType, len(artwork.Data))
+    }
+    return &audiotag.Picture{MIMEType: artwork.MIMEType, Data: artwork.Data}
+}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/audiotag"
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/cerberussg/tagger/pkg/tagger"
+    "github.com/spf13/viper"
+)
+
+func TestBuildTagUpdate_Genre(t *testing.T) {
+    enriched := &enricher.TrackMetadata{Label: "Metalheadz", Genre: "Drum and Bass"}
+
+    testCases := []struct {
+        name          string
+        existingGenre string
+        overwrite     bool
+        expected      string
+        kept          bool
+    }{
+        {"empty genre is filled", "", false, "Drum and Bass", false},
+        {"curated genre is kept", "Jungle", false, "", true},
+        {"overwrite replaces curated genre", "Jungle", true, "Drum and Bass", false},
+    }
+
+    defer func() { overwriteGenre = false }()
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            overwriteGenre = tc.overwrite
+
+            existing := &fileResult{Genre: tc.existingGenre}
+            update := buildTagUpdate(existing, enriched)
+            if update.Genre != tc.expected {
+                t.Errorf("Expected genre %q, got %q", tc.expected, update.Genre)
+            }
+            if got := genreKept(existing, update); got != tc.kept {
+                t.Errorf("Expected genreKept %v, got %v", tc.kept, got)
+            }
+        })
+    }
+}
+
+func TestBuildTagUpdate_RecordingID(t *testing.T) {
+    enriched := &enricher.TrackMetadata{
+        Label: "FFRR",
+        Extra: map[string]interface{}{"musicbrainz_recording_id": "b1a9c0e9-d987-4042-ae91-78d6a3267d69"},
+    }
+    
+    update := buildTagUpdate(&fileResult{}, enriched)
+    if update.RecordingID != "b1a9c0e9-d987-4042-ae91-78d6a3267d69" {
+        t.Errorf("Expected recording ID to be written, got %q", update.RecordingID)
+    }
+    
+    update = buildTagUpdate(&fileResult{RecordingID: "b1a9c0e9-d987-4042-ae91-78d6a3267d69"}, enriched)
+    if update.RecordingID != "" {
+        t.Errorf("Expected an unchanged recording ID not to be rewritten, got %q", update.RecordingID)
+    }
+}
+
+func TestBuildTagUpdate_GenreHint(t *testing.T) {
+    testCases := []struct {
+        name          string
+        providerGenre string
+        override      bool
+        expected      string
+    }{
+        {"hint fills missing provider genre", "", false, "Drum and Bass"},
+        {"provider genre wins by default", "Jungle", false, "Jungle"},
+        {"override forces hint", "Jungle", true, "Drum and Bass"},
+    }
+
+    genreHint = "dnb"
+    defer func() { genreHint, genreOverride = "", false }()
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            genreOverride = tc.override
+
+            enriched := &enricher.TrackMetadata{Label: "Metalheadz", Genre: tc.providerGenre}
+            update := buildTagUpdate(&fileResult{}, enriched)
+            if update.Genre != tc.expected {
+                t.Errorf("Expected genre %q, got %q", tc.expected, update.Genre)
+            }
+        })
+    }
+}
+
+func TestEnrichedGenre_Aliases(t *testing.T) {
+    viper.Set("genres.aliases", map[string]string{"jungle": "Drum & Bass", "dnb": "Drum & Bass"})
+    defer func() {
+        viper.Set("genres.aliases", nil)
+        loadGenreMap()
+    }()
+    loadGenreMap()
+    
+    for _, providerGenre := range []string{"drum and bass", "D&B", "Jungle", "drum n bass"} {
+        enriched := &enricher.TrackMetadata{Genre: providerGenre}
+        if got := enrichedGenre(enriched); got != "Drum & Bass" {
+            t.Errorf("Expected provider genre %q written as \"Drum & Bass\", got %q", providerGenre, got)
+        }
+    }
+    
+    genreHint = "dnb"
+    defer func() { genreHint = "" }()
+    if got := enrichedGenre(&enricher.TrackMetadata{}); got != "Drum & Bass" {
+        t.Errorf("Expected the hint mapped through the aliases, got %q", got)
+    }
+}
+
+func TestBatchTimeout(t *testing.T) {
+    if got := batchTimeout(10); got != minBatchTimeout {
+        t.Errorf("Expected small batches to get the %s minimum, got %s", minBatchTimeout, got)
+    }
+    if got := batchTimeout(5000); got != 5000*batchTimeoutPerFile {
+        t.Errorf("Expected large batches to scale with file count, got %s", got)
+    }
+}
+
+func TestParseFilename_HyphenLayouts(t *testing.T) {
+    layout, err := tagger.ParseHyphenLayout("Artist - Album - Catno - Title", 3)
+    if err != nil {
+        t.Fatalf("ParseHyphenLayout returned error: %v", err)
+    }
+    hyphenLayouts = map[int]tagger.HyphenLayout{3: layout, 1: nil}
+    defer func() { hyphenLayouts = nil }()
+    
+    artist, title, edgeCase := parseFilenameWithEdgeCase("/music/Photek - Modus Operandi - SCI 001 - The Hidden Camera.aiff")
+    if artist != "Photek" || title != "The Hidden Camera" || edgeCase != "" {
+        t.Errorf("Expected 'Photek' / 'The Hidden Camera', got %q / %q (edge case %q)", artist, title, edgeCase)
+    }
+    
+    if _, _, edgeCase := parseFilenameWithEdgeCase("/music/Photek - Ni Ten Ichi Ryu.aiff"); edgeCase != tagger.EdgeProfile {
+        t.Errorf("Expected a declared edge case, got %q", edgeCase)
+    }
+    
+    // Counts the profile doesn't mention keep the built-in rules
+    if artist, title, _ := parseFilenameWithEdgeCase("/music/Photek - Form & Function - Rings Around Saturn.aiff"); artist != "Photek" || title != "Rings Around Saturn" {
+        t.Errorf("Expected built-in two-hyphen parsing, got %q / %q", artist, title)
+    }
+}
+
+func TestProcessFile_UnreadableAIFC(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "Goldie - Inner City Life.aifc")
+    if err := os.WriteFile(path, []byte("FORM\x00\x00\x00\x10AIFCCO"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    
+    files, err := findAudioFiles(filepath.Dir(path), false, getSupportedExtensions())
+    if err != nil || len(files) != 1 {
+        t.Fatalf("Expected the .aifc file to be scanned, got %v (%v)", files, err)
+    }
+    
+    result := processFileWithEdgeCase(path, nil, context.Background())
+    if result.Status != "error" || result.EdgeCase != "unreadable_file" {
+        t.Errorf("Expected error status with unreadable_file edge case, got %q / %q", result.Status, result.EdgeCase)
+    }
+}
+
+func TestBuildTagUpdate_LabelOnly(t *testing.T) {
+    enriched := &enricher.TrackMetadata{
+        Label:         "Metalheadz",
+        CatalogNumber: "METH 001",
+        Album:         "Timeless",
+        Year:          1995,
+        Genre:         "Drum and Bass",
+        Extra:         map[string]interface{}{"musicbrainz_recording_id": "b1a9c0e9-d987-4042-ae91-78d6a3267d69"},
+    }
+    
+    labelOnly = true
+    defer func() { labelOnly = false }()
+    
+    update := buildTagUpdate(&fileResult{}, enriched)
+    expected := []string{"Label: Metalheadz", "Catalog number: METH 001"}
+    if got := describeUpdate(update); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+        t.Errorf("Expected only label fields %v, got %v", expected, got)
+    }
+}
+
+func TestProcessFile_TagGenreFromHint(t *testing.T) {
+    // A minimal untagged AIFF: a FORM holding only an 18-byte COMM chunk
+    aiff := append([]byte("FORM\x00\x00\x00\x1eAIFFCOMM\x00\x00\x00\x12"), make([]byte, 18)...)
+    path := filepath.Join(t.TempDir(), "Goldie - Inner City Life.aiff")
+    if err := os.WriteFile(path, aiff, 0644); err != nil {
+        t.Fatal(err)
+    }
+    
+    genreHint = "dnb"
+    defer func() { genreHint, tagGenreFromHint = "", false }()
+    
+    result := processFileWithEdgeCase(path, nil, context.Background())
+    if result.Status != "needs_enrichment" || result.Genre != "" {
+        t.Fatalf("Expected an untagged file needing enrichment, got %q / %q", result.Status, result.Genre)
+    }
+    if metadata, err := audiotag.ReadFile(path); err == nil && metadata.Genre() != "" {
+        t.Fatalf("Expected no genre written without --tag-genre-from-hint, got %q", metadata.Genre())
+    }
+    
+    tagGenreFromHint = true
+    processFileWithEdgeCase(path, nil, context.Background())
+    metadata, err := audiotag.ReadFile(path)
+    if err != nil {
+        t.Fatalf("Failed to read tagged file: %v", err)
+    }
+    if metadata.Genre() != "Drum and Bass" {
+        t.Errorf("Expected the canonical hint genre, got %q", metadata.Genre())
+    }
+    
+    // A file that already has a genre is left alone
+    genreHint = "house"
+    result = processFileWithEdgeCase(path, nil, context.Background())
+    if metadata, _ := audiotag.ReadFile(path); metadata.Genre() != "Drum and Bass" || result.Genre != "Drum and Bass" {
+        t.Errorf("Expected the existing genre to be kept, got %q", metadata.Genre())
+    }
+}
+
+func TestProcessFile_GenrePresentNotLookedUp(t *testing.T) {
+    aiff := append([]byte("FORM\x00\x00\x00\x1eAIFFCOMM\x00\x00\x00\x12"), make([]byte, 18)...)
+    path := filepath.Join(t.TempDir(), "Goldie - Inner City Life.aiff")
+    if err := os.WriteFile(path, aiff, 0644); err != nil {
+        t.Fatal(err)
+    }
+    if err := audiotag.WriteFile(path, &audiotag.Update{Artist: "Goldie", Title: "Inner City Life", Label: "FFRR", Genre: "Jungle"}); err != nil {
+        t.Fatal(err)
+    }
+    
+    provider := enricher.NewFakeProvider("Fake", enricher.FakeResponse{
+        Result: &enricher.TrackMetadata{Label: "FFRR", Genre: "Drum and Bass", Confidence: 1},
+    })
+    e := enricher.NewEnricher([]enricher.MetadataProvider{provider}, &enricher.EnricherConfig{Strategy: enricher.StrategyFirst, RequestTimeout: time.Second})
+    lookups = newLookupDeduper()
+    defer func() { lookups, overwriteGenre = nil, false }()
+    
+    if result := processFileWithEdgeCase(path, e, context.Background()); result.Status != "has_label" || provider.Calls() != 0 {
+        t.Fatalf("Expected the file to be left alone without --force-genre, got %q after %d lookups", result.Status, provider.Calls())
+    }
+    
+    overwriteGenre = true
+    if result := processFileWithEdgeCase(path, e, context.Background()); result.Status != "enriched" || provider.Calls() != 1 {
+        t.Fatalf("Expected --force-genre to look the file up, got %q after %d lookups", result.Status, provider.Calls())
+    }
+    if metadata, err := audiotag.ReadFile(path); err != nil || metadata.Genre() != "Drum and Bass" {
+        t.Errorf("Expected the genre to be replaced, got %v", err)
+    }
+}
+
+func TestProcessFile_WriteFailedNotEnriched(t *testing.T) {
+    dir := t.TempDir()
+    aiff := append([]byte("FORM\x00\x00\x00\x1eAIFFCOMM\x00\x00\x00\x12"), make([]byte, 18)...)
+    path := filepath.Join(dir, "Goldie - Inner City Life.aiff")
+    if err := os.WriteFile(path, aiff, 0644); err != nil {
+        t.Fatal(err)
+    }
+    
+    // An --output-dir beneath a regular file can never be created, so the
+    // copy (and with it the write) fails however permissions are set
+    blocker := filepath.Join(dir, "blocker")
+    if err := os.WriteFile(blocker, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    outputDir, outputRoot, lookups = filepath.Join(blocker, "out"), dir, newLookupDeduper()
+    defer func() { outputDir, outputRoot, lookups = "", "", nil }()
+    
+    e := enricher.NewEnricher([]enricher.MetadataProvider{enricher.NewFakeProvider("Fake", enricher.FakeResponse{
+        Result: &enricher.TrackMetadata{Label: "Metalheadz", Confidence: 1},
+    })}, &enricher.EnricherConfig{Strategy: enricher.StrategyFirst, RequestTimeout: time.Second})
+    
+    result := processFileWithEdgeCase(path, e, context.Background())
+    if result.Status != "write_failed" {
+        t.Fatalf("Expected write_failed, got %q", result.Status)
+    }
+    if result.Error == "" {
+        t.Error("Expected the write error to be recorded")
+    }
+}
+
+func TestArtworkForFile_DryRun(t *testing.T) {
+    viper.Set("dry-run", true)
+    defer viper.Set("dry-run", false)
+    
+    // A nil enricher would panic if anything were fetched
+    if artwork := artworkForFile(context.Background(), nil, &enricher.TrackMetadata{Label: "Metalheadz"}, false); artwork != nil {
+        t.Errorf("Expected no artwork in dry-run mode, got %+v", artwork)
+    }
+}
+
+func TestHeldBackLine(t *testing.T) {
+    result := &fileResult{
+        Path:     "/music/DnB/goldie_icl.aiff",
+        Status:   "enriched_low_confidence",
+        Enriched: &enricher.TrackMetadata{Artist: "Goldie", Title: "Inner City Life", Label: "FFRR", Confidence: 0.74},
+    }
+    if got, want := heldBackLine(result), "0.74  goldie_icl.aiff -> Goldie - Inner City Life [FFRR]"; got != want {
+        t.Errorf("heldBackLine() = %q, expected %q", got, want)
+    }
+    
+    result.Enriched.Label = ""
+    if got := heldBackLine(result); strings.Contains(got, "[") {
+        t.Errorf("Expected no label brackets without a label, got %q", got)
+    }
+}
+
+func TestFailureBreakdown(t *testing.T) {
+    if got := failureBreakdown(map[string]int{}); got != "" {
+        t.Errorf("Expected no breakdown without failures, got %q", got)
+    }
+    
+    kinds := map[string]int{"network": 2, "not_found": 12, "unknown": 1}
+    if got, want := failureBreakdown(kinds), " (12 not found, 2 network, 1 unknown)"; got != want {
+        t.Errorf("failureBreakdown() = %q, expected %q", got, want)
+    }
+}
+
+func TestLoadOverwritePolicy(t *testing.T) {
+    viper.Set("write.overwrite", []string{"Label", "bogus", "catalog", "label"})
+    defer func() {
+        viper.Set("write.overwrite", nil)
+        loadOverwritePolicy()
+    }()
+    
+    loadOverwritePolicy()
+    if strings.Join(overwritePolicy, ",") != "label,catalog" {
+        t.Errorf("Expected label and catalog once each, got %v", overwritePolicy)
+    }
+    
+    viper.Set("write.overwrite", []string{"all"})
+    loadOverwritePolicy()
+    if len(overwritePolicy) != len(tagger.Fields) {
+        t.Errorf("Expected all to name every field, got %v", overwritePolicy)
+    }
+}
+
+func TestLoadGenrePolicy(t *testing.T) {
+    viper.Set("write.genre_policy", "Append")
+    defer func() {
+        viper.Set("write.genre_policy", nil)
+        appendGenreFlag, replaceGenreFlag, appendGenre = false, false, false
+    }()
+    
+    if err := loadGenrePolicy(); err != nil || !appendGenre {
+        t.Errorf("Expected the append policy from config, got %v, %v", appendGenre, err)
+    }
+    
+    replaceGenreFlag = true
+    if err := loadGenrePolicy(); err != nil || appendGenre {
+        t.Errorf("Expected --replace-genre to override the config, got %v, %v", appendGenre, err)
+    }
+    
+    appendGenreFlag = true
+    if err := loadGenrePolicy(); err == nil {
+        t.Error("Expected an error for --append-genre with --replace-genre")
+    }
+    
+    appendGenreFlag, replaceGenreFlag = false, false
+    viper.Set("write.genre_policy", "merge")
+    if err := loadGenrePolicy(); err == nil {
+        t.Error("Expected an error for an unknown policy")
+    }
+}
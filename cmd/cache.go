@@ -0,0 +1,198 @@
+// cmd/cache.go
+package cmd
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/cache"
+    "github.com/spf13/cobra"
+    "github.com/spf13/viper"
+)
+
+var cacheCmd = &cobra.Command{
+    Use:   "cache",
+    Short: "Inspect and manage the lookup cache",
+    Long: `Inspect and manage the on-disk cache of lookup results that batch, warm
+and verify share. The cache lives in cache.dir (default ~/.tagger/cache).`,
+}
+
+var cacheShowCmd = &cobra.Command{
+    Use:   "show <artist> <title>",
+    Short: "Show cached lookups for a track",
+    Long: `Print every cached lookup for an artist and title, including ones made
+with album, label, year or other hints. Artist and title are matched the
+way the cache keys them: ignoring case and extra whitespace.
+
+Examples:
+  tagger cache show "Goldie" "Inner City Life"`,
+    Args: cobra.ExactArgs(2),
+    Run:  runCacheShow,
+}
+
+var cacheClearCmd = &cobra.Command{
+    Use:   "clear",
+    Short: "Remove every cached lookup",
+    Long: `Delete all cached lookups and the hit rate totals. The next run looks
+everything up again. With --dry-run, only reports what would be removed.`,
+    Args: cobra.NoArgs,
+    Run:  runCacheClear,
+}
+
+var cacheStatsCmd = &cobra.Command{
+    Use:   "stats",
+    Short: "Report cache size and hit rate",
+    Args:  cobra.NoArgs,
+    Run:   runCacheStats,
+}
+
+func init() {
+    rootCmd.AddCommand(cacheCmd)
+    cacheCmd.AddCommand(cacheShowCmd)
+    cacheCmd.AddCommand(cacheClearCmd)
+    cacheCmd.AddCommand(cacheStatsCmd)
+}
+
+// openCacheDir opens the configured cache directory for the cache commands
+func openCacheDir() *cache.DiskCache {
+    c, err := cache.NewDiskCache(viper.GetString("cache.dir"))
+    if err != nil {
+        fatalf("opening cache: %v", err)
+        return nil
+    }
+    return c
+}
+
+// entriesFor returns the entries cached for artist and title under any
+// hints, oldest first
+func entriesFor(entries []cache.Entry, artist, title string) []cache.Entry {
+    key := cache.Key(artist, title)
+    var matched []cache.Entry
+    for _, entry := range entries {
+        if entry.Key == key || strings.HasPrefix(entry.Key, key+"|") {
+            matched = append(matched, entry)
+        }
+    }
+    sort.Slice(matched, func(i, j int) bool {
+        return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+    })
+    return matched
+}
+
+func runCacheShow(cmd *cobra.Command, args []string) {
+    c := openCacheDir()
+    if c == nil {
+        return
+    }
+
+    entries, err := c.Entries()
+    if err != nil {
+        fatalf("reading cache: %v", err)
+        return
+    }
+
+    matched := entriesFor(entries, args[0], args[1])
+    if len(matched) == 0 {
+        fmt.Fprintf(console, "No cached lookups for %s - %s\n", args[0], args[1])
+        exitCode = exitNothingFound
+        return
+    }
+
+    now := time.Now()
+    for i, entry := range matched {
+        if i > 0 {
+            fmt.Fprintln(console)
+        }
+        fmt.Fprintf(console, "Key:     %s\n", entry.Key)
+        expires := "never"
+        if !entry.ExpiresAt.IsZero() {
+            expires = entry.ExpiresAt.Format("2006-01-02 15:04")
+            if entry.Expired(now) {
+                expires += " (expired)"
+            }
+        }
+        fmt.Fprintf(console, "Cached:  %s, expires %s\n", entry.CreatedAt.Format("2006-01-02 15:04"), expires)
+
+        if entry.NotFound || entry.Metadata == nil {
+            fmt.Fprintln(console, "Result:  not found")
+            continue
+        }
+        metadata := entry.Metadata
+        fmt.Fprintf(console, "Match:   %s - %s (confidence %.2f)\n", metadata.Artist, metadata.Title, metadata.Confidence)
+        if metadata.Label != "" || metadata.CatalogNumber != "" {
+            fmt.Fprintf(console, "Label:   %s %s\n", metadata.Label, metadata.CatalogNumber)
+        }
+        if metadata.Album != "" {
+            fmt.Fprintf(console, "Album:   %s\n", metadata.Album)
+        }
+        if metadata.ReleaseDate != "" {
+            fmt.Fprintf(console, "Date:    %s\n", metadata.ReleaseDate)
+        }
+        if metadata.Genre != "" {
+            fmt.Fprintf(console, "Genre:   %s\n", metadata.Genre)
+        }
+        if metadata.ProviderID != "" {
+            fmt.Fprintf(console, "Source:  %s %s\n", metadata.ProviderName, metadata.ProviderID)
+        }
+    }
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) {
+    c := openCacheDir()
+    if c == nil {
+        return
+    }
+
+    if viper.GetBool("dry-run") {
+        stats, err := c.Stats()
+        if err != nil {
+            fatalf("reading cache: %v", err)
+            return
+        }
+        fmt.Fprintf(console, "DRY RUN: Would remove %d cached lookups (%s) from %s\n", stats.Entries, formatBytes(stats.Bytes), viper.GetString("cache.dir"))
+        return
+    }
+
+    removed, err := c.Clear()
+    if err != nil {
+        fatalf("clearing cache after %d entries: %v", removed, err)
+        return
+    }
+    fmt.Fprintf(console, "Removed %d cached lookups from %s\n", removed, viper.GetString("cache.dir"))
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) {
+    c := openCacheDir()
+    if c == nil {
+        return
+    }
+
+    stats, err := c.Stats()
+    if err != nil {
+        fatalf("reading cache: %v", err)
+        return
+    }
+
+    fmt.Fprintf(console, "Cache directory: %s\n", viper.GetString("cache.dir"))
+    fmt.Fprintf(console, "Entries: %d (%d not found, %d expired)\n", stats.Entries, stats.NotFound, stats.Expired)
+    fmt.Fprintf(console, "Size: %s\n", formatBytes(stats.Bytes))
+    if rate, ok := stats.HitRate(); ok {
+        fmt.Fprintf(console, "Hit rate: %.1f%% (%d hits, %d misses)\n", rate*100, stats.Hits, stats.Misses)
+    } else {
+        fmt.Fprintln(console, "Hit rate: no lookups recorded yet")
+    }
+}
+
+// formatBytes renders a size in B, KB or MB
+func formatBytes(n int64) string {
+    switch {
+    case n >= 1<<20:
+        return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+    case n >= 1<<10:
+        return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+    default:
+        return fmt.Sprintf("%d B", n)
+    }
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+    "testing"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/cache"
+)
+
+func TestEntriesFor(t *testing.T) {
+    now := time.Now()
+    entries := []cache.Entry{
+        {Key: cache.Key("Goldie", "Inner City Life", "Timeless"), CreatedAt: now},
+        {Key: cache.Key("Goldie", "Inner City Life"), CreatedAt: now.Add(-time.Hour)},
+        {Key: cache.Key("Goldie", "Inner City Life Remix"), CreatedAt: now},
+        {Key: cache.Key("Photek", "Ni Ten Ichi Ryu"), CreatedAt: now},
+    }
+    
+    matched := entriesFor(entries, "  goldie ", "INNER CITY LIFE")
+    if len(matched) != 2 {
+        t.Fatalf("Expected the plain and hinted lookups, got %d entries", len(matched))
+    }
+    if matched[0].Key != "goldie|inner city life" {
+        t.Errorf("Expected the oldest entry first, got %q", matched[0].Key)
+    }
+}
+
+func TestFormatBytes(t *testing.T) {
+    for n, want := range map[int64]string{512: "512 B", 2048: "2.0 KB", 3 << 20: "3.0 MB"} {
+        if got := formatBytes(n); got != want {
+            t.Errorf("formatBytes(%d) = %q, expected %q", n, got, want)
+        }
+    }
+}
@@ -0,0 +1,153 @@
+// cmd/confidence.go
+package cmd
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+)
+
+var (
+    confidenceReport bool
+    confidenceJSON   string
+)
+
+// confidenceBuckets is the number of histogram buckets, each 0.1 wide
+const confidenceBuckets = 10
+
+// confidenceBarWidth is the length of the longest bar in the text histogram
+const confidenceBarWidth = 40
+
+// confidenceHistogram counts the best match confidence of every file looked
+// up in a run, to help pick --min-confidence and --write-min-confidence
+type confidenceHistogram struct {
+    Buckets [confidenceBuckets]int
+    Matched int
+    NoMatch int
+}
+
+// confidenceBucket is one bucket of the JSON histogram
+type confidenceBucket struct {
+    Min   float64 `json:"min"`
+    Max   float64 `json:"max"`
+    Count int     `json:"count"`
+}
+
+// confidenceHistogramJSON is what --confidence-json writes
+type confidenceHistogramJSON struct {
+    Buckets            []confidenceBucket `json:"buckets"`
+    Matched            int                `json:"matched"`
+    NoMatch            int                `json:"no_match"`
+    MinConfidence      float64            `json:"min_confidence"`
+    WriteMinConfidence float64            `json:"write_min_confidence"`
+}
+
+// wantConfidenceReport reports whether either histogram output was asked for
+func wantConfidenceReport() bool {
+    return confidenceReport || confidenceJSON != ""
+}
+
+// add counts a file's best match: the one found, or the candidate rejected
+// for low confidence. Files that weren't looked up aren't counted.
+func (h *confidenceHistogram) add(result *fileResult) {
+    match := result.Enriched
+    if match == nil {
+        match = result.Candidate
+    }
+    switch {
+    case match != nil:
+        h.Buckets[confidenceBucketFor(match.Confidence)]++
+        h.Matched++
+    case result.notFound:
+        h.NoMatch++
+    }
+}
+
+// confidenceBucketFor maps a confidence to its bucket; 1.0 falls in the
+// last one
+func confidenceBucketFor(confidence float64) int {
+    bucket := int(confidence * confidenceBuckets)
+    if bucket < 0 {
+        return 0
+    }
+    if bucket >= confidenceBuckets {
+        return confidenceBuckets - 1
+    }
+    return bucket
+}
+
+// print writes the histogram as text bars scaled to the largest bucket,
+// marking the buckets a match has to reach to be accepted and written
+func (h *confidenceHistogram) print(w io.Writer) {
+    fmt.Fprintf(w, "\n=== CONFIDENCE ===\n")
+    if h.Matched == 0 {
+        fmt.Fprintf(w, "No matches to chart (%d files with no match)\n", h.NoMatch)
+        return
+    }
+
+    largest := 0
+    for _, count := range h.Buckets {
+        largest = max(largest, count)
+    }
+    bar := "█"
+    if !useEmoji() {
+        bar = "#"
+    }
+
+    for i := confidenceBuckets - 1; i >= 0; i-- {
+        count := h.Buckets[i]
+        width := count * confidenceBarWidth / largest
+        if count > 0 && width == 0 {
+            width = 1
+        }
+        low := float64(i) / confidenceBuckets
+        fmt.Fprintf(w, "%.1f-%.1f %6d %-*s%s\n", low, low+0.1, count, confidenceBarWidth, strings.Repeat(bar, width), thresholdMarker(i))
+    }
+    fmt.Fprintf(w, "Matched: %d, no match: %d\n", h.Matched, h.NoMatch)
+}
+
+// thresholdMarker labels the bucket holding --min-confidence and the write
+// threshold
+func thresholdMarker(bucket int) string {
+    var marks []string
+    for _, threshold := range []struct {
+        name  string
+        value float64
+    }{
+        {"min-confidence", minConfidence},
+        {"write-min-confidence", effectiveWriteMinConfidence()},
+    } {
+        if confidenceBucketFor(threshold.value) == bucket {
+            marks = append(marks, fmt.Sprintf("%s %.2f", threshold.name, threshold.value))
+        }
+    }
+    if len(marks) == 0 {
+        return ""
+    }
+    return " <- " + strings.Join(marks, ", ")
+}
+
+// writeJSON writes the histogram to path
+func (h *confidenceHistogram) writeJSON(path string) error {
+    out := confidenceHistogramJSON{
+        Matched:            h.Matched,
+        NoMatch:            h.NoMatch,
+        MinConfidence:      minConfidence,
+        WriteMinConfidence: effectiveWriteMinConfidence(),
+    }
+    for i, count := range h.Buckets {
+        out.Buckets = append(out.Buckets, confidenceBucket{
+            Min:   float64(i) / confidenceBuckets,
+            Max:   float64(i+1) / confidenceBuckets,
+            Count: count,
+        })
+    }
+
+    data, err := json.MarshalIndent(out, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, append(data, '\n'), 0644)
+}
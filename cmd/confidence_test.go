@@ -0,0 +1,58 @@
+// cmd/confidence_test.go
+package cmd
+
+import (
+    "bytes"
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestConfidenceHistogram(t *testing.T) {
+    var h confidenceHistogram
+    for _, result := range []*fileResult{
+        {Enriched: &enricher.TrackMetadata{Confidence: 1.0}},
+        {Enriched: &enricher.TrackMetadata{Confidence: 0.95}},
+        {Enriched: &enricher.TrackMetadata{Confidence: 0.72}},
+        {Candidate: &enricher.TrackMetadata{Confidence: 0.45}, notFound: true},
+        {notFound: true},
+        {Status: "has_label"},
+    } {
+        h.add(result)
+    }
+
+    if h.Buckets[9] != 2 || h.Buckets[7] != 1 || h.Buckets[4] != 1 || h.Matched != 4 || h.NoMatch != 1 {
+        t.Fatalf("Unexpected histogram: %+v", h)
+    }
+
+    t.Setenv("LC_ALL", "en_US.UTF-8")
+    var buf bytes.Buffer
+    h.print(&buf)
+    lines := strings.Split(buf.String(), "\n")
+    if !strings.HasPrefix(lines[2], "0.9-1.0      2 "+strings.Repeat("█", confidenceBarWidth)) {
+        t.Errorf("Expected the fullest bucket at full width first, got %q", lines[2])
+    }
+    if !strings.Contains(buf.String(), "<- min-confidence 0.70, write-min-confidence 0.70") {
+        t.Errorf("Expected the threshold bucket marked, got:\n%s", buf.String())
+    }
+
+    path := filepath.Join(t.TempDir(), "confidence.json")
+    if err := h.writeJSON(path); err != nil {
+        t.Fatal(err)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var out confidenceHistogramJSON
+    if err := json.Unmarshal(data, &out); err != nil {
+        t.Fatal(err)
+    }
+    if len(out.Buckets) != confidenceBuckets || out.Buckets[4].Min != 0.4 || out.Buckets[4].Count != 1 || out.NoMatch != 1 {
+        t.Errorf("Unexpected JSON histogram: %s", data)
+    }
+}
@@ -22,14 +22,15 @@ var configSetCmd = &cobra.Command{
     Long: `Set a configuration key to a specific value.
 
 Available keys:
-  api.musicbrainz.rate_limit    - API calls per minute (default: 10)
+  api.musicbrainz.requests_per_second - API requests per second (default: 1)
   api.musicbrainz.user_agent    - User agent for API requests
+  api.musicbrainz.min_score     - Minimum recording score 0-100 (default: 50)
   processing.concurrent_workers - Number of parallel workers (default: 3)
   cache.ttl_hours              - Cache TTL in hours (default: 168)
   watch_dirs                   - Comma-separated list of directories to watch
 
 Examples:
-  tagger config set api.musicbrainz.rate_limit 15
+  tagger config set api.musicbrainz.min_score 70
   tagger config set watch_dirs "~/Music/DnB,~/Downloads"`,
     Args: cobra.ExactArgs(2),
     Run:  runConfigSet,
@@ -43,7 +44,7 @@ shows all settings.
 
 Examples:
   tagger config show
-  tagger config show api.musicbrainz.rate_limit`,
+  tagger config show api.musicbrainz.requests_per_second`,
     Args: cobra.MaximumNArgs(1),
     Run:  runConfigShow,
 }
@@ -74,12 +75,12 @@ func runConfigSet(cmd *cobra.Command, args []string) {
         // Try to write to default location if config doesn't exist
         err = viper.SafeWriteConfig()
         if err != nil {
-            fmt.Printf("Error writing config: %v\n", err)
+            fmt.Fprintf(console, "Error writing config: %v\n", err)
             return
         }
     }
     
-    fmt.Printf("Set %s = %v\n", key, viper.Get(key))
+    fmt.Fprintf(console, "Set %s = %v\n", key, viper.Get(key))
 }
 
 func runConfigShow(cmd *cobra.Command, args []string) {
@@ -88,25 +89,26 @@ func runConfigShow(cmd *cobra.Command, args []string) {
         key := args[0]
         value := viper.Get(key)
         if value == nil {
-            fmt.Printf("Key '%s' is not set\n", key)
+            fmt.Fprintf(console, "Key '%s' is not set\n", key)
             return
         }
-        fmt.Printf("%s = %v\n", key, value)
+        fmt.Fprintf(console, "%s = %v\n", key, value)
     } else {
         // Show all settings
-        fmt.Println("Current configuration:")
-        fmt.Printf("Config file: %s\n\n", viper.ConfigFileUsed())
+        fmt.Fprintln(console, "Current configuration:")
+        fmt.Fprintf(console, "Config file: %s\n\n", viper.ConfigFileUsed())
         
         settings := map[string]interface{}{
-            "api.musicbrainz.rate_limit":    viper.Get("api.musicbrainz.rate_limit"),
+            "api.musicbrainz.requests_per_second": viper.Get("api.musicbrainz.requests_per_second"),
             "api.musicbrainz.user_agent":    viper.Get("api.musicbrainz.user_agent"),
+            "api.musicbrainz.min_score":     viper.Get("api.musicbrainz.min_score"),
             "processing.concurrent_workers": viper.Get("processing.concurrent_workers"),
             "cache.ttl_hours":              viper.Get("cache.ttl_hours"),
             "watch_dirs":                   viper.Get("watch_dirs"),
         }
         
         for key, value := range settings {
-            fmt.Printf("%-30s = %v\n", key, value)
+            fmt.Fprintf(console, "%-30s = %v\n", key, value)
         }
     }
 }
\ No newline at end of file
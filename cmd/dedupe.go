@@ -0,0 +1,61 @@
+// cmd/dedupe.go
+package cmd
+
+import (
+    "context"
+    "sync"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+// lookupGroup is the shared outcome for every file with the same
+// normalized lookup key
+type lookupGroup struct {
+    once     sync.Once
+    metadata *enricher.TrackMetadata
+    err      error
+    members  int
+}
+
+// lookupDeduper makes one lookup per unique artist/title (plus hints) in a
+// run and hands the result to every file in the group, so duplicates in
+// different folders don't each cost API calls
+type lookupDeduper struct {
+    mu     sync.Mutex
+    groups map[string]*lookupGroup
+}
+
+func newLookupDeduper() *lookupDeduper {
+    return &lookupDeduper{groups: make(map[string]*lookupGroup)}
+}
+
+// lookup resolves req once per key; concurrent callers for the same key
+// wait for the first. The boolean reports whether the result was shared.
+func (d *lookupDeduper) lookup(ctx context.Context, metadataEnricher *enricher.Enricher, req *enricher.SearchRequest) (*enricher.TrackMetadata, bool, error) {
+    key := lookupKey(req)
+    
+    d.mu.Lock()
+    group, ok := d.groups[key]
+    if !ok {
+        group = &lookupGroup{}
+        d.groups[key] = group
+    }
+    group.members++
+    d.mu.Unlock()
+    
+    group.once.Do(func() {
+        group.metadata, group.err = cachedLookup(ctx, metadataEnricher, req)
+    })
+    return group.metadata, ok, group.err
+}
+
+// stats returns the number of lookups requested and how many were unique
+func (d *lookupDeduper) stats() (total, unique int) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    
+    for _, group := range d.groups {
+        total += group.members
+    }
+    return total, len(d.groups)
+}
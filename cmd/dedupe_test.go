@@ -0,0 +1,44 @@
+package cmd
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestLookupDeduper(t *testing.T) {
+    provider := enricher.NewFakeProvider("Fake", enricher.FakeResponse{
+        Result: &enricher.TrackMetadata{Label: "Metalheadz", Confidence: 1},
+    })
+    e := enricher.NewEnricher([]enricher.MetadataProvider{provider}, &enricher.EnricherConfig{
+        Strategy:       enricher.StrategyFirst,
+        RequestTimeout: time.Second,
+    })
+    deduper := newLookupDeduper()
+    ctx := context.Background()
+
+    requests := []*enricher.SearchRequest{
+        {Artist: "Goldie", Title: "Inner City Life"},
+        {Artist: "goldie", Title: "Inner  City Life"}, // Same track, different folder's spelling
+        {Artist: "Goldie", Title: "Angel"},
+    }
+
+    for i, req := range requests {
+        metadata, shared, err := deduper.lookup(ctx, e, req)
+        if err != nil || metadata == nil {
+            t.Fatalf("Lookup %d failed: %v", i, err)
+        }
+        if expected := i == 1; shared != expected {
+            t.Errorf("Lookup %d: expected shared=%v, got %v", i, expected, shared)
+        }
+    }
+
+    if provider.Calls() != 2 {
+        t.Errorf("Expected 2 provider lookups, got %d", provider.Calls())
+    }
+    if total, unique := deduper.stats(); total != 3 || unique != 2 {
+        t.Errorf("Expected 3 total / 2 unique, got %d / %d", total, unique)
+    }
+}
@@ -0,0 +1,255 @@
+// cmd/doctor.go
+package cmd
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/enricher/musicbrainz"
+    "github.com/spf13/cobra"
+    "github.com/spf13/viper"
+)
+
+var doctorCmd = &cobra.Command{
+    Use:   "doctor",
+    Short: "Check configuration and connectivity",
+    Long: `Run a series of checks to diagnose common setup problems:
+
+  - the config file is readable and writable
+  - a MusicBrainz user agent with contact details is configured
+  - the MusicBrainz API is reachable
+  - each HTTP request fits within the per-lookup timeout
+  - a custom MusicBrainz query template builds a valid query
+  - the cache and history directories are writable
+
+Each check prints a pass/fail marker and, on failure, a suggested fix.`,
+    Args: cobra.NoArgs,
+    Run:  runDoctor,
+}
+
+func init() {
+    rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorCheck is the outcome of a single diagnostic check
+type doctorCheck struct {
+    name   string
+    passed bool
+    detail string
+    fix    string
+}
+
+func runDoctor(cmd *cobra.Command, args []string) {
+    fmt.Fprintln(console, "Running tagger diagnostics...")
+    fmt.Fprintln(console)
+
+    checks := []doctorCheck{
+        checkConfigFile(),
+        checkUserAgent(),
+        checkMusicBrainz(),
+        checkRateLimit(),
+        checkTimeouts(),
+        checkQueryTemplate(),
+        checkWritableDir("Cache directory", viper.GetString("cache.dir"), "cache.dir"),
+        checkWritableDir("History directory", viper.GetString("history.dir"), "history.dir"),
+    }
+
+    failed := 0
+    for _, check := range checks {
+        if check.passed {
+            fmt.Fprintf(console, "✅ %s: %s\n", check.name, check.detail)
+            continue
+        }
+
+        failed++
+        fmt.Fprintf(console, "❌ %s: %s\n", check.name, check.detail)
+        if check.fix != "" {
+            fmt.Fprintf(console, "   💡 Fix: %s\n", check.fix)
+        }
+    }
+
+    fmt.Fprintln(console)
+    if failed == 0 {
+        fmt.Fprintln(console, "All checks passed! 🎉")
+    } else {
+        fmt.Fprintf(console, "%d of %d checks failed\n", failed, len(checks))
+    }
+}
+
+// checkConfigFile verifies the config file (or the directory it would be
+// created in) can be read and written
+func checkConfigFile() doctorCheck {
+    check := doctorCheck{name: "Config file"}
+
+    path := viper.ConfigFileUsed()
+    if path == "" {
+        dir, err := taggerDir()
+        if err != nil {
+            check.detail = fmt.Sprintf("could not determine home directory: %v", err)
+            check.fix = "ensure $HOME is set"
+            return check
+        }
+        if err := ensureWritableDir(dir); err != nil {
+            check.detail = fmt.Sprintf("no config file and %s is not writable: %v", dir, err)
+            check.fix = fmt.Sprintf("check permissions on %s", dir)
+            return check
+        }
+        check.passed = true
+        check.detail = fmt.Sprintf("no config file yet, defaults in use (%s is writable)", dir)
+        return check
+    }
+
+    f, err := os.OpenFile(path, os.O_RDWR, 0)
+    if err != nil {
+        check.detail = fmt.Sprintf("%s is not readable/writable: %v", path, err)
+        check.fix = fmt.Sprintf("check permissions on %s", path)
+        return check
+    }
+    f.Close()
+
+    check.passed = true
+    check.detail = path
+    return check
+}
+
+// checkUserAgent verifies a non-default MusicBrainz user agent is configured
+func checkUserAgent() doctorCheck {
+    check := doctorCheck{name: "MusicBrainz user agent"}
+
+    ua := viper.GetString("api.musicbrainz.user_agent")
+    switch {
+    case ua == "":
+        check.detail = "not set"
+    case ua == defaultUserAgent:
+        check.detail = fmt.Sprintf("using the default %q", ua)
+    default:
+        check.passed = true
+        check.detail = ua
+        return check
+    }
+
+    check.fix = `tagger config set api.musicbrainz.user_agent "tagger/0.1.0 (you@example.com)"`
+    return check
+}
+
+// checkTimeouts verifies a single HTTP request can finish within the
+// enricher's timeout for a whole lookup
+func checkTimeouts() doctorCheck {
+    check := doctorCheck{name: "Timeouts"}
+
+    httpTimeout := time.Duration(viper.GetInt("http.timeout_seconds")) * time.Second
+    if httpTimeout > requestTimeout() {
+        check.detail = fmt.Sprintf("http.timeout_seconds (%s) is longer than api.request_timeout_seconds (%s), so slow requests are cut off early", httpTimeout, requestTimeout())
+        check.fix = fmt.Sprintf("tagger config set api.request_timeout_seconds %d", int(httpTimeout.Seconds())*2)
+        return check
+    }
+
+    check.passed = true
+    check.detail = fmt.Sprintf("%s per HTTP request, %s per lookup", httpTimeout, requestTimeout())
+    return check
+}
+
+// checkQueryTemplate verifies a custom MusicBrainz query template parses
+// and builds a valid query, since a broken one is only warned about and
+// replaced by the default
+func checkQueryTemplate() doctorCheck {
+    check := doctorCheck{name: "MusicBrainz query template"}
+    
+    text := viper.GetString("api.musicbrainz.query_template")
+    if text == "" {
+        check.passed = true
+        check.detail = "default"
+        return check
+    }
+    if _, err := musicbrainz.ParseQueryTemplate(text); err != nil {
+        check.detail = fmt.Sprintf("api.musicbrainz.query_template is ignored: %v", err)
+        check.fix = "tagger config set api.musicbrainz.query_template \"\" to use the default, or correct the template"
+        return check
+    }
+    
+    check.passed = true
+    check.detail = text
+    return check
+}
+
+// checkRateLimit verifies the MusicBrainz request rate is positive and
+// only above the public limit of 1 per second when pointed at a mirror
+func checkRateLimit() doctorCheck {
+    check := doctorCheck{name: "MusicBrainz rate limit"}
+
+    rate := viper.GetFloat64("api.musicbrainz.requests_per_second")
+    switch {
+    case rate <= 0:
+        check.detail = fmt.Sprintf("api.musicbrainz.requests_per_second is %v; it must be positive", viper.Get("api.musicbrainz.requests_per_second"))
+    case rate > 1 && viper.GetString("api.musicbrainz.base_url") == "":
+        check.detail = fmt.Sprintf("%g requests per second against the public web service, which allows 1", rate)
+    default:
+        check.passed = true
+        check.detail = fmt.Sprintf("%g requests per second", rate)
+        return check
+    }
+
+    check.fix = "tagger config set api.musicbrainz.requests_per_second 1"
+    return check
+}
+
+// checkMusicBrainz issues a single rate-limited request to the API
+func checkMusicBrainz() doctorCheck {
+    check := doctorCheck{name: "MusicBrainz API"}
+
+    provider := newMusicBrainzProvider()
+    defer provider.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+    defer cancel()
+
+    start := time.Now()
+    if err := provider.Ping(ctx); err != nil {
+        check.detail = fmt.Sprintf("unreachable: %v", err)
+        check.fix = "check your network connection or proxy settings"
+        return check
+    }
+
+    check.passed = true
+    check.detail = fmt.Sprintf("reachable (%s)", time.Since(start).Round(time.Millisecond))
+    return check
+}
+
+// checkWritableDir verifies dir exists (creating it if needed) and is writable
+func checkWritableDir(name, dir, key string) doctorCheck {
+    check := doctorCheck{name: name}
+
+    if dir == "" {
+        check.detail = "not configured"
+        check.fix = fmt.Sprintf("tagger config set %s <path>", key)
+        return check
+    }
+
+    if err := ensureWritableDir(dir); err != nil {
+        check.detail = fmt.Sprintf("%s is not writable: %v", dir, err)
+        check.fix = fmt.Sprintf("check permissions on %s or set %s to another path", dir, key)
+        return check
+    }
+
+    check.passed = true
+    check.detail = dir
+    return check
+}
+
+// ensureWritableDir creates dir if missing and confirms a file can be
+// written inside it
+func ensureWritableDir(dir string) error {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return err
+    }
+
+    f, err := os.CreateTemp(dir, ".doctor-*")
+    if err != nil {
+        return err
+    }
+    name := f.Name()
+    f.Close()
+    return os.Remove(name)
+}
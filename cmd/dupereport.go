@@ -0,0 +1,151 @@
+// cmd/dupereport.go
+package cmd
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "html/template"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// dedupeReport is the --dedupe-report output path; its extension picks
+// HTML, JSON or CSV
+var dedupeReport string
+
+// duplicateReportTemplate renders the duplicate groups as HTML
+var duplicateReportTemplate = template.Must(template.New("duplicates").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <title>Duplicate Tracks</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 40px; }
+        h1 { color: #333; }
+        h2 { color: #666; margin-top: 30px; }
+        table { border-collapse: collapse; width: 100%; margin-bottom: 20px; }
+        th, td { border: 1px solid #ddd; padding: 12px; text-align: left; }
+        th { background-color: #f2f2f2; }
+        tr:nth-child(even) { background-color: #f9f9f9; }
+        .description { background-color: #f0f8ff; padding: 15px; border-radius: 5px; margin-bottom: 20px; }
+        .path { font-family: monospace; font-size: 0.9em; color: #666; word-break: break-all; }
+    </style>
+</head>
+<body>
+    <h1>Duplicate Tracks</h1>
+    <div class="description">
+        <p>{{.Groups}} tracks with more than one file ({{.Files}} files), generated {{.Generated.Format "2006-01-02 15:04"}}.
+        Files are grouped when they share a normalized artist and title, or a MusicBrainz recording ID.</p>
+    </div>
+{{range .Duplicates}}
+    <h2>{{.Name}} ({{len .Files}} files)</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Format</th>
+                <th>Size</th>
+                <th>Length</th>
+                <th>Tagged As</th>
+                <th>Path</th>
+            </tr>
+        </thead>
+        <tbody>
+{{range .Files}}            <tr>
+                <td>{{.Format}}</td>
+                <td>{{.Size}}</td>
+                <td>{{.Length}}</td>
+                <td>{{.Artist}} - {{.Title}}{{if .RecordingID}}<br><span class="path">{{.RecordingID}}</span>{{end}}</td>
+                <td class="path">{{.Path}}</td>
+            </tr>
+{{end}}        </tbody>
+    </table>
+{{else}}
+    <p>No duplicates found.</p>
+{{end}}
+</body>
+</html>`))
+
+// duplicateReportFile is one file as written to the report
+type duplicateReportFile struct {
+    Path        string `json:"path"`
+    Artist      string `json:"artist,omitempty"`
+    Title       string `json:"title,omitempty"`
+    RecordingID string `json:"recording_id,omitempty"`
+    Format      string `json:"format"`
+    Bytes       int64  `json:"bytes"`
+    Size        string `json:"-"`
+    Seconds     int    `json:"seconds,omitempty"`
+    Length      string `json:"-"`
+}
+
+// duplicateReportGroup is one group of files for the same track
+type duplicateReportGroup struct {
+    Name  string                `json:"name"`
+    Files []duplicateReportFile `json:"files"`
+}
+
+// duplicateReportData is the JSON document and HTML template data
+type duplicateReportData struct {
+    Generated  time.Time              `json:"generated"`
+    Groups     int                    `json:"groups"`
+    Files      int                    `json:"files"`
+    Duplicates []duplicateReportGroup `json:"duplicates"`
+}
+
+// buildDuplicateReport converts clusters into report data
+func buildDuplicateReport(clusters []*duplicateGroup) duplicateReportData {
+    data := duplicateReportData{Generated: time.Now(), Groups: len(clusters), Duplicates: []duplicateReportGroup{}}
+    for _, group := range clusters {
+        out := duplicateReportGroup{Name: group.name()}
+        for _, file := range group.Files {
+            out.Files = append(out.Files, duplicateReportFile{
+                Path:        file.Path,
+                Artist:      file.Artist,
+                Title:       file.Title,
+                RecordingID: file.RecordingID,
+                Format:      file.Format,
+                Bytes:       file.Size,
+                Size:        formatBytes(file.Size),
+                Seconds:     int(file.Duration.Seconds()),
+                Length:      formatLength(file.Duration),
+            })
+        }
+        data.Files += len(out.Files)
+        data.Duplicates = append(data.Duplicates, out)
+    }
+    return data
+}
+
+// writeDuplicateReport writes clusters to outputPath as JSON or CSV when it
+// ends in .json or .csv, and as HTML otherwise. The CSV has one row per
+// file, numbered by group.
+func writeDuplicateReport(clusters []*duplicateGroup, outputPath string) error {
+    data := buildDuplicateReport(clusters)
+
+    file, err := os.Create(outputPath)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    switch strings.ToLower(filepath.Ext(outputPath)) {
+    case ".json":
+        encoder := json.NewEncoder(file)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(data)
+    case ".csv":
+        w := csv.NewWriter(file)
+        w.Write([]string{"group", "name", "path", "artist", "title", "recording_id", "format", "bytes", "seconds"})
+        for i, group := range data.Duplicates {
+            for _, f := range group.Files {
+                w.Write([]string{strconv.Itoa(i + 1), group.Name, f.Path, f.Artist, f.Title, f.RecordingID, f.Format, strconv.FormatInt(f.Bytes, 10), strconv.Itoa(f.Seconds)})
+            }
+        }
+        w.Flush()
+        return w.Error()
+    default:
+        return duplicateReportTemplate.Execute(file, data)
+    }
+}
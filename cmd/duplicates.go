@@ -0,0 +1,212 @@
+// cmd/duplicates.go
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/audiotag"
+    "github.com/cerberussg/tagger/pkg/normalize"
+)
+
+var findDuplicates bool
+
+// duplicateFile is one copy of a track, with what sets it apart from the
+// others
+type duplicateFile struct {
+    Path        string        `json:"path"`
+    Artist      string        `json:"artist"`
+    Title       string        `json:"title"`
+    RecordingID string        `json:"recording_id,omitempty"`
+    Format      string        `json:"format"`
+    Size        int64         `json:"size"`
+    Duration    time.Duration `json:"-"`
+}
+
+// duplicateGroup is every file found for one track
+type duplicateGroup struct {
+    Artist string          `json:"artist"`
+    Title  string          `json:"title"`
+    Files  []duplicateFile `json:"files"`
+}
+
+// duplicateFinder groups scanned files that share a normalized artist and
+// title or a MusicBrainz recording ID. Either is enough, so a file tagged
+// with the ID of a differently named copy joins that copy's group.
+type duplicateFinder struct {
+    files  []duplicateFile
+    parent []int          // Union-find over files; each group's root is its first file
+    keys   map[string]int // Grouping key to the first file seen with it
+}
+
+func newDuplicateFinder() *duplicateFinder {
+    return &duplicateFinder{keys: make(map[string]int)}
+}
+
+// duplicateKey normalizes artist and title the way lookups compare them:
+// folded, case-insensitive, without a leading "The" on the artist, and with
+// "(Original Mix)" dropped since it names the default version
+func duplicateKey(artist, title string) string {
+    core, qualifiers := normalize.CleanTitle(title)
+    for _, q := range qualifiers {
+        if !strings.EqualFold(q, "Original Mix") {
+            core += " (" + q + ")"
+        }
+    }
+    artist = normalize.StripArtistThe(normalize.Fold(artist))
+    return strings.ToLower(artist) + "|" + strings.ToLower(core)
+}
+
+// resolvedRecordingID returns the MusicBrainz recording ID known for a
+// file: the one tagged in it, or the one its lookup matched
+func resolvedRecordingID(result *fileResult) string {
+    if result.RecordingID != "" {
+        return strings.ToLower(result.RecordingID)
+    }
+    if result.Enriched != nil && result.Enriched.ProviderName == "MusicBrainz" {
+        return strings.ToLower(result.Enriched.ProviderID)
+    }
+    return ""
+}
+
+// add records a processed file. Files with neither an artist and title nor
+// a recording ID can't be grouped and are skipped. A nil finder ignores
+// everything.
+func (f *duplicateFinder) add(result *fileResult) {
+    if f == nil {
+        return
+    }
+
+    var keys []string
+    if result.Artist != "" && result.Title != "" {
+        keys = append(keys, "track:"+duplicateKey(result.Artist, result.Title))
+    }
+    recordingID := resolvedRecordingID(result)
+    if recordingID != "" {
+        keys = append(keys, "mbid:"+recordingID)
+    }
+    if len(keys) == 0 {
+        return
+    }
+
+    file := duplicateFile{
+        Path:        result.Path,
+        Artist:      result.Artist,
+        Title:       result.Title,
+        RecordingID: recordingID,
+        Format:      strings.ToUpper(strings.TrimPrefix(filepath.Ext(result.Path), ".")),
+    }
+    if info, err := os.Stat(result.Path); err == nil {
+        file.Size = info.Size()
+    }
+    if duration, err := audiotag.Duration(result.Path); err == nil {
+        file.Duration = duration
+    }
+
+    index := len(f.files)
+    f.files = append(f.files, file)
+    f.parent = append(f.parent, index)
+    for _, key := range keys {
+        if first, ok := f.keys[key]; ok {
+            f.union(first, index)
+        } else {
+            f.keys[key] = index
+        }
+    }
+}
+
+// root returns the first file of the group holding file i
+func (f *duplicateFinder) root(i int) int {
+    for f.parent[i] != i {
+        f.parent[i] = f.parent[f.parent[i]]
+        i = f.parent[i]
+    }
+    return i
+}
+
+// union merges the groups holding files a and b, keeping the earlier root
+func (f *duplicateFinder) union(a, b int) {
+    ra, rb := f.root(a), f.root(b)
+    if ra > rb {
+        ra, rb = rb, ra
+    }
+    f.parent[rb] = ra
+}
+
+// clusters returns the groups holding more than one file, by artist then
+// title. A group is named after its first file with an artist and title.
+func (f *duplicateFinder) clusters() []*duplicateGroup {
+    if f == nil {
+        return nil
+    }
+
+    groups := make(map[int]*duplicateGroup)
+    for i, file := range f.files {
+        root := f.root(i)
+        group, ok := groups[root]
+        if !ok {
+            group = &duplicateGroup{}
+            groups[root] = group
+        }
+        if group.Artist == "" && file.Artist != "" && file.Title != "" {
+            group.Artist, group.Title = file.Artist, file.Title
+        }
+        group.Files = append(group.Files, file)
+    }
+
+    var clusters []*duplicateGroup
+    for _, group := range groups {
+        if len(group.Files) > 1 {
+            if group.Artist == "" {
+                group.Title = group.Files[0].RecordingID
+            }
+            clusters = append(clusters, group)
+        }
+    }
+    sort.Slice(clusters, func(i, j int) bool {
+        a, b := clusters[i], clusters[j]
+        if !strings.EqualFold(a.Artist, b.Artist) {
+            return strings.ToLower(a.Artist) < strings.ToLower(b.Artist)
+        }
+        return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+    })
+    return clusters
+}
+
+// name describes the group: its artist and title, or the recording ID for
+// a group of files that could only be matched by ID
+func (g *duplicateGroup) name() string {
+    if g.Artist == "" {
+        return "MusicBrainz recording " + g.Title
+    }
+    return g.Artist + " - " + g.Title
+}
+
+// formatLength renders a track length as m:ss, or "?" when unknown
+func formatLength(d time.Duration) string {
+    if d <= 0 {
+        return "?"
+    }
+    return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// printDuplicates lists each cluster with every copy's format, size,
+// length and path
+func printDuplicates(clusters []*duplicateGroup) {
+    files := 0
+    for _, group := range clusters {
+        files += len(group.Files)
+    }
+    fmt.Fprintf(console, "\n=== LIKELY DUPLICATES (%d tracks, %d files) ===\n", len(clusters), files)
+
+    for _, group := range clusters {
+        fmt.Fprintf(console, "\n%s (%d files):\n", group.name(), len(group.Files))
+        for _, file := range group.Files {
+            fmt.Fprintf(console, "  %-5s %9s %6s  %s\n", file.Format, formatBytes(file.Size), formatLength(file.Duration), file.Path)
+        }
+    }
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestDuplicateKey(t *testing.T) {
+    same := [][2]string{
+        {"Goldie", "Inner City Life"},
+        {"goldie", "Inner  City Life (Original Mix)"},
+    }
+    key := duplicateKey(same[0][0], same[0][1])
+    for _, track := range same[1:] {
+        if got := duplicateKey(track[0], track[1]); got != key {
+            t.Errorf("Expected %q to group with %q, got %q", track, same[0], got)
+        }
+    }
+
+    if duplicateKey("The Prodigy", "Firestarter") != duplicateKey("Prodigy", "Firestarter") {
+        t.Error("Expected a leading The to be ignored")
+    }
+    if duplicateKey("Goldie", "Inner City Life (Roni Size Remix)") == key {
+        t.Error("Expected a remix to be a different track")
+    }
+}
+
+func TestDuplicateFinder_Clusters(t *testing.T) {
+    dir := t.TempDir()
+    path := func(name string) string {
+        p := filepath.Join(dir, name)
+        os.WriteFile(p, make([]byte, 100), 0644)
+        return p
+    }
+
+    finder := newDuplicateFinder()
+    finder.add(&fileResult{Path: path("icl.aiff"), Artist: "Goldie", Title: "Inner City Life"})
+    finder.add(&fileResult{Path: path("icl.mp3"), Artist: "GOLDIE", Title: "Inner City Life (Original Mix)"})
+    finder.add(&fileResult{Path: path("angel.aiff"), Artist: "Goldie", Title: "Angel"})
+    finder.add(&fileResult{Path: path("unknown.aiff")})
+
+    clusters := finder.clusters()
+    if len(clusters) != 1 || len(clusters[0].Files) != 2 {
+        t.Fatalf("Expected one cluster of two files, got %+v", clusters)
+    }
+    if files := clusters[0].Files; files[0].Format != "AIFF" || files[1].Format != "MP3" || files[1].Size != 100 {
+        t.Errorf("Unexpected file details: %+v", files)
+    }
+
+    var disabled *duplicateFinder
+    disabled.add(&fileResult{Path: "x.aiff", Artist: "a", Title: "b"})
+    if disabled.clusters() != nil {
+        t.Error("Expected a nil finder to report nothing")
+    }
+}
+
+func TestDuplicateFinder_RecordingID(t *testing.T) {
+    id := "4dd7c5e8-1b0a-4bba-9d8b-bd4f1c1f4a7c"
+    finder := newDuplicateFinder()
+    finder.add(&fileResult{Path: "a/icl.aiff", Artist: "Goldie", Title: "Inner City Life"})
+    finder.add(&fileResult{Path: "b/track01.aiff", Artist: "Metalheadz", Title: "Track 01", RecordingID: strings.ToUpper(id)})
+    finder.add(&fileResult{Path: "c/icl.mp3", Artist: "Goldie", Title: "Inner City Life (Original Mix)",
+        Enriched: &enricher.TrackMetadata{ProviderName: "MusicBrainz", ProviderID: id}})
+    finder.add(&fileResult{Path: "d/angel.aiff", Artist: "Goldie", Title: "Angel"})
+
+    clusters := finder.clusters()
+    if len(clusters) != 1 || len(clusters[0].Files) != 3 {
+        t.Fatalf("Expected the tagged file to join the Inner City Life group through its recording ID, got %+v", clusters)
+    }
+    if got := clusters[0].name(); got != "Goldie - Inner City Life" {
+        t.Errorf("Expected the group named after its first file, got %q", got)
+    }
+}
+
+func TestWriteDuplicateReport(t *testing.T) {
+    clusters := []*duplicateGroup{{
+        Artist: "Goldie",
+        Title:  "Inner City Life",
+        Files: []duplicateFile{
+            {Path: "/music/icl.aiff", Artist: "Goldie", Title: "Inner City Life", Format: "AIFF", Size: 2048},
+            {Path: "/music/<icl>.mp3", Artist: "Goldie", Title: "Inner City Life", Format: "MP3", Size: 1024},
+        },
+    }}
+    dir := t.TempDir()
+
+    jsonPath := filepath.Join(dir, "dupes.json")
+    if err := writeDuplicateReport(clusters, jsonPath); err != nil {
+        t.Fatalf("writeDuplicateReport returned error: %v", err)
+    }
+    var report duplicateReportData
+    data, _ := os.ReadFile(jsonPath)
+    if err := json.Unmarshal(data, &report); err != nil || report.Groups != 1 || report.Files != 2 || report.Duplicates[0].Files[1].Bytes != 1024 {
+        t.Errorf("Unexpected JSON report (%v): %s", err, data)
+    }
+
+    csvPath := filepath.Join(dir, "dupes.csv")
+    if err := writeDuplicateReport(clusters, csvPath); err != nil {
+        t.Fatalf("writeDuplicateReport returned error: %v", err)
+    }
+    rows, err := csv.NewReader(strings.NewReader(readString(t, csvPath))).ReadAll()
+    if err != nil || len(rows) != 3 || rows[1][0] != "1" || rows[2][2] != "/music/<icl>.mp3" {
+        t.Errorf("Expected a header and one row per file, got %v (%v)", rows, err)
+    }
+
+    htmlPath := filepath.Join(dir, "dupes.html")
+    if err := writeDuplicateReport(clusters, htmlPath); err != nil {
+        t.Fatalf("writeDuplicateReport returned error: %v", err)
+    }
+    if html := readString(t, htmlPath); !strings.Contains(html, "Goldie - Inner City Life (2 files)") || !strings.Contains(html, "/music/&lt;icl&gt;.mp3") {
+        t.Errorf("Expected the group with escaped paths in the HTML report, got:\n%s", html)
+    }
+}
+
+// readString returns the contents of path
+func readString(t *testing.T, path string) string {
+    t.Helper()
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return string(data)
+}
@@ -0,0 +1,79 @@
+// cmd/emoji.go
+package cmd
+
+import (
+    "io"
+    "os"
+    "strings"
+
+    "github.com/spf13/viper"
+)
+
+// emojiReplacer swaps the emoji tagger prints for ASCII markers. Emoji that
+// only decorate the end of a line are dropped instead.
+var emojiReplacer = strings.NewReplacer(
+    "! 🎉", "!",
+    "⚠️", "[!]",
+    "⚠", "[!]",
+    "❗", "[!]",
+    "❌", "[ERR]",
+    "✅", "[OK]",
+    "🎉", "[OK]",
+    "💡", "[i]",
+    "ℹ️", "[i]",
+    "ℹ", "[i]",
+    "📝", "[*]",
+    "🔍", "[>]",
+    "♻️", "[=]",
+    "🔀", "[<>]",
+    "⏭️", "[skip]",
+    "⏱️", "[time]",
+    "📴", "[off]",
+    "🎼", "[genre]",
+    "🏷️", "[tag]",
+    "🖼️", "[art]",
+    "📄", "[nfo]",
+    "💾", "[cache]",
+    "🔖", "[hint]",
+)
+
+// plainOutput is set when emoji are off and console prints ASCII markers
+var plainOutput bool
+
+// useEmoji reports whether output may contain emoji: not with --no-emoji,
+// nor when the locale names a character set other than UTF-8
+func useEmoji() bool {
+    if viper.GetBool("no-emoji") {
+        return false
+    }
+    for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+        if locale := os.Getenv(key); locale != "" {
+            locale = strings.ToLower(locale)
+            return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+        }
+    }
+    return true
+}
+
+// initEmoji decides once per run whether console prints emoji
+func initEmoji() {
+    plainOutput = !useEmoji()
+}
+
+// console is where commands print. It writes to os.Stdout as it is at the
+// time, so --quiet and --jsonl can still redirect it, and swaps emoji for
+// ASCII markers when they are off. Each write is replaced whole, so a
+// marker printed in one call is never split.
+var console io.Writer = consoleWriter{}
+
+type consoleWriter struct{}
+
+func (consoleWriter) Write(p []byte) (int, error) {
+    if !plainOutput {
+        return os.Stdout.Write(p)
+    }
+    if _, err := io.WriteString(os.Stdout, emojiReplacer.Replace(string(p))); err != nil {
+        return 0, err
+    }
+    return len(p), nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "testing"
+
+    "github.com/spf13/viper"
+)
+
+func TestConsole_NoEmoji(t *testing.T) {
+    input := "✅ Config file: ok\n❌ MusicBrainz API: unreachable\n   💡 Fix: check your network\n  ⚠️  Cache disabled\nAll checks passed! 🎉\n  🖼️  No artwork available\n    ℹ️  Writing .m4a tags isn't supported yet"
+    expected := "[OK] Config file: ok\n[ERR] MusicBrainz API: unreachable\n   [i] Fix: check your network\n  [!]  Cache disabled\nAll checks passed!\n  [art]  No artwork available\n    [i]  Writing .m4a tags isn't supported yet"
+    
+    out, err := os.CreateTemp(t.TempDir(), "stdout")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer out.Close()
+    stdout := os.Stdout
+    os.Stdout, plainOutput = out, true
+    defer func() { os.Stdout, plainOutput = stdout, false }()
+    
+    fmt.Fprint(console, input)
+    if written, _ := os.ReadFile(out.Name()); string(written) != expected {
+        t.Errorf("Expected:\n%s\ngot:\n%s", expected, written)
+    }
+}
+
+func TestUseEmoji(t *testing.T) {
+    t.Setenv("LC_ALL", "")
+    t.Setenv("LC_CTYPE", "")
+    t.Setenv("LANG", "en_GB.UTF-8")
+    if !useEmoji() {
+        t.Error("Expected emoji with a UTF-8 locale")
+    }
+
+    viper.Set("no-emoji", true)
+    if useEmoji() {
+        t.Error("Expected --no-emoji to turn emoji off")
+    }
+    viper.Set("no-emoji", false)
+
+    for _, locale := range []string{"C", "POSIX", "en_US.ISO-8859-1"} {
+        t.Setenv("LC_ALL", locale)
+        if useEmoji() {
+            t.Errorf("Expected no emoji with locale %q", locale)
+        }
+    }
+
+    // LC_ALL wins over LANG
+    t.Setenv("LC_ALL", "de_DE.utf8")
+    t.Setenv("LANG", "C")
+    if !useEmoji() {
+        t.Error("Expected LC_ALL to decide")
+    }
+}
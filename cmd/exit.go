@@ -0,0 +1,83 @@
+// cmd/exit.go
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/viper"
+)
+
+// Exit statuses, so shell pipelines and cron jobs can act on the outcome
+const (
+    exitOK           = 0
+    exitError        = 1   // The command couldn't run: bad arguments, unreadable folder
+    exitFilesFailed  = 2   // Some files couldn't be read, looked up or written
+    exitNothingFound = 3   // Files were looked up but none matched
+    exitInterrupted  = 130 // Stopped by Ctrl-C or SIGTERM
+)
+
+// exitCode is the status Execute exits with once the command returns
+var exitCode = exitOK
+
+// fatalf reports an error that stops the command and sets the exit status.
+// It goes to stderr so it still shows with --quiet and --jsonl.
+func fatalf(format string, args ...interface{}) {
+    fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+    exitCode = exitError
+}
+
+// quietOutput discards everything written to stdout when --quiet is set,
+// leaving stderr for errors. It returns a func that restores stdout.
+func quietOutput() func() {
+    if !viper.GetBool("quiet") {
+        return func() {}
+    }
+
+    devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+    if err != nil {
+        return func() {}
+    }
+    stdout := os.Stdout
+    os.Stdout = devNull
+    return func() {
+        os.Stdout = stdout
+        devNull.Close()
+    }
+}
+
+// runOutcome tallies the per-file results that decide a run's exit status
+type runOutcome struct {
+    failed   int // Read, lookup or write errors
+    matched  int // Files a provider found a match for
+    notFound int // Files looked up without a match
+}
+
+// add counts a file's result, printing it on stderr under --quiet if it
+// failed, since the summary that would list it is suppressed
+func (o *runOutcome) add(result *fileResult) {
+    switch {
+    case result.Status == "error" || (result.Error != "" && !result.notFound):
+        o.failed++
+        if viper.GetBool("quiet") {
+            fmt.Fprintf(os.Stderr, "%s: %s\n", result.Path, result.Error)
+        }
+    case result.notFound:
+        o.notFound++
+    case result.Enriched != nil:
+        o.matched++
+    }
+}
+
+// exitStatus returns the exit code for the run: failures win over an empty
+// result, since a file that errored may well have matched
+func (o runOutcome) exitStatus() int {
+    switch {
+    case o.failed > 0:
+        return exitFilesFailed
+    case o.notFound > 0 && o.matched == 0:
+        return exitNothingFound
+    default:
+        return exitOK
+    }
+}
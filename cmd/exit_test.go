@@ -0,0 +1,63 @@
+package cmd
+
+import (
+    "os"
+    "testing"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/spf13/viper"
+)
+
+func TestRunOutcome_ExitStatus(t *testing.T) {
+    matched := &fileResult{Status: "enriched", Enriched: &enricher.TrackMetadata{Label: "Metalheadz"}}
+    notFound := &fileResult{Status: "enrichment_failed", Error: enricher.ErrNotFound.Error(), notFound: true}
+    lookupError := &fileResult{Status: "enrichment_failed", Error: "musicbrainz recording search failed: timeout"}
+    readError := &fileResult{Status: "error", Error: "no tags found"}
+    writeError := &fileResult{Status: "enriched", Enriched: &enricher.TrackMetadata{}, Error: "permission denied"}
+    
+    tests := []struct {
+        name     string
+        results  []*fileResult
+        expected int
+    }{
+        {"all matched", []*fileResult{matched, {Status: "has_label"}}, exitOK},
+        {"some not found", []*fileResult{matched, notFound}, exitOK},
+        {"nothing found", []*fileResult{notFound, notFound}, exitNothingFound},
+        {"nothing looked up", []*fileResult{{Status: "needs_enrichment"}}, exitOK},
+        {"lookup error", []*fileResult{matched, lookupError}, exitFilesFailed},
+        {"read error beats nothing found", []*fileResult{notFound, readError}, exitFilesFailed},
+        {"write error", []*fileResult{writeError}, exitFilesFailed},
+    }
+    
+    for _, tt := range tests {
+        var outcome runOutcome
+        for _, result := range tt.results {
+            outcome.add(result)
+        }
+        if got := outcome.exitStatus(); got != tt.expected {
+            t.Errorf("%s: expected exit status %d, got %d", tt.name, tt.expected, got)
+        }
+    }
+}
+
+func TestQuietOutput(t *testing.T) {
+    stdout := os.Stdout
+    
+    restore := quietOutput()
+    if os.Stdout != stdout {
+        t.Error("Expected stdout untouched without --quiet")
+    }
+    restore()
+    
+    viper.Set("quiet", true)
+    defer viper.Set("quiet", false)
+    
+    restore = quietOutput()
+    if os.Stdout == stdout {
+        t.Error("Expected stdout to be discarded with --quiet")
+    }
+    restore()
+    if os.Stdout != stdout {
+        t.Error("Expected stdout to be restored")
+    }
+}
@@ -0,0 +1,82 @@
+// cmd/filelist.go
+package cmd
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/spf13/viper"
+)
+
+// fromFile names a list of files to process instead of walking a folder
+// (--from-file); "-" reads the list from stdin
+var fromFile string
+
+// readFileList reads one path per line from the list at path. Blank lines
+// and lines starting with "#" are skipped, relative paths are resolved
+// against the working directory, and repeated paths are listed once.
+func readFileList(path string) ([]string, error) {
+    var r io.Reader = os.Stdin
+    if path != "-" {
+        f, err := os.Open(path)
+        if err != nil {
+            return nil, err
+        }
+        defer f.Close()
+        r = f
+    }
+    
+    var files []string
+    seen := make(map[string]bool)
+    scanner := bufio.NewScanner(r)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        
+        abs, err := filepath.Abs(line)
+        if err != nil {
+            abs = line
+        }
+        if !seen[abs] {
+            seen[abs] = true
+            files = append(files, abs)
+        }
+    }
+    return files, scanner.Err()
+}
+
+// checkListedFile validates a path from --from-file, returning an error
+// result for a missing file, a directory or an unsupported format, or nil
+// if the file can be processed
+func checkListedFile(filePath string) *fileResult {
+    fail := func(format string, args ...interface{}) *fileResult {
+        if viper.GetBool("verbose") {
+            fmt.Fprintf(console, "  ❌ "+format+"\n", args...)
+        }
+        return &fileResult{Path: filePath, Status: "error", Error: fmt.Sprintf(format, args...)}
+    }
+    
+    info, err := os.Stat(filePath)
+    switch {
+    case os.IsNotExist(err):
+        return fail("file not found")
+    case err != nil:
+        return fail("%v", err)
+    case info.IsDir():
+        return fail("is a directory, not an audio file")
+    }
+    
+    ext := strings.ToLower(filepath.Ext(filePath))
+    for _, supported := range getSupportedExtensions() {
+        if ext == supported {
+            return nil
+        }
+    }
+    return fail("unsupported file type %q", filepath.Ext(filePath))
+}
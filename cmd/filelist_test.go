@@ -0,0 +1,50 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestReadFileList(t *testing.T) {
+    dir := t.TempDir()
+    track := filepath.Join(dir, "Goldie - Inner City Life.aiff")
+    list := filepath.Join(dir, "list.txt")
+    content := "# from another tool\n" + track + "\n\n  " + track + "  \nrelative.wav\n"
+    if err := os.WriteFile(list, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+    
+    files, err := readFileList(list)
+    if err != nil {
+        t.Fatalf("readFileList returned error: %v", err)
+    }
+    relative, _ := filepath.Abs("relative.wav")
+    if len(files) != 2 || files[0] != track || files[1] != relative {
+        t.Errorf("Expected %q and %q, got %q", track, relative, files)
+    }
+    
+    if _, err := readFileList(filepath.Join(dir, "missing.txt")); err == nil {
+        t.Error("Expected an error for a missing list")
+    }
+}
+
+func TestCheckListedFile(t *testing.T) {
+    dir := t.TempDir()
+    track := filepath.Join(dir, "track.aiff")
+    notes := filepath.Join(dir, "notes.txt")
+    for _, path := range []string{track, notes} {
+        if err := os.WriteFile(path, nil, 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    if result := checkListedFile(track); result != nil {
+        t.Errorf("Expected %s to be accepted, got %+v", track, result)
+    }
+    for _, path := range []string{filepath.Join(dir, "missing.aiff"), dir, notes} {
+        if result := checkListedFile(path); result == nil || result.Status != "error" || result.Error == "" {
+            t.Errorf("Expected an error result for %s, got %+v", path, result)
+        }
+    }
+}
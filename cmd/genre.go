@@ -0,0 +1,28 @@
+// cmd/genre.go
+package cmd
+
+import (
+    "fmt"
+
+    "github.com/cerberussg/tagger/pkg/normalize"
+    "github.com/spf13/viper"
+)
+
+// genreMap canonicalizes genres before they are written: the built-in
+// aliases plus any from genres.aliases
+var genreMap = normalize.NewGenreMap(nil)
+
+// loadGenreMap reads user genre aliases from genres.aliases, a map of
+// spelling to canonical name, e.g. {"jungle": "Drum and Bass"}
+func loadGenreMap() {
+    aliases := viper.GetStringMapString("genres.aliases")
+    genreMap = normalize.NewGenreMap(aliases)
+    if len(aliases) > 0 {
+        fmt.Fprintf(console, "Genre aliases: %d custom\n", len(aliases))
+    }
+}
+
+// canonicalGenre returns the form of a genre that gets written
+func canonicalGenre(genre string) string {
+    return genreMap.Canonical(genre)
+}
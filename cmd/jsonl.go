@@ -0,0 +1,89 @@
+// cmd/jsonl.go
+package cmd
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "strings"
+)
+
+// jsonlWriter streams one JSON object per line. batch processes files one at
+// a time and emits every line itself, so the writer isn't safe for
+// concurrent use.
+type jsonlWriter struct {
+    enc *json.Encoder
+}
+
+// jsonlFileRecord is emitted once per processed file
+type jsonlFileRecord struct {
+    Type string `json:"type"`
+    *fileResult
+}
+
+// jsonlSummary is emitted as the final line of a run
+type jsonlSummary struct {
+    Type             string `json:"type"`
+    Total            int    `json:"total"`
+    HasLabel         int    `json:"has_label"`
+    NeedsEnrichment  int    `json:"needs_enrichment"`
+    Errors           int    `json:"errors"`
+    Enriched         int    `json:"enriched"`
+    NotWritten       int    `json:"not_written"`
+    GenrePresent     int    `json:"genre_present"`
+    EnrichmentFailed int    `json:"enrichment_failed"`
+    WriteFailed      int    `json:"write_failed"`
+    BudgetSkipped    int    `json:"skipped_budget_exhausted"`
+    TimeoutSkipped   int    `json:"skipped_batch_timeout"`
+    OfflineMisses    int    `json:"needs_enrichment_offline"`
+    SharedLookups    int    `json:"shared_lookups"`
+    APICalls         int    `json:"api_calls"`
+    EdgeCases        int    `json:"edge_cases"`
+}
+
+// line renders the summary on one line for --summary-line, e.g.
+// "150 files: 12 with label, 120 enriched, 10 failed, 8 edge cases".
+// Counts that are zero are left out, except enriched and failed.
+func (s jsonlSummary) line() string {
+    parts := []string{}
+    if s.HasLabel > 0 {
+        parts = append(parts, fmt.Sprintf("%d with label", s.HasLabel))
+    }
+    if s.NeedsEnrichment > 0 {
+        parts = append(parts, fmt.Sprintf("%d need enrichment", s.NeedsEnrichment))
+    }
+    enriched := fmt.Sprintf("%d enriched", s.Enriched)
+    if s.NotWritten > 0 {
+        enriched += fmt.Sprintf(" (%d not written)", s.NotWritten)
+    }
+    parts = append(parts, enriched, fmt.Sprintf("%d failed", s.EnrichmentFailed+s.WriteFailed+s.Errors))
+    if skipped := s.BudgetSkipped + s.TimeoutSkipped; skipped > 0 {
+        parts = append(parts, fmt.Sprintf("%d skipped", skipped))
+    }
+    if s.EdgeCases > 0 {
+        parts = append(parts, fmt.Sprintf("%d edge cases", s.EdgeCases))
+    }
+    if s.APICalls > 0 {
+        parts = append(parts, fmt.Sprintf("%d API calls", s.APICalls))
+    }
+    return fmt.Sprintf("%d files: %s", s.Total, strings.Join(parts, ", "))
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+    return &jsonlWriter{enc: json.NewEncoder(w)}
+}
+
+func (j *jsonlWriter) emit(v interface{}) {
+    j.enc.Encode(v) // Encode appends the newline
+}
+
+// emitFile writes the result for a single file
+func (j *jsonlWriter) emitFile(result *fileResult) {
+    j.emit(jsonlFileRecord{Type: "file", fileResult: result})
+}
+
+// emitSummary writes the aggregate summary line
+func (j *jsonlWriter) emitSummary(summary jsonlSummary) {
+    summary.Type = "summary"
+    j.emit(summary)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+    "bytes"
+    "encoding/json"
+    "testing"
+)
+
+func TestJSONLWriter_Summary(t *testing.T) {
+    var buf bytes.Buffer
+    newJSONLWriter(&buf).emitSummary(jsonlSummary{Total: 3, Enriched: 2})
+    
+    var record map[string]interface{}
+    if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+        t.Fatalf("Expected one JSON object, got %q: %v", buf.String(), err)
+    }
+    if record["type"] != "summary" || record["total"] != 3.0 || record["enriched"] != 2.0 {
+        t.Errorf("Unexpected summary record: %v", record)
+    }
+}
+
+func TestJSONLSummary_Line(t *testing.T) {
+    summary := jsonlSummary{Total: 4}
+    if got, want := summary.line(), "4 files: 0 enriched, 0 failed"; got != want {
+        t.Errorf("line() = %q, expected %q", got, want)
+    }
+    
+    summary = jsonlSummary{
+        Total:            150,
+        HasLabel:         12,
+        Enriched:         120,
+        NotWritten:       3,
+        EnrichmentFailed: 9,
+        Errors:           1,
+        BudgetSkipped:    5,
+        TimeoutSkipped:   3,
+        APICalls:         240,
+        EdgeCases:        8,
+    }
+    want := "150 files: 12 with label, 120 enriched (3 not written), 10 failed, 8 skipped, 8 edge cases, 240 API calls"
+    if got := summary.line(); got != want {
+        t.Errorf("line() = %q, expected %q", got, want)
+    }
+}
@@ -0,0 +1,295 @@
+// cmd/lookup.go
+package cmd
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/cache"
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/cerberussg/tagger/pkg/tagger"
+    "github.com/spf13/viper"
+)
+
+var (
+    // lookupCache holds lookup results between runs; nil disables caching
+    lookupCache *cache.DiskCache
+    
+    // lookups dedupes identical lookups within a batch run
+    lookups *lookupDeduper
+    
+    // offlineMode serves lookups exclusively from lookupCache
+    offlineMode bool
+    
+    // apiBudget caps provider requests for the run (--max-api-calls, or
+    // api.max_calls_per_run)
+    apiBudget *enricher.CallBudget
+    maxAPICalls int
+    
+    // preferredFormat tie-breaks releases by media format (--prefer-format)
+    preferredFormat string
+    
+    // allowBootleg lets unofficial releases compete with official ones
+    // (--allow-bootleg)
+    allowBootleg bool
+    
+    // useSidecars seeds lookups with hints from .nfo/.txt files
+    useSidecars bool
+)
+
+// apiCallLimit returns the per-run provider request cap: --max-api-calls
+// when given, otherwise api.max_calls_per_run. 0 means unlimited.
+func apiCallLimit() int {
+    if maxAPICalls > 0 {
+        return maxAPICalls
+    }
+    return viper.GetInt("api.max_calls_per_run")
+}
+
+// errCacheMiss is returned in offline mode when a track isn't cached
+var errCacheMiss = errors.New("not in cache (offline mode)")
+
+// openLookupCache opens the configured disk cache, warning and carrying on
+// without one if it can't be used
+func openLookupCache() *cache.DiskCache {
+    dir := viper.GetString("cache.dir")
+    c, err := cache.NewDiskCache(dir)
+    if err != nil {
+        fmt.Fprintf(console, "⚠️  Cache disabled: %v\n", err)
+        return nil
+    }
+    return c
+}
+
+// saveCacheStats adds the run's cache hits and misses to the totals shown
+// by 'tagger cache stats'
+func saveCacheStats() {
+    if err := lookupCache.SaveStats(); err != nil && viper.GetBool("verbose") {
+        fmt.Fprintf(console, "⚠️  Failed to save cache stats: %v\n", err)
+    }
+}
+
+// cacheTTL returns how long cached lookups stay valid
+func cacheTTL() time.Duration {
+    return time.Duration(viper.GetInt("cache.ttl_hours")) * time.Hour
+}
+
+// prepareLookup builds the search request for a file, using its embedded
+// album and year to narrow the search to the right release. With --sidecar,
+// release info from the folder's .nfo/.txt seeds the request, and the
+// tracklist replaces artist/title that are missing or were parsed from a
+// problematic filename.
+func prepareLookup(filePath string, info *trackInfo) *enricher.SearchRequest {
+    req := tagger.NewSearchRequest(filePath, info)
+    req.PreferredFormat = releaseFormat()
+    req.MinYear, req.MaxYear = minReleaseYear, maxReleaseYear
+    req.AllowBootleg = allowBootleg
+    
+    applyParenthesesHints(req)
+    
+    if !useSidecars {
+        return req
+    }
+    
+    sidecar := sidecars.forDir(filepath.Dir(filePath))
+    if sidecar == nil {
+        return req
+    }
+    
+    hints := sidecar.hintsFor(filePath, info.Title)
+    if hints.Artist != "" && hints.Title != "" && (info.Artist == "" || info.Title == "" || info.EdgeCase != "") {
+        if viper.GetBool("verbose") {
+            fmt.Fprintf(console, "  📄 Sidecar tracklist: %s - %s\n", hints.Artist, hints.Title)
+        }
+        info.Artist, info.Title, info.EdgeCase = hints.Artist, hints.Title, ""
+        req.Artist, req.Title = hints.Artist, hints.Title
+    }
+    
+    // Tags the file already has win over the folder-wide sidecar
+    if req.Album == "" {
+        req.Album = hints.Album
+    }
+    if req.Year == "" {
+        req.Year = hints.Year
+    }
+    if req.CatalogNumber == "" {
+        req.CatalogNumber = hints.Catalog
+    }
+    if req.Label == "" {
+        req.Label = hints.Label
+    }
+    return req
+}
+
+// releaseFormat returns --prefer-format, falling back to
+// api.musicbrainz.prefer_format so a collection's format can be set once
+func releaseFormat() string {
+    if preferredFormat != "" {
+        return preferredFormat
+    }
+    return viper.GetString("api.musicbrainz.prefer_format")
+}
+
+// lookupKey returns the cache key for a search request
+func lookupKey(req *enricher.SearchRequest) string {
+    return cache.Key(req.Artist, req.Title, req.Album, req.Label, req.Year, req.PreferredFormat, req.RecordingID, req.CatalogNumber, yearRangeKey(req), releaseStatusKey(req), req.ISRC, durationKey(req))
+}
+
+// durationKey renders a request's length for its cache key, or "" if it is
+// unknown. Length picks between recordings, so an edit mustn't be served
+// the full-length version's match.
+func durationKey(req *enricher.SearchRequest) string {
+    if req.Duration <= 0 {
+        return ""
+    }
+    return fmt.Sprintf("%ds", enricher.DurationBucket(req.Duration)*5)
+}
+
+// yearRangeKey renders a request's year range for its cache key, or "" if
+// it has none
+func yearRangeKey(req *enricher.SearchRequest) string {
+    if req.MinYear == 0 && req.MaxYear == 0 {
+        return ""
+    }
+    return fmt.Sprintf("%d-%d", req.MinYear, req.MaxYear)
+}
+
+// releaseStatusKey marks the cache key of a request that lets unofficial
+// releases compete, since it can pick a different release
+func releaseStatusKey(req *enricher.SearchRequest) string {
+    if req.AllowBootleg {
+        return "bootleg"
+    }
+    return ""
+}
+
+// lowConfidenceError reports a match that was found but fell below
+// --min-confidence. It counts as enricher.ErrNotFound, but keeps the
+// candidate so it can be listed in the review report.
+type lowConfidenceError struct {
+    candidate *enricher.TrackMetadata
+}
+
+func (e *lowConfidenceError) Error() string {
+    return fmt.Sprintf("%v: best match confidence %.2f is below %.2f", enricher.ErrNotFound, e.candidate.Confidence, minConfidence)
+}
+
+// diagnostics describes the rejection as the enricher would
+func (e *lowConfidenceError) diagnostics() *enricher.NotFoundError {
+    return &enricher.NotFoundError{
+        Candidates: e.candidate.Candidates,
+        Best:       e.candidate,
+        Reason:     fmt.Sprintf("best scored %.2f (below %.2f)", e.candidate.Confidence, minConfidence),
+    }
+}
+
+func (e *lowConfidenceError) Is(target error) bool {
+    return target == enricher.ErrNotFound
+}
+
+// notFoundReason explains a lookup that matched nothing, e.g. "3
+// candidates, best scored 0.45 (below 0.70)". With several providers the
+// first that says why is used.
+func notFoundReason(err error) string {
+    var lowConfidence *lowConfidenceError
+    if errors.As(err, &lowConfidence) {
+        return lowConfidence.diagnostics().Summary()
+    }
+    var notFound *enricher.NotFoundError
+    if errors.As(err, &notFound) {
+        return notFound.Summary()
+    }
+    return "no candidates"
+}
+
+// checkMinConfidence rejects matches under --min-confidence. The enricher
+// only returns those when its threshold was lowered for --review-report, and
+// the cache may hold them from such a run.
+func checkMinConfidence(metadata *enricher.TrackMetadata, err error) (*enricher.TrackMetadata, error) {
+    if err == nil && metadata != nil && metadata.Confidence < minConfidence {
+        return nil, &lowConfidenceError{candidate: metadata}
+    }
+    return metadata, err
+}
+
+// cachedLookup resolves a request through the disk cache, only asking the
+// enricher on a miss. Successful and not-found results are stored. A match
+// rejected for scoring under the threshold is stored as found, since the
+// threshold isn't part of the key: checkMinConfidence rejects it again on
+// read, but a run with a lower --min-confidence can still use it.
+func cachedLookup(ctx context.Context, metadataEnricher *enricher.Enricher, req *enricher.SearchRequest) (*enricher.TrackMetadata, error) {
+    key := lookupKey(req)
+    
+    if lookupCache != nil {
+        if metadata, hit := lookupCache.Get(key); hit {
+            if viper.GetBool("verbose") {
+                fmt.Fprintf(console, "  💾 Cache hit\n")
+            }
+            if metadata == nil {
+                return nil, &enricher.NotFoundError{Reason: "cached as not found"}
+            }
+            return checkMinConfidence(metadata, nil)
+        }
+    }
+    
+    if offlineMode {
+        return nil, errCacheMiss
+    }
+    
+    if apiBudget.Exhausted() {
+        return nil, enricher.ErrBudgetExhausted
+    }
+    
+    metadata, err := metadataEnricher.LookupWithRequest(ctx, req)
+    if lookupCache == nil {
+        return checkMinConfidence(metadata, err)
+    }
+    
+    var notFound *enricher.NotFoundError
+    switch {
+    case err == nil:
+        if cacheErr := lookupCache.Set(key, metadata, cacheTTL()); cacheErr != nil && viper.GetBool("verbose") {
+            fmt.Fprintf(console, "  ⚠️  Failed to cache result: %v\n", cacheErr)
+        }
+    case errors.As(err, &notFound) && notFound.Best != nil:
+        if cacheErr := lookupCache.Set(key, notFound.Best, cacheTTL()); cacheErr != nil && viper.GetBool("verbose") {
+            fmt.Fprintf(console, "  ⚠️  Failed to cache result: %v\n", cacheErr)
+        }
+    case enricher.IsNotFound(err):
+        if cacheErr := lookupCache.SetNegative(key, cacheTTL()); cacheErr != nil && viper.GetBool("verbose") {
+            fmt.Fprintf(console, "  ⚠️  Failed to cache result: %v\n", cacheErr)
+        }
+    }
+    
+    return checkMinConfidence(metadata, err)
+}
+
+// swapMargin is how much more confident a lookup with artist and title
+// swapped must be before it replaces a weak original match
+const swapMargin = 0.2
+
+// swappedLookup retries a failed or weak lookup with artist and title
+// swapped, for files named "Title - Artist". It returns the swapped request
+// and its match when that is clearly better than original (which may be
+// nil), or nils otherwise.
+func swappedLookup(ctx context.Context, metadataEnricher *enricher.Enricher, req *enricher.SearchRequest, original *enricher.TrackMetadata) (*enricher.SearchRequest, *enricher.TrackMetadata) {
+    if req.Artist == "" || req.Title == "" || strings.EqualFold(req.Artist, req.Title) {
+        return nil, nil
+    }
+    
+    swapped := *req
+    swapped.Artist, swapped.Title = req.Title, req.Artist
+    
+    metadata, err := cachedLookup(ctx, metadataEnricher, &swapped)
+    if err != nil || metadata == nil {
+        return nil, nil
+    }
+    if original != nil && metadata.Confidence < original.Confidence+swapMargin {
+        return nil, nil
+    }
+    return &swapped, metadata
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/cache"
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestSwappedLookup(t *testing.T) {
+    provider := enricher.NewFakeProvider("Fake")
+    provider.Respond = func(req *enricher.SearchRequest) (*enricher.TrackMetadata, error) {
+        if req.Artist == "Goldie" && req.Title == "Inner City Life" {
+            return &enricher.TrackMetadata{Artist: req.Artist, Title: req.Title, Label: "FFRR", Confidence: 0.9}, nil
+        }
+        return nil, enricher.ErrNotFound
+    }
+    e := enricher.NewEnricher([]enricher.MetadataProvider{provider}, &enricher.EnricherConfig{
+        Strategy:       enricher.StrategyFirst,
+        MinConfidence:  0.5,
+        RequestTimeout: time.Second,
+    })
+    ctx := context.Background()
+    reversed := &enricher.SearchRequest{Artist: "Inner City Life", Title: "Goldie"}
+    
+    req, metadata := swappedLookup(ctx, e, reversed, nil)
+    if metadata == nil || req.Artist != "Goldie" || req.Title != "Inner City Life" {
+        t.Fatalf("Expected the swapped lookup to match, got %+v", metadata)
+    }
+    
+    // Only a clearly better match replaces a weak original
+    if _, metadata := swappedLookup(ctx, e, reversed, &enricher.TrackMetadata{Confidence: 0.8}); metadata != nil {
+        t.Error("Expected a marginally better swapped match to be ignored")
+    }
+    
+    if _, metadata := swappedLookup(ctx, e, &enricher.SearchRequest{Artist: "Goldie", Title: "Angel"}, nil); metadata != nil {
+        t.Error("Expected no match when the swapped lookup also fails")
+    }
+}
+
+func TestCachedLookup_RejectedMatchReusable(t *testing.T) {
+    provider := enricher.NewFakeProvider("Fake", enricher.FakeResponse{
+        Result: &enricher.TrackMetadata{Label: "Metalheadz", Confidence: 0.5},
+    })
+    e := enricher.NewEnricher([]enricher.MetadataProvider{provider}, &enricher.EnricherConfig{
+        Strategy:       enricher.StrategyFirst,
+        MinConfidence:  0.7,
+        RequestTimeout: time.Second,
+    })
+    diskCache, err := cache.NewDiskCache(t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    savedCache, savedMin := lookupCache, minConfidence
+    lookupCache, minConfidence = diskCache, 0.7
+    defer func() { lookupCache, minConfidence = savedCache, savedMin }()
+    
+    ctx := context.Background()
+    req := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life"}
+    if _, err := cachedLookup(ctx, e, req); !errors.Is(err, enricher.ErrNotFound) {
+        t.Fatalf("Expected the weak match to be rejected, got %v", err)
+    }
+    
+    // The cached match is still rejected at the same threshold...
+    if _, err := cachedLookup(ctx, e, req); !errors.Is(err, enricher.ErrNotFound) {
+        t.Errorf("Expected the cached match to be rejected again, got %v", err)
+    }
+    
+    // ...but a run with a lower threshold uses it without a new request
+    minConfidence = 0.4
+    metadata, err := cachedLookup(ctx, e, req)
+    if err != nil || metadata == nil || metadata.Label != "Metalheadz" {
+        t.Fatalf("Expected the cached match under a lower threshold, got %+v / %v", metadata, err)
+    }
+    if provider.Calls() != 1 {
+        t.Errorf("Expected 1 provider lookup, got %d", provider.Calls())
+    }
+}
+
+func TestPrepareLookup_EmbeddedAlbumAndYear(t *testing.T) {
+    info := &trackInfo{Artist: "Goldie", Title: "Inner City Life", Album: "Timeless", Year: 1995}
+    
+    req := prepareLookup("missing.aiff", info)
+    if req.Album != "Timeless" || req.Year != "1995" {
+        t.Errorf("Expected album and year hints from the tags, got %q / %q", req.Album, req.Year)
+    }
+    
+    req = prepareLookup("missing.aiff", &trackInfo{Artist: "Goldie", Title: "Inner City Life"})
+    if req.Album != "" || req.Year != "" {
+        t.Errorf("Expected no hints for an untagged file, got %q / %q", req.Album, req.Year)
+    }
+}
+
+
+func TestPrepareLookup_AllowBootleg(t *testing.T) {
+    info := &trackInfo{Artist: "Goldie", Title: "Inner City Life"}
+    req := prepareLookup("missing.aiff", info)
+    plain := lookupKey(req)
+    if req.AllowBootleg || plain != "goldie|inner city life" {
+        t.Errorf("Expected official releases preferred and the key unchanged by default, got %v / %q", req.AllowBootleg, plain)
+    }
+    
+    allowBootleg = true
+    defer func() { allowBootleg = false }()
+    req = prepareLookup("missing.aiff", info)
+    if !req.AllowBootleg || lookupKey(req) == plain {
+        t.Errorf("Expected --allow-bootleg on the request and in its cache key, got %v / %q", req.AllowBootleg, lookupKey(req))
+    }
+}
+
+func TestNotFoundReason(t *testing.T) {
+    saved := minConfidence
+    minConfidence = 0.7
+    defer func() { minConfidence = saved }()
+    
+    rejected := &enricher.ProviderError{Provider: "MusicBrainz", Op: "lookup", Err: &enricher.NotFoundError{Candidates: 3, Reason: "none matched the artist and title"}}
+    testCases := []struct {
+        err      error
+        expected string
+    }{
+        {enricher.ErrNotFound, "no candidates"},
+        {errors.Join(rejected, enricher.ErrNotFound), "3 candidates, none matched the artist and title"},
+        {&lowConfidenceError{candidate: &enricher.TrackMetadata{Confidence: 0.45, Candidates: 2}}, "2 candidates, best scored 0.45 (below 0.70)"},
+    }
+    
+    for _, tc := range testCases {
+        if got := notFoundReason(tc.err); got != tc.expected {
+            t.Errorf("%v: expected %q, got %q", tc.err, tc.expected, got)
+        }
+    }
+}
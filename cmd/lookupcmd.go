@@ -0,0 +1,109 @@
+// cmd/lookupcmd.go
+package cmd
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/spf13/cobra"
+)
+
+var lookupCmd = &cobra.Command{
+    Use:   "lookup [artist] [title]",
+    Short: "Look up a single track and print the resolved metadata",
+    Long: `Resolve metadata for one track without touching any files.
+
+With --mbid, the MusicBrainz recording is fetched directly by its ID (from
+Picard or the MusicBrainz website) with no fuzzy search. This is the most
+reliable match and is useful for checking what batch would write for a
+known recording. Otherwise artist and title are searched as batch would.
+
+Examples:
+  tagger lookup --mbid b1a9c0e9-d987-4042-ae91-78d6a3267d69
+  tagger lookup "Goldie" "Inner City Life"
+  tagger lookup --mbid b1a9c0e9-d987-4042-ae91-78d6a3267d69 --json`,
+    Args: func(cmd *cobra.Command, args []string) error {
+        if lookupMBID != "" {
+            return cobra.NoArgs(cmd, args)
+        }
+        return cobra.ExactArgs(2)(cmd, args)
+    },
+    Run: runLookup,
+}
+
+var (
+    lookupMBID string
+    lookupJSON bool
+)
+
+func init() {
+    rootCmd.AddCommand(lookupCmd)
+    
+    lookupCmd.Flags().StringVar(&lookupMBID, "mbid", "", "MusicBrainz recording ID to fetch directly")
+    lookupCmd.Flags().BoolVar(&lookupJSON, "json", false, "print the metadata as JSON")
+}
+
+func runLookup(cmd *cobra.Command, args []string) {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+    
+    provider := newMusicBrainzProvider()
+    defer provider.Close()
+    
+    var metadata *enricher.TrackMetadata
+    var err error
+    if lookupMBID != "" {
+        metadata, err = provider.LookupByID(ctx, lookupMBID)
+    } else {
+        metadata, err = provider.Lookup(ctx, args[0], args[1])
+    }
+    
+    if err != nil {
+        if errors.Is(err, enricher.ErrNotFound) {
+            fmt.Fprintf(console, "No match found (%s)\n", notFoundReason(err))
+            exitCode = exitNothingFound
+        } else {
+            fatalf("%v", err)
+        }
+        return
+    }
+    
+    if lookupJSON {
+        encoder := json.NewEncoder(os.Stdout)
+        encoder.SetIndent("", "  ")
+        encoder.Encode(metadata)
+        return
+    }
+    
+    printTrackMetadata(metadata)
+}
+
+// printTrackMetadata prints the fields batch would write, plus provider IDs
+func printTrackMetadata(metadata *enricher.TrackMetadata) {
+    fmt.Fprintf(console, "Artist:         %s\n", metadata.Artist)
+    fmt.Fprintf(console, "Title:          %s\n", metadata.Title)
+    printIfSet("Album", metadata.Album)
+    printIfSet("Label", metadata.Label)
+    printIfSet("Catalog number", metadata.CatalogNumber)
+    printIfSet("Release date", metadata.ReleaseDate)
+    printIfSet("Genre", metadata.Genre)
+    fmt.Fprintf(console, "Confidence:     %.2f\n", metadata.Confidence)
+    
+    if id, ok := metadata.Extra["musicbrainz_recording_id"].(string); ok && id != "" {
+        fmt.Fprintf(console, "Recording:      https://musicbrainz.org/recording/%s\n", id)
+    }
+    if id, ok := metadata.Extra["musicbrainz_release_id"].(string); ok && id != "" {
+        fmt.Fprintf(console, "Release:        https://musicbrainz.org/release/%s\n", id)
+    }
+}
+
+func printIfSet(name, value string) {
+    if value != "" {
+        fmt.Fprintf(console, "%-16s%s\n", name+":", value)
+    }
+}
@@ -0,0 +1,135 @@
+// cmd/output.go
+package cmd
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+var (
+    // outputDir receives enriched copies instead of writing in place
+    // (--output-dir)
+    outputDir string
+    
+    // outputRoot is the scanned folder; copies keep their path relative to it
+    outputRoot string
+)
+
+// validateOutputDir resolves --output-dir against the scanned folder. Writing
+// copies back into the folder being scanned would clobber or mix with the
+// originals, and a later scan would pick the copies up as new files, so the
+// output dir must lie outside it. --from-file doesn't scan, so there only
+// the folder itself is refused.
+func validateOutputDir(root string) (string, error) {
+    dir, err := filepath.Abs(outputDir)
+    if err != nil {
+        return "", fmt.Errorf("could not resolve --output-dir %q: %w", outputDir, err)
+    }
+    if dir == root {
+        return "", fmt.Errorf("--output-dir must differ from the folder being processed")
+    }
+    if rel, err := filepath.Rel(root, dir); err == nil && fromFile == "" && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+        return "", fmt.Errorf("--output-dir must not be inside the folder being processed")
+    }
+    return dir, nil
+}
+
+// outputPathFor returns where the enriched copy of filePath goes, keeping
+// its path relative to outputRoot. An existing file is never overwritten:
+// the copy gets a " (1)", " (2)", ... suffix instead.
+func outputPathFor(filePath string) (string, error) {
+    rel, err := filepath.Rel(outputRoot, filePath)
+    if err != nil || strings.HasPrefix(rel, "..") {
+        rel = filepath.Base(filePath)
+    }
+    
+    target := filepath.Join(outputDir, rel)
+    ext := filepath.Ext(target)
+    base := strings.TrimSuffix(target, ext)
+    for n := 1; ; n++ {
+        if _, err := os.Lstat(target); os.IsNotExist(err) {
+            return target, nil
+        } else if err != nil {
+            return "", err
+        }
+        target = fmt.Sprintf("%s (%d)%s", base, n, ext)
+    }
+}
+
+// copyForWriting copies filePath to its output location and verifies the
+// copy is byte-for-byte identical before any tags are touched. It returns
+// the copy's path.
+func copyForWriting(filePath string) (string, error) {
+    target, err := outputPathFor(filePath)
+    if err != nil {
+        return "", err
+    }
+    if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+        return "", err
+    }
+    
+    if err := copyVerified(filePath, target); err != nil {
+        return "", fmt.Errorf("failed to copy to %s: %w", target, err)
+    }
+    return target, nil
+}
+
+// copyVerified copies src to a temporary file beside dst, re-reads it to
+// compare checksums, then renames it into place. dst is only created once
+// the copy is known to be good.
+func copyVerified(src, dst string) error {
+    in, err := os.Open(src)
+    if err != nil {
+        return err
+    }
+    defer in.Close()
+    
+    info, err := in.Stat()
+    if err != nil {
+        return err
+    }
+    
+    tmp, err := os.CreateTemp(filepath.Dir(dst), ".tagger-copy-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name()) // No-op once renamed
+    
+    srcHash := sha256.New()
+    if _, err := io.Copy(tmp, io.TeeReader(in, srcHash)); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return err
+    }
+    
+    dstHash := sha256.New()
+    if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+        tmp.Close()
+        return err
+    }
+    if _, err := io.Copy(dstHash, tmp); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    if !bytes.Equal(srcHash.Sum(nil), dstHash.Sum(nil)) {
+        return fmt.Errorf("copy does not match the original")
+    }
+    
+    if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+        return err
+    }
+    os.Chtimes(tmp.Name(), info.ModTime(), info.ModTime())
+    
+    return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestCopyForWriting(t *testing.T) {
+    root, out := t.TempDir(), t.TempDir()
+    outputRoot, outputDir = root, out
+    defer func() { outputRoot, outputDir = "", "" }()
+    
+    src := filepath.Join(root, "Metalheadz", "Goldie - Inner City Life.aiff")
+    content := []byte("FORM\x00\x00\x00\x04AIFF")
+    if err := os.MkdirAll(filepath.Dir(src), 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(src, content, 0644); err != nil {
+        t.Fatal(err)
+    }
+    
+    first, err := copyForWriting(src)
+    if err != nil {
+        t.Fatalf("copyForWriting returned error: %v", err)
+    }
+    if expected := filepath.Join(out, "Metalheadz", "Goldie - Inner City Life.aiff"); first != expected {
+        t.Errorf("Expected copy at %s, got %s", expected, first)
+    }
+    if copied, err := os.ReadFile(first); err != nil || !bytes.Equal(copied, content) {
+        t.Errorf("Expected an identical copy, got %q (%v)", copied, err)
+    }
+    
+    // A second copy must not overwrite the first
+    second, err := copyForWriting(src)
+    if err != nil {
+        t.Fatalf("copyForWriting returned error: %v", err)
+    }
+    if expected := filepath.Join(out, "Metalheadz", "Goldie - Inner City Life (1).aiff"); second != expected {
+        t.Errorf("Expected collision to get a suffix %s, got %s", expected, second)
+    }
+    
+    if original, _ := os.ReadFile(src); !bytes.Equal(original, content) {
+        t.Error("Expected the original to be untouched")
+    }
+}
+
+func TestValidateOutputDir_RejectsSourceFolder(t *testing.T) {
+    root := t.TempDir()
+    outputDir = root
+    defer func() { outputDir = "" }()
+    
+    if _, err := validateOutputDir(root); err == nil {
+        t.Error("Expected an error when --output-dir is the folder being processed")
+    }
+    
+    outputDir = filepath.Join(root, "tagged")
+    if _, err := validateOutputDir(root); err == nil {
+        t.Error("Expected an error when --output-dir is inside the folder being processed")
+    }
+    
+    // A folder whose name starts with ".." is still inside
+    outputDir = filepath.Join(root, "..tagged")
+    if _, err := validateOutputDir(root); err == nil {
+        t.Error("Expected an error for a folder inside the source named ..tagged")
+    }
+    
+    // --from-file doesn't walk the folder, so the copies can't be rescanned
+    fromFile = "tracks.txt"
+    defer func() { fromFile = "" }()
+    if _, err := validateOutputDir(root); err != nil {
+        t.Errorf("Expected a folder inside the source to be accepted with --from-file, got %v", err)
+    }
+    fromFile = ""
+    
+    outputDir = filepath.Join(filepath.Dir(root), filepath.Base(root)+"-tagged")
+    if _, err := validateOutputDir(root); err != nil {
+        t.Errorf("Expected a sibling folder to be accepted, got %v", err)
+    }
+}
@@ -0,0 +1,251 @@
+// cmd/overrides.go
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+
+    "github.com/cerberussg/tagger/pkg/audiotag"
+    "github.com/spf13/viper"
+)
+
+// overrideFileName is the per-folder override file. It applies to files in
+// its folder and every folder below it.
+const overrideFileName = ".tagger.yaml"
+
+// fileOverride is one manual correction: the files it matches get these
+// values whatever their tags, filename or lookup say. Empty fields are
+// left to the usual parsing and enrichment.
+type fileOverride struct {
+    Match  string `mapstructure:"match"`
+    Artist string `mapstructure:"artist"`
+    Title  string `mapstructure:"title"`
+    Label  string `mapstructure:"label"`
+    Year   int    `mapstructure:"year"`
+}
+
+// overrideFile is a parsed override file; its patterns are relative to dir
+type overrideFile struct {
+    path  string
+    dir   string
+    rules []fileOverride
+}
+
+// overrideCache remembers parsed override files for a run
+type overrideCache struct {
+    mu     sync.Mutex
+    files  map[string]*overrideFile
+    loaded map[string]bool
+}
+
+var overrides = newOverrideCache()
+
+func newOverrideCache() *overrideCache {
+    return &overrideCache{files: make(map[string]*overrideFile), loaded: make(map[string]bool)}
+}
+
+// forFile returns the rule for filePath and the file it came from. The
+// nearest folder's .tagger.yaml wins over those further up, and all of them
+// over the global overrides.file; within a file the first match wins.
+func (c *overrideCache) forFile(filePath string) (*fileOverride, string) {
+    abs, err := filepath.Abs(filePath)
+    if err != nil {
+        return nil, ""
+    }
+
+    for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+        if file := c.load(filepath.Join(dir, overrideFileName)); file != nil {
+            if rule := file.match(abs); rule != nil {
+                return rule, file.path
+            }
+        }
+        if parent := filepath.Dir(dir); parent == dir {
+            break
+        }
+    }
+
+    if global := viper.GetString("overrides.file"); global != "" {
+        if file := c.load(global); file != nil {
+            if rule := file.match(abs); rule != nil {
+                return rule, file.path
+            }
+        }
+    }
+    return nil, ""
+}
+
+// load parses the override file at path once per run; nil means there is
+// none or it couldn't be read
+func (c *overrideCache) load(path string) *overrideFile {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if c.loaded[path] {
+        return c.files[path]
+    }
+    c.loaded[path] = true
+
+    file, err := loadOverrideFile(path)
+    if err != nil {
+        fmt.Fprintf(console, "⚠️  Ignoring override file %s: %v\n", path, err)
+        return nil
+    }
+    c.files[path] = file
+    return file
+}
+
+// loadOverrideFile reads the overrides list from a YAML file:
+//
+//	overrides:
+//	  - match: "Goldie - Inner City Life*.aiff"
+//	    label: FFRR
+//	    year: 1994
+//
+// A missing file is not an error and returns nil.
+func loadOverrideFile(path string) (*overrideFile, error) {
+    if _, err := os.Stat(path); err != nil {
+        if os.IsNotExist(err) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    v := viper.New()
+    v.SetConfigFile(path)
+    v.SetConfigType("yaml")
+    if err := v.ReadInConfig(); err != nil {
+        return nil, err
+    }
+
+    var rules []fileOverride
+    if err := v.UnmarshalKey("overrides", &rules); err != nil {
+        return nil, err
+    }
+
+    file := &overrideFile{path: path, dir: filepath.Dir(path)}
+    for i, rule := range rules {
+        if rule.Match == "" {
+            fmt.Fprintf(console, "⚠️  Ignoring override %d in %s: no match pattern\n", i+1, path)
+            continue
+        }
+        if _, err := filepath.Match(rule.Match, ""); err != nil {
+            fmt.Fprintf(console, "⚠️  Ignoring override %q in %s: %v\n", rule.Match, path, err)
+            continue
+        }
+        file.rules = append(file.rules, rule)
+    }
+    return file, nil
+}
+
+// match returns the first rule matching the absolute path abs. A pattern
+// with a slash is matched against the path relative to the override file's
+// folder, any other against the file name. Matching ignores case, and an
+// exact name is tried before it is read as a glob, so names with [brackets]
+// can be listed as they are.
+func (f *overrideFile) match(abs string) *fileOverride {
+    rel, err := filepath.Rel(f.dir, abs)
+    if err != nil || strings.HasPrefix(rel, "..") {
+        rel = abs
+    }
+    rel = strings.ToLower(filepath.ToSlash(rel))
+    base := strings.ToLower(filepath.Base(abs))
+
+    for i := range f.rules {
+        pattern := strings.ToLower(filepath.ToSlash(f.rules[i].Match))
+        name := base
+        if strings.Contains(pattern, "/") {
+            name = rel
+        }
+        if name == pattern {
+            return &f.rules[i]
+        }
+        if ok, _ := filepath.Match(pattern, name); ok {
+            return &f.rules[i]
+        }
+    }
+    return nil
+}
+
+// applyOverride replaces what was read about filePath with its override, if
+// it has one, so the lookup searches for the corrected artist and title. It
+// returns the tags the override changes, to be written whatever the lookup
+// finds (only the label under --label-only), or nil when no rule matches.
+func applyOverride(filePath string, info *trackInfo) *audiotag.Update {
+    rule, source := overrides.forFile(filePath)
+    if rule == nil {
+        return nil
+    }
+    if viper.GetBool("verbose") {
+        fmt.Fprintf(console, "  🔖 Override from %s (%s)\n", source, rule.Match)
+    }
+
+    pinned := &audiotag.Update{}
+    if rule.Artist != "" && rule.Artist != info.Artist {
+        pinned.Artist = rule.Artist
+        info.Artist = rule.Artist
+    }
+    if rule.Title != "" && rule.Title != info.Title {
+        pinned.Title = rule.Title
+        info.Title = rule.Title
+    }
+    if rule.Label != "" && rule.Label != info.Label {
+        pinned.Label = rule.Label
+        info.Label = rule.Label
+    }
+    if rule.Year > 0 && rule.Year != info.Year {
+        pinned.Year = rule.Year
+        info.Year = rule.Year
+    }
+
+    // The artist and title are no longer a guess from the filename
+    if rule.Artist != "" && rule.Title != "" {
+        info.EdgeCase = ""
+    }
+    
+    // --label-only still searches with the corrected fields but writes only
+    // the label
+    if labelOnly && (pinned.Artist != "" || pinned.Title != "" || pinned.Year > 0) {
+        if viper.GetBool("verbose") {
+            fmt.Fprintf(console, "  ℹ️  --label-only: not writing the override's artist, title or year\n")
+        }
+        pinned = &audiotag.Update{Label: pinned.Label}
+    }
+    return pinned
+}
+
+// writeOverride writes the tags an override pins to a file the lookup
+// wrote nothing to
+func writeOverride(result *fileResult, pinned *audiotag.Update) {
+    if viper.GetBool("verbose") && !pinned.IsEmpty() {
+        fmt.Fprintf(console, "  🔖 Writing override\n")
+    }
+    written, err := writeTagUpdate(result.Path, pinned)
+    if written != "" && written != result.Path {
+        result.OutputPath = written
+    }
+    if err != nil {
+        if viper.GetBool("verbose") {
+            fmt.Fprintf(console, "    ❌ Failed to write override: %v\n", err)
+        }
+        result.Error = err.Error()
+    }
+}
+
+// mergeOverride lays the pinned tags over an update built from a match
+func mergeOverride(update, pinned *audiotag.Update) {
+    if pinned.Artist != "" {
+        update.Artist = pinned.Artist
+    }
+    if pinned.Title != "" {
+        update.Title = pinned.Title
+    }
+    if pinned.Label != "" {
+        update.Label = pinned.Label
+    }
+    if pinned.Year > 0 {
+        update.Year = pinned.Year
+    }
+}
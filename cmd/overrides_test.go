@@ -0,0 +1,145 @@
+// cmd/overrides_test.go
+package cmd
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/cerberussg/tagger/pkg/audiotag"
+)
+
+func writeOverrideFile(t *testing.T, dir, content string) {
+    t.Helper()
+    if err := os.WriteFile(filepath.Join(dir, overrideFileName), []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestOverrideFile_Match(t *testing.T) {
+    file := &overrideFile{dir: "/music", rules: []fileOverride{
+        {Match: "[METH 001] Goldie - Inner City Life.aiff", Label: "FFRR"},
+        {Match: "Photek - *", Label: "Science"},
+        {Match: "Promos/*.aiff", Label: "Promo"},
+    }}
+
+    testCases := []struct {
+        path     string
+        expected string
+    }{
+        {"/music/[METH 001] Goldie - Inner City Life.aiff", "FFRR"},
+        {"/music/dnb/[meth 001] goldie - inner city life.AIFF", "FFRR"},
+        {"/music/Photek - Ni-Ten-Ichi-Ryu.aiff", "Science"},
+        {"/music/Promos/Unknown - Dub.aiff", "Promo"},
+        {"/music/Elsewhere/Unknown - Dub.aiff", ""},
+    }
+
+    for _, tc := range testCases {
+        rule := file.match(tc.path)
+        got := ""
+        if rule != nil {
+            got = rule.Label
+        }
+        if got != tc.expected {
+            t.Errorf("%s: expected label %q, got %q", tc.path, tc.expected, got)
+        }
+    }
+}
+
+func TestOverrides_NearestFileWins(t *testing.T) {
+    root := t.TempDir()
+    sub := filepath.Join(root, "Metalheadz")
+    if err := os.Mkdir(sub, 0755); err != nil {
+        t.Fatal(err)
+    }
+    writeOverrideFile(t, root, "overrides:\n  - match: \"*.aiff\"\n    label: Root\n")
+    writeOverrideFile(t, sub, "overrides:\n  - match: \"Goldie - *\"\n    label: Metalheadz\n")
+
+    overrides = newOverrideCache()
+    defer func() { overrides = newOverrideCache() }()
+
+    if rule, _ := overrides.forFile(filepath.Join(sub, "Goldie - Angel.aiff")); rule == nil || rule.Label != "Metalheadz" {
+        t.Errorf("Expected the folder's own rule, got %+v", rule)
+    }
+    if rule, _ := overrides.forFile(filepath.Join(sub, "Dillinja - Angels Fell.aiff")); rule == nil || rule.Label != "Root" {
+        t.Errorf("Expected the parent folder's rule, got %+v", rule)
+    }
+}
+
+func TestProcessFile_Override(t *testing.T) {
+    dir := t.TempDir()
+    aiff := append([]byte("FORM\x00\x00\x00\x1eAIFFCOMM\x00\x00\x00\x12"), make([]byte, 18)...)
+    path := filepath.Join(dir, "gldi-icl-1994.aiff")
+    if err := os.WriteFile(path, aiff, 0644); err != nil {
+        t.Fatal(err)
+    }
+    writeOverrideFile(t, dir, `overrides:
+  - match: gldi-icl-*.aiff
+    artist: Goldie
+    title: Inner City Life
+    label: FFRR
+    year: 1994
+`)
+
+    overrides = newOverrideCache()
+    defer func() { overrides = newOverrideCache() }()
+
+    result := processFileWithEdgeCase(path, nil, context.Background())
+    if !result.Override || result.Status != "has_label" || result.Artist != "Goldie" || result.EdgeCase != "" {
+        t.Fatalf("Expected the override to stand in for parsing and lookup, got %+v", result)
+    }
+
+    metadata, err := audiotag.ReadFile(path)
+    if err != nil {
+        t.Fatalf("Failed to read tagged file: %v", err)
+    }
+    if metadata.Artist() != "Goldie" || metadata.Title() != "Inner City Life" || audiotag.Label(metadata) != "FFRR" || metadata.Year() != 1994 {
+        t.Errorf("Expected the override written, got %q - %q, %q, %d", metadata.Artist(), metadata.Title(), audiotag.Label(metadata), metadata.Year())
+    }
+
+    // Once written, later runs leave the file alone
+    info, err := readTrackInfo(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if pinned := applyOverride(path, info); pinned == nil || !pinned.IsEmpty() {
+        t.Errorf("Expected nothing left to write, got %+v", pinned)
+    }
+}
+
+func TestProcessFile_OverrideLabelOnly(t *testing.T) {
+    dir := t.TempDir()
+    aiff := append([]byte("FORM\x00\x00\x00\x1eAIFFCOMM\x00\x00\x00\x12"), make([]byte, 18)...)
+    path := filepath.Join(dir, "gldi-icl-1994.aiff")
+    if err := os.WriteFile(path, aiff, 0644); err != nil {
+        t.Fatal(err)
+    }
+    writeOverrideFile(t, dir, `overrides:
+  - match: gldi-icl-*.aiff
+    artist: Goldie
+    title: Inner City Life
+    label: FFRR
+    year: 1994
+`)
+
+    overrides = newOverrideCache()
+    labelOnly = true
+    defer func() {
+        overrides = newOverrideCache()
+        labelOnly = false
+    }()
+
+    processFileWithEdgeCase(path, nil, context.Background())
+
+    metadata, err := audiotag.ReadFile(path)
+    if err != nil {
+        t.Fatalf("Failed to read tagged file: %v", err)
+    }
+    if audiotag.Label(metadata) != "FFRR" {
+        t.Errorf("Expected the override's label written, got %q", audiotag.Label(metadata))
+    }
+    if metadata.Artist() != "" || metadata.Title() != "" || metadata.Year() != 0 {
+        t.Errorf("Expected --label-only to leave other tags alone, got %q - %q, %d", metadata.Artist(), metadata.Title(), metadata.Year())
+    }
+}
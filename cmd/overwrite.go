@@ -0,0 +1,87 @@
+// cmd/overwrite.go
+package cmd
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/cerberussg/tagger/pkg/tagger"
+    "github.com/spf13/viper"
+)
+
+// overwritePolicy holds the fields a match may replace when the file already
+// has them, from --overwrite or write.overwrite. Everything else is only
+// filled when empty.
+var overwritePolicy []string
+
+// loadOverwritePolicy reads write.overwrite, warning about and skipping
+// names that aren't fields. "all" names every field.
+func loadOverwritePolicy() {
+    overwritePolicy = nil
+    
+    seen := make(map[string]bool)
+    for _, name := range viper.GetStringSlice("write.overwrite") {
+        name = strings.ToLower(strings.TrimSpace(name))
+        fields := []string{name}
+        if name == "all" {
+            fields = tagger.Fields
+        } else if !isOverwriteField(name) {
+            fmt.Fprintf(console, "⚠️  Ignoring --overwrite %q: fields are %s or all\n", name, strings.Join(tagger.Fields, ", "))
+            continue
+        }
+        for _, field := range fields {
+            if !seen[field] {
+                seen[field] = true
+                overwritePolicy = append(overwritePolicy, field)
+            }
+        }
+    }
+    
+    if len(overwritePolicy) > 0 {
+        fmt.Fprintf(console, "OVERWRITE: %s will be replaced when a match differs; other fields are only filled when empty\n", strings.Join(overwritePolicy, ", "))
+    }
+}
+
+func isOverwriteField(name string) bool {
+    for _, field := range tagger.Fields {
+        if field == name {
+            return true
+        }
+    }
+    return false
+}
+
+// appendGenre is set when the genre policy is "append": an enriched genre
+// is added to the file's existing genres instead of replacing them
+var appendGenre bool
+
+// Genre policy flags; each overrides write.genre_policy for one run
+var (
+    appendGenreFlag  bool
+    replaceGenreFlag bool
+)
+
+// loadGenrePolicy reads write.genre_policy, "replace" or "append", unless
+// --append-genre or --replace-genre picks one
+func loadGenrePolicy() error {
+    policy := strings.ToLower(strings.TrimSpace(viper.GetString("write.genre_policy")))
+    switch {
+    case appendGenreFlag && replaceGenreFlag:
+        return fmt.Errorf("--append-genre and --replace-genre can't be used together")
+    case appendGenreFlag:
+        policy = "append"
+    case replaceGenreFlag:
+        policy = "replace"
+    }
+    
+    switch policy {
+    case "", "replace":
+        appendGenre = false
+    case "append":
+        appendGenre = true
+        fmt.Fprintln(console, "APPEND GENRE: enriched genres are added to existing genres instead of replacing them")
+    default:
+        return fmt.Errorf("write.genre_policy must be replace or append, not %q", policy)
+    }
+    return nil
+}
@@ -0,0 +1,71 @@
+// cmd/parens.go
+package cmd
+
+import (
+    "fmt"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/cerberussg/tagger/pkg/normalize"
+    "github.com/spf13/viper"
+)
+
+// qualifierRules builds the parentheses classifier rules from
+// parsing.parentheses; unset thresholds keep the defaults
+func qualifierRules() normalize.QualifierRules {
+    rules := normalize.DefaultQualifierRules()
+    rules.Labels = viper.GetStringSlice("parsing.parentheses.labels")
+    for key, threshold := range map[string]*int{
+        "max_label_words":  &rules.MaxLabelWords,
+        "min_album_length": &rules.MinAlbumLength,
+        "max_album_words":  &rules.MaxAlbumWords,
+    } {
+        if viper.IsSet("parsing.parentheses." + key) {
+            *threshold = viper.GetInt("parsing.parentheses." + key)
+        }
+    }
+    return rules
+}
+
+// applyParenthesesHints moves bracketed qualifiers that look like a label,
+// catalog number, year or album out of the search title and into the
+// matching hint, unless the file already supplies that hint. Mixes, guest
+// credits and anything unrecognised stay in the title. It does nothing
+// unless parsing.parentheses.hints is set.
+func applyParenthesesHints(req *enricher.SearchRequest) {
+    if !viper.GetBool("parsing.parentheses.hints") {
+        return
+    }
+    
+    core, qualifiers := normalize.CleanTitle(req.Title)
+    rules := qualifierRules()
+    title, moved := core, false
+    for _, q := range qualifiers {
+        kind := normalize.ClassifyQualifier(q, rules)
+        var hint *string
+        switch kind {
+        case normalize.QualifierLabel:
+            hint = &req.Label
+        case normalize.QualifierCatalog:
+            hint = &req.CatalogNumber
+        case normalize.QualifierYear:
+            hint = &req.Year
+        case normalize.QualifierAlbum:
+            hint = &req.Album
+        default:
+            title += " (" + q + ")"
+            continue
+        }
+        
+        moved = true
+        if *hint == "" {
+            *hint = q
+            if viper.GetBool("verbose") {
+                fmt.Fprintf(console, "  🔖 Parentheses hint: %s %q\n", kind, q)
+            }
+        }
+    }
+    
+    if moved {
+        req.Title = title
+    }
+}
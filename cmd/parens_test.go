@@ -0,0 +1,40 @@
+package cmd
+
+import (
+    "testing"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/spf13/viper"
+)
+
+func TestApplyParenthesesHints(t *testing.T) {
+    req := &enricher.SearchRequest{Title: "Inner City Life (Roni Size Remix) (FFRR Records) (1995)", Year: "1994"}
+    applyParenthesesHints(req)
+    if req.Title != "Inner City Life (Roni Size Remix) (FFRR Records) (1995)" {
+        t.Errorf("Expected the title untouched without parsing.parentheses.hints, got %q", req.Title)
+    }
+    
+    viper.Set("parsing.parentheses.hints", true)
+    viper.Set("parsing.parentheses.labels", []string{"Metalheadz"})
+    defer func() {
+        viper.Set("parsing.parentheses.hints", nil)
+        viper.Set("parsing.parentheses.labels", nil)
+    }()
+    
+    applyParenthesesHints(req)
+    if req.Title != "Inner City Life (Roni Size Remix)" {
+        t.Errorf("Expected the remix kept and the hints moved out of the title, got %q", req.Title)
+    }
+    if req.Label != "FFRR Records" {
+        t.Errorf("Expected label hint FFRR Records, got %q", req.Label)
+    }
+    if req.Year != "1994" {
+        t.Errorf("Expected the file's own year to win, got %q", req.Year)
+    }
+    
+    req = &enricher.SearchRequest{Title: "Angel (Metalheadz) [METH 001]"}
+    applyParenthesesHints(req)
+    if req.Title != "Angel" || req.Label != "Metalheadz" || req.CatalogNumber != "METH 001" {
+        t.Errorf("Unexpected request after hints: %+v", req)
+    }
+}
@@ -0,0 +1,75 @@
+// cmd/parseprofile.go
+package cmd
+
+import (
+    "fmt"
+    "log"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/cerberussg/tagger/pkg/tagger"
+    "github.com/spf13/viper"
+)
+
+// hyphenLayouts holds the parse profile from parsing.hyphen_patterns, keyed
+// by hyphen count. Counts without an entry use the built-in rules; a nil
+// layout marks the count as an edge case.
+var hyphenLayouts map[int]tagger.HyphenLayout
+
+// loadHyphenLayouts reads parsing.hyphen_patterns, warning about and
+// skipping invalid entries
+func loadHyphenLayouts() {
+    hyphenLayouts = nil
+    
+    patterns := viper.GetStringMapString("parsing.hyphen_patterns")
+    if len(patterns) == 0 {
+        return
+    }
+    
+    hyphenLayouts = make(map[int]tagger.HyphenLayout)
+    var counts []string
+    for key, spec := range patterns {
+        hyphens, err := strconv.Atoi(key)
+        if err != nil || hyphens < 0 {
+            fmt.Fprintf(console, "⚠️  Ignoring parse pattern %q: key must be a hyphen count\n", key)
+            continue
+        }
+        
+        layout, err := tagger.ParseHyphenLayout(spec, hyphens)
+        if err != nil {
+            fmt.Fprintf(console, "⚠️  Ignoring parse pattern for %d hyphens: %v\n", hyphens, err)
+            continue
+        }
+        hyphenLayouts[hyphens] = layout
+        counts = append(counts, key)
+    }
+    
+    if len(counts) > 0 {
+        sort.Strings(counts)
+        fmt.Fprintf(console, "Parse profile: custom patterns for %s hyphens\n", strings.Join(counts, ", "))
+    }
+}
+
+// filenameParser returns a parser using the parse profile that, with
+// --verbose, explains its guesses. It is built per call so the log follows
+// os.Stdout when --quiet or --jsonl redirect it.
+func filenameParser() *tagger.FilenameParser {
+    parser := &tagger.FilenameParser{Layouts: hyphenLayouts}
+    if viper.GetBool("verbose") {
+        parser.Logger = log.New(console, "  🔍 ", 0)
+    }
+    return parser
+}
+
+// parseFilenameWithEdgeCase extracts artist and title from a filename, also
+// returning the edge case it hit, if any
+func parseFilenameWithEdgeCase(filePath string) (artist, title, edgeCase string) {
+    return filenameParser().Parse(filePath)
+}
+
+// parseFilename extracts artist and title from a filename
+func parseFilename(filePath string) (artist, title string) {
+    artist, title, _ = parseFilenameWithEdgeCase(filePath)
+    return artist, title
+}
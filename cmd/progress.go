@@ -0,0 +1,122 @@
+// cmd/progress.go
+package cmd
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "time"
+)
+
+// progressLineStep is how often, in percent, plain-line progress is printed
+const progressLineStep = 10
+
+// progressBarWidth is the width of the bar drawn on a terminal
+const progressBarWidth = 20
+
+// musicBrainzCallInterval is the gap MusicBrainz enforces between requests
+const musicBrainzCallInterval = time.Second
+
+// progress reports how far a run has got, with an ETA from the average
+// time per file so far. On a terminal it redraws a single line in place;
+// elsewhere (logs, pipes) it prints a plain line every progressLineStep
+// percent. With a rate floor set, the ETA is never less than the time the
+// API calls still to come must take.
+type progress struct {
+    out          io.Writer
+    total        int
+    inPlace      bool
+    start        time.Time
+    now          func() time.Time
+    lastStep     int
+    drawn        bool
+    calls        func() int
+    callInterval time.Duration
+}
+
+func newProgress(out io.Writer, total int, inPlace bool) *progress {
+    return &progress{out: out, total: total, inPlace: inPlace, start: time.Now(), now: time.Now}
+}
+
+// withRateFloor makes the ETA account for rate-limited API calls: calls
+// returns how many have been made so far, and each takes at least interval.
+// The calls per file so far are assumed for the files left, so cache hits
+// lower the floor.
+func (p *progress) withRateFloor(calls func() int, interval time.Duration) *progress {
+    if p != nil {
+        p.calls, p.callInterval = calls, interval
+    }
+    return p
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+    info, err := f.Stat()
+    return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// update records that done files have been processed
+func (p *progress) update(done int) {
+    if p == nil || p.total == 0 {
+        return
+    }
+    
+    if p.inPlace {
+        fmt.Fprintf(p.out, "\r%s\033[K", p.line(done))
+        p.drawn = true
+        return
+    }
+    
+    step := done * 100 / p.total / progressLineStep
+    if step > p.lastStep || done == p.total {
+        p.lastStep = step
+        fmt.Fprintln(p.out, p.line(done))
+    }
+}
+
+// finish ends the in-place line, leaving the final count on screen
+func (p *progress) finish() {
+    if p != nil && p.drawn {
+        fmt.Fprintln(p.out)
+        p.drawn = false
+    }
+}
+
+// clear removes the in-place line so other output starts on a clean line
+func (p *progress) clear() {
+    if p != nil && p.drawn {
+        fmt.Fprint(p.out, "\r\033[K")
+        p.drawn = false
+    }
+}
+
+// line formats the count, percentage, elapsed time and ETA, led by a bar
+// when drawn in place
+func (p *progress) line(done int) string {
+    elapsed := p.now().Sub(p.start)
+    width := len(fmt.Sprint(p.total))
+    text := fmt.Sprintf("[%*d/%d] %5.1f%%  elapsed %s", width, done, p.total, float64(done)/float64(p.total)*100, elapsed.Round(time.Second))
+    if p.inPlace {
+        filled := done * progressBarWidth / p.total
+        text = fmt.Sprintf("[%s%s] ", strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled)) + text
+    }
+    
+    if done > 0 && done < p.total {
+        text += fmt.Sprintf("  ETA %s", p.eta(done, elapsed).Round(time.Second))
+    }
+    return text
+}
+
+// eta estimates the time left from the average time per file, raised to the
+// rate-limit floor when one is set
+func (p *progress) eta(done int, elapsed time.Duration) time.Duration {
+    left := time.Duration(p.total - done)
+    remaining := elapsed / time.Duration(done) * left
+    if p.calls != nil {
+        if floor := time.Duration(p.calls()) * p.callInterval / time.Duration(done) * left; floor > remaining {
+            remaining = floor
+        }
+    }
+    return remaining
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestProgress_PlainLines(t *testing.T) {
+    var out bytes.Buffer
+    bar := newProgress(&out, 20, false)
+    start := bar.start
+    elapsed := time.Duration(0)
+    bar.now = func() time.Time { return start.Add(elapsed) }
+    
+    for done := 1; done <= 20; done++ {
+        elapsed = time.Duration(done) * time.Second
+        bar.update(done)
+    }
+    bar.finish()
+    
+    lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+    if len(lines) != 10 {
+        t.Fatalf("Expected a line every 10%%, got %d lines:\n%s", len(lines), out.String())
+    }
+    if lines[0] != "[ 2/20]  10.0%  elapsed 2s  ETA 18s" {
+        t.Errorf("Unexpected first line %q", lines[0])
+    }
+    if lines[9] != "[20/20] 100.0%  elapsed 20s" {
+        t.Errorf("Unexpected last line %q", lines[9])
+    }
+}
+
+func TestProgress_InPlace(t *testing.T) {
+    var out bytes.Buffer
+    bar := newProgress(&out, 3, true)
+    
+    bar.update(1)
+    bar.update(2)
+    bar.clear()
+    
+    if got := strings.Count(out.String(), "\r"); got != 3 {
+        t.Errorf("Expected each update and the clear to return the cursor, got %d in %q", got, out.String())
+    }
+    if strings.Contains(out.String(), "\n") {
+        t.Errorf("Expected no newlines from in-place updates, got %q", out.String())
+    }
+}
+
+func TestProgress_RateFloor(t *testing.T) {
+    var out bytes.Buffer
+    calls := 0
+    bar := newProgress(&out, 10, false).withRateFloor(func() int { return calls }, time.Second)
+    
+    // Two files in half a second would suggest 2s left, but they made four
+    // rate-limited calls, so the eight files left need at least 16s
+    calls = 4
+    if got := bar.eta(2, 500*time.Millisecond); got != 16*time.Second {
+        t.Errorf("Expected the ETA raised to the rate floor, got %s", got)
+    }
+    
+    // Cache hits make no calls, leaving the average-based estimate
+    calls = 0
+    if got := bar.eta(2, 500*time.Millisecond); got != 2*time.Second {
+        t.Errorf("Expected the average-based ETA without calls, got %s", got)
+    }
+}
+
+func TestProgress_InPlaceBar(t *testing.T) {
+    var out bytes.Buffer
+    bar := newProgress(&out, 4, true)
+    
+    if line := bar.line(1); !strings.HasPrefix(line, "[#####---------------] [1/4]") {
+        t.Errorf("Expected a quarter-filled bar, got %q", line)
+    }
+    if line := newProgress(&out, 4, false).line(1); strings.Contains(line, "#") {
+        t.Errorf("Expected no bar in plain lines, got %q", line)
+    }
+}
@@ -0,0 +1,115 @@
+// cmd/rename.go
+package cmd
+
+import (
+    "bufio"
+    "encoding/csv"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+var renameScript string
+
+// renameEntry is an unresolved file and the name suggested for it, or ""
+// when there is no usable guess
+type renameEntry struct {
+    Path      string
+    EdgeCase  string
+    Suggested string
+}
+
+// renameCandidate returns the rename entry for a file whose name couldn't
+// be parsed cleanly, was found to be swapped or matched nothing, and false
+// for everything else. Unreadable files are left out: renaming won't help
+// them.
+func renameCandidate(result *fileResult) (renameEntry, bool) {
+    if renameScript == "" || result.EdgeCase == "unreadable_file" {
+        return renameEntry{}, false
+    }
+    edgeCase := result.EdgeCase
+    if edgeCase == "" {
+        if !result.notFound {
+            return renameEntry{}, false
+        }
+        edgeCase = "no_match"
+    }
+    return renameEntry{
+        Path:      result.Path,
+        EdgeCase:  edgeCase,
+        Suggested: suggestedFilename(result.Path, result.Artist, result.Title),
+    }, true
+}
+
+// filenameReplacer makes an artist or title safe to use in a filename.
+// Slashes from reconstructed "A/B" artists become commas, and hyphens
+// inside a part become spaces so "Artist - Title" is the only delimiter.
+var filenameReplacer = strings.NewReplacer(
+    "/", ", ", "\\", ", ", "-", " ",
+    ":", "", "*", "", "?", "", "\"", "", "<", "", ">", "", "|", "",
+)
+
+// suggestedFilename returns "Artist - Title" with path's extension, in the
+// same folder, or "" when either part is missing or the name wouldn't
+// change
+func suggestedFilename(path, artist, title string) string {
+    clean := func(s string) string {
+        return strings.Join(strings.Fields(filenameReplacer.Replace(s)), " ")
+    }
+    artist, title = clean(artist), clean(title)
+    if artist == "" || title == "" {
+        return ""
+    }
+
+    suggested := filepath.Join(filepath.Dir(path), artist+" - "+title+filepath.Ext(path))
+    if suggested == path {
+        return ""
+    }
+    return suggested
+}
+
+// writeRenameScript writes the entries as a CSV mapping when outputPath
+// ends in .csv, and as a POSIX shell script of mv commands otherwise.
+// Files without a suggestion are listed as comments (or an empty column)
+// so nothing unresolved drops out of sight.
+func writeRenameScript(entries []renameEntry, outputPath string) error {
+    file, err := os.Create(outputPath)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    if strings.EqualFold(filepath.Ext(outputPath), ".csv") {
+        w := csv.NewWriter(file)
+        w.Write([]string{"path", "edge_case", "suggested"})
+        for _, entry := range entries {
+            w.Write([]string{entry.Path, entry.EdgeCase, entry.Suggested})
+        }
+        w.Flush()
+        return w.Error()
+    }
+
+    w := bufio.NewWriter(file)
+    fmt.Fprintln(w, "#!/bin/sh")
+    fmt.Fprintln(w, "# Suggested renames for files tagger couldn't parse or match.")
+    fmt.Fprintln(w, "# Review each line before running; mv -n never overwrites a file.")
+    for _, entry := range entries {
+        fmt.Fprintf(w, "\n# %s\n", strings.Replace(entry.EdgeCase, "_", " ", -1))
+        if entry.Suggested == "" {
+            // Quoted Go-style: a newline in the name would end the comment
+            fmt.Fprintf(w, "# no suggestion: %q\n", entry.Path)
+            continue
+        }
+        fmt.Fprintf(w, "mv -n -- %s %s\n", shellQuote(entry.Path), shellQuote(entry.Suggested))
+    }
+    if err := w.Flush(); err != nil {
+        return err
+    }
+    return file.Chmod(0755)
+}
+
+// shellQuote single-quotes s for a POSIX shell
+func shellQuote(s string) string {
+    return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+    "encoding/csv"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestSuggestedFilename(t *testing.T) {
+    tests := []struct {
+        path, artist, title, want string
+    }{
+        {"/music/Goldie Inner City Life.aiff", "Goldie", "Inner City Life", "/music/Goldie - Inner City Life.aiff"},
+        {"/music/a-b-c-d.aiff", "Dillinja/Lemon D", "Killer-Bees", "/music/Dillinja, Lemon D - Killer Bees.aiff"},
+        {"/music/x.wav", "Photek", "Ni Ten Ichi Ryu: Two Swords?", "/music/Photek - Ni Ten Ichi Ryu Two Swords.wav"},
+        {"/music/Goldie - Angel.aiff", "Goldie", "Angel", ""},
+        {"/music/untitled.aiff", "", "untitled", ""},
+    }
+
+    for _, tt := range tests {
+        if got := suggestedFilename(tt.path, tt.artist, tt.title); got != tt.want {
+            t.Errorf("suggestedFilename(%q, %q, %q) = %q, expected %q", tt.path, tt.artist, tt.title, got, tt.want)
+        }
+    }
+}
+
+func TestRenameCandidate(t *testing.T) {
+    renameScript = "renames.sh"
+    defer func() { renameScript = "" }()
+
+    if _, ok := renameCandidate(&fileResult{Path: "/music/Goldie - Angel.aiff", Artist: "Goldie", Title: "Angel"}); ok {
+        t.Error("Expected a cleanly parsed file to be left out")
+    }
+    if _, ok := renameCandidate(&fileResult{Path: "/music/broken.aiff", EdgeCase: "unreadable_file"}); ok {
+        t.Error("Expected unreadable files to be left out")
+    }
+
+    entry, ok := renameCandidate(&fileResult{Path: "/music/Angel - Goldie.aiff", EdgeCase: "swapped_artist_title", Artist: "Goldie", Title: "Angel", Swapped: true})
+    if !ok || entry.Suggested != "/music/Goldie - Angel.aiff" {
+        t.Errorf("Expected a swapped file renamed to artist first, got %+v", entry)
+    }
+    
+    entry, ok = renameCandidate(&fileResult{Path: "/music/goldie_angel.aiff", Artist: "Goldie", Title: "Angel", notFound: true})
+    if !ok || entry.EdgeCase != "no_match" || entry.Suggested != "/music/Goldie - Angel.aiff" {
+        t.Errorf("Expected an unmatched file to get a suggestion, got %+v", entry)
+    }
+}
+
+func TestWriteRenameScript(t *testing.T) {
+    dir := t.TempDir()
+    entries := []renameEntry{
+        {Path: "/music/Goldie's Angel.aiff", EdgeCase: "no_hyphens", Suggested: "/music/Goldie - Angel.aiff"},
+        {Path: "/music/a-b-c.aiff", EdgeCase: "three_hyphens"},
+        {Path: "/music/x\ntouch pwned #.aiff", EdgeCase: "no_hyphens"},
+    }
+
+    script := filepath.Join(dir, "renames.sh")
+    if err := writeRenameScript(entries, script); err != nil {
+        t.Fatalf("writeRenameScript returned error: %v", err)
+    }
+    data, err := os.ReadFile(script)
+    if err != nil {
+        t.Fatal(err)
+    }
+    text := string(data)
+    if !strings.HasPrefix(text, "#!/bin/sh\n") {
+        t.Error("Expected a shell script")
+    }
+    if !strings.Contains(text, `mv -n -- '/music/Goldie'\''s Angel.aiff' '/music/Goldie - Angel.aiff'`) {
+        t.Errorf("Expected a quoted mv command, got:\n%s", text)
+    }
+    if !strings.Contains(text, `# no suggestion: "/music/a-b-c.aiff"`) {
+        t.Errorf("Expected files without a suggestion listed as comments, got:\n%s", text)
+    }
+    if !strings.Contains(text, `# no suggestion: "/music/x\ntouch pwned #.aiff"`) {
+        t.Errorf("Expected a newline in a name to stay inside its comment, got:\n%s", text)
+    }
+
+    mapping := filepath.Join(dir, "renames.csv")
+    if err := writeRenameScript(entries, mapping); err != nil {
+        t.Fatalf("writeRenameScript returned error: %v", err)
+    }
+    f, err := os.Open(mapping)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    records, err := csv.NewReader(f).ReadAll()
+    if err != nil {
+        t.Fatalf("failed to parse CSV: %v", err)
+    }
+    if len(records) != 4 || records[1][2] != "/music/Goldie - Angel.aiff" || records[2][2] != "" {
+        t.Errorf("Unexpected CSV mapping: %v", records)
+    }
+}
@@ -0,0 +1,264 @@
+// cmd/report.go
+package cmd
+
+import (
+    "fmt"
+    "html/template"
+    "os"
+    "os/signal"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+
+    "github.com/spf13/viper"
+)
+
+// edgeCaseReportTemplate renders the edge case HTML report. html/template
+// escapes file names and paths for both the HTML body and the JS string
+// inside the onclick handler.
+var edgeCaseReportTemplate = template.Must(template.New("edge-cases").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <title>Library Edge Cases</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 40px; }
+        h1 { color: #333; }
+        h2 { color: #666; margin-top: 30px; }
+        table { border-collapse: collapse; width: 100%; margin-bottom: 20px; }
+        th, td { border: 1px solid #ddd; padding: 12px; text-align: left; }
+        th { background-color: #f2f2f2; }
+        tr:nth-child(even) { background-color: #f9f9f9; }
+        .description { background-color: #f0f8ff; padding: 15px; border-radius: 5px; margin-bottom: 20px; }
+        .path { font-family: monospace; font-size: 0.9em; color: #666; word-break: break-all; cursor: pointer; }
+        .path:hover { background-color: #f0f0f0; }
+        .partial { background-color: #fff4e5; padding: 15px; border-radius: 5px; margin-bottom: 20px; }
+        .copy-hint { font-size: 0.8em; color: #888; font-style: italic; }
+    </style>
+    <script>
+        function copyToClipboard(text) {
+            navigator.clipboard.writeText(text).then(function() {
+                alert('Path copied to clipboard!');
+            }).catch(function() {
+                // Fallback for older browsers
+                prompt('Copy this path:', text);
+            });
+        }
+    </script>
+</head>
+<body>
+    <h1>Library Edge Cases</h1>
+{{if .Partial}}    <div class="partial">
+        <p><strong>⚠️ Partial report:</strong> {{.Scanned}} of {{.Total}} files scanned so far. It is rewritten as the run goes on and completed when it finishes.</p>
+    </div>
+{{end}}    <div class="description">
+        <p>These files have naming patterns that couldn't be automatically parsed for artist and title extraction. 
+        They may need manual review or custom parsing rules.</p>
+        <p><strong>💡 Tip:</strong> Click on any path to copy it to your clipboard, then use ⌘+Shift+G in Finder to navigate there.</p>
+        <p><strong>Edge Case Types:</strong></p>
+        <ul>
+            <li><strong>No Hyphens:</strong> Files without hyphen separators</li>
+            <li><strong>Three Hyphens:</strong> Ambiguous patterns requiring manual review</li>
+            <li><strong>Many Hyphens:</strong> Complex patterns with 5+ hyphens</li>
+            <li><strong>Profile Edge Case:</strong> Hyphen counts your parse profile marks as <code>edge</code></li>
+            <li><strong>Swapped Artist Title:</strong> Named "Title - Artist"; these only matched with the two swapped, so consider renaming them</li>
+            <li><strong>Unreadable File:</strong> Damaged audio containers (truncated chunks, or an AIFF/AIFF-C without a usable COMM chunk); re-rip or re-download these</li>
+        </ul>
+    </div>
+{{range .Categories}}
+    <h2>{{.Title}} ({{len .Files}} files)</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>File Name</th>
+                <th>Path (Click to Copy)</th>
+            </tr>
+        </thead>
+        <tbody>
+{{range .Files}}            <tr>
+                <td>{{.Name}}</td>
+                <td class="path" onclick="copyToClipboard({{.Dir}})" title="Click to copy path">{{.Dir}}<br><span class="copy-hint">📋 Click to copy</span></td>
+            </tr>
+{{end}}        </tbody>
+    </table>
+{{end}}
+</body>
+</html>`))
+
+// reportFile is a single file row in the edge case report
+type reportFile struct {
+    Name string
+    Dir  string
+}
+
+// reportCategory groups the files for one edge case type
+type reportCategory struct {
+    Title string
+    Files []reportFile
+}
+
+// edgeCaseReport is the data passed to edgeCaseReportTemplate
+type edgeCaseReport struct {
+    Categories []reportCategory
+
+    // Partial is set while the run is still going, or was interrupted,
+    // after Scanned of Total files
+    Partial        bool
+    Scanned, Total int
+}
+
+// buildEdgeCaseReport converts the edge case map into template data,
+// ordering categories by name so output is stable between runs
+func buildEdgeCaseReport(edgeCases map[string][]string) edgeCaseReport {
+    caseTypes := make([]string, 0, len(edgeCases))
+    for caseType := range edgeCases {
+        caseTypes = append(caseTypes, caseType)
+    }
+    sort.Strings(caseTypes)
+
+    var report edgeCaseReport
+    for _, caseType := range caseTypes {
+        category := reportCategory{
+            Title: strings.ToUpper(strings.Replace(caseType, "_", " ", -1)),
+        }
+        for _, filePath := range edgeCases[caseType] {
+            category.Files = append(category.Files, reportFile{
+                Name: filepath.Base(filePath),
+                Dir:  filepath.Dir(filePath),
+            })
+        }
+        report.Categories = append(report.Categories, category)
+    }
+    return report
+}
+
+// generateHTMLReport creates an HTML file showing edge cases with links to file locations
+func generateHTMLReport(edgeCases map[string][]string, outputPath string) error {
+    return writeEdgeCaseReport(buildEdgeCaseReport(edgeCases), outputPath)
+}
+
+// writeEdgeCaseReport renders report to a temporary file and renames it
+// into place, so an interrupted write never leaves a truncated report. The
+// report keeps the mode of the one it replaces, or is readable by everyone
+// (0644) like any other new file.
+func writeEdgeCaseReport(report edgeCaseReport, outputPath string) error {
+    tmp, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    
+    mode := os.FileMode(0644)
+    if info, err := os.Stat(outputPath); err == nil {
+        mode = info.Mode().Perm()
+    }
+    if err := tmp.Chmod(mode); err != nil {
+        tmp.Close()
+        return err
+    }
+
+    if err := edgeCaseReportTemplate.Execute(tmp, report); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), outputPath)
+}
+
+// edgeCaseFlushInterval is how often at most a run rewrites --html-report
+// as it finds edge cases
+const edgeCaseFlushInterval = 10 * time.Second
+
+// edgeCaseLog collects edge cases during a batch run. With --html-report it
+// keeps a partial report on disk as they're found, and writes one when the
+// run is interrupted, so a crash or Ctrl-C doesn't lose hours of scanning.
+type edgeCaseLog struct {
+    mu        sync.Mutex
+    path      string
+    byType    map[string][]string
+    scanned   int
+    total     int
+    dirty     bool
+    lastWrite time.Time
+    interrupt chan os.Signal
+}
+
+// newEdgeCaseLog starts a log for total files. With an empty path nothing
+// is written until the run ends.
+func newEdgeCaseLog(path string, total int) *edgeCaseLog {
+    l := &edgeCaseLog{path: path, byType: make(map[string][]string), total: total, lastWrite: time.Now()}
+    if path != "" {
+        l.interrupt = make(chan os.Signal, 1)
+        signal.Notify(l.interrupt, os.Interrupt, syscall.SIGTERM)
+        go l.watchInterrupt()
+    }
+    return l
+}
+
+// add records one file's edge case
+func (l *edgeCaseLog) add(caseType, filePath string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    l.byType[caseType] = append(l.byType[caseType], filePath)
+    l.dirty = true
+}
+
+// progress notes that scanned files are done, rewriting the partial report
+// if edge cases were found since the last write and the interval is up
+func (l *edgeCaseLog) progress(scanned int) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    l.scanned = scanned
+    if l.path == "" || !l.dirty || time.Since(l.lastWrite) < edgeCaseFlushInterval {
+        return
+    }
+    if err := l.writePartial(); err != nil && viper.GetBool("verbose") {
+        fmt.Fprintf(console, "  ⚠️  Could not update partial report: %v\n", err)
+    }
+}
+
+// writePartial writes what has been found so far; l.mu must be held
+func (l *edgeCaseLog) writePartial() error {
+    report := buildEdgeCaseReport(l.byType)
+    report.Partial, report.Scanned, report.Total = true, l.scanned, l.total
+    l.dirty, l.lastWrite = false, time.Now()
+    return writeEdgeCaseReport(report, l.path)
+}
+
+// watchInterrupt writes the partial report and exits when the run is
+// interrupted
+func (l *edgeCaseLog) watchInterrupt() {
+    if _, ok := <-l.interrupt; !ok {
+        return
+    }
+
+    l.mu.Lock()
+    err := l.writePartial()
+    scanned, total := l.scanned, l.total
+    l.mu.Unlock()
+
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "\nInterrupted - could not write partial report: %v\n", err)
+    } else {
+        fmt.Fprintf(os.Stderr, "\nInterrupted - partial report written: %s (%d of %d files scanned)\n", l.path, scanned, total)
+    }
+    os.Exit(exitInterrupted)
+}
+
+// finish stops watching for interrupts and returns the edge cases by type
+func (l *edgeCaseLog) finish() map[string][]string {
+    if l.interrupt != nil {
+        signal.Stop(l.interrupt)
+        close(l.interrupt)
+    }
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.byType
+}
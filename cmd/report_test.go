@@ -0,0 +1,106 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestGenerateHTMLReport_EscapesPaths(t *testing.T) {
+    output := filepath.Join(t.TempDir(), "report.html")
+
+    edgeCases := map[string][]string{
+        "no_hyphens": {
+            "/music/it's <b>bold</b>/Artist's Track.aiff",
+        },
+    }
+
+    if err := generateHTMLReport(edgeCases, output); err != nil {
+        t.Fatalf("generateHTMLReport returned error: %v", err)
+    }
+
+    data, err := os.ReadFile(output)
+    if err != nil {
+        t.Fatalf("failed to read report: %v", err)
+    }
+    html := string(data)
+
+    if strings.Contains(html, "<b>bold</b>") {
+        t.Error("expected markup in paths to be escaped")
+    }
+
+    if strings.Contains(html, "copyToClipboard('/music/it's") {
+        t.Error("expected apostrophe in onclick path to be escaped")
+    }
+
+    if !strings.Contains(html, "NO HYPHENS (1 files)") {
+        t.Error("expected category heading with file count")
+    }
+}
+
+func TestGenerateHTMLReport_Mode(t *testing.T) {
+    output := filepath.Join(t.TempDir(), "report.html")
+    edgeCases := map[string][]string{"no_hyphens": {"/music/track.aiff"}}
+    
+    if err := generateHTMLReport(edgeCases, output); err != nil {
+        t.Fatalf("generateHTMLReport returned error: %v", err)
+    }
+    info, err := os.Stat(output)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if info.Mode().Perm() != 0644 {
+        t.Errorf("Expected a new report to be 0644, got %v", info.Mode().Perm())
+    }
+    
+    if err := os.Chmod(output, 0640); err != nil {
+        t.Fatal(err)
+    }
+    if err := generateHTMLReport(edgeCases, output); err != nil {
+        t.Fatalf("generateHTMLReport returned error: %v", err)
+    }
+    if info, err = os.Stat(output); err != nil {
+        t.Fatal(err)
+    }
+    if info.Mode().Perm() != 0640 {
+        t.Errorf("Expected the replaced report's mode kept, got %v", info.Mode().Perm())
+    }
+}
+
+func TestEdgeCaseLog_WritesPartialReport(t *testing.T) {
+    output := filepath.Join(t.TempDir(), "report.html")
+    log := newEdgeCaseLog(output, 3)
+
+    log.add("no_hyphens", "/music/untitled.aiff")
+    log.progress(1)
+    if _, err := os.Stat(output); !os.IsNotExist(err) {
+        t.Fatalf("Expected no rewrite before the flush interval, got %v", err)
+    }
+
+    // Pretend the interval has passed
+    log.lastWrite = log.lastWrite.Add(-edgeCaseFlushInterval)
+    log.progress(2)
+    data, err := os.ReadFile(output)
+    if err != nil {
+        t.Fatalf("Expected a partial report, got %v", err)
+    }
+    if html := string(data); !strings.Contains(html, "2 of 3 files scanned") || !strings.Contains(html, "NO HYPHENS (1 files)") {
+        t.Errorf("Expected the partial report to show progress and the edge case, got:\n%s", html)
+    }
+
+    edgeCases := log.finish()
+    if len(edgeCases["no_hyphens"]) != 1 {
+        t.Errorf("Expected the collected edge cases back, got %v", edgeCases)
+    }
+
+    if err := generateHTMLReport(edgeCases, output); err != nil {
+        t.Fatal(err)
+    }
+    if data, _ := os.ReadFile(output); strings.Contains(string(data), "Partial report") {
+        t.Error("Expected the final report to replace the partial one")
+    }
+    if entries, _ := os.ReadDir(filepath.Dir(output)); len(entries) != 1 {
+        t.Errorf("Expected no temporary files left behind, got %d entries", len(entries))
+    }
+}
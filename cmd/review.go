@@ -0,0 +1,133 @@
+// cmd/review.go
+package cmd
+
+import (
+    "html/template"
+    "os"
+    "path/filepath"
+    "sort"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+var (
+    reviewReport string
+    reviewMin    float64
+    reviewMax    float64
+)
+
+// reviewReportTemplate renders the review queue, least confident match first
+var reviewReportTemplate = template.Must(template.New("review").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <title>Match Review Queue</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 40px; }
+        h1 { color: #333; }
+        table { border-collapse: collapse; width: 100%; margin-bottom: 20px; }
+        th, td { border: 1px solid #ddd; padding: 12px; text-align: left; vertical-align: top; }
+        th { background-color: #f2f2f2; }
+        tr:nth-child(even) { background-color: #f9f9f9; }
+        .description { background-color: #f0f8ff; padding: 15px; border-radius: 5px; margin-bottom: 20px; }
+        .path { font-family: monospace; font-size: 0.9em; color: #666; word-break: break-all; }
+        .confidence { font-weight: bold; }
+        .status { font-size: 0.9em; color: #888; }
+    </style>
+</head>
+<body>
+    <h1>Match Review Queue ({{len .Entries}} files)</h1>
+    <div class="description">
+        <p>These files matched with a confidence between {{printf "%.2f" .Min}} and {{printf "%.2f" .Max}}.
+        They are sorted with the least confident match first, so the riskiest are at the top.</p>
+        <p>Files with no match at all are not listed here; see the edge case report for those.</p>
+    </div>
+    <table>
+        <thead>
+            <tr>
+                <th>Confidence</th>
+                <th>File</th>
+                <th>Searched As</th>
+                <th>Candidate</th>
+                <th>Label / Catalog</th>
+                <th>Release</th>
+            </tr>
+        </thead>
+        <tbody>
+{{range .Entries}}            <tr>
+                <td class="confidence">{{printf "%.2f" .Match.Confidence}}<br><span class="status">{{.Result.Status}}</span></td>
+                <td>{{.Name}}<br><span class="path">{{.Dir}}</span></td>
+                <td>{{.Result.Artist}} - {{.Result.Title}}</td>
+                <td>{{.Match.Artist}} - {{.Match.Title}}{{if .Match.ProviderID}}<br><span class="path">{{.Match.ProviderName}} {{.Match.ProviderID}}</span>{{end}}</td>
+                <td>{{.Match.Label}}{{if .Match.CatalogNumber}}<br>{{.Match.CatalogNumber}}{{end}}</td>
+                <td>{{.Match.Album}}{{if .Match.ReleaseDate}}<br>{{.Match.ReleaseDate}}{{end}}</td>
+            </tr>
+{{end}}        </tbody>
+    </table>
+</body>
+</html>`))
+
+// reviewEntry is a borderline match queued for human review
+type reviewEntry struct {
+    Result *fileResult
+    Match  *enricher.TrackMetadata
+}
+
+// Name returns the file name shown in the report
+func (e reviewEntry) Name() string {
+    return filepath.Base(e.Result.Path)
+}
+
+// Dir returns the file's folder shown in the report
+func (e reviewEntry) Dir() string {
+    return filepath.Dir(e.Result.Path)
+}
+
+// lookupMinConfidence is the threshold the enricher applies. With a review
+// report it drops to the bottom of the review band so borderline matches
+// come back, and with a confidence report to zero so every match is
+// charted; cachedLookup still rejects them below --min-confidence.
+func lookupMinConfidence() float64 {
+    if wantConfidenceReport() {
+        return 0
+    }
+    if reviewReport != "" && reviewMin < minConfidence {
+        return reviewMin
+    }
+    return minConfidence
+}
+
+// reviewMatch returns the best match for a file when its confidence falls in
+// the review band, whether it was written, held back or rejected
+func reviewMatch(result *fileResult) *enricher.TrackMetadata {
+    if reviewReport == "" {
+        return nil
+    }
+    match := result.Enriched
+    if match == nil {
+        match = result.Candidate
+    }
+    if match == nil || match.Confidence < reviewMin || match.Confidence >= reviewMax {
+        return nil
+    }
+    return match
+}
+
+// generateReviewReport writes the review queue as HTML, sorted ascending by
+// confidence
+func generateReviewReport(entries []reviewEntry, outputPath string) error {
+    sorted := append([]reviewEntry(nil), entries...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return sorted[i].Match.Confidence < sorted[j].Match.Confidence
+    })
+
+    file, err := os.Create(outputPath)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    return reviewReportTemplate.Execute(file, struct {
+        Entries  []reviewEntry
+        Min, Max float64
+    }{sorted, reviewMin, reviewMax})
+}
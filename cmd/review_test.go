@@ -0,0 +1,88 @@
+package cmd
+
+import (
+    "errors"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestReviewMatch_Band(t *testing.T) {
+    reviewReport, reviewMin, reviewMax = "review.html", 0.4, 0.7
+    defer func() { reviewReport, reviewMin, reviewMax = "", 0.4, 0.7 }()
+
+    tests := []struct {
+        name   string
+        result *fileResult
+        want   bool
+    }{
+        {"rejected candidate in band", &fileResult{Candidate: &enricher.TrackMetadata{Confidence: 0.5}}, true},
+        {"held back match in band", &fileResult{Enriched: &enricher.TrackMetadata{Confidence: 0.65}}, true},
+        {"lower bound included", &fileResult{Candidate: &enricher.TrackMetadata{Confidence: 0.4}}, true},
+        {"upper bound excluded", &fileResult{Enriched: &enricher.TrackMetadata{Confidence: 0.7}}, false},
+        {"below band", &fileResult{Candidate: &enricher.TrackMetadata{Confidence: 0.2}}, false},
+        {"no match", &fileResult{Status: "enrichment_failed"}, false},
+    }
+
+    for _, tt := range tests {
+        if got := reviewMatch(tt.result) != nil; got != tt.want {
+            t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+        }
+    }
+
+    reviewReport = ""
+    if reviewMatch(tests[0].result) != nil {
+        t.Error("Expected nothing queued without --review-report")
+    }
+}
+
+func TestCheckMinConfidence(t *testing.T) {
+    candidate := &enricher.TrackMetadata{Label: "Metalheadz", Confidence: 0.5}
+
+    metadata, err := checkMinConfidence(candidate, nil)
+    if metadata != nil || !errors.Is(err, enricher.ErrNotFound) {
+        t.Fatalf("Expected a weak match to count as not found, got %v, %v", metadata, err)
+    }
+    var lowConfidence *lowConfidenceError
+    if !errors.As(err, &lowConfidence) || lowConfidence.candidate != candidate {
+        t.Error("Expected the rejected candidate to be kept")
+    }
+
+    strong := &enricher.TrackMetadata{Confidence: 0.9}
+    if metadata, err := checkMinConfidence(strong, nil); metadata != strong || err != nil {
+        t.Errorf("Expected a strong match to pass through, got %v, %v", metadata, err)
+    }
+}
+
+func TestGenerateReviewReport_SortsAscending(t *testing.T) {
+    output := filepath.Join(t.TempDir(), "review.html")
+
+    entries := []reviewEntry{
+        {Result: &fileResult{Path: "/music/b.aiff", Status: "enriched_low_confidence"}, Match: &enricher.TrackMetadata{Title: "Second", Confidence: 0.65}},
+        {Result: &fileResult{Path: "/music/<a>.aiff", Status: "enrichment_failed"}, Match: &enricher.TrackMetadata{Title: "First", Label: "Moving Shadow", Confidence: 0.45}},
+    }
+
+    if err := generateReviewReport(entries, output); err != nil {
+        t.Fatalf("generateReviewReport returned error: %v", err)
+    }
+
+    data, err := os.ReadFile(output)
+    if err != nil {
+        t.Fatalf("failed to read report: %v", err)
+    }
+    html := string(data)
+
+    first, second := strings.Index(html, "First"), strings.Index(html, "Second")
+    if first < 0 || second < 0 || first > second {
+        t.Error("Expected the least confident match to be listed first")
+    }
+    if !strings.Contains(html, "Moving Shadow") || !strings.Contains(html, "0.45") {
+        t.Error("Expected candidate metadata and confidence in the report")
+    }
+    if strings.Contains(html, "<a>.aiff") {
+        t.Error("Expected markup in file names to be escaped")
+    }
+}
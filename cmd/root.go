@@ -2,15 +2,27 @@ package cmd
 
 import (
     "fmt"
+    "log"
+    "net/http"
+    "net/url"
     "os"
     "path/filepath"
+    "time"
 
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/cerberussg/tagger/pkg/enricher/deezer"
+    "github.com/cerberussg/tagger/pkg/enricher/musicbrainz"
+    "github.com/cerberussg/tagger/pkg/normalize"
     "github.com/spf13/cobra"
     "github.com/spf13/viper"
 )
 
 var cfgFile string
 
+// defaultUserAgent is the placeholder user agent shipped in the default
+// config. MusicBrainz expects contact details, so doctor flags it.
+const defaultUserAgent = "tagger/0.1.0"
+
 var rootCmd = &cobra.Command{
     Use:   "tagger",
     Short: "Audio metadata enrichment tool for AIFF files",
@@ -23,21 +35,28 @@ all electronic music genres.`,
 func Execute() {
     err := rootCmd.Execute()
     if err != nil {
-        os.Exit(1)
+        os.Exit(exitError)
+    }
+    if exitCode != exitOK {
+        os.Exit(exitCode)
     }
 }
 
 func init() {
-    cobra.OnInitialize(initConfig)
+    cobra.OnInitialize(initConfig, initEmoji)
 
     rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.tagger/config.yaml)")
     rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
     rootCmd.PersistentFlags().Bool("dry-run", false, "show what would be done without making changes")
+    rootCmd.PersistentFlags().BoolP("quiet", "q", false, "suppress all output except errors (batch and warm)")
+    rootCmd.PersistentFlags().Bool("no-emoji", false, "print ASCII markers such as [OK] and [ERR] instead of emoji (also off when the locale isn't UTF-8)")
 
     rootCmd.CompletionOptions.DisableDefaultCmd = true
 
     viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
     viper.BindPFlag("dry-run", rootCmd.PersistentFlags().Lookup("dry-run"))
+    viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
+    viper.BindPFlag("no-emoji", rootCmd.PersistentFlags().Lookup("no-emoji"))
 }
 
 func initConfig() {
@@ -56,16 +75,216 @@ func initConfig() {
     }
 
     viper.AutomaticEnv()
+    bindEnvKeys(viper.GetViper())
 
     if err := viper.ReadInConfig(); err == nil {
         if viper.GetBool("verbose") {
-            fmt.Println("Using config file:", viper.ConfigFileUsed())
+            fmt.Fprintln(console, "Using config file:", viper.ConfigFileUsed())
         }
     }
 
+    // api.musicbrainz.rate_limit was never read; say so rather than let it
+    // look like it does something
+    if viper.IsSet("api.musicbrainz.rate_limit") {
+        fmt.Fprintln(console, "⚠️  api.musicbrainz.rate_limit is no longer used - set api.musicbrainz.requests_per_second instead")
+    }
+
     // Set defaults
-    viper.SetDefault("api.musicbrainz.rate_limit", 10)
-    viper.SetDefault("api.musicbrainz.user_agent", "tagger/0.1.0")
+    viper.SetDefault("api.musicbrainz.user_agent", defaultUserAgent)
+    viper.SetDefault("api.musicbrainz.requests_per_second", 1.0)
+    viper.SetDefault("api.musicbrainz.min_score", 50)
+    viper.SetDefault("api.musicbrainz.prefer_format", "")
+    viper.SetDefault("api.musicbrainz.prefer_country", "")
+    viper.SetDefault("api.musicbrainz.query_template", "")
+    viper.SetDefault("api.musicbrainz.year_source", string(musicbrainz.YearFromReleaseGroup))
+    viper.SetDefault("api.musicbrainz.search_timeout_seconds", 15)
+    viper.SetDefault("api.musicbrainz.lookup_timeout_seconds", 10)
+    weights := musicbrainz.DefaultMatchWeights()
+    viper.SetDefault("api.musicbrainz.weights.title", weights.Title)
+    viper.SetDefault("api.musicbrainz.weights.core_title", weights.CoreTitle)
+    viper.SetDefault("api.musicbrainz.weights.artist", weights.Artist)
+    viper.SetDefault("api.musicbrainz.weights.featured", weights.Featured)
+    viper.SetDefault("api.musicbrainz.weights.remix", weights.Remix)
+    viper.SetDefault("api.musicbrainz.weights.length", weights.Length)
+    viper.SetDefault("api.musicbrainz.weights.length_mismatch", weights.LengthMismatch)
+    viper.SetDefault("api.max_calls_per_run", 0) // unlimited
+    viper.SetDefault("api.request_timeout_seconds", 30)
+    viper.SetDefault("api.external.name", "External")
+    viper.SetDefault("api.deezer.enabled", false)
+    viper.SetDefault("http.timeout_seconds", 30)
     viper.SetDefault("processing.concurrent_workers", 3)
     viper.SetDefault("cache.ttl_hours", 168) // 1 week
+    viper.SetDefault("sidecar.patterns", defaultSidecarPatterns)
+    qualifiers := normalize.DefaultQualifierRules()
+    viper.SetDefault("write.genre_policy", "replace")
+    viper.SetDefault("parsing.parentheses.hints", false)
+    viper.SetDefault("parsing.parentheses.max_label_words", qualifiers.MaxLabelWords)
+    viper.SetDefault("parsing.parentheses.min_album_length", qualifiers.MinAlbumLength)
+    viper.SetDefault("parsing.parentheses.max_album_words", qualifiers.MaxAlbumWords)
+    if dir, err := taggerDir(); err == nil {
+        viper.SetDefault("cache.dir", filepath.Join(dir, "cache"))
+        viper.SetDefault("history.dir", filepath.Join(dir, "history"))
+        viper.SetDefault("overrides.file", filepath.Join(dir, "overrides.yaml"))
+    }
+}
+
+// envKeys maps nested config keys to the environment variables that
+// override them. AutomaticEnv can't reach keys with dots in, so these are
+// bound explicitly for CI and shared machines where secrets and endpoints
+// shouldn't live in the config file.
+var envKeys = map[string]string{
+    "api.musicbrainz.user_agent": "TAGGER_MUSICBRAINZ_USER_AGENT",
+    "api.musicbrainz.base_url":   "TAGGER_MUSICBRAINZ_BASE_URL",
+    "api.external.command":       "TAGGER_EXTERNAL_COMMAND",
+    "http.proxy":                 "TAGGER_HTTP_PROXY",
+    "cache.dir":                  "TAGGER_CACHE_DIR",
+    "history.dir":                "TAGGER_HISTORY_DIR",
+    "no-emoji":                   "TAGGER_NO_EMOJI",
+}
+
+// bindEnvKeys binds envKeys on v. Viper resolves a key from a flag, then
+// the environment, then the config file, then the default.
+func bindEnvKeys(v *viper.Viper) {
+    for key, env := range envKeys {
+        v.BindEnv(key, env)
+    }
+}
+
+// taggerDir returns the per-user tagger directory (~/.tagger)
+func taggerDir() (string, error) {
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(home, ".tagger"), nil
+}
+
+// newMusicBrainzProvider builds a MusicBrainz provider from the current
+// configuration. The shipped placeholder user agent is ignored in favour of
+// the provider's own default, which includes contact details. Extra options
+// are applied after the configured ones.
+func newMusicBrainzProvider(extra ...musicbrainz.Option) *musicbrainz.MusicBrainzProvider {
+    var opts []musicbrainz.Option
+    if ua := viper.GetString("api.musicbrainz.user_agent"); ua != "" && ua != defaultUserAgent {
+        opts = append(opts, musicbrainz.WithUserAgent(ua))
+    }
+    if baseURL := viper.GetString("api.musicbrainz.base_url"); baseURL != "" {
+        opts = append(opts, musicbrainz.WithBaseURL(baseURL))
+    }
+    if rate := viper.GetFloat64("api.musicbrainz.requests_per_second"); rate > 0 {
+        opts = append(opts, musicbrainz.WithRateLimit(rate))
+    } else {
+        fmt.Fprintf(console, "⚠️  api.musicbrainz.requests_per_second must be positive, got %v - using 1\n", viper.Get("api.musicbrainz.requests_per_second"))
+    }
+    opts = append(opts, musicbrainz.WithMatchWeights(matchWeights()))
+    if country := viper.GetString("api.musicbrainz.prefer_country"); country != "" {
+        opts = append(opts, musicbrainz.WithPreferredCountry(country))
+    }
+    switch source := musicbrainz.YearSource(viper.GetString("api.musicbrainz.year_source")); source {
+    case musicbrainz.YearFromReleaseGroup, musicbrainz.YearFromRelease:
+        opts = append(opts, musicbrainz.WithYearSource(source))
+    default:
+        fmt.Fprintf(console, "⚠️  api.musicbrainz.year_source must be %s or %s, got %q - using %s\n", musicbrainz.YearFromReleaseGroup, musicbrainz.YearFromRelease, source, musicbrainz.YearFromReleaseGroup)
+    }
+    if text := viper.GetString("api.musicbrainz.query_template"); text != "" {
+        if tmpl, err := musicbrainz.ParseQueryTemplate(text); err != nil {
+            fmt.Fprintf(console, "⚠️  Ignoring api.musicbrainz.query_template: %v - using the default query\n", err)
+        } else {
+            opts = append(opts, musicbrainz.WithQueryTemplate(tmpl))
+        }
+    }
+    if viper.GetBool("verbose") {
+        opts = append(opts, musicbrainz.WithLogger(log.New(console, "  ⚠️  ", 0)))
+    }
+    opts = append(opts, musicbrainz.WithPhaseTimeouts(
+        time.Duration(viper.GetInt("api.musicbrainz.search_timeout_seconds"))*time.Second,
+        time.Duration(viper.GetInt("api.musicbrainz.lookup_timeout_seconds"))*time.Second,
+    ))
+    
+    client, err := newHTTPClient()
+    if err != nil {
+        fmt.Fprintf(console, "⚠️  %v - using default HTTP client\n", err)
+    } else {
+        opts = append(opts, musicbrainz.WithHTTPClient(client))
+    }
+    
+    return musicbrainz.NewMusicBrainzProvider(append(opts, extra...)...)
+}
+
+// matchWeights reads the recording ranking bonuses from
+// api.musicbrainz.weights; unset keys keep the provider defaults
+func matchWeights() musicbrainz.MatchWeights {
+    weights := musicbrainz.DefaultMatchWeights()
+    for key, weight := range map[string]*int{
+        "title":           &weights.Title,
+        "core_title":      &weights.CoreTitle,
+        "artist":          &weights.Artist,
+        "featured":        &weights.Featured,
+        "remix":           &weights.Remix,
+        "length":          &weights.Length,
+        "length_mismatch": &weights.LengthMismatch,
+    } {
+        if viper.IsSet("api.musicbrainz.weights." + key) {
+            *weight = viper.GetInt("api.musicbrainz.weights." + key)
+        }
+    }
+    return weights
+}
+
+// newExternalProvider returns the subprocess provider configured under
+// api.external, or nil if no command is set. It is consulted after
+// MusicBrainz.
+func newExternalProvider() *enricher.ExternalProvider {
+    command := viper.GetString("api.external.command")
+    if command == "" {
+        return nil
+    }
+    return enricher.NewExternalProvider(viper.GetString("api.external.name"), command, viper.GetStringSlice("api.external.args")...)
+}
+
+// newDeezerProvider returns the Deezer provider if api.deezer.enabled is
+// set, or nil. It needs no key and is consulted after MusicBrainz, for
+// recent releases MusicBrainz doesn't have yet.
+func newDeezerProvider(extra ...deezer.Option) *deezer.DeezerProvider {
+    if !viper.GetBool("api.deezer.enabled") {
+        return nil
+    }
+    
+    var opts []deezer.Option
+    if baseURL := viper.GetString("api.deezer.base_url"); baseURL != "" {
+        opts = append(opts, deezer.WithBaseURL(baseURL))
+    }
+    client, err := newHTTPClient()
+    if err != nil {
+        fmt.Fprintf(console, "⚠️  %v - using default HTTP client\n", err)
+    } else {
+        opts = append(opts, deezer.WithHTTPClient(client))
+    }
+    
+    return deezer.NewDeezerProvider(append(opts, extra...)...)
+}
+
+// requestTimeout is the enricher's limit on one file's whole lookup, retries and waits included
+func requestTimeout() time.Duration {
+    return time.Duration(viper.GetInt("api.request_timeout_seconds")) * time.Second
+}
+
+// newHTTPClient builds the client used for provider requests. Proxies are
+// taken from HTTP_PROXY/HTTPS_PROXY/NO_PROXY unless http.proxy is set.
+func newHTTPClient() (*http.Client, error) {
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.Proxy = http.ProxyFromEnvironment
+    
+    if proxy := viper.GetString("http.proxy"); proxy != "" {
+        proxyURL, err := url.Parse(proxy)
+        if err != nil || proxyURL.Host == "" {
+            return nil, fmt.Errorf("invalid http.proxy %q", proxy)
+        }
+        transport.Proxy = http.ProxyURL(proxyURL)
+    }
+    
+    return &http.Client{
+        Timeout:   time.Duration(viper.GetInt("http.timeout_seconds")) * time.Second,
+        Transport: transport,
+    }, nil
 }
\ No newline at end of file
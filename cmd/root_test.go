@@ -0,0 +1,92 @@
+package cmd
+
+import (
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/enricher/musicbrainz"
+    "github.com/spf13/pflag"
+    "github.com/spf13/viper"
+)
+
+func TestMatchWeights(t *testing.T) {
+    viper.Set("api.musicbrainz.weights.artist", 25)
+    viper.Set("api.musicbrainz.weights.title", 4)
+    defer func() {
+        viper.Set("api.musicbrainz.weights.artist", nil)
+        viper.Set("api.musicbrainz.weights.title", nil)
+    }()
+    
+    expected := musicbrainz.DefaultMatchWeights()
+    expected.Artist, expected.Title = 25, 4
+    if got := matchWeights(); got != expected {
+        t.Errorf("Expected %+v, got %+v", expected, got)
+    }
+}
+
+func TestReleaseFormat(t *testing.T) {
+    viper.Set("api.musicbrainz.prefer_format", "vinyl")
+    defer viper.Set("api.musicbrainz.prefer_format", nil)
+    
+    if got := releaseFormat(); got != "vinyl" {
+        t.Errorf("Expected the configured format, got %q", got)
+    }
+    
+    preferredFormat = "cd"
+    defer func() { preferredFormat = "" }()
+    if got := releaseFormat(); got != "cd" {
+        t.Errorf("Expected --prefer-format to override the config, got %q", got)
+    }
+}
+
+func TestCheckTimeouts(t *testing.T) {
+    viper.Set("http.timeout_seconds", 60)
+    viper.Set("api.request_timeout_seconds", 30)
+    defer func() {
+        viper.Set("http.timeout_seconds", nil)
+        viper.Set("api.request_timeout_seconds", nil)
+    }()
+    
+    if check := checkTimeouts(); check.passed || check.fix == "" {
+        t.Errorf("Expected an HTTP timeout longer than the lookup timeout to fail, got %+v", check)
+    }
+    
+    viper.Set("api.request_timeout_seconds", 120)
+    if requestTimeout() != 2*time.Minute {
+        t.Errorf("Expected a 2m request timeout, got %s", requestTimeout())
+    }
+    if check := checkTimeouts(); !check.passed {
+        t.Errorf("Expected the raised lookup timeout to pass, got %+v", check)
+    }
+}
+
+func TestBindEnvKeys_Precedence(t *testing.T) {
+    v := viper.New()
+    v.SetDefault("api.musicbrainz.user_agent", "default-agent")
+    bindEnvKeys(v)
+    if got := v.GetString("api.musicbrainz.user_agent"); got != "default-agent" {
+        t.Errorf("Expected the default with nothing else set, got %q", got)
+    }
+    
+    v.SetConfigType("yaml")
+    if err := v.ReadConfig(strings.NewReader("api:\n  musicbrainz:\n    user_agent: config-agent\n")); err != nil {
+        t.Fatal(err)
+    }
+    if got := v.GetString("api.musicbrainz.user_agent"); got != "config-agent" {
+        t.Errorf("Expected the config to beat the default, got %q", got)
+    }
+    
+    t.Setenv("TAGGER_MUSICBRAINZ_USER_AGENT", "env-agent")
+    if got := v.GetString("api.musicbrainz.user_agent"); got != "env-agent" {
+        t.Errorf("Expected the environment to beat the config, got %q", got)
+    }
+    
+    flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+    flags.String("user-agent", "", "")
+    v.BindPFlag("api.musicbrainz.user_agent", flags.Lookup("user-agent"))
+    flags.Parse([]string{"--user-agent", "flag-agent"})
+    if got := v.GetString("api.musicbrainz.user_agent"); got != "flag-agent" {
+        t.Errorf("Expected a flag to beat the environment, got %q", got)
+    }
+}
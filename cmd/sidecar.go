@@ -0,0 +1,257 @@
+// cmd/sidecar.go
+package cmd
+
+import (
+    "bufio"
+    "io"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "strings"
+    "sync"
+
+    "github.com/spf13/viper"
+)
+
+// defaultSidecarPatterns are the release info files checked for hints
+var defaultSidecarPatterns = []string{"*.nfo", "*.txt"}
+
+// sidecarInfo is what could be parsed from a folder's .nfo/.txt files
+type sidecarInfo struct {
+    Artist  string
+    Album   string
+    Label   string
+    Catalog string
+    Year    string
+    Tracks  []sidecarTrack
+}
+
+// sidecarTrack is one tracklist line
+type sidecarTrack struct {
+    Position string // "01", "A1", ...
+    Artist   string
+    Title    string
+}
+
+// trackHints seed a lookup for a single file
+type trackHints struct {
+    Artist  string
+    Title   string
+    Album   string
+    Label   string
+    Catalog string
+    Year    string
+}
+
+var (
+    // sidecarFieldPattern matches "Label: X" or NFO-style "Label.....: X"
+    sidecarFieldPattern = regexp.MustCompile(`(?i)^\s*(artist|album|title|release\s+date|release|label|year|date|released|rel\.?\s*date|cat(?:alog(?:ue)?)?\.?(?:\s*(?:no|number|#))?)\s*[.:]*\s*[:.]\s*(.+?)\s*$`)
+
+    // sidecarTrackPattern matches "01. Artist - Title (5:32)" and friends
+    sidecarTrackPattern = regexp.MustCompile(`^\s*\[?([A-Da-d]?\d{1,3})\]?[.):]?\s+(.+?)\s*$`)
+
+    // sidecarDurationPattern strips a trailing "5:32" or "(5:32)"
+    sidecarDurationPattern = regexp.MustCompile(`\s*[\[(]?\d{1,2}:\d{2}[\])]?$`)
+
+    yearPattern = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+    // filenamePositionPattern finds a leading track position in a filename
+    filenamePositionPattern = regexp.MustCompile(`^\s*[\[(]?([A-Da-d]?\d{1,3})[\])]?[\s._-]`)
+)
+
+// sidecarCache remembers parsed sidecars per directory for a run
+type sidecarCache struct {
+    mu   sync.Mutex
+    dirs map[string]*sidecarInfo
+}
+
+var sidecars = &sidecarCache{dirs: make(map[string]*sidecarInfo)}
+
+// forDir returns the merged sidecar info for dir, or nil if there is none
+func (c *sidecarCache) forDir(dir string) *sidecarInfo {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if info, ok := c.dirs[dir]; ok {
+        return info
+    }
+
+    info := loadSidecars(dir, sidecarPatterns())
+    c.dirs[dir] = info
+    return info
+}
+
+// sidecarPatterns returns the configured sidecar filename globs
+func sidecarPatterns() []string {
+    if patterns := viper.GetStringSlice("sidecar.patterns"); len(patterns) > 0 {
+        return patterns
+    }
+    return defaultSidecarPatterns
+}
+
+// loadSidecars parses every file in dir matching one of patterns
+// (case-insensitively) and merges the results
+func loadSidecars(dir string, patterns []string) *sidecarInfo {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil
+    }
+
+    var merged *sidecarInfo
+    for _, entry := range entries {
+        if entry.IsDir() || !matchesAny(strings.ToLower(entry.Name()), patterns) {
+            continue
+        }
+
+        f, err := os.Open(filepath.Join(dir, entry.Name()))
+        if err != nil {
+            continue
+        }
+        info := parseSidecar(f)
+        f.Close()
+
+        if merged == nil {
+            merged = info
+            continue
+        }
+        merged.merge(info)
+    }
+
+    return merged
+}
+
+// matchesAny reports whether name matches any of the glob patterns
+func matchesAny(name string, patterns []string) bool {
+    for _, pattern := range patterns {
+        if ok, _ := filepath.Match(strings.ToLower(pattern), name); ok {
+            return true
+        }
+    }
+    return false
+}
+
+// parseSidecar extracts release fields and a tracklist from an .nfo or
+// tracklist .txt. Unrecognised lines (ASCII art, greetings) are ignored.
+func parseSidecar(r io.Reader) *sidecarInfo {
+    info := &sidecarInfo{}
+
+    scanner := bufio.NewScanner(r)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" {
+            continue
+        }
+
+        if m := sidecarFieldPattern.FindStringSubmatch(line); m != nil {
+            info.setField(strings.ToLower(m[1]), m[2])
+            continue
+        }
+
+        if m := sidecarTrackPattern.FindStringSubmatch(line); m != nil {
+            if track, ok := parseSidecarTrack(m[1], m[2]); ok {
+                info.Tracks = append(info.Tracks, track)
+            }
+        }
+    }
+
+    return info
+}
+
+// setField stores a "Key: value" header line
+func (s *sidecarInfo) setField(key, value string) {
+    switch {
+    case key == "artist":
+        setIfEmpty(&s.Artist, value)
+    case key == "album" || key == "title" || key == "release":
+        setIfEmpty(&s.Album, value)
+    case key == "label":
+        setIfEmpty(&s.Label, value)
+    case strings.HasPrefix(key, "cat"):
+        setIfEmpty(&s.Catalog, value)
+    default: // year, date, released, release date
+        setIfEmpty(&s.Year, yearPattern.FindString(value))
+    }
+}
+
+// merge fills empty fields from other and appends its tracks
+func (s *sidecarInfo) merge(other *sidecarInfo) {
+    setIfEmpty(&s.Artist, other.Artist)
+    setIfEmpty(&s.Album, other.Album)
+    setIfEmpty(&s.Label, other.Label)
+    setIfEmpty(&s.Catalog, other.Catalog)
+    setIfEmpty(&s.Year, other.Year)
+    s.Tracks = append(s.Tracks, other.Tracks...)
+}
+
+func setIfEmpty(field *string, value string) {
+    if *field == "" {
+        *field = value
+    }
+}
+
+// parseSidecarTrack splits a numbered tracklist entry into artist and
+// title. Entries without " - " are title-only.
+func parseSidecarTrack(position, rest string) (sidecarTrack, bool) {
+    rest = sidecarDurationPattern.ReplaceAllString(rest, "")
+    track := sidecarTrack{Position: normalizePosition(position)}
+
+    if parts := strings.SplitN(rest, " - ", 2); len(parts) == 2 {
+        track.Artist = strings.TrimSpace(parts[0])
+        track.Title = strings.TrimSpace(parts[1])
+    } else {
+        track.Title = strings.TrimSpace(rest)
+    }
+
+    return track, track.Title != ""
+}
+
+// normalizePosition makes "01", "1" and "a1" comparable
+func normalizePosition(position string) string {
+    position = strings.ToUpper(position)
+    side := strings.TrimRight(position, "0123456789")
+    if n, err := strconv.Atoi(position[len(side):]); err == nil {
+        return side + strconv.Itoa(n)
+    }
+    return position
+}
+
+// hintsFor finds the tracklist entry for filePath (by track position, then
+// by title) and returns lookup hints. Release-level fields apply even when
+// no track matches.
+func (s *sidecarInfo) hintsFor(filePath, title string) *trackHints {
+    hints := &trackHints{Album: s.Album, Label: s.Label, Catalog: s.Catalog, Year: s.Year}
+
+    if track := s.findTrack(filePath, title); track != nil {
+        hints.Artist = track.Artist
+        hints.Title = track.Title
+        if hints.Artist == "" {
+            hints.Artist = s.Artist
+        }
+    }
+
+    return hints
+}
+
+// findTrack matches a file against the tracklist
+func (s *sidecarInfo) findTrack(filePath, title string) *sidecarTrack {
+    name := filepath.Base(filePath)
+    if m := filenamePositionPattern.FindStringSubmatch(name); m != nil {
+        position := normalizePosition(m[1])
+        for i := range s.Tracks {
+            if s.Tracks[i].Position == position {
+                return &s.Tracks[i]
+            }
+        }
+    }
+
+    if title != "" {
+        for i := range s.Tracks {
+            if strings.EqualFold(s.Tracks[i].Title, title) {
+                return &s.Tracks[i]
+            }
+        }
+    }
+
+    return nil
+}
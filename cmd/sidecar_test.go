@@ -0,0 +1,71 @@
+package cmd
+
+import (
+    "strings"
+    "testing"
+)
+
+const testNFO = `
+   ▄▄▄  SCENE GROUP PRESENTS  ▄▄▄
+
+   Artist.......: Goldie
+   Album........: Timeless
+   Label........: FFRR
+   Cat. No......: FX 240
+   Release Date.: 07/1995
+
+   Tracklist:
+   01. Inner City Life (21:03)
+   02. Saint Angel 5:47
+   03 Goldie feat. Diane Charlemagne - State Of Mind
+`
+
+func TestParseSidecar(t *testing.T) {
+    info := parseSidecar(strings.NewReader(testNFO))
+
+    if info.Album != "Timeless" || info.Label != "FFRR" || info.Catalog != "FX 240" || info.Year != "1995" {
+        t.Errorf("Unexpected release fields: album=%q label=%q catalog=%q year=%q", info.Album, info.Label, info.Catalog, info.Year)
+    }
+
+    if len(info.Tracks) != 3 {
+        t.Fatalf("Expected 3 tracks, got %d: %+v", len(info.Tracks), info.Tracks)
+    }
+    if info.Tracks[0].Title != "Inner City Life" || info.Tracks[0].Position != "1" {
+        t.Errorf("Unexpected first track: %+v", info.Tracks[0])
+    }
+    if info.Tracks[1].Title != "Saint Angel" {
+        t.Errorf("Expected duration to be stripped, got %q", info.Tracks[1].Title)
+    }
+    if info.Tracks[2].Artist != "Goldie feat. Diane Charlemagne" || info.Tracks[2].Title != "State Of Mind" {
+        t.Errorf("Unexpected third track: %+v", info.Tracks[2])
+    }
+}
+
+func TestSidecarHintsFor(t *testing.T) {
+    info := parseSidecar(strings.NewReader(testNFO))
+
+    testCases := []struct {
+        name           string
+        path           string
+        title          string
+        expectedArtist string
+        expectedTitle  string
+    }{
+        {"matched by position", "/music/02_gldi-sngl.aiff", "", "Goldie", "Saint Angel"},
+        {"matched by title", "/music/track.aiff", "inner city life", "Goldie", "Inner City Life"},
+        {"track artist wins", "/music/03-gldi.aiff", "", "Goldie feat. Diane Charlemagne", "State Of Mind"},
+        {"no match keeps release hints only", "/music/unknown.aiff", "Other", "", ""},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            hints := info.hintsFor(tc.path, tc.title)
+            if hints.Artist != tc.expectedArtist || hints.Title != tc.expectedTitle {
+                t.Errorf("Expected %q - %q, got %q - %q", tc.expectedArtist, tc.expectedTitle, hints.Artist, hints.Title)
+            }
+            if hints.Album != "Timeless" || hints.Label != "FFRR" {
+                t.Errorf("Expected release hints to always apply, got %+v", hints)
+            }
+        })
+    }
+}
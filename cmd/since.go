@@ -0,0 +1,89 @@
+// cmd/since.go
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// since limits batch, warm and verify to files modified after a point in
+// time (--since)
+var since string
+
+// sinceLayouts are the timestamp formats accepted by --since, most
+// specific first. Times without a zone are local.
+var sinceLayouts = []string{
+    time.RFC3339,
+    "2006-01-02T15:04:05",
+    "2006-01-02 15:04:05",
+    "2006-01-02 15:04",
+    "2006-01-02",
+}
+
+// parseSince turns a --since value into a cutoff time. It accepts a
+// duration before now ("24h", "90m", or days and weeks as "7d" and "2w") or
+// a timestamp
+// ("2024-01-01", "2024-01-01 18:30", RFC 3339).
+func parseSince(value string, now time.Time) (time.Time, error) {
+    value = strings.TrimSpace(value)
+    
+    if days, ok := strings.CutSuffix(value, "d"); ok {
+        if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+            return now.AddDate(0, 0, -n), nil
+        }
+    }
+    if weeks, ok := strings.CutSuffix(value, "w"); ok {
+        if n, err := strconv.Atoi(weeks); err == nil && n >= 0 {
+            return now.AddDate(0, 0, -7*n), nil
+        }
+    }
+    if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+        return now.Add(-d), nil
+    }
+    for _, layout := range sinceLayouts {
+        if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+            return t, nil
+        }
+    }
+    
+    return time.Time{}, fmt.Errorf("invalid --since value %q: use a duration like 24h, 7d or 2w, or a date like 2024-01-01", value)
+}
+
+// filterModifiedSince keeps the files modified after cutoff. Files that
+// can't be stat'ed are kept so processing reports their error.
+func filterModifiedSince(files []string, cutoff time.Time) []string {
+    var recent []string
+    for _, file := range files {
+        info, err := os.Stat(file)
+        if err != nil || info.ModTime().After(cutoff) {
+            recent = append(recent, file)
+        }
+    }
+    return recent
+}
+
+// applySince narrows files to those modified since --since, if given, and
+// reports how many are left. It returns false when the value is invalid
+// or nothing is left to process.
+func applySince(files []string) ([]string, bool) {
+    if since == "" {
+        return files, true
+    }
+    
+    cutoff, err := parseSince(since, time.Now())
+    if err != nil {
+        fatalf("%v", err)
+        return nil, false
+    }
+    total := len(files)
+    files = filterModifiedSince(files, cutoff)
+    fmt.Fprintf(console, "Since %s: %d of %d files modified\n", cutoff.Format("2006-01-02 15:04"), len(files), total)
+    if len(files) == 0 && total > 0 {
+        fmt.Fprintln(console, "Nothing new to process")
+        return nil, false
+    }
+    return files, true
+}
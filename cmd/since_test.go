@@ -0,0 +1,60 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestParseSince(t *testing.T) {
+    now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)
+    
+    testCases := map[string]time.Time{
+        "24h":                  now.Add(-24 * time.Hour),
+        "90m":                  now.Add(-90 * time.Minute),
+        "7d":                   now.AddDate(0, 0, -7),
+        "2w":                   now.AddDate(0, 0, -14),
+        "2024-01-01":           time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+        "2024-01-01 18:30":     time.Date(2024, 1, 1, 18, 30, 0, 0, time.Local),
+        "2024-01-01T18:30:00Z": time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC),
+    }
+    
+    for input, expected := range testCases {
+        got, err := parseSince(input, now)
+        if err != nil {
+            t.Errorf("parseSince(%q) returned error: %v", input, err)
+            continue
+        }
+        if !got.Equal(expected) {
+            t.Errorf("parseSince(%q) = %v, expected %v", input, got, expected)
+        }
+    }
+    
+    for _, input := range []string{"", "yesterday", "-5h", "2024-13-01"} {
+        if _, err := parseSince(input, now); err == nil {
+            t.Errorf("parseSince(%q) expected an error", input)
+        }
+    }
+}
+
+func TestFilterModifiedSince(t *testing.T) {
+    dir := t.TempDir()
+    old := filepath.Join(dir, "old.aiff")
+    recent := filepath.Join(dir, "recent.aiff")
+    for _, path := range []string{old, recent} {
+        if err := os.WriteFile(path, nil, 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    cutoff := time.Now().Add(-time.Hour)
+    if err := os.Chtimes(old, cutoff.Add(-time.Hour), cutoff.Add(-time.Hour)); err != nil {
+        t.Fatal(err)
+    }
+    
+    files := filterModifiedSince([]string{old, recent}, cutoff)
+    if len(files) != 1 || files[0] != recent {
+        t.Errorf("Expected only %s, got %v", recent, files)
+    }
+}
@@ -0,0 +1,63 @@
+// cmd/templatereport.go
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "text/template"
+    "time"
+)
+
+var templateReport string
+
+// templateReportData is what a --template-report template is rendered
+// with: every file's result in processing order and the run's totals
+type templateReportData struct {
+    Folder    string
+    Generated time.Time
+    Results   []*fileResult
+    Summary   jsonlSummary
+}
+
+// templateReportFuncs are available to report templates on top of the
+// text/template built-ins
+var templateReportFuncs = template.FuncMap{
+    "base":  filepath.Base,
+    "dir":   filepath.Dir,
+    "join":  strings.Join,
+    "upper": strings.ToUpper,
+    "lower": strings.ToLower,
+}
+
+// parseTemplateReport splits a "template.tmpl:output.ext" spec at its last
+// colon and parses the template, so a broken one fails before the run
+// rather than after it
+func parseTemplateReport(spec string) (*template.Template, string, error) {
+    i := strings.LastIndex(spec, ":")
+    if i <= 0 || i == len(spec)-1 {
+        return nil, "", fmt.Errorf("--template-report %q must be template:output, e.g. report.tmpl:report.md", spec)
+    }
+    templatePath, outputPath := spec[:i], spec[i+1:]
+
+    tmpl, err := template.New(filepath.Base(templatePath)).Funcs(templateReportFuncs).ParseFiles(templatePath)
+    if err != nil {
+        return nil, "", fmt.Errorf("parsing report template: %w", err)
+    }
+    return tmpl, outputPath, nil
+}
+
+// writeTemplateReport renders data through tmpl to outputPath
+func writeTemplateReport(tmpl *template.Template, data templateReportData, outputPath string) error {
+    file, err := os.Create(outputPath)
+    if err != nil {
+        return err
+    }
+
+    if err := tmpl.Execute(file, data); err != nil {
+        file.Close()
+        return err
+    }
+    return file.Close()
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestTemplateReport(t *testing.T) {
+    dir := t.TempDir()
+    templatePath := filepath.Join(dir, "report.tmpl")
+    content := `# {{.Summary.Total}} files, {{.Summary.Enriched}} enriched
+{{range .Results}}- {{base .Path}}: {{.Status}}{{with .Label}} ({{.}}){{end}}
+{{end}}`
+    if err := os.WriteFile(templatePath, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    tmpl, outputPath, err := parseTemplateReport(templatePath + ":" + filepath.Join(dir, "report.md"))
+    if err != nil {
+        t.Fatalf("parseTemplateReport returned error: %v", err)
+    }
+    if outputPath != filepath.Join(dir, "report.md") {
+        t.Errorf("Expected the output after the last colon, got %q", outputPath)
+    }
+
+    data := templateReportData{
+        Results: []*fileResult{
+            {Path: "/music/Goldie - Angel.aiff", Status: "has_label", Label: "FFRR"},
+            {Path: "/music/Photek - Rings.aiff", Status: "enriched"},
+        },
+        Summary: jsonlSummary{Total: 2, Enriched: 1},
+    }
+    if err := writeTemplateReport(tmpl, data, outputPath); err != nil {
+        t.Fatalf("writeTemplateReport returned error: %v", err)
+    }
+
+    got, err := os.ReadFile(outputPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := "# 2 files, 1 enriched\n- Goldie - Angel.aiff: has_label (FFRR)\n- Photek - Rings.aiff: enriched\n"
+    if string(got) != expected {
+        t.Errorf("Expected report:\n%s\ngot:\n%s", expected, got)
+    }
+}
+
+func TestParseTemplateReport_Invalid(t *testing.T) {
+    dir := t.TempDir()
+    broken := filepath.Join(dir, "broken.tmpl")
+    if err := os.WriteFile(broken, []byte("{{range .Results}"), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    for _, spec := range []string{"report.tmpl", "report.tmpl:", ":report.md", filepath.Join(dir, "missing.tmpl") + ":out.md", broken + ":out.md"} {
+        if _, _, err := parseTemplateReport(spec); err == nil {
+            t.Errorf("Expected an error for %q", spec)
+        } else if strings.Contains(spec, "broken") && !strings.Contains(err.Error(), "parsing report template") {
+            t.Errorf("Expected a template parse error for %q, got %v", spec, err)
+        }
+    }
+}
@@ -0,0 +1,218 @@
+// cmd/verify.go
+package cmd
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/cerberussg/tagger/pkg/normalize"
+    "github.com/spf13/cobra"
+    "github.com/spf13/viper"
+)
+
+var verifyCmd = &cobra.Command{
+    Use:   "verify <folder>",
+    Short: "Check existing label and year tags against MusicBrainz",
+    Long: `Audit files that already carry a label or year: look each one up and
+report where the tags disagree with MusicBrainz, e.g. a file tagged 2010
+whose original release was in 1995. Nothing is written.
+
+The file's own label and year are not used as search hints, so a wrong tag
+can't steer the lookup towards a release that agrees with it. Lookups go
+through the same cache as batch and warm.
+
+Examples:
+  tagger verify ~/Music/DnB
+  tagger verify ~/Music/DnB --prefer-format vinyl --min-confidence 0.8`,
+    Args: cobra.ExactArgs(1),
+    Run:  runVerify,
+}
+
+func init() {
+    rootCmd.AddCommand(verifyCmd)
+    
+    verifyCmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "process subdirectories recursively")
+    verifyCmd.Flags().StringVar(&since, "since", "", "only process files modified after this (a duration like 24h, 7d or 2w, or a date like 2024-01-01)")
+    verifyCmd.Flags().BoolVar(&allowBootleg, "allow-bootleg", false, "let bootleg, promo and other unofficial releases compete with official ones when choosing label and catalog number")
+    verifyCmd.Flags().StringVar(&preferredFormat, "prefer-format", "", "prefer releases in this media format when dates tie (e.g. vinyl, cd, digital)")
+    verifyCmd.Flags().IntVar(&maxAPICalls, "max-api-calls", 0, "stop making provider requests after this many (0 = use api.max_calls_per_run)")
+    verifyCmd.Flags().Float64Var(&minConfidence, "min-confidence", 0.7, "minimum match confidence (0.0-1.0) before tags are compared")
+}
+
+// tagMismatch is a tag whose value in the file disagrees with the provider
+type tagMismatch struct {
+    Field    string
+    File     string
+    Provider string
+}
+
+// compareTags returns the label and year tags that disagree with metadata.
+// Fields either side leaves empty aren't compared.
+func compareTags(info *trackInfo, metadata *enricher.TrackMetadata) []tagMismatch {
+    var mismatches []tagMismatch
+    if info.Label != "" && metadata.Label != "" && !sameLabel(info.Label, metadata.Label) {
+        mismatches = append(mismatches, tagMismatch{Field: "Label", File: info.Label, Provider: metadata.Label})
+    }
+    if info.Year > 0 && metadata.Year > 0 && info.Year != metadata.Year {
+        mismatches = append(mismatches, tagMismatch{Field: "Year", File: strconv.Itoa(info.Year), Provider: strconv.Itoa(metadata.Year)})
+    }
+    return mismatches
+}
+
+// labelSuffixes are dropped before comparing labels, so "FFRR Records"
+// matches "FFRR"
+var labelSuffixes = []string{" records", " recordings", " music", " ltd", " ltd."}
+
+// sameLabel reports whether two label names refer to the same label,
+// ignoring case, Unicode composition and common company suffixes
+func sameLabel(a, b string) bool {
+    return labelKey(a) == labelKey(b)
+}
+
+func labelKey(label string) string {
+    key := strings.ToLower(strings.TrimSpace(normalize.Fold(label)))
+    for _, suffix := range labelSuffixes {
+        key = strings.TrimSuffix(key, suffix)
+    }
+    return key
+}
+
+func runVerify(cmd *cobra.Command, args []string) {
+    folder := args[0]
+    
+    if viper.GetBool("quiet") && viper.GetBool("verbose") {
+        fatalf("--quiet and --verbose can't be used together")
+        return
+    }
+    defer quietOutput()()
+    
+    if !isValidDirectory(folder) {
+        fatalf("Directory '%s' does not exist or is not accessible", folder)
+        return
+    }
+    
+    absPath, err := filepath.Abs(folder)
+    if err != nil {
+        fatalf("Could not resolve path '%s': %v", folder, err)
+        return
+    }
+    
+    loadHyphenLayouts()
+    lookupCache = openLookupCache()
+    defer saveCacheStats()
+    
+    files, err := findAudioFiles(absPath, recursive, getSupportedExtensions())
+    if err != nil {
+        fatalf("scanning directory: %v", err)
+        return
+    }
+    files, ok := applySince(files)
+    if !ok {
+        return
+    }
+    
+    if len(files) == 0 {
+        fmt.Fprintln(console, "No supported audio files found in the specified directory")
+        return
+    }
+    
+    fmt.Fprintf(console, "Verifying tags of %d audio files in %s\n\n", len(files), absPath)
+    
+    metadataEnricher := newBatchEnricher()
+    defer metadataEnricher.Close()
+    
+    ctx := context.Background()
+    
+    var bar *progress
+    if !viper.GetBool("verbose") && !viper.GetBool("quiet") {
+        bar = newProgress(os.Stderr, len(files), isTerminal(os.Stdout) && isTerminal(os.Stderr)).withRateFloor(apiBudget.Used, musicBrainzCallInterval)
+    }
+    
+    var checked, matching, mismatched, notFound, failed, skipped, overridden, budgetSkipped int
+    for i, file := range files {
+        bar.update(i)
+        if viper.GetBool("verbose") {
+            fmt.Fprintf(console, "[%d/%d] %s\n", i+1, len(files), file)
+        }
+        
+        info, err := readTrackInfo(file)
+        if err == nil && applyOverride(file, info) != nil {
+            // Manual corrections are the truth, not something to audit
+            overridden++
+            continue
+        }
+        if err != nil || info.Artist == "" || info.Title == "" || (info.Label == "" && info.Year == 0) {
+            skipped++
+            continue
+        }
+        
+        // Search without the tags under audit
+        req := prepareLookup(file, info)
+        req.Label, req.Year = "", ""
+        
+        metadata, err := cachedLookup(ctx, metadataEnricher, req)
+        switch {
+        case err == nil:
+        case errors.Is(err, enricher.ErrNotFound):
+            notFound++
+            continue
+        case errors.Is(err, enricher.ErrBudgetExhausted):
+            budgetSkipped++
+            continue
+        default:
+            failed++
+            if viper.GetBool("verbose") {
+                fmt.Fprintf(console, "  ❌ Lookup failed: %v\n", err)
+            } else if viper.GetBool("quiet") {
+                fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+            }
+            continue
+        }
+        
+        checked++
+        mismatches := compareTags(info, metadata)
+        if len(mismatches) == 0 {
+            matching++
+            if viper.GetBool("verbose") {
+                fmt.Fprintf(console, "  ✅ Tags agree with %s\n", metadata.ProviderName)
+            }
+            continue
+        }
+        
+        mismatched++
+        bar.clear()
+        fmt.Fprintf(console, "❗ %s\n", file)
+        fmt.Fprintf(console, "   Matched: %s - %s (confidence %.2f)\n", metadata.Artist, metadata.Title, metadata.Confidence)
+        for _, mismatch := range mismatches {
+            fmt.Fprintf(console, "   %-6s file %q, %s %q\n", mismatch.Field+":", mismatch.File, metadata.ProviderName, mismatch.Provider)
+        }
+    }
+    
+    bar.update(len(files))
+    bar.finish()
+    
+    fmt.Fprintf(console, "\n=== VERIFY SUMMARY ===\n")
+    fmt.Fprintf(console, "Files checked: %d\n", checked)
+    fmt.Fprintf(console, "Tags agree: %d\n", matching)
+    fmt.Fprintf(console, "Tags disagree: %d\n", mismatched)
+    fmt.Fprintf(console, "No confident match: %d\n", notFound)
+    fmt.Fprintf(console, "Skipped (no label or year to check): %d\n", skipped)
+    if overridden > 0 {
+        fmt.Fprintf(console, "Skipped (manual override): %d\n", overridden)
+    }
+    if budgetSkipped > 0 {
+        fmt.Fprintf(console, "Skipped (budget exhausted): %d\n", budgetSkipped)
+    }
+    printAPICallsUsed()
+    if failed > 0 {
+        fmt.Fprintf(console, "Lookup errors: %d\n", failed)
+    }
+    
+    exitCode = runOutcome{failed: failed, matched: checked, notFound: notFound}.exitStatus()
+}
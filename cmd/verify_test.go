@@ -0,0 +1,44 @@
+package cmd
+
+import (
+    "testing"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestCompareTags(t *testing.T) {
+    metadata := &enricher.TrackMetadata{Label: "FFRR", Year: 1995}
+    
+    mismatches := compareTags(&trackInfo{Label: "FFRR Records", Year: 2010}, metadata)
+    if len(mismatches) != 1 || mismatches[0].Field != "Year" || mismatches[0].File != "2010" || mismatches[0].Provider != "1995" {
+        t.Errorf("Expected only a year mismatch, got %+v", mismatches)
+    }
+    
+    mismatches = compareTags(&trackInfo{Label: "Bootleg Recordz"}, metadata)
+    if len(mismatches) != 1 || mismatches[0].Field != "Label" {
+        t.Errorf("Expected a label mismatch, got %+v", mismatches)
+    }
+    
+    // Fields missing on either side aren't compared
+    if mismatches := compareTags(&trackInfo{Year: 2010}, &enricher.TrackMetadata{Label: "FFRR"}); len(mismatches) != 0 {
+        t.Errorf("Expected no mismatches without a provider year, got %+v", mismatches)
+    }
+}
+
+func TestSameLabel(t *testing.T) {
+    tests := []struct {
+        a, b string
+        want bool
+    }{
+        {"Metalheadz", "metalheadz", true},
+        {"Moving Shadow Records", "Moving Shadow", true},
+        {"Cr\u00e8me Organization", "Cre\u0300me Organization", true},
+        {"Metalheadz", "Moving Shadow", false},
+    }
+    
+    for _, tt := range tests {
+        if got := sameLabel(tt.a, tt.b); got != tt.want {
+            t.Errorf("sameLabel(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+        }
+    }
+}
@@ -11,9 +11,9 @@ var versionCmd = &cobra.Command{
     Short: "Print version information",
     Long:  "Print the version number of tagger",
     Run: func(cmd *cobra.Command, args []string) {
-        fmt.Println("tagger v0.1.0")
-        fmt.Println("Audio metadata enrichment tool")
-        fmt.Println("Built for finding Record Label, Release Date, and Genre in AIFF files")
+        fmt.Fprintln(console, "tagger v0.1.0")
+        fmt.Fprintln(console, "Audio metadata enrichment tool")
+        fmt.Fprintln(console, "Built for finding Record Label, Release Date, and Genre in AIFF files")
     },
 }
 
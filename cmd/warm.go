@@ -0,0 +1,165 @@
+// cmd/warm.go
+package cmd
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+    "github.com/spf13/cobra"
+    "github.com/spf13/viper"
+)
+
+var warmCmd = &cobra.Command{
+    Use:   "warm <folder>",
+    Short: "Resolve and cache metadata without writing any tags",
+    Long: `Run the lookup phase of batch enrichment for every file in a folder
+and store the results in the disk cache. No files are modified.
+
+Files that already carry label info are skipped, since batch never looks
+them up. Once the cache is warm, the tag-writing pass can run without a
+network connection:
+
+Examples:
+  tagger warm ~/Music/DnB
+  tagger batch ~/Music/DnB --offline`,
+    Args: cobra.ExactArgs(1),
+    Run:  runWarm,
+}
+
+func init() {
+    rootCmd.AddCommand(warmCmd)
+    
+    warmCmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "process subdirectories recursively")
+    warmCmd.Flags().StringVar(&since, "since", "", "only process files modified after this (a duration like 24h, 7d or 2w, or a date like 2024-01-01)")
+    warmCmd.Flags().StringVar(&yearRange, "year-range", "", "prefer releases from these years, e.g. 1993-1997, 1993- or 90s, for tracks reissued many times")
+    warmCmd.Flags().BoolVar(&allowBootleg, "allow-bootleg", false, "let bootleg, promo and other unofficial releases compete with official ones when choosing label and catalog number")
+    warmCmd.Flags().StringVar(&preferredFormat, "prefer-format", "", "prefer releases in this media format when dates tie (e.g. vinyl, cd, digital)")
+    warmCmd.Flags().BoolVar(&useSidecars, "sidecar", false, "use .nfo/.txt release info in each folder as lookup hints")
+    warmCmd.Flags().IntVar(&maxAPICalls, "max-api-calls", 0, "stop making provider requests after this many (0 = use api.max_calls_per_run)")
+    warmCmd.Flags().Float64Var(&minConfidence, "min-confidence", 0.7, "minimum match confidence (0.0-1.0) for a lookup to succeed")
+}
+
+func runWarm(cmd *cobra.Command, args []string) {
+    folder := args[0]
+    
+    if viper.GetBool("quiet") && viper.GetBool("verbose") {
+        fatalf("--quiet and --verbose can't be used together")
+        return
+    }
+    defer quietOutput()()
+    
+    if !isValidDirectory(folder) {
+        fatalf("Directory '%s' does not exist or is not accessible", folder)
+        return
+    }
+    
+    absPath, err := filepath.Abs(folder)
+    if err != nil {
+        fatalf("Could not resolve path '%s': %v", folder, err)
+        return
+    }
+    
+    loadHyphenLayouts()
+    if !loadYearRange() {
+        return
+    }
+    lookupCache = openLookupCache()
+    defer saveCacheStats()
+    if lookupCache == nil {
+        fatalf("warm requires a usable cache directory (see 'tagger doctor')")
+        return
+    }
+    
+    files, err := findAudioFiles(absPath, recursive, getSupportedExtensions())
+    if err != nil {
+        fatalf("scanning directory: %v", err)
+        return
+    }
+    files, ok := applySince(files)
+    if !ok {
+        return
+    }
+    
+    if len(files) == 0 {
+        fmt.Fprintln(console, "No supported audio files found in the specified directory")
+        return
+    }
+    
+    fmt.Fprintf(console, "Warming cache for %d audio files in %s\n", len(files), absPath)
+    fmt.Fprintf(console, "Cache directory: %s\n\n", viper.GetString("cache.dir"))
+    
+    metadataEnricher := newBatchEnricher()
+    defer metadataEnricher.Close()
+    
+    ctx := context.Background()
+    
+    var bar *progress
+    if !viper.GetBool("verbose") && !viper.GetBool("quiet") {
+        bar = newProgress(os.Stderr, len(files), isTerminal(os.Stdout) && isTerminal(os.Stderr)).withRateFloor(apiBudget.Used, musicBrainzCallInterval)
+    }
+    
+    var cached, alreadyCached, notFound, failed, skipped, budgetSkipped int
+    for i, file := range files {
+        bar.update(i)
+        if viper.GetBool("verbose") {
+            fmt.Fprintf(console, "[%d/%d] %s\n", i+1, len(files), file)
+        }
+        
+        info, err := readTrackInfo(file)
+        if err != nil {
+            skipped++
+            continue
+        }
+        
+        applyOverride(file, info)
+        req := prepareLookup(file, info)
+        if info.Artist == "" || info.Title == "" || info.Label != "" {
+            skipped++
+            continue
+        }
+        
+        if lookupCache.Contains(lookupKey(req)) {
+            alreadyCached++
+            continue
+        }
+        
+        _, err = cachedLookup(ctx, metadataEnricher, req)
+        switch {
+        case err == nil:
+            cached++
+        case errors.Is(err, enricher.ErrNotFound):
+            notFound++
+        case errors.Is(err, enricher.ErrBudgetExhausted):
+            budgetSkipped++
+        default:
+            failed++
+            if viper.GetBool("verbose") {
+                fmt.Fprintf(console, "  ❌ Lookup failed: %v\n", err)
+            } else if viper.GetBool("quiet") {
+                fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+            }
+        }
+    }
+    
+    bar.update(len(files))
+    bar.finish()
+    
+    fmt.Fprintf(console, "\n=== CACHE WARM SUMMARY ===\n")
+    fmt.Fprintf(console, "Newly cached matches: %d\n", cached)
+    fmt.Fprintf(console, "Cached as not found: %d\n", notFound)
+    fmt.Fprintf(console, "Already cached: %d\n", alreadyCached)
+    fmt.Fprintf(console, "Skipped (labelled or unparseable): %d\n", skipped)
+    if budgetSkipped > 0 {
+        fmt.Fprintf(console, "Skipped (budget exhausted): %d\n", budgetSkipped)
+    }
+    printAPICallsUsed()
+    if failed > 0 {
+        fmt.Fprintf(console, "Lookup errors (not cached, retry later): %d\n", failed)
+    }
+    
+    exitCode = runOutcome{failed: failed, matched: cached + alreadyCached, notFound: notFound}.exitStatus()
+}
@@ -0,0 +1,89 @@
+// cmd/yearrange.go
+package cmd
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+var (
+    // yearRange narrows release selection to a span of years (--year-range)
+    yearRange string
+    
+    // minReleaseYear and maxReleaseYear are yearRange parsed by
+    // loadYearRange; 0 leaves that end open
+    minReleaseYear int
+    maxReleaseYear int
+)
+
+// decadePattern matches a decade such as "90s", "90's" or "1990s"
+var decadePattern = regexp.MustCompile(`^(\d{2}|\d{4})'?s$`)
+
+// parseYearRange parses a --year-range value: "1993-1997", an open-ended
+// "1993-" or "-1997", a single year, or a decade like "90s" or "1990s".
+// Two-digit decades before 30 are taken as 2000s.
+func parseYearRange(value string) (minYear, maxYear int, err error) {
+    value = strings.ToLower(strings.TrimSpace(value))
+    
+    if m := decadePattern.FindStringSubmatch(value); m != nil {
+        start, _ := strconv.Atoi(m[1])
+        switch {
+        case len(m[1]) == 2 && start < 30:
+            start += 2000
+        case len(m[1]) == 2:
+            start += 1900
+        }
+        if start%10 == 0 {
+            return start, start + 9, nil
+        }
+    }
+    
+    from, to, isRange := strings.Cut(value, "-")
+    if !isRange {
+        to = from
+    }
+    if minYear, err = parseRangeYear(strings.TrimSpace(from)); err == nil {
+        maxYear, err = parseRangeYear(strings.TrimSpace(to))
+    }
+    switch {
+    case err != nil:
+    case minYear == 0 && maxYear == 0:
+        err = fmt.Errorf("no years given")
+    case maxYear > 0 && minYear > maxYear:
+        err = fmt.Errorf("%d is after %d", minYear, maxYear)
+    }
+    if err != nil {
+        return 0, 0, fmt.Errorf("invalid --year-range value %q (%v): use a range like 1993-1997, 1993-, -1997 or a decade like 90s", value, err)
+    }
+    return minYear, maxYear, nil
+}
+
+// parseRangeYear parses one end of a year range; empty means open
+func parseRangeYear(s string) (int, error) {
+    if s == "" {
+        return 0, nil
+    }
+    year, err := strconv.Atoi(s)
+    if err != nil || len(s) != 4 {
+        return 0, fmt.Errorf("%q is not a year", s)
+    }
+    return year, nil
+}
+
+// loadYearRange parses --year-range for the run, reporting an invalid
+// value. It returns false if the command should stop.
+func loadYearRange() bool {
+    minReleaseYear, maxReleaseYear = 0, 0
+    if yearRange == "" {
+        return true
+    }
+    
+    var err error
+    if minReleaseYear, maxReleaseYear, err = parseYearRange(yearRange); err != nil {
+        fatalf("%v", err)
+        return false
+    }
+    return true
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+    "testing"
+    "time"
+
+    "github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestParseYearRange(t *testing.T) {
+    testCases := []struct {
+        value            string
+        minYear, maxYear int
+    }{
+        {"1993-1997", 1993, 1997},
+        {" 1993 - 1997 ", 1993, 1997},
+        {"1993-", 1993, 0},
+        {"-1997", 0, 1997},
+        {"1995", 1995, 1995},
+        {"90s", 1990, 1999},
+        {"90's", 1990, 1999},
+        {"1990s", 1990, 1999},
+        {"00s", 2000, 2009},
+    }
+    
+    for _, tc := range testCases {
+        minYear, maxYear, err := parseYearRange(tc.value)
+        if err != nil {
+            t.Errorf("parseYearRange(%q) returned error: %v", tc.value, err)
+            continue
+        }
+        if minYear != tc.minYear || maxYear != tc.maxYear {
+            t.Errorf("parseYearRange(%q) = %d-%d, expected %d-%d", tc.value, minYear, maxYear, tc.minYear, tc.maxYear)
+        }
+    }
+    
+    for _, value := range []string{"", "-", "1997-1993", "93-97", "nineties", "1995s"} {
+        if _, _, err := parseYearRange(value); err == nil {
+            t.Errorf("Expected parseYearRange(%q) to fail", value)
+        }
+    }
+}
+
+func TestLookupKey_YearRange(t *testing.T) {
+    req := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life"}
+    plain := lookupKey(req)
+    if plain != "goldie|inner city life" {
+        t.Errorf("Expected no year range in the key without one, got %q", plain)
+    }
+    
+    req.MinYear, req.MaxYear = 1993, 1997
+    if got := lookupKey(req); got == plain {
+        t.Error("Expected the year range to change the cache key")
+    }
+}
+
+func TestLookupKey_Duration(t *testing.T) {
+    full := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life", Duration: 372 * time.Second}
+    edit := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life", Duration: 210 * time.Second}
+    if lookupKey(full) == lookupKey(edit) {
+        t.Error("Expected files of different lengths to have different cache keys")
+    }
+    
+    again := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life", Duration: 373 * time.Second}
+    if lookupKey(full) != lookupKey(again) {
+        t.Error("Expected lengths within the same 5 seconds to share a cache key")
+    }
+    if got := lookupKey(&enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life"}); got != "goldie|inner city life" {
+        t.Errorf("Expected no length in the key without one, got %q", got)
+    }
+}
@@ -0,0 +1,110 @@
+// pkg/audiotag/aiff.go - AIFF/AIFF-C chunk handling
+
+package audiotag
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// isAIFF reports whether r begins with an AIFF or AIFF-C FORM header.
+// The reader is returned to the start.
+func isAIFF(r io.ReadSeeker) bool {
+	form := aiffForm(r)
+	return form == "AIFF" || form == "AIFC"
+}
+
+// isAIFC reports whether r begins with an AIFF-C FORM header, whose COMM
+// chunk carries extra compression fields
+func isAIFC(r io.ReadSeeker) bool {
+	return aiffForm(r) == "AIFC"
+}
+
+// aiffForm returns the form type of a FORM container, or "" if r doesn't
+// start with one. The reader is returned to the start.
+func aiffForm(r io.ReadSeeker) string {
+	header := make([]byte, 12)
+	defer r.Seek(0, io.SeekStart)
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	if _, err := io.ReadFull(r, header); err != nil || string(header[0:4]) != "FORM" {
+		return ""
+	}
+	return string(header[8:12])
+}
+
+// readAIFFChunks walks the chunk list of an AIFF FORM
+func readAIFFChunks(r io.ReadSeeker) ([]iffChunk, error) {
+	chunks, err := readChunks(r, binary.BigEndian)
+	if err != nil {
+		return nil, fmt.Errorf("%w: aiff: %w", ErrUnreadable, err)
+	}
+	return chunks, nil
+}
+
+// findID3Chunk returns the ID3 chunk, if any. Most taggers name it "ID3 ",
+// but some write "id3 " as in WAV, so the ID is matched in any case.
+func findID3Chunk(chunks []iffChunk) *iffChunk {
+	for i := range chunks {
+		if isID3Chunk(chunks[i]) {
+			return &chunks[i]
+		}
+	}
+	return nil
+}
+
+// isID3Chunk reports whether chunk is an ID3 chunk under any spelling
+func isID3Chunk(chunk iffChunk) bool {
+	return strings.EqualFold(chunk.id, "ID3 ")
+}
+
+// aiffCommon holds the fields of an AIFF/AIFF-C COMM chunk
+type aiffCommon struct {
+	channels    uint16
+	frames      uint32
+	sampleSize  uint16
+	sampleRate  float64
+	compression string // AIFF-C compression type, "NONE" for plain AIFF
+}
+
+// readAIFFCommon parses the COMM chunk. Plain AIFF stores 18 bytes; AIFF-C
+// follows them with a compression type and a Pascal-string name, so its
+// chunk must hold at least 22.
+func readAIFFCommon(r io.ReadSeeker, chunks []iffChunk, aifc bool) (*aiffCommon, error) {
+	comm := findChunk(chunks, "COMM")
+	if comm == nil {
+		return nil, fmt.Errorf("%w: aiff: no COMM chunk", ErrUnreadable)
+	}
+
+	minSize := int64(18)
+	if aifc {
+		minSize = 22
+	}
+	if comm.size < minSize {
+		return nil, fmt.Errorf("%w: aiff: COMM chunk is %d bytes, want at least %d", ErrUnreadable, comm.size, minSize)
+	}
+
+	data := make([]byte, minSize)
+	if _, err := r.Seek(comm.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("%w: aiff: %w", ErrUnreadable, err)
+	}
+
+	common := &aiffCommon{
+		channels:    binary.BigEndian.Uint16(data[0:2]),
+		frames:      binary.BigEndian.Uint32(data[2:6]),
+		sampleSize:  binary.BigEndian.Uint16(data[6:8]),
+		sampleRate:  extendedToFloat(data[8:18]),
+		compression: "NONE",
+	}
+	if aifc {
+		common.compression = string(data[18:22])
+	}
+	return common, nil
+}
@@ -0,0 +1,283 @@
+// pkg/audiotag/audiotag.go - Format-aware tag reading
+
+// Package audiotag reads and writes embedded metadata for the audio
+// container formats tagger supports. Reading is delegated to dhowden/tag,
+// with container-specific handling for formats it doesn't understand
+// natively (AIFF and WAV store their tags inside IFF/RIFF chunks). MP4
+// files (.m4a, .mp4) are read only.
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/dhowden/tag"
+)
+
+// CatalogNumberDescription is the TXXX description used for catalog
+// numbers, as read by Picard, Rekordbox and most DJ software
+const CatalogNumberDescription = "CATALOGNUMBER"
+
+// RecordingIDDescription is the TXXX description for a MusicBrainz
+// recording ID. Picard reads the ID from a UFID frame owned by
+// musicBrainzUFIDOwner, so both are written.
+const RecordingIDDescription = "MusicBrainz Recording Id"
+
+const musicBrainzUFIDOwner = "http://musicbrainz.org"
+
+// Common errors
+var (
+	ErrUnsupportedFormat = errors.New("unsupported audio format for writing")
+
+	// ErrUnreadable means the container itself is damaged (a truncated
+	// chunk list, or an AIFF without a usable COMM chunk), as opposed to
+	// a readable file that simply has no tags
+	ErrUnreadable = errors.New("unreadable audio file")
+)
+
+// ReadFile opens path and reads its embedded metadata
+func ReadFile(path string) (tag.Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadFrom(f)
+}
+
+// ReadFrom reads embedded metadata from r, handling AIFF and WAV containers
+// before falling back to tag.ReadFrom for everything else
+func ReadFrom(r io.ReadSeeker) (tag.Metadata, error) {
+	if isAIFF(r) {
+		chunks, err := readAIFFChunks(r)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := readAIFFCommon(r, chunks, isAIFC(r)); err != nil {
+			return nil, err
+		}
+
+		id3 := findID3Chunk(chunks)
+		if id3 == nil {
+			return nil, tag.ErrNoTagsFound
+		}
+
+		data := make([]byte, id3.size)
+		if _, err := r.Seek(id3.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return readID3v2(data)
+	}
+
+	if isWAV(r) {
+		return readWAV(r)
+	}
+
+	if data, err := readLeadingID3v2(r); err != nil {
+		return nil, err
+	} else if data != nil {
+		return readID3v2(data)
+	}
+	return tag.ReadFrom(r)
+}
+
+// id3HeaderSize is the size of an ID3v2 tag header, which the size field in
+// it leaves out
+const id3HeaderSize = 10
+
+// readLeadingID3v2 returns the ID3v2 tag at the start of r (an MP3), or nil
+// if r doesn't begin with one. The reader is returned to the start.
+func readLeadingID3v2(r io.ReadSeeker) ([]byte, error) {
+	header := make([]byte, id3HeaderSize)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	defer r.Seek(0, io.SeekStart)
+
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:3]) != "ID3" {
+		return nil, nil
+	}
+
+	data := make([]byte, id3HeaderSize+syncsafeSize(header[6:10]))
+	copy(data, header)
+	if _, err := io.ReadFull(r, data[id3HeaderSize:]); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readID3v2 parses a complete ID3v2 tag. dhowden/tag counts the header
+// against the tag size and so drops a frame it doesn't know (iTunes' GRP1)
+// when it is the last one in a tag without padding. The tag is given
+// id3HeaderSize bytes of padding, which it stops at, to keep that frame.
+func readID3v2(data []byte) (tag.Metadata, error) {
+	if len(data) < id3HeaderSize || string(data[:3]) != "ID3" {
+		return tag.ReadID3v2Tags(bytes.NewReader(data))
+	}
+
+	size := syncsafeSize(data[6:10])
+	if id3HeaderSize+size > len(data) {
+		size = len(data) - id3HeaderSize
+	}
+	padded := make([]byte, id3HeaderSize+size+id3HeaderSize)
+	copy(padded, data[:id3HeaderSize+size])
+	padded[5] &^= 0x10 // The footer, if any, is left behind
+	putSyncsafeSize(padded[6:10], size+id3HeaderSize)
+	return tag.ReadID3v2Tags(bytes.NewReader(padded))
+}
+
+// syncsafeSize decodes a 4-byte ID3v2 size, seven bits to a byte
+func syncsafeSize(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// putSyncsafeSize encodes size as a 4-byte ID3v2 size
+func putSyncsafeSize(b []byte, size int) {
+	for i := 3; i >= 0; i-- {
+		b[i] = byte(size & 0x7F)
+		size >>= 7
+	}
+}
+
+// Label returns the record label (ID3 TPUB, WAV INFO IPUB, the FLAC LABEL
+// comment or the MP4 LABEL freeform atom), or "" if unset
+func Label(m tag.Metadata) string {
+	if m.Format() == tag.MP4 {
+		return mp4Freeform(m, vorbisLabel)
+	}
+	raw := m.Raw()
+	for _, key := range []string{"TPUB", infoLabel, vorbisKey(vorbisLabel)} {
+		if text, ok := raw[key].(string); ok {
+			return strings.TrimSpace(text)
+		}
+	}
+	return ""
+}
+
+// Grouping returns the grouping DJ software files a track under: iTunes'
+// GRP1 frame, falling back to the standard content group (TIT1), or the
+// MP4 \xa9grp atom. It is "" when neither is set.
+func Grouping(m tag.Metadata) string {
+	raw := m.Raw()
+	if m.Format() == tag.MP4 {
+		text, _ := raw[mp4Grouping].(string)
+		return strings.TrimSpace(text)
+	}
+	// dhowden/tag leaves GRP1, which isn't a T frame, undecoded
+	if data, ok := raw["GRP1"].([]byte); ok {
+		if text := strings.TrimSpace(decodeFrameText(data)); text != "" {
+			return text
+		}
+	}
+	if text, ok := raw["TIT1"].(string); ok {
+		return strings.TrimSpace(text)
+	}
+	return ""
+}
+
+// ISRC returns the recording's International Standard Recording Code from
+// its TSRC frame (TRC in ID3v2.2), ISRC comment or MP4 atom, uppercased with the hyphens some
+// taggers add removed, or "" if there is none. It isn't validated.
+func ISRC(m tag.Metadata) string {
+	clean := func(text string) string {
+		code := strings.ToUpper(strings.TrimSpace(text))
+		return strings.NewReplacer("-", "", " ", "").Replace(code)
+	}
+	if m.Format() == tag.MP4 {
+		return clean(mp4Freeform(m, mp4ISRC))
+	}
+	raw := m.Raw()
+	for _, key := range []string{"TSRC", "TRC", "isrc"} {
+		if text, ok := raw[key].(string); ok {
+			return clean(text)
+		}
+	}
+	return ""
+}
+
+// decodeFrameText decodes the body of an ID3v2 text frame: an encoding byte
+// followed by ISO-8859-1, UTF-16 (with or without BOM) or UTF-8 text
+func decodeFrameText(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	encoding, text := data[0], data[1:]
+
+	switch encoding {
+	case 1, 2:
+		var order binary.ByteOrder = binary.BigEndian
+		if len(text) >= 2 && text[0] == 0xFF && text[1] == 0xFE {
+			order, text = binary.LittleEndian, text[2:]
+		} else if len(text) >= 2 && text[0] == 0xFE && text[1] == 0xFF {
+			text = text[2:]
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			units = append(units, order.Uint16(text[i:]))
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	case 3:
+		return strings.TrimRight(string(text), "\x00")
+	default:
+		runes := make([]rune, len(text))
+		for i, b := range text {
+			runes[i] = rune(b)
+		}
+		return strings.TrimRight(string(runes), "\x00")
+	}
+}
+
+// UserText returns the value of the first TXXX frame whose description
+// matches (case-insensitively), or "" if there is none. For Vorbis comments
+// (FLAC) it is the comment of that name, and for MP4 the freeform atom.
+func UserText(m tag.Metadata, description string) string {
+	if m.Format() == tag.MP4 {
+		return mp4Freeform(m, description)
+	}
+	if m.Format() == tag.VORBIS {
+		text, _ := m.Raw()[vorbisKey(description)].(string)
+		return strings.TrimSpace(text)
+	}
+	for name, value := range m.Raw() {
+		if !strings.HasPrefix(name, "TXXX") {
+			continue
+		}
+		if comm, ok := value.(*tag.Comm); ok && strings.EqualFold(comm.Description, description) {
+			return strings.TrimSpace(comm.Text)
+		}
+	}
+	return ""
+}
+
+// RecordingID returns the MusicBrainz recording ID stored in the TXXX frame
+// or Picard's UFID frame (MUSICBRAINZ_TRACKID in FLAC, the "MusicBrainz
+// Track Id" atom in MP4), or "" if there is none
+func RecordingID(m tag.Metadata) string {
+	if m.Format() == tag.VORBIS {
+		return UserText(m, vorbisRecordingID)
+	}
+	if m.Format() == tag.MP4 {
+		return UserText(m, mp4RecordingID)
+	}
+	if id := UserText(m, RecordingIDDescription); id != "" {
+		return id
+	}
+	for name, value := range m.Raw() {
+		if !strings.HasPrefix(name, "UFID") {
+			continue
+		}
+		if ufid, ok := value.(*tag.UFID); ok && ufid.Provider == musicBrainzUFIDOwner {
+			return strings.TrimSpace(string(ufid.Identifier))
+		}
+	}
+	return ""
+}
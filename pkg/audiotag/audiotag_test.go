@@ -0,0 +1,672 @@
+// pkg/audiotag/audiotag_test.go
+
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/dhowden/tag"
+)
+
+// buildAIFF assembles a minimal AIFF file from the given chunks
+func buildAIFF(form string, chunks ...[]byte) []byte {
+	var body bytes.Buffer
+	body.WriteString(form)
+	for _, chunk := range chunks {
+		body.Write(chunk)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("FORM")
+	binary.Write(&out, binary.BigEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+// rawChunk encodes a single IFF chunk, adding the pad byte for odd sizes
+func rawChunk(id string, data []byte) []byte {
+	var out bytes.Buffer
+	out.WriteString(id)
+	binary.Write(&out, binary.BigEndian, uint32(len(data)))
+	out.Write(data)
+	if len(data)%2 == 1 {
+		out.WriteByte(0)
+	}
+	return out.Bytes()
+}
+
+// writeTestAIFF writes a small AIFF with COMM and SSND chunks to a temp dir
+func writeTestAIFF(t *testing.T, extra ...[]byte) string {
+	t.Helper()
+
+	comm := rawChunk("COMM", make([]byte, 18))
+	ssnd := rawChunk("SSND", []byte{0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3})
+	chunks := append([][]byte{comm, ssnd}, extra...)
+
+	path := filepath.Join(t.TempDir(), "test.aiff")
+	if err := os.WriteFile(path, buildAIFF("AIFF", chunks...), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	return path
+}
+
+// wavChunk encodes a single little-endian RIFF chunk
+func wavChunk(id string, data []byte) []byte {
+	var out bytes.Buffer
+	out.WriteString(id)
+	binary.Write(&out, binary.LittleEndian, uint32(len(data)))
+	out.Write(data)
+	if len(data)%2 == 1 {
+		out.WriteByte(0)
+	}
+	return out.Bytes()
+}
+
+// writeTestWAV writes a small WAV with fmt and data chunks to a temp dir
+func writeTestWAV(t *testing.T, extra ...[]byte) string {
+	t.Helper()
+
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+	body.Write(wavChunk("fmt ", make([]byte, 16)))
+	body.Write(wavChunk("data", []byte{1, 2, 3}))
+	for _, chunk := range extra {
+		body.Write(chunk)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+
+	path := filepath.Join(t.TempDir(), "test.wav")
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestReadFile_WAVInfoChunk(t *testing.T) {
+	info := encodeInfoChunk(map[string]string{
+		infoArtist: "LTJ Bukem",
+		infoTitle:  "Horizons",
+		infoDate:   "1995-06-01",
+		infoLabel:  "Good Looking Records",
+	})
+	path := writeTestWAV(t, wavChunk("LIST", info))
+
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+
+	if metadata.Format() != RIFFInfo {
+		t.Errorf("Expected format %q, got %q", RIFFInfo, metadata.Format())
+	}
+	if got := metadata.Artist(); got != "LTJ Bukem" {
+		t.Errorf("Expected artist 'LTJ Bukem', got '%s'", got)
+	}
+	if got := metadata.Title(); got != "Horizons" {
+		t.Errorf("Expected title 'Horizons', got '%s'", got)
+	}
+	if got := metadata.Year(); got != 1995 {
+		t.Errorf("Expected year 1995, got %d", got)
+	}
+	if got := Label(metadata); got != "Good Looking Records" {
+		t.Errorf("Expected IPUB 'Good Looking Records', got '%s'", got)
+	}
+}
+
+func TestWriteFile_WAVKeepsInfoInSync(t *testing.T) {
+	info := encodeInfoChunk(map[string]string{infoArtist: "LTJ Bukem", infoTitle: "Horizons"})
+	path := writeTestWAV(t, wavChunk("LIST", info))
+
+	if err := WriteFile(path, &Update{Label: "Good Looking Records", Year: 1995}); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	chunks, err := readWAVChunks(f)
+	if err != nil {
+		t.Fatalf("failed to read chunks: %v", err)
+	}
+	if findChunk(chunks, "data") == nil {
+		t.Fatal("Expected data chunk to be preserved")
+	}
+
+	infoChunk := findInfoChunk(f, chunks)
+	if infoChunk == nil {
+		t.Fatal("Expected LIST/INFO chunk after write")
+	}
+	fields, err := readInfoFields(f, infoChunk)
+	if err != nil {
+		t.Fatalf("failed to read INFO fields: %v", err)
+	}
+	if fields[infoLabel] != "Good Looking Records" || fields[infoDate] != "1995" {
+		t.Errorf("Expected INFO label and year to be updated, got %v", fields)
+	}
+
+	// The new ID3 chunk is seeded from INFO, so artist and title survive
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if metadata.Format() == RIFFInfo {
+		t.Error("Expected ID3 chunk to take precedence after write")
+	}
+	if got := metadata.Artist(); got != "LTJ Bukem" {
+		t.Errorf("Expected artist 'LTJ Bukem', got '%s'", got)
+	}
+}
+
+func TestReadFile_AIFFWithoutID3(t *testing.T) {
+	path := writeTestAIFF(t)
+
+	_, err := ReadFile(path)
+	if !errors.Is(err, tag.ErrNoTagsFound) {
+		t.Errorf("Expected ErrNoTagsFound, got %v", err)
+	}
+}
+
+func TestReadFile_AIFFLowercaseID3Chunk(t *testing.T) {
+	// Tag a file normally, then rebuild it with the ID3 chunk renamed
+	tagged := writeTestAIFF(t)
+	if err := WriteFile(tagged, &Update{Label: "Metalheadz"}); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	data, err := os.ReadFile(tagged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id3 := bytes.Index(data, []byte("ID3 "))
+	if id3 < 0 {
+		t.Fatal("Expected an ID3 chunk after write")
+	}
+	copy(data[id3:], "id3 ")
+
+	path := filepath.Join(t.TempDir(), "lowercase.aiff")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if label := Label(metadata); label != "Metalheadz" {
+		t.Errorf("Expected label Metalheadz from the id3 chunk, got %q", label)
+	}
+
+	// Rewriting replaces the lowercase chunk rather than adding a second one
+	if err := WriteFile(path, &Update{CatalogNumber: "META001"}); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	chunks, err := readAIFFChunks(f)
+	if err != nil {
+		t.Fatalf("readAIFFChunks returned error: %v", err)
+	}
+	var ids []string
+	for _, chunk := range chunks {
+		ids = append(ids, chunk.id)
+	}
+	if len(ids) != 3 || ids[2] != "ID3 " {
+		t.Errorf("Unexpected chunk layout after rewrite: %v", ids)
+	}
+
+	metadata, err = ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if Label(metadata) != "Metalheadz" || UserText(metadata, CatalogNumberDescription) != "META001" {
+		t.Error("Expected existing frames to survive the rewrite")
+	}
+}
+
+func TestReadFile_AIFC(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+		return path
+	}
+
+	// A float AIFF-C COMM chunk is 24 bytes: the AIFF fields, the
+	// compression type and an empty compression name
+	comm := make([]byte, 24)
+	copy(comm[18:22], "fl32")
+	valid := write("float.aifc", buildAIFF("AIFC", rawChunk("COMM", comm), rawChunk("SSND", make([]byte, 8))))
+	if _, err := ReadFile(valid); !errors.Is(err, tag.ErrNoTagsFound) {
+		t.Errorf("Expected ErrNoTagsFound for untagged AIFF-C, got %v", err)
+	}
+
+	unreadable := map[string][]byte{
+		"no COMM":        buildAIFF("AIFC", rawChunk("SSND", make([]byte, 8))),
+		"short COMM":     buildAIFF("AIFC", rawChunk("COMM", make([]byte, 18))),
+		"no chunks":      buildAIFF("AIFC"),
+		"truncated AIFF": []byte("FORM\x00\x00\x10\x00AIFFCO"),
+	}
+	for name, data := range unreadable {
+		path := write(strings.ReplaceAll(name, " ", "_")+".aifc", data)
+		if _, err := ReadFile(path); !errors.Is(err, ErrUnreadable) {
+			t.Errorf("%s: expected ErrUnreadable, got %v", name, err)
+		}
+	}
+}
+
+func TestWriteFile_AIFFArtworkRoundTrip(t *testing.T) {
+	path := writeTestAIFF(t)
+
+	art := &Picture{MIMEType: "image/jpeg", Data: []byte{0xFF, 0xD8, 0xFF, 0xE0, 1, 2, 3}}
+	if err := WriteFile(path, &Update{Artwork: art}); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+
+	pic := metadata.Picture()
+	if pic == nil {
+		t.Fatal("Expected embedded picture after write")
+	}
+	if pic.MIMEType != "image/jpeg" {
+		t.Errorf("Expected MIME type image/jpeg, got %s", pic.MIMEType)
+	}
+	if !bytes.Equal(pic.Data, art.Data) {
+		t.Errorf("Picture data did not round-trip")
+	}
+
+	// Audio chunks must survive the rewrite untouched
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	chunks, err := readAIFFChunks(f)
+	if err != nil {
+		t.Fatalf("readAIFFChunks returned error: %v", err)
+	}
+
+	var ids []string
+	for _, chunk := range chunks {
+		ids = append(ids, chunk.id)
+	}
+	if len(ids) != 3 || ids[0] != "COMM" || ids[1] != "SSND" || ids[2] != "ID3 " {
+		t.Errorf("Unexpected chunk layout after write: %v", ids)
+	}
+	if chunks[1].size != 11 {
+		t.Errorf("Expected SSND size 11, got %d", chunks[1].size)
+	}
+}
+
+func TestWriteFile_LabelAndCatalogRoundTrip(t *testing.T) {
+	for _, ext := range []string{".aiff", ".wav", ".mp3", ".flac"} {
+		t.Run(ext, func(t *testing.T) {
+			var path string
+			switch ext {
+			case ".aiff":
+				path = writeTestAIFF(t)
+			case ".wav":
+				path = writeTestWAV(t)
+			case ".flac":
+				path = writeTestFLAC(t)
+			default:
+				path = filepath.Join(t.TempDir(), "test.mp3")
+				audio := append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 60)...)
+				if err := os.WriteFile(path, audio, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			update := &Update{
+				Artist:        "LTJ Bukem",
+				Title:         "Music",
+				Album:         "Logical Progression",
+				Label:         "Good Looking Records",
+				CatalogNumber: "GLRLP001",
+				Year:          1996,
+				Genre:         "Drum and Bass",
+			}
+			if err := WriteFile(path, update); err != nil {
+				t.Fatalf("WriteFile returned error: %v", err)
+			}
+
+			metadata, err := ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile returned error: %v", err)
+			}
+
+			if got := Label(metadata); got != "Good Looking Records" {
+				t.Errorf("Expected TPUB 'Good Looking Records', got '%s'", got)
+			}
+			if got := UserText(metadata, CatalogNumberDescription); got != "GLRLP001" {
+				t.Errorf("Expected TXXX:CATALOGNUMBER 'GLRLP001', got '%s'", got)
+			}
+			if got := metadata.Artist(); got != "LTJ Bukem" {
+				t.Errorf("Expected artist 'LTJ Bukem', got '%s'", got)
+			}
+			if got := metadata.Title(); got != "Music" {
+				t.Errorf("Expected title 'Music', got '%s'", got)
+			}
+			if got := metadata.Album(); got != "Logical Progression" {
+				t.Errorf("Expected album 'Logical Progression', got '%s'", got)
+			}
+			if got := metadata.Year(); got != 1996 {
+				t.Errorf("Expected year 1996, got %d", got)
+			}
+			if got := metadata.Genre(); got != "Drum and Bass" {
+				t.Errorf("Expected genre 'Drum and Bass', got '%s'", got)
+			}
+		})
+	}
+}
+
+// djTag encodes an ID3 tag carrying the frames DJ software keeps its own
+// notes in: a comment, a grouping and key and energy TXXX frames
+func djTag(t *testing.T) []byte {
+	t.Helper()
+	tg := newTag()
+	enc := tg.DefaultEncoding()
+	tg.SetTitle("Inner City Life")
+	tg.AddCommentFrame(id3v2.CommentFrame{Encoding: enc, Language: "eng", Text: "Peak time, long intro"})
+	tg.AddTextFrame("GRP1", enc, "Warmup")
+	tg.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{Encoding: enc, Description: "INITIALKEY", Value: "8A"})
+	tg.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{Encoding: enc, Description: "ENERGY", Value: "7"})
+
+	var buf bytes.Buffer
+	if _, err := tg.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteFile_PreservesUnrelatedFrames(t *testing.T) {
+	for _, ext := range []string{".aiff", ".wav", ".mp3"} {
+		t.Run(ext, func(t *testing.T) {
+			var path string
+			switch ext {
+			case ".aiff":
+				path = writeTestAIFF(t, rawChunk("ID3 ", djTag(t)))
+			case ".wav":
+				path = writeTestWAV(t, wavChunk("id3 ", djTag(t)))
+			default:
+				path = filepath.Join(t.TempDir(), "test.mp3")
+				audio := append(djTag(t), 0xFF, 0xFB, 0x90, 0x64)
+				if err := os.WriteFile(path, append(audio, make([]byte, 60)...), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := WriteFile(path, &Update{Label: "FFRR", Year: 1994}); err != nil {
+				t.Fatalf("WriteFile returned error: %v", err)
+			}
+
+			metadata, err := ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile returned error: %v", err)
+			}
+			if got := Label(metadata); got != "FFRR" {
+				t.Errorf("Expected the label written, got %q", got)
+			}
+			if got := metadata.Comment(); got != "Peak time, long intro" {
+				t.Errorf("Expected the comment kept, got %q", got)
+			}
+			if got := Grouping(metadata); got != "Warmup" {
+				t.Errorf("Expected the grouping kept, got %q", got)
+			}
+			if got := UserText(metadata, "INITIALKEY"); got != "8A" {
+				t.Errorf("Expected the key kept, got %q", got)
+			}
+			if got := UserText(metadata, "ENERGY"); got != "7" {
+				t.Errorf("Expected the energy kept, got %q", got)
+			}
+			if got := metadata.Title(); got != "Inner City Life" {
+				t.Errorf("Expected the title kept, got %q", got)
+			}
+		})
+	}
+}
+
+func TestGrouping_LastFrame(t *testing.T) {
+	// A tag holding only GRP1, so it ends the tag with no padding after it
+	tg := newTag()
+	tg.AddTextFrame("GRP1", tg.DefaultEncoding(), "Warmup")
+	var buf bytes.Buffer
+	if _, err := tg.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if got := Grouping(metadata); got != "Warmup" {
+		t.Errorf("Expected grouping Warmup, got %q", got)
+	}
+}
+
+func TestISRC(t *testing.T) {
+	tg := newTag()
+	tg.AddTextFrame("TSRC", tg.DefaultEncoding(), " gb-a7b-94-00123 ")
+	var buf bytes.Buffer
+	if _, err := tg.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := ReadFile(writeTestAIFF(t, rawChunk("ID3 ", buf.Bytes())))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if got := ISRC(metadata); got != "GBA7B9400123" {
+		t.Errorf("Expected normalized ISRC GBA7B9400123, got %q", got)
+	}
+}
+
+func TestDecodeFrameText(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"ISO-8859-1", []byte("\x00Caf\xe9"), "Café"},
+		{"UTF-16 with BOM", []byte("\x01\xff\xfeW\x00u\x00\x00\x00"), "Wu"},
+		{"UTF-16BE", []byte("\x02\x00W\x00u"), "Wu"},
+		{"UTF-8", []byte("\x03Café\x00"), "Café"},
+		{"empty", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		if got := decodeFrameText(tc.data); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestWriteFile_WAVSeedsCommentFromInfo(t *testing.T) {
+	info := encodeInfoChunk(map[string]string{infoComment: "Dubplate"})
+	path := writeTestWAV(t, wavChunk("LIST", info))
+
+	if err := WriteFile(path, &Update{Label: "Metalheadz"}); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if metadata.Format() == RIFFInfo || metadata.Comment() != "Dubplate" {
+		t.Errorf("Expected the INFO comment carried into the new ID3 tag, got %q (%s)", metadata.Comment(), metadata.Format())
+	}
+}
+
+func TestWriteFile_RecordingIDRoundTrip(t *testing.T) {
+	path := writeTestAIFF(t)
+	const id = "b1a9c0e9-d987-4042-ae91-78d6a3267d69"
+
+	// Written twice to check the frames are replaced, not duplicated
+	for i := 0; i < 2; i++ {
+		if err := WriteFile(path, &Update{RecordingID: id}); err != nil {
+			t.Fatalf("WriteFile returned error: %v", err)
+		}
+	}
+
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if got := RecordingID(metadata); got != id {
+		t.Errorf("Expected recording ID %q, got %q", id, got)
+	}
+
+	ufid, ok := metadata.Raw()["UFID"].(*tag.UFID)
+	if !ok || ufid.Provider != musicBrainzUFIDOwner || string(ufid.Identifier) != id {
+		t.Errorf("Expected Picard UFID frame for %q, got %v", id, metadata.Raw()["UFID"])
+	}
+	if _, dup := metadata.Raw()["TXXX_1"]; dup {
+		t.Error("Expected a single TXXX frame after writing twice")
+	}
+}
+
+func TestWriteFile_ReplacesExistingFrontCover(t *testing.T) {
+	path := writeTestAIFF(t)
+
+	first := &Picture{MIMEType: "image/png", Data: []byte{1}}
+	second := &Picture{MIMEType: "image/jpeg", Data: []byte{2, 2}}
+
+	if err := WriteFile(path, &Update{Artwork: first}); err != nil {
+		t.Fatalf("first write failed: %v", err)
+	}
+	if err := WriteFile(path, &Update{Artwork: second}); err != nil {
+		t.Fatalf("second write failed: %v", err)
+	}
+
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if pic := metadata.Picture(); pic == nil || !bytes.Equal(pic.Data, second.Data) {
+		t.Errorf("Expected second picture to replace the first, got %+v", pic)
+	}
+}
+
+func TestWriteFile_MP3(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mp3")
+	audio := append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 60)...)
+	if err := os.WriteFile(path, audio, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	art := &Picture{MIMEType: "image/jpeg", Data: []byte{9, 9, 9}}
+	if err := WriteFile(path, &Update{Artwork: art}); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if metadata.Picture() == nil {
+		t.Error("Expected embedded picture after write")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(data, audio) {
+		t.Error("Expected audio frames to be preserved after the tag")
+	}
+}
+
+func TestWriteFile_UnsupportedFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ogg")
+	if err := os.WriteFile(path, []byte("OggS"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WriteFile(path, &Update{})
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestWriteFile_AppendGenre(t *testing.T) {
+	for _, tc := range []struct {
+		version  byte
+		expected string
+	}{
+		{3, "Drum and Bass/Jungle"},
+		{4, "Drum and Bass\x00Jungle"},
+	} {
+		t.Run(fmt.Sprintf("ID3v2.%d", tc.version), func(t *testing.T) {
+			existing := id3v2.NewEmptyTag()
+			existing.SetVersion(tc.version)
+			existing.SetGenre("Drum and Bass")
+			var file bytes.Buffer
+			if _, err := existing.WriteTo(&file); err != nil {
+				t.Fatal(err)
+			}
+			file.Write(append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 60)...))
+			path := filepath.Join(t.TempDir(), "test.mp3")
+			if err := os.WriteFile(path, file.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// The second write finds the genre already there
+			for _, genre := range []string{"Jungle", "jungle"} {
+				if err := WriteFile(path, &Update{Genre: genre, AppendGenre: true}); err != nil {
+					t.Fatalf("WriteFile returned error: %v", err)
+				}
+			}
+
+			written, err := id3v2.Open(path, id3v2.Options{Parse: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer written.Close()
+			if got := written.GetTextFrame(written.CommonID("Content type")).Text; got != tc.expected {
+				t.Errorf("Expected TCON %q, got %q", tc.expected, got)
+			}
+		})
+	}
+
+	// Without AppendGenre the new genre replaces them all
+	path := writeTestAIFF(t)
+	for _, update := range []*Update{{Genre: "Drum and Bass"}, {Genre: "Jungle", AppendGenre: true}, {Genre: "Breakbeat"}} {
+		if err := WriteFile(path, update); err != nil {
+			t.Fatalf("WriteFile returned error: %v", err)
+		}
+	}
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if got := metadata.Genre(); got != "Breakbeat" {
+		t.Errorf("Expected the genre replaced, got %q", got)
+	}
+}
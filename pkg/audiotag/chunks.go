@@ -0,0 +1,160 @@
+// pkg/audiotag/chunks.go - Shared IFF/RIFF chunk handling
+
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// iffChunk describes the location of a chunk inside an AIFF FORM or a
+// RIFF WAVE container
+type iffChunk struct {
+	id     string
+	offset int64 // offset of the chunk data (after the 8-byte header)
+	size   int64 // size of the chunk data, excluding any pad byte
+}
+
+// newChunk is a chunk to append when rewriting a container
+type newChunk struct {
+	id   string
+	data []byte
+}
+
+// readChunks walks the chunk list following a 12-byte container header.
+// AIFF stores chunk sizes big-endian, RIFF little-endian.
+func readChunks(r io.ReadSeeker, order binary.ByteOrder) ([]iffChunk, error) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var chunks []iffChunk
+	offset := int64(12)
+	header := make([]byte, 8)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break // Tolerate trailing garbage after the last chunk
+			}
+			return nil, err
+		}
+
+		size := int64(order.Uint32(header[4:8]))
+		chunk := iffChunk{
+			id:     string(header[0:4]),
+			offset: offset + 8,
+			size:   size,
+		}
+		chunks = append(chunks, chunk)
+
+		// Chunks are padded to an even length
+		next := chunk.offset + size + size%2
+		if _, err := r.Seek(next, io.SeekStart); err != nil {
+			return nil, err
+		}
+		offset = next
+	}
+
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no chunks found")
+	}
+
+	return chunks, nil
+}
+
+// findChunk returns the first chunk with one of the given IDs, if any
+func findChunk(chunks []iffChunk, ids ...string) *iffChunk {
+	for i := range chunks {
+		for _, id := range ids {
+			if chunks[i].id == id {
+				return &chunks[i]
+			}
+		}
+	}
+	return nil
+}
+
+// writeChunks writes a complete container to dst: the 12-byte header,
+// every chunk from src not rejected by skip, then the appended chunks. The
+// container size in the header is patched once the length is known.
+func writeChunks(dst *os.File, src io.ReaderAt, header []byte, chunks []iffChunk, skip func(iffChunk) bool, appended []newChunk, order binary.ByteOrder) error {
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	chunkHeader := make([]byte, 8)
+	writeChunk := func(id string, size int64, body io.Reader) error {
+		copy(chunkHeader[0:4], id)
+		order.PutUint32(chunkHeader[4:8], uint32(size))
+		if _, err := dst.Write(chunkHeader); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(dst, body, size); err != nil {
+			return err
+		}
+		if size%2 == 1 {
+			_, err := dst.Write([]byte{0})
+			return err
+		}
+		return nil
+	}
+
+	for _, chunk := range chunks {
+		if skip(chunk) {
+			continue
+		}
+		if err := writeChunk(chunk.id, chunk.size, io.NewSectionReader(src, chunk.offset, chunk.size)); err != nil {
+			return err
+		}
+	}
+
+	for _, chunk := range appended {
+		if err := writeChunk(chunk.id, int64(len(chunk.data)), bytes.NewReader(chunk.data)); err != nil {
+			return err
+		}
+	}
+
+	// Patch the container size now that the total length is known
+	end, err := dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	size := make([]byte, 4)
+	order.PutUint32(size, uint32(end-8))
+	_, err = dst.WriteAt(size, 4)
+	return err
+}
+
+// replaceFile writes a new version of path via a temp file in the same
+// directory, then renames it into place preserving the file mode
+func replaceFile(path string, write func(tmp *os.File) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tagger-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // No-op once renamed
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, info.Mode()); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, path)
+}
@@ -0,0 +1,362 @@
+// pkg/audiotag/duration.go - Playing time from audio headers
+
+package audiotag
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// ErrUnknownDuration is returned when a file's playing time can't be
+// worked out from its headers
+var ErrUnknownDuration = errors.New("audiotag: unknown duration")
+
+// Duration returns the playing time of the audio file at path. AIFF/AIFF-C
+// use the COMM chunk, WAV the fmt and data chunks, FLAC its STREAMINFO
+// block, MP4 its movie header, and MP3 a Xing/Info header or, failing
+// that, a frame scan.
+func Duration(path string) (time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return ReadDuration(f)
+}
+
+// ReadDuration returns the playing time of the audio in r
+func ReadDuration(r io.ReadSeeker) (time.Duration, error) {
+	switch {
+	case isAIFF(r):
+		return aiffDuration(r)
+	case isWAV(r):
+		return wavDuration(r)
+	case isMP4(r):
+		return mp4Duration(r)
+	}
+
+	// FLAC and MP3 may both start with an ID3v2 tag
+	start, err := skipID3v2(r)
+	if err != nil {
+		return 0, err
+	}
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return 0, ErrUnknownDuration
+	}
+	if string(magic) == "fLaC" {
+		return flacDuration(r)
+	}
+
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return mp3Duration(r)
+}
+
+// aiffDuration reads the frame count and sample rate from the COMM chunk
+func aiffDuration(r io.ReadSeeker) (time.Duration, error) {
+	aifc := isAIFC(r)
+	chunks, err := readAIFFChunks(r)
+	if err != nil {
+		return 0, err
+	}
+
+	common, err := readAIFFCommon(r, chunks, aifc)
+	if err != nil {
+		return 0, err
+	}
+	return samplesToDuration(uint64(common.frames), common.sampleRate)
+}
+
+// extendedToFloat converts an 80-bit IEEE 754 extended float, which AIFF
+// uses for its sample rate
+func extendedToFloat(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+
+	value := float64(mantissa) * math.Pow(2, float64(exponent-16383-63))
+	if b[0]&0x80 != 0 {
+		value = -value
+	}
+	return value
+}
+
+// wavDuration divides the data chunk size by the byte rate in fmt
+func wavDuration(r io.ReadSeeker) (time.Duration, error) {
+	chunks, err := readWAVChunks(r)
+	if err != nil {
+		return 0, err
+	}
+
+	format, data := findChunk(chunks, "fmt "), findChunk(chunks, "data")
+	if format == nil || format.size < 12 || data == nil {
+		return 0, ErrUnknownDuration
+	}
+
+	header := make([]byte, 12)
+	if _, err := r.Seek(format.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+
+	byteRate := binary.LittleEndian.Uint32(header[8:12])
+	if byteRate == 0 {
+		return 0, ErrUnknownDuration
+	}
+	return time.Duration(float64(data.size) / float64(byteRate) * float64(time.Second)), nil
+}
+
+// flacDuration reads STREAMINFO, which is always the first metadata block.
+// r must be positioned just after the "fLaC" marker.
+func flacDuration(r io.Reader) (time.Duration, error) {
+	block := make([]byte, 4+34)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return 0, ErrUnknownDuration
+	}
+	if block[0]&0x7F != 0 { // Block type 0 is STREAMINFO
+		return 0, ErrUnknownDuration
+	}
+
+	info := block[4:]
+	rate := uint32(info[10])<<12 | uint32(info[11])<<4 | uint32(info[12])>>4
+	samples := uint64(info[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(info[14:18]))
+	return samplesToDuration(samples, float64(rate))
+}
+
+// skipID3v2 positions r after a leading ID3v2 tag, if there is one, and
+// returns that offset
+func skipID3v2(r io.ReadSeeker) (int64, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[0:3]) != "ID3" {
+		_, err := r.Seek(0, io.SeekStart)
+		return 0, err
+	}
+
+	// Tag size is syncsafe: 7 bits per byte
+	size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+	offset := 10 + size
+	if header[5]&0x10 != 0 { // Footer present
+		offset += 10
+	}
+
+	_, err := r.Seek(offset, io.SeekStart)
+	return offset, err
+}
+
+// mpegFrame is a decoded MPEG audio frame header
+type mpegFrame struct {
+	version    int // 1, 2, or 25 for MPEG 2.5
+	layer      int
+	bitrate    int // bits per second
+	sampleRate int
+	padding    int
+	mono       bool
+}
+
+// MPEG bitrates in kbps, indexed by [version 1 or 2/2.5][layer-1][index]
+var mpegBitrates = [2][3][15]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// mpeg1SampleRates are divided by 2 for MPEG 2 and 4 for MPEG 2.5
+var mpeg1SampleRates = [3]int{44100, 48000, 32000}
+
+// parseMPEGFrame decodes a 4-byte frame header, reporting false if it
+// isn't one
+func parseMPEGFrame(h []byte) (mpegFrame, bool) {
+	if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mpegFrame{}, false
+	}
+
+	var f mpegFrame
+	switch (h[1] >> 3) & 0x03 {
+	case 0:
+		f.version = 25
+	case 2:
+		f.version = 2
+	case 3:
+		f.version = 1
+	default:
+		return mpegFrame{}, false
+	}
+
+	layerBits := (h[1] >> 1) & 0x03
+	bitrateIndex := int(h[2] >> 4)
+	rateIndex := int((h[2] >> 2) & 0x03)
+	if layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mpegFrame{}, false
+	}
+	f.layer = 4 - int(layerBits)
+
+	table := 0
+	if f.version != 1 {
+		table = 1
+	}
+	f.bitrate = mpegBitrates[table][f.layer-1][bitrateIndex] * 1000
+
+	f.sampleRate = mpeg1SampleRates[rateIndex]
+	switch f.version {
+	case 2:
+		f.sampleRate /= 2
+	case 25:
+		f.sampleRate /= 4
+	}
+
+	f.padding = int((h[2] >> 1) & 0x01)
+	f.mono = h[3]>>6 == 3
+	return f, true
+}
+
+// samples returns the number of samples per channel in the frame
+func (f mpegFrame) samples() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && f.version != 1:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// length returns the frame size in bytes, including the header
+func (f mpegFrame) length() int {
+	if f.layer == 1 {
+		return (12*f.bitrate/f.sampleRate + f.padding) * 4
+	}
+	return f.samples()/8*f.bitrate/f.sampleRate + f.padding
+}
+
+// xingOffset returns where a Xing/Info header would start in the frame
+func (f mpegFrame) xingOffset() int {
+	switch {
+	case f.version == 1 && !f.mono:
+		return 4 + 32
+	case f.version == 1, !f.mono:
+		return 4 + 17
+	default:
+		return 4 + 9
+	}
+}
+
+// mp3Duration finds the first frame and uses its Xing/Info frame count if
+// present; otherwise every frame is walked and its samples summed, which
+// is accurate for VBR files without a Xing header too
+func mp3Duration(r io.Reader) (time.Duration, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	first, err := syncToFrame(br)
+	if err != nil {
+		return 0, err
+	}
+
+	if head, err := br.Peek(first.length()); err == nil {
+		if frames, ok := xingFrameCount(head, first); ok {
+			return samplesToDuration(uint64(frames)*uint64(first.samples()), float64(first.sampleRate))
+		}
+	}
+
+	var total time.Duration
+	for frame := first; ; {
+		if _, err := br.Discard(frame.length()); err != nil {
+			break
+		}
+		total += time.Duration(frame.samples()) * time.Second / time.Duration(frame.sampleRate)
+
+		h, err := br.Peek(4)
+		if err != nil {
+			break
+		}
+		next, ok := parseMPEGFrame(h)
+		if !ok {
+			break // Trailing ID3v1/APE tag or garbage
+		}
+		frame = next
+	}
+
+	if total == 0 {
+		return 0, ErrUnknownDuration
+	}
+	return total, nil
+}
+
+// syncToFrame advances br to the first valid frame whose successor is also
+// valid, so stray sync bytes in leading junk are skipped
+func syncToFrame(br *bufio.Reader) (mpegFrame, error) {
+	for {
+		h, err := br.Peek(4)
+		if err != nil {
+			return mpegFrame{}, ErrUnknownDuration
+		}
+
+		if frame, ok := parseMPEGFrame(h); ok {
+			next, err := br.Peek(frame.length() + 4)
+			if err == nil {
+				if _, ok := parseMPEGFrame(next[frame.length():]); ok {
+					return frame, nil
+				}
+			} else if len(next) >= frame.length() {
+				return frame, nil // Single-frame file
+			}
+		}
+
+		if _, err := br.Discard(1); err != nil {
+			return mpegFrame{}, ErrUnknownDuration
+		}
+	}
+}
+
+// xingFrameCount reads the frame count from a Xing/Info header in frame
+func xingFrameCount(frame []byte, f mpegFrame) (uint32, bool) {
+	offset := f.xingOffset()
+	if len(frame) < offset+12 {
+		return 0, false
+	}
+
+	id := frame[offset : offset+4]
+	if !bytes.Equal(id, []byte("Xing")) && !bytes.Equal(id, []byte("Info")) {
+		return 0, false
+	}
+
+	flags := binary.BigEndian.Uint32(frame[offset+4 : offset+8])
+	if flags&0x01 == 0 { // Frame count not present
+		return 0, false
+	}
+	frames := binary.BigEndian.Uint32(frame[offset+8 : offset+12])
+	return frames, frames > 0
+}
+
+// samplesToDuration converts a per-channel sample count to playing time
+func samplesToDuration(samples uint64, rate float64) (time.Duration, error) {
+	if samples == 0 || rate <= 0 {
+		return 0, ErrUnknownDuration
+	}
+	return time.Duration(float64(samples) / rate * float64(time.Second)), nil
+}
@@ -0,0 +1,143 @@
+// pkg/audiotag/duration_test.go
+
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// commChunk encodes an AIFF COMM chunk for a stereo 16-bit file. Passing
+// an AIFF-C compression type appends it and an empty compression name.
+func commChunk(frames uint32, rate float64, compression ...string) []byte {
+	data := make([]byte, 18)
+	binary.BigEndian.PutUint16(data[0:2], 2)
+	binary.BigEndian.PutUint32(data[2:6], frames)
+	binary.BigEndian.PutUint16(data[6:8], 16)
+
+	// 80-bit extended: 15-bit biased exponent, 64-bit mantissa with an
+	// explicit integer bit
+	exponent := int(math.Floor(math.Log2(rate)))
+	binary.BigEndian.PutUint16(data[8:10], uint16(exponent+16383))
+	binary.BigEndian.PutUint64(data[10:18], uint64(rate*math.Pow(2, float64(63-exponent))))
+	for _, c := range compression {
+		data = append(data, c...)
+		data = append(data, 0, 0) // Empty Pascal string, padded to even length
+	}
+	return rawChunk("COMM", data)
+}
+
+// assertDuration checks d is within a millisecond of expected
+func assertDuration(t *testing.T, d time.Duration, err error, expected time.Duration) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Duration returned error: %v", err)
+	}
+	if diff := d - expected; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("Expected duration %v, got %v", expected, d)
+	}
+}
+
+func TestDuration_AIFF(t *testing.T) {
+	tests := map[string][]byte{
+		"AIFF": buildAIFF("AIFF", commChunk(88200, 44100), rawChunk("SSND", make([]byte, 8))),
+		"AIFC": buildAIFF("AIFC", commChunk(88200, 44100, "sowt"), rawChunk("SSND", make([]byte, 8))),
+	}
+	for form, file := range tests {
+		t.Run(form, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.aiff")
+			if err := os.WriteFile(path, file, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			d, err := Duration(path)
+			assertDuration(t, d, err, 2*time.Second)
+		})
+	}
+}
+
+func TestDuration_WAV(t *testing.T) {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:2], 1)       // PCM
+	binary.LittleEndian.PutUint16(format[2:4], 2)       // Stereo
+	binary.LittleEndian.PutUint32(format[4:8], 44100)   // Sample rate
+	binary.LittleEndian.PutUint32(format[8:12], 176400) // Byte rate
+
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+	body.Write(wavChunk("fmt ", format))
+	body.Write(wavChunk("data", make([]byte, 88200)))
+
+	var file bytes.Buffer
+	file.WriteString("RIFF")
+	binary.Write(&file, binary.LittleEndian, uint32(body.Len()))
+	file.Write(body.Bytes())
+
+	d, err := ReadDuration(bytes.NewReader(file.Bytes()))
+	assertDuration(t, d, err, 500*time.Millisecond)
+}
+
+func TestDuration_FLAC(t *testing.T) {
+	info := make([]byte, 34)
+	rate, samples := uint32(48000), uint64(48000*3)
+	info[10] = byte(rate >> 12)
+	info[11] = byte(rate >> 4)
+	info[12] = byte(rate<<4) | 0x02 // Channels and bit depth share this byte
+	info[13] = 0xF0 | byte(samples>>32)
+	binary.BigEndian.PutUint32(info[14:18], uint32(samples))
+
+	var file bytes.Buffer
+	file.WriteString("fLaC")
+	file.Write([]byte{0x80, 0, 0, 34}) // Last block, STREAMINFO
+	file.Write(info)
+
+	d, err := ReadDuration(bytes.NewReader(file.Bytes()))
+	assertDuration(t, d, err, 3*time.Second)
+}
+
+// mp3Frames returns n MPEG-1 Layer III frames at 128kbps/44.1kHz. Each
+// frame is 417 bytes and holds 1152 samples.
+func mp3Frames(n int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+func TestDuration_MP3(t *testing.T) {
+	expected := 100 * 1152 * time.Second / 44100
+
+	t.Run("frame scan", func(t *testing.T) {
+		// Leading ID3v2 tag (10 byte body) and trailing ID3v1 tag
+		file := append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 10}, make([]byte, 10)...)
+		file = append(file, mp3Frames(100)...)
+		file = append(file, append([]byte("TAG"), make([]byte, 125)...)...)
+
+		d, err := ReadDuration(bytes.NewReader(file))
+		assertDuration(t, d, err, expected)
+	})
+
+	t.Run("xing header", func(t *testing.T) {
+		// A Xing frame claiming 100 frames, followed by only a few
+		file := mp3Frames(3)
+		xing := file[4+32:]
+		copy(xing, "Xing")
+		binary.BigEndian.PutUint32(xing[4:8], 0x01)
+		binary.BigEndian.PutUint32(xing[8:12], 100)
+
+		d, err := ReadDuration(bytes.NewReader(file))
+		assertDuration(t, d, err, expected)
+	})
+}
+
+func TestDuration_Unknown(t *testing.T) {
+	_, err := ReadDuration(bytes.NewReader([]byte("not audio at all")))
+	if !errors.Is(err, ErrUnknownDuration) {
+		t.Errorf("Expected ErrUnknownDuration, got %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+// pkg/audiotag/flac.go - FLAC Vorbis comment writing
+
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FLAC keeps its tags in a VORBIS_COMMENT metadata block and its artwork in
+// PICTURE blocks, among the metadata blocks between the "fLaC" marker and
+// the audio frames. Reading is left to dhowden/tag. Writing rebuilds the
+// metadata blocks and copies the audio frames unchanged.
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+	flacPicture       = 6
+
+	// flacMaxBlockSize is the largest block the 24-bit length can describe
+	flacMaxBlockSize = 1<<24 - 1
+)
+
+// Vorbis comment field names, as Picard writes them. Names compare
+// without regard to case.
+const (
+	vorbisArtist        = "ARTIST"
+	vorbisTitle         = "TITLE"
+	vorbisAlbum         = "ALBUM"
+	vorbisLabel         = "LABEL"
+	vorbisCatalogNumber = "CATALOGNUMBER"
+	vorbisDate          = "DATE"
+	vorbisGenre         = "GENRE"
+	vorbisRecordingID   = "MUSICBRAINZ_TRACKID"
+)
+
+// vorbisKey is the key dhowden/tag files a Vorbis comment under in Raw()
+func vorbisKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// flacVendor is the vendor string given to a comment block tagger creates
+const flacVendor = "tagger"
+
+// flacBlock is one metadata block, without its header
+type flacBlock struct {
+	kind byte
+	data []byte
+}
+
+// isFLAC reports whether r begins with the FLAC stream marker.
+// The reader is returned to the start.
+func isFLAC(r io.ReadSeeker) bool {
+	marker := make([]byte, 4)
+	defer r.Seek(0, io.SeekStart)
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return false
+	}
+	if _, err := io.ReadFull(r, marker); err != nil {
+		return false
+	}
+	return string(marker) == "fLaC"
+}
+
+// readFLACBlocks reads the metadata blocks of a FLAC stream and returns
+// them with the offset of the first audio frame
+func readFLACBlocks(r io.ReadSeeker) ([]flacBlock, int64, error) {
+	offset, err := r.Seek(4, io.SeekStart)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var blocks []flacBlock
+	header := make([]byte, 4)
+	for last := false; !last; {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, 0, fmt.Errorf("%w: flac: truncated metadata block header", ErrUnreadable)
+		}
+		last = header[0]&0x80 != 0
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		block := flacBlock{kind: header[0] & 0x7F, data: make([]byte, size)}
+		if _, err := io.ReadFull(r, block.data); err != nil {
+			return nil, 0, fmt.Errorf("%w: flac: truncated metadata block", ErrUnreadable)
+		}
+		blocks = append(blocks, block)
+		offset += int64(4 + size)
+	}
+
+	if blocks[0].kind != flacStreamInfo {
+		return nil, 0, fmt.Errorf("%w: flac: no STREAMINFO block", ErrUnreadable)
+	}
+	return blocks, offset, nil
+}
+
+// vorbisComment is a parsed VORBIS_COMMENT block. Fields are kept as
+// "NAME=value" in their original order.
+type vorbisComment struct {
+	vendor string
+	fields []string
+}
+
+// parseVorbisComment decodes a VORBIS_COMMENT block, whose lengths are
+// little-endian unlike the rest of FLAC
+func parseVorbisComment(data []byte) (*vorbisComment, error) {
+	r := bytes.NewReader(data)
+	readString := func() (string, error) {
+		var length uint32
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return "", err
+		}
+		if int64(length) > int64(r.Len()) {
+			return "", io.ErrUnexpectedEOF
+		}
+		text := make([]byte, length)
+		_, err := io.ReadFull(r, text)
+		return string(text), err
+	}
+
+	vendor, err := readString()
+	if err != nil {
+		return nil, fmt.Errorf("flac: invalid vorbis comment: %w", err)
+	}
+	comment := &vorbisComment{vendor: vendor}
+
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("flac: invalid vorbis comment: %w", err)
+	}
+	for i := uint32(0); i < count; i++ {
+		field, err := readString()
+		if err != nil {
+			return nil, fmt.Errorf("flac: invalid vorbis comment: %w", err)
+		}
+		comment.fields = append(comment.fields, field)
+	}
+	return comment, nil
+}
+
+// set replaces every field called name with a single one holding value
+func (c *vorbisComment) set(name, value string) {
+	kept := c.fields[:0]
+	for _, field := range c.fields {
+		if key, _, _ := strings.Cut(field, "="); !strings.EqualFold(key, name) {
+			kept = append(kept, field)
+		}
+	}
+	c.fields = append(kept, name+"="+value)
+}
+
+// values returns the values of every field called name, in order
+func (c *vorbisComment) values(name string) []string {
+	var values []string
+	for _, field := range c.fields {
+		if key, value, _ := strings.Cut(field, "="); strings.EqualFold(key, name) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// encode renders the comment as a VORBIS_COMMENT block body
+func (c *vorbisComment) encode() []byte {
+	var buf bytes.Buffer
+	writeString := func(s string) {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(s)))
+		buf.WriteString(s)
+	}
+
+	writeString(c.vendor)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(c.fields)))
+	for _, field := range c.fields {
+		writeString(field)
+	}
+	return buf.Bytes()
+}
+
+// applyVorbisUpdate sets the fields update changes, under the names Picard
+// and dhowden/tag read
+func applyVorbisUpdate(c *vorbisComment, update *Update) {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{vorbisArtist, update.Artist},
+		{vorbisTitle, update.Title},
+		{vorbisAlbum, update.Album},
+		{vorbisLabel, update.Label},
+		{vorbisCatalogNumber, update.CatalogNumber},
+		{vorbisRecordingID, update.RecordingID},
+	} {
+		if field.value != "" {
+			c.set(field.name, field.value)
+		}
+	}
+	if update.Genre != "" {
+		applyVorbisGenre(c, update)
+	}
+	if update.Year > 0 {
+		c.set(vorbisDate, strconv.Itoa(update.Year))
+	}
+}
+
+// applyVorbisGenre writes update's genre as the only GENRE comment, or
+// with AppendGenre adds it after the existing ones unless one matches
+func applyVorbisGenre(c *vorbisComment, update *Update) {
+	if !update.AppendGenre {
+		c.set(vorbisGenre, update.Genre)
+		return
+	}
+	for _, genre := range c.values(vorbisGenre) {
+		if strings.EqualFold(strings.TrimSpace(genre), strings.TrimSpace(update.Genre)) {
+			return
+		}
+	}
+	c.fields = append(c.fields, vorbisGenre+"="+update.Genre)
+}
+
+// encodeFLACPicture renders pic as a front cover PICTURE block body. Image
+// dimensions are optional and left as zero.
+func encodeFLACPicture(pic *Picture) []byte {
+	var buf bytes.Buffer
+	field := func(v uint32) { binary.Write(&buf, binary.BigEndian, v) }
+
+	field(3) // Front cover
+	field(uint32(len(pic.MIMEType)))
+	buf.WriteString(pic.MIMEType)
+	field(uint32(len("Front cover")))
+	buf.WriteString("Front cover")
+	field(0) // Width
+	field(0) // Height
+	field(0) // Colour depth
+	field(0) // Indexed colours
+	field(uint32(len(pic.Data)))
+	buf.Write(pic.Data)
+	return buf.Bytes()
+}
+
+// isFrontCover reports whether a PICTURE block holds a front cover
+func isFrontCover(block flacBlock) bool {
+	return block.kind == flacPicture && len(block.data) >= 4 && binary.BigEndian.Uint32(block.data) == 3
+}
+
+// writeFLAC rewrites a FLAC file with an updated VORBIS_COMMENT block,
+// creating one after STREAMINFO if there is none. Other metadata blocks
+// are kept in order, except a front cover the update replaces.
+func writeFLAC(path string, update *Update) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	blocks, audioStart, err := readFLACBlocks(src)
+	if err != nil {
+		return err
+	}
+
+	replaceCover := update.Artwork != nil && len(update.Artwork.Data) > 0
+	comment := &vorbisComment{vendor: flacVendor}
+	commentAt := 1 // After STREAMINFO unless the file has a comment block
+	var kept []flacBlock
+	for _, block := range blocks {
+		switch {
+		case block.kind == flacVorbisComment:
+			existing, err := parseVorbisComment(block.data)
+			if err != nil {
+				return err
+			}
+			comment, commentAt = existing, len(kept)
+		case replaceCover && isFrontCover(block):
+			// Replaced by the new cover below
+		default:
+			kept = append(kept, block)
+		}
+	}
+
+	applyVorbisUpdate(comment, update)
+	blocks = append([]flacBlock{}, kept[:commentAt]...)
+	blocks = append(blocks, flacBlock{kind: flacVorbisComment, data: comment.encode()})
+	blocks = append(blocks, kept[commentAt:]...)
+	if replaceCover {
+		blocks = append(blocks, flacBlock{kind: flacPicture, data: encodeFLACPicture(update.Artwork)})
+	}
+
+	return replaceFile(path, func(tmp *os.File) error {
+		if _, err := tmp.WriteString("fLaC"); err != nil {
+			return err
+		}
+		for i, block := range blocks {
+			if len(block.data) > flacMaxBlockSize {
+				return fmt.Errorf("flac: metadata block of %d bytes is too large", len(block.data))
+			}
+			header := []byte{block.kind, byte(len(block.data) >> 16), byte(len(block.data) >> 8), byte(len(block.data))}
+			if i == len(blocks)-1 {
+				header[0] |= 0x80 // Last metadata block
+			}
+			if _, err := tmp.Write(header); err != nil {
+				return err
+			}
+			if _, err := tmp.Write(block.data); err != nil {
+				return err
+			}
+		}
+
+		if _, err := src.Seek(audioStart, io.SeekStart); err != nil {
+			return err
+		}
+		_, err := io.Copy(tmp, src)
+		return err
+	})
+}
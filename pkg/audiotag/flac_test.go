@@ -0,0 +1,140 @@
+// pkg/audiotag/flac_test.go
+
+package audiotag
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// flacAudio stands in for the audio frames after the metadata blocks
+var flacAudio = []byte{0xFF, 0xF8, 0x69, 0x08, 0x00, 0x00, 0x01, 0x02, 0x03}
+
+// writeTestFLAC writes a FLAC file with a STREAMINFO block, the given
+// blocks and flacAudio, returning its path
+func writeTestFLAC(t *testing.T, blocks ...flacBlock) string {
+	t.Helper()
+	all := append([]flacBlock{{kind: flacStreamInfo, data: make([]byte, 34)}}, blocks...)
+
+	var file bytes.Buffer
+	file.WriteString("fLaC")
+	for i, block := range all {
+		kind := block.kind
+		if i == len(all)-1 {
+			kind |= 0x80
+		}
+		file.Write([]byte{kind, byte(len(block.data) >> 16), byte(len(block.data) >> 8), byte(len(block.data))})
+		file.Write(block.data)
+	}
+	file.Write(flacAudio)
+
+	path := filepath.Join(t.TempDir(), "test.flac")
+	if err := os.WriteFile(path, file.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWriteFile_FLACKeepsOtherFieldsAndBlocks(t *testing.T) {
+	existing := &vorbisComment{vendor: "reference libFLAC 1.4.3", fields: []string{
+		"TITLE=Inner City Life",
+		"COMMENT=Peak time, long intro",
+		"catalognumber=OLD001",
+		"INITIALKEY=8A",
+	}}
+	padding := flacBlock{kind: 1, data: make([]byte, 64)}
+	path := writeTestFLAC(t, flacBlock{kind: flacVorbisComment, data: existing.encode()}, padding)
+
+	update := &Update{Label: "FFRR", CatalogNumber: "FX 242", Artwork: &Picture{MIMEType: "image/jpeg", Data: []byte{0xFF, 0xD8, 0xFF}}}
+	if err := WriteFile(path, update); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if got := UserText(metadata, CatalogNumberDescription); got != "FX 242" {
+		t.Errorf("Expected the catalog number replaced, got %q", got)
+	}
+	if got := Label(metadata); got != "FFRR" {
+		t.Errorf("Expected LABEL 'FFRR', got %q", got)
+	}
+	if metadata.Title() != "Inner City Life" || metadata.Comment() != "Peak time, long intro" || UserText(metadata, "INITIALKEY") != "8A" {
+		t.Errorf("Expected other comments kept, got %q / %q / %q", metadata.Title(), metadata.Comment(), UserText(metadata, "INITIALKEY"))
+	}
+	if pic := metadata.Picture(); pic == nil || !bytes.Equal(pic.Data, update.Artwork.Data) {
+		t.Errorf("Expected the front cover written, got %+v", pic)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	blocks, audioStart, err := readFLACBlocks(f)
+	if err != nil {
+		t.Fatalf("readFLACBlocks returned error: %v", err)
+	}
+	kinds := []byte{}
+	for _, block := range blocks {
+		kinds = append(kinds, block.kind)
+	}
+	if !bytes.Equal(kinds, []byte{flacStreamInfo, flacVorbisComment, 1, flacPicture}) {
+		t.Errorf("Expected STREAMINFO, comment, padding and picture blocks, got %v", kinds)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data[audioStart:], flacAudio) {
+		t.Errorf("Expected the audio frames unchanged, got %x", data[audioStart:])
+	}
+}
+
+func TestVorbisComment_Set(t *testing.T) {
+	comment := &vorbisComment{fields: []string{"LABEL=Old", "GENRE=Jungle", "label=Older"}}
+	comment.set(vorbisLabel, "Metalheadz")
+
+	expected := []string{"GENRE=Jungle", "LABEL=Metalheadz"}
+	if len(comment.fields) != len(expected) || comment.fields[0] != expected[0] || comment.fields[1] != expected[1] {
+		t.Errorf("Expected %q, got %q", expected, comment.fields)
+	}
+
+	parsed, err := parseVorbisComment(comment.encode())
+	if err != nil || len(parsed.fields) != 2 || parsed.fields[1] != "LABEL=Metalheadz" {
+		t.Errorf("Expected the comment to survive encoding, got %+v, %v", parsed, err)
+	}
+}
+
+func TestWriteFile_FLACAppendGenre(t *testing.T) {
+	existing := &vorbisComment{vendor: "reference libFLAC 1.4.3", fields: []string{"GENRE=Drum and Bass", "TITLE=Inner City Life"}}
+	path := writeTestFLAC(t, flacBlock{kind: flacVorbisComment, data: existing.encode()})
+
+	for _, genre := range []string{"Jungle", "drum and bass"} {
+		if err := WriteFile(path, &Update{Genre: genre, AppendGenre: true}); err != nil {
+			t.Fatalf("WriteFile returned error: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	blocks, _, err := readFLACBlocks(f)
+	if err != nil {
+		t.Fatalf("readFLACBlocks returned error: %v", err)
+	}
+	comment, err := parseVorbisComment(blocks[1].data)
+	if err != nil {
+		t.Fatalf("parseVorbisComment returned error: %v", err)
+	}
+	genres := comment.values(vorbisGenre)
+	if len(genres) != 2 || genres[0] != "Drum and Bass" || genres[1] != "Jungle" {
+		t.Errorf("Expected GENRE comments [Drum and Bass Jungle], got %q", genres)
+	}
+}
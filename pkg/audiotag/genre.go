@@ -0,0 +1,51 @@
+// pkg/audiotag/genre.go - Multi-value genres
+
+package audiotag
+
+import "strings"
+
+// id3v23GenreSeparator joins several genres in one ID3v2.3 TCON frame,
+// which, unlike v2.4, has no null-separated values. It is what Picard
+// writes and most players split on.
+const id3v23GenreSeparator = "/"
+
+// splitGenres breaks a genre tag into its values. ID3v2.4 TCON values are
+// null-separated; v2.3 frames and WAV INFO fields use "/" as well.
+func splitGenres(text string, slash bool) []string {
+	separators := "\x00"
+	if slash {
+		separators += id3v23GenreSeparator
+	}
+	var genres []string
+	for _, value := range strings.FieldsFunc(text, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+		if value = strings.TrimSpace(value); value != "" {
+			genres = append(genres, value)
+		}
+	}
+	return genres
+}
+
+// mergeGenres appends genre to existing unless it is already there,
+// ignoring case. Order is kept and duplicates in existing are dropped.
+func mergeGenres(existing []string, genre string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, value := range append(existing, genre) {
+		key := strings.ToLower(strings.TrimSpace(value))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, strings.TrimSpace(value))
+	}
+	return merged
+}
+
+// appendID3Genre returns the TCON text holding the tag's current genres
+// followed by genre: null-separated values in ID3v2.4, "/"-joined in v2.3
+func appendID3Genre(current string, version byte, genre string) string {
+	if version >= 4 {
+		return strings.Join(mergeGenres(splitGenres(current, false), genre), "\x00")
+	}
+	return strings.Join(mergeGenres(splitGenres(current, true), genre), id3v23GenreSeparator)
+}
@@ -0,0 +1,120 @@
+// pkg/audiotag/mp4.go - MP4/M4A reading
+
+package audiotag
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+)
+
+// Freeform ("----") atom names under com.apple.iTunes, as Picard and
+// iTunes write them. Label and catalog number use LABEL and CATALOGNUMBER,
+// the same names as the Vorbis comments.
+const (
+	mp4RecordingID = "MusicBrainz Track Id"
+	mp4ISRC        = "ISRC"
+)
+
+// mp4Grouping is the iTunes grouping atom
+const mp4Grouping = "\xa9grp"
+
+// isMP4 reports whether r is an MP4 container (.m4a, .mp4), which opens
+// with an ftyp atom. The reader is returned to the start.
+func isMP4(r io.ReadSeeker) bool {
+	header := make([]byte, 8)
+	_, err := r.Seek(0, io.SeekStart)
+	if err == nil {
+		_, err = io.ReadFull(r, header)
+	}
+	r.Seek(0, io.SeekStart)
+	return err == nil && string(header[4:8]) == "ftyp"
+}
+
+// mp4Freeform returns the text of the freeform atom called name, ignoring
+// case, or "" if there is none. dhowden/tag keys these atoms by name but
+// leaves the data atom's locale bytes in front of the text.
+func mp4Freeform(m tag.Metadata, name string) string {
+	for key, value := range m.Raw() {
+		if text, ok := value.(string); ok && strings.EqualFold(key, name) {
+			return strings.TrimSpace(strings.ReplaceAll(text, "\x00", ""))
+		}
+	}
+	return ""
+}
+
+// mp4Duration reads the timescale and duration of the movie header (mvhd)
+// inside the moov atom
+func mp4Duration(r io.ReadSeeker) (time.Duration, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	moov, err := findMP4Atom(r, "moov", -1)
+	if err != nil {
+		return 0, err
+	}
+	size, err := findMP4Atom(r, "mvhd", moov)
+	if err != nil {
+		return 0, err
+	}
+
+	if size < 20 {
+		return 0, ErrUnknownDuration
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, ErrUnknownDuration
+	}
+
+	// Version 1 headers use 64-bit times and duration
+	var timescale uint32
+	var duration uint64
+	if body[0] == 1 {
+		if size < 32 {
+			return 0, ErrUnknownDuration
+		}
+		timescale = binary.BigEndian.Uint32(body[20:24])
+		duration = binary.BigEndian.Uint64(body[24:32])
+	} else {
+		timescale = binary.BigEndian.Uint32(body[12:16])
+		duration = uint64(binary.BigEndian.Uint32(body[16:20]))
+	}
+	return samplesToDuration(duration, float64(timescale))
+}
+
+// findMP4Atom skips sibling atoms until one called name, leaving r at the
+// start of its body and returning the body's size. limit bounds the search
+// to the enclosing atom's body, or is -1 to read to the end.
+func findMP4Atom(r io.ReadSeeker, name string, limit int64) (int64, error) {
+	header := make([]byte, 8)
+	for limit < 0 || limit >= 8 {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, ErrUnknownDuration
+		}
+		size, headerSize := int64(binary.BigEndian.Uint32(header[0:4])), int64(8)
+		atom := string(header[4:8])
+		if size == 1 {
+			// A 64-bit size follows, as on a large mdat
+			if _, err := io.ReadFull(r, header); err != nil {
+				return 0, ErrUnknownDuration
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(header)), 16
+		}
+		if size < headerSize {
+			return 0, ErrUnknownDuration
+		}
+		if atom == name {
+			return size - headerSize, nil
+		}
+		if _, err := r.Seek(size-headerSize, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+		if limit >= 0 {
+			limit -= size
+		}
+	}
+	return 0, ErrUnknownDuration
+}
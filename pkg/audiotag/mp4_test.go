@@ -0,0 +1,103 @@
+// pkg/audiotag/mp4_test.go
+
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mp4Atom frames body as an atom called name
+func mp4Atom(name string, body ...[]byte) []byte {
+	data := bytes.Join(body, nil)
+	atom := binary.BigEndian.AppendUint32(nil, uint32(8+len(data)))
+	return append(append(atom, name...), data...)
+}
+
+// mp4Text is an ilst item holding text
+func mp4Text(name, text string) []byte {
+	return mp4Atom(name, mp4Atom("data", []byte{0, 0, 0, 1, 0, 0, 0, 0}, []byte(text)))
+}
+
+// mp4FreeformAtom is an iTunes "----" item holding text
+func mp4FreeformAtom(name, text string) []byte {
+	return mp4Atom("----",
+		mp4Atom("mean", []byte{0, 0, 0, 0}, []byte("com.apple.iTunes")),
+		mp4Atom("name", []byte{0, 0, 0, 0}, []byte(name)),
+		mp4Atom("data", []byte{0, 0, 0, 1, 0, 0, 0, 0}, []byte(text)))
+}
+
+// writeTestM4A writes an M4A file whose movie header gives a duration of
+// seconds and whose ilst holds items, returning its path
+func writeTestM4A(t *testing.T, seconds uint32, items ...[]byte) string {
+	t.Helper()
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 44100)
+	binary.BigEndian.PutUint32(mvhd[16:], seconds*44100)
+
+	file := bytes.Join([][]byte{
+		mp4Atom("ftyp", []byte("M4A "), make([]byte, 4), []byte("M4A mp42isom")),
+		mp4Atom("mdat", make([]byte, 32)),
+		mp4Atom("moov",
+			mp4Atom("mvhd", mvhd),
+			mp4Atom("udta", mp4Atom("meta", make([]byte, 4),
+				mp4Atom("hdlr", make([]byte, 25)),
+				mp4Atom("ilst", items...)))),
+	}, nil)
+
+	path := filepath.Join(t.TempDir(), "test.m4a")
+	if err := os.WriteFile(path, file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFile_M4AFreeformAtoms(t *testing.T) {
+	path := writeTestM4A(t, 351,
+		mp4Text("\xa9nam", "Inner City Life"),
+		mp4Text("\xa9ART", "Goldie"),
+		mp4Text("\xa9grp", "Peak Time"),
+		mp4FreeformAtom("LABEL", "FFRR"),
+		mp4FreeformAtom("CATALOGNUMBER", "FX 242"),
+		mp4FreeformAtom("MusicBrainz Track Id", "8f3471b5-7e6a-48da-86a9-c1c07a0f47ae"),
+		mp4FreeformAtom("ISRC", "gb-aap-95-00123"),
+	)
+
+	metadata, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if metadata.Artist() != "Goldie" || metadata.Title() != "Inner City Life" {
+		t.Errorf("Expected artist and title, got %q / %q", metadata.Artist(), metadata.Title())
+	}
+	if got := Label(metadata); got != "FFRR" {
+		t.Errorf("Expected label 'FFRR' from the freeform atom, got %q", got)
+	}
+	if got := UserText(metadata, CatalogNumberDescription); got != "FX 242" {
+		t.Errorf("Expected catalog number 'FX 242', got %q", got)
+	}
+	if got := RecordingID(metadata); got != "8f3471b5-7e6a-48da-86a9-c1c07a0f47ae" {
+		t.Errorf("Expected the recording ID, got %q", got)
+	}
+	if got := ISRC(metadata); got != "GBAAP9500123" {
+		t.Errorf("Expected ISRC 'GBAAP9500123', got %q", got)
+	}
+	if got := Grouping(metadata); got != "Peak Time" {
+		t.Errorf("Expected grouping 'Peak Time', got %q", got)
+	}
+
+	d, err := Duration(path)
+	assertDuration(t, d, err, 351*time.Second)
+
+	if CanWrite(path) {
+		t.Error("Expected MP4 files not to be writable yet")
+	}
+	if err := WriteFile(path, &Update{Label: "FFRR"}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
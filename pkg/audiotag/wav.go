@@ -0,0 +1,322 @@
+// pkg/audiotag/wav.go - RIFF WAVE handling
+
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/dhowden/tag"
+)
+
+// WAV tags live in one of two places: an ID3v2 tag in an "id3 " chunk, or
+// RIFF INFO fields in a LIST chunk. Reading prefers ID3 and falls back to
+// INFO. Writing updates the ID3 chunk (seeding it from INFO when the file
+// has none) and mirrors album, genre, year and label into INFO for
+// software that only reads that.
+const (
+	// WAV is the file type reported for RIFF WAVE files
+	WAV tag.FileType = "WAV"
+
+	// RIFFInfo is the format reported for tags read from a LIST/INFO chunk
+	RIFFInfo tag.Format = "RIFF INFO"
+)
+
+// INFO field IDs. IPUB (label) is not part of the original RIFF spec but is
+// the conventional place for a publisher.
+const (
+	infoTitle   = "INAM"
+	infoArtist  = "IART"
+	infoAlbum   = "IPRD"
+	infoGenre   = "IGNR"
+	infoDate    = "ICRD"
+	infoComment = "ICMT"
+	infoLabel   = "IPUB"
+)
+
+// isWAV reports whether r begins with a RIFF WAVE header.
+// The reader is returned to the start.
+func isWAV(r io.ReadSeeker) bool {
+	header := make([]byte, 12)
+	defer r.Seek(0, io.SeekStart)
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return false
+	}
+	if _, err := io.ReadFull(r, header); err != nil {
+		return false
+	}
+
+	return string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE"
+}
+
+// readWAVChunks walks the chunk list of a RIFF WAVE file
+func readWAVChunks(r io.ReadSeeker) ([]iffChunk, error) {
+	chunks, err := readChunks(r, binary.LittleEndian)
+	if err != nil {
+		return nil, fmt.Errorf("%w: wav: %w", ErrUnreadable, err)
+	}
+	return chunks, nil
+}
+
+// findWAVID3Chunk returns the ID3 chunk, which writers name either
+// "id3 " or "ID3 "
+func findWAVID3Chunk(chunks []iffChunk) *iffChunk {
+	return findChunk(chunks, "id3 ", "ID3 ")
+}
+
+// findInfoChunk returns the LIST chunk of type INFO, if any
+func findInfoChunk(r io.ReaderAt, chunks []iffChunk) *iffChunk {
+	listType := make([]byte, 4)
+	for i := range chunks {
+		if chunks[i].id != "LIST" || chunks[i].size < 4 {
+			continue
+		}
+		if _, err := r.ReadAt(listType, chunks[i].offset); err == nil && string(listType) == "INFO" {
+			return &chunks[i]
+		}
+	}
+	return nil
+}
+
+// readInfoFields decodes the NUL-terminated text fields of a LIST/INFO chunk
+func readInfoFields(r io.ReaderAt, chunk *iffChunk) (map[string]string, error) {
+	data := make([]byte, chunk.size)
+	if _, err := r.ReadAt(data, chunk.offset); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	for pos := 4; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		start := pos + 8
+		if start+size > len(data) {
+			break
+		}
+
+		value := strings.TrimRight(string(data[start:start+size]), "\x00")
+		if value = strings.TrimSpace(value); value != "" {
+			fields[id] = value
+		}
+		pos = start + size + size%2
+	}
+
+	return fields, nil
+}
+
+// encodeInfoChunk builds the body of a LIST/INFO chunk, with fields in a
+// stable order
+func encodeInfoChunk(fields map[string]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("INFO")
+
+	ids := make([]string, 0, len(fields))
+	for id := range fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		value := append([]byte(fields[id]), 0)
+		buf.WriteString(id)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(value)))
+		buf.Write(value)
+		if len(value)%2 == 1 {
+			buf.WriteByte(0)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// readWAV reads tags from a WAV file, preferring its ID3 chunk
+func readWAV(r io.ReadSeeker) (tag.Metadata, error) {
+	chunks, err := readWAVChunks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if id3 := findWAVID3Chunk(chunks); id3 != nil {
+		data := make([]byte, id3.size)
+		if _, err := r.Seek(id3.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return readID3v2(data)
+	}
+
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return nil, tag.ErrNoTagsFound
+	}
+	info := findInfoChunk(ra, chunks)
+	if info == nil {
+		return nil, tag.ErrNoTagsFound
+	}
+
+	fields, err := readInfoFields(ra, info)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, tag.ErrNoTagsFound
+	}
+	return infoMetadata(fields), nil
+}
+
+// infoMetadata exposes RIFF INFO fields through tag.Metadata
+type infoMetadata map[string]string
+
+func (m infoMetadata) Format() tag.Format     { return RIFFInfo }
+func (m infoMetadata) FileType() tag.FileType { return WAV }
+func (m infoMetadata) Title() string          { return m[infoTitle] }
+func (m infoMetadata) Album() string          { return m[infoAlbum] }
+func (m infoMetadata) Artist() string         { return m[infoArtist] }
+func (m infoMetadata) AlbumArtist() string    { return "" }
+func (m infoMetadata) Composer() string       { return "" }
+func (m infoMetadata) Genre() string          { return m[infoGenre] }
+func (m infoMetadata) Track() (int, int)      { return 0, 0 }
+func (m infoMetadata) Disc() (int, int)       { return 0, 0 }
+func (m infoMetadata) Picture() *tag.Picture  { return nil }
+func (m infoMetadata) Lyrics() string         { return "" }
+func (m infoMetadata) Comment() string        { return m[infoComment] }
+
+// Year parses the leading year of ICRD, which is usually "YYYY" or
+// "YYYY-MM-DD"
+func (m infoMetadata) Year() int {
+	date := m[infoDate]
+	if len(date) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(date[:4])
+	return year
+}
+
+// Raw returns the INFO fields keyed by their four-character IDs
+func (m infoMetadata) Raw() map[string]interface{} {
+	raw := make(map[string]interface{}, len(m))
+	for id, value := range m {
+		raw[id] = value
+	}
+	return raw
+}
+
+// writeWAV rewrites a WAV file with updated ID3 and LIST/INFO chunks. All
+// other chunks are copied unchanged; the tag chunks are placed last.
+func writeWAV(path string, update *Update) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return err
+	}
+
+	chunks, err := readWAVChunks(src)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]string)
+	infoChunk := findInfoChunk(src, chunks)
+	if infoChunk != nil {
+		if fields, err = readInfoFields(src, infoChunk); err != nil {
+			return fmt.Errorf("failed to read INFO chunk: %w", err)
+		}
+	}
+
+	id3ID := "id3 "
+	var t *id3v2.Tag
+	if id3 := findWAVID3Chunk(chunks); id3 != nil {
+		id3ID = id3.id
+		t, err = id3v2.ParseReader(io.NewSectionReader(src, id3.offset, id3.size), id3v2.Options{Parse: true})
+		if err != nil {
+			return fmt.Errorf("failed to parse existing ID3 chunk: %w", err)
+		}
+	} else {
+		t = newTagFromInfo(fields)
+	}
+
+	applyUpdate(t, update)
+	applyInfoUpdate(fields, update)
+
+	var tagData bytes.Buffer
+	if _, err := t.WriteTo(&tagData); err != nil {
+		return fmt.Errorf("failed to encode ID3 tag: %w", err)
+	}
+
+	appended := []newChunk{{id: "LIST", data: encodeInfoChunk(fields)}, {id: id3ID, data: tagData.Bytes()}}
+	skipTags := func(chunk iffChunk) bool {
+		return chunk.id == "id3 " || chunk.id == "ID3 " || (infoChunk != nil && chunk.offset == infoChunk.offset)
+	}
+
+	return replaceFile(path, func(tmp *os.File) error {
+		return writeChunks(tmp, src, header, chunks, skipTags, appended, binary.LittleEndian)
+	})
+}
+
+// newTagFromInfo seeds a new ID3 tag with the file's INFO fields so that
+// readers, which prefer ID3, don't lose them
+func newTagFromInfo(fields map[string]string) *id3v2.Tag {
+	t := newTag()
+	if v := fields[infoTitle]; v != "" {
+		t.SetTitle(v)
+	}
+	if v := fields[infoArtist]; v != "" {
+		t.SetArtist(v)
+	}
+	if v := fields[infoAlbum]; v != "" {
+		t.SetAlbum(v)
+	}
+	if v := fields[infoGenre]; v != "" {
+		t.SetGenre(v)
+	}
+	if v := fields[infoDate]; len(v) >= 4 {
+		t.SetYear(v[:4])
+	}
+	if v := fields[infoLabel]; v != "" {
+		t.AddTextFrame(t.CommonID("Publisher"), t.DefaultEncoding(), v)
+	}
+	if v := fields[infoComment]; v != "" {
+		t.AddCommentFrame(id3v2.CommentFrame{Encoding: t.DefaultEncoding(), Language: "eng", Text: v})
+	}
+	return t
+}
+
+// applyInfoUpdate mirrors the update into INFO fields
+func applyInfoUpdate(fields map[string]string, update *Update) {
+	if update.Artist != "" {
+		fields[infoArtist] = update.Artist
+	}
+	if update.Title != "" {
+		fields[infoTitle] = update.Title
+	}
+	if update.Album != "" {
+		fields[infoAlbum] = update.Album
+	}
+	if update.Genre != "" && update.AppendGenre {
+		// INFO has one value per field, so genres are joined as in ID3v2.3
+		fields[infoGenre] = appendID3Genre(fields[infoGenre], 3, update.Genre)
+	} else if update.Genre != "" {
+		fields[infoGenre] = update.Genre
+	}
+	if update.Year > 0 {
+		fields[infoDate] = strconv.Itoa(update.Year)
+	}
+	if update.Label != "" {
+		fields[infoLabel] = update.Label
+	}
+}
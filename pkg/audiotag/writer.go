@@ -0,0 +1,226 @@
+// pkg/audiotag/writer.go - Tag writing
+
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+// Picture is an image to embed as front cover art
+type Picture struct {
+	MIMEType string
+	Data     []byte
+}
+
+// Update describes the changes to apply to a file's tags.
+// Nil or empty fields leave the existing value untouched.
+type Update struct {
+	Artist        string   // TPE1
+	Title         string   // TIT2
+	Album         string   // TALB
+	Label         string   // TPUB
+	CatalogNumber string   // TXXX:CATALOGNUMBER
+	Year          int      // TYER (v2.3) / TDRC (v2.4)
+	Genre         string   // TCON
+	Artwork       *Picture // APIC, front cover
+	RecordingID   string   // TXXX:MusicBrainz Recording Id and UFID
+
+	// AppendGenre adds Genre to the file's existing genres, unless one
+	// already matches, instead of replacing them: another TCON value in
+	// ID3v2.4 ("/"-joined in v2.3), another GENRE comment in FLAC
+	AppendGenre bool
+}
+
+// IsEmpty reports whether the update would change nothing
+func (u *Update) IsEmpty() bool {
+	return u.Artist == "" && u.Title == "" && u.Album == "" && u.Label == "" && u.CatalogNumber == "" && u.Year == 0 && u.Genre == "" && u.Artwork == nil && u.RecordingID == ""
+}
+
+// WriteFile applies update to the tags of the file at path: the ID3 tag of
+// AIFF, WAV and MP3 files, the Vorbis comment of FLAC files. Frames and
+// fields not touched by the update are preserved.
+func WriteFile(path string, update *Update) error {
+	write, err := writerFor(path)
+	if err != nil {
+		return err
+	}
+	return write(path, update)
+}
+
+// CanWrite reports whether WriteFile supports the file at path. MP4 files
+// (.m4a, .mp4) can be read but not yet written.
+func CanWrite(path string) bool {
+	_, err := writerFor(path)
+	return !errors.Is(err, ErrUnsupportedFormat)
+}
+
+// writerFor picks the writer for the file at path by its contents, or for
+// MP3 by its extension
+func writerFor(path string) (func(string, *Update) error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	aiff, wav, flac := isAIFF(f), isWAV(f), isFLAC(f)
+	f.Close()
+
+	switch {
+	case aiff:
+		return writeAIFF, nil
+	case wav:
+		return writeWAV, nil
+	case flac:
+		return writeFLAC, nil
+	case strings.EqualFold(filepath.Ext(path), ".mp3"):
+		return writeMP3, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, filepath.Ext(path))
+	}
+}
+
+// applyUpdate modifies the targeted frames of an ID3v2 tag in place
+func applyUpdate(t *id3v2.Tag, update *Update) {
+	enc := t.DefaultEncoding()
+
+	if update.Artist != "" {
+		t.SetArtist(update.Artist)
+	}
+	if update.Title != "" {
+		t.SetTitle(update.Title)
+	}
+	if update.Album != "" {
+		t.SetAlbum(update.Album)
+	}
+	if update.Label != "" {
+		t.AddTextFrame(t.CommonID("Publisher"), enc, update.Label)
+	}
+	if update.CatalogNumber != "" {
+		t.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    enc,
+			Description: CatalogNumberDescription,
+			Value:       update.CatalogNumber,
+		})
+	}
+	if update.Year > 0 {
+		t.SetYear(strconv.Itoa(update.Year))
+	}
+	if update.Genre != "" && update.AppendGenre {
+		t.SetGenre(appendID3Genre(t.GetTextFrame(t.CommonID("Content type")).Text, t.Version(), update.Genre))
+	} else if update.Genre != "" {
+		t.SetGenre(update.Genre)
+	}
+	if update.Artwork != nil && len(update.Artwork.Data) > 0 {
+		setFrontCover(t, update.Artwork)
+	}
+	if update.RecordingID != "" {
+		t.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    enc,
+			Description: RecordingIDDescription,
+			Value:       update.RecordingID,
+		})
+		t.AddUFIDFrame(id3v2.UFIDFrame{
+			OwnerIdentifier: musicBrainzUFIDOwner,
+			Identifier:      []byte(update.RecordingID),
+		})
+	}
+}
+
+// setFrontCover replaces any existing front cover, keeping other pictures
+func setFrontCover(t *id3v2.Tag, pic *Picture) {
+	pictureID := t.CommonID("Attached picture")
+
+	var keep []id3v2.PictureFrame
+	for _, frame := range t.GetFrames(pictureID) {
+		if pf, ok := frame.(id3v2.PictureFrame); ok && pf.PictureType != id3v2.PTFrontCover {
+			keep = append(keep, pf)
+		}
+	}
+
+	t.DeleteFrames(pictureID)
+	for _, pf := range keep {
+		t.AddAttachedPicture(pf)
+	}
+
+	t.AddAttachedPicture(id3v2.PictureFrame{
+		Encoding:    t.DefaultEncoding(),
+		MimeType:    pic.MIMEType,
+		PictureType: id3v2.PTFrontCover,
+		Description: "Front cover",
+		Picture:     pic.Data,
+	})
+}
+
+// newTag returns an empty tag using ID3v2.3, which DJ software reads
+// more reliably than v2.4
+func newTag() *id3v2.Tag {
+	t := id3v2.NewEmptyTag()
+	t.SetVersion(3)
+	return t
+}
+
+// writeMP3 rewrites the ID3v2 tag at the start of an MP3 file
+func writeMP3(path string, update *Update) error {
+	t, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("failed to parse ID3 tag: %w", err)
+	}
+	defer t.Close()
+
+	if !t.HasFrames() {
+		t.SetVersion(3)
+	}
+
+	applyUpdate(t, update)
+	return t.Save()
+}
+
+// writeAIFF rewrites an AIFF file with an updated ID3 chunk. All other
+// chunks are copied unchanged; the new ID3 chunk is placed last, named
+// "ID3 " whatever the spelling of the one it replaces.
+func writeAIFF(path string, update *Update) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return err
+	}
+
+	chunks, err := readAIFFChunks(src)
+	if err != nil {
+		return err
+	}
+
+	t := newTag()
+	if id3 := findID3Chunk(chunks); id3 != nil {
+		existing, err := id3v2.ParseReader(io.NewSectionReader(src, id3.offset, id3.size), id3v2.Options{Parse: true})
+		if err != nil {
+			return fmt.Errorf("failed to parse existing ID3 chunk: %w", err)
+		}
+		t = existing
+	}
+
+	applyUpdate(t, update)
+
+	var tagData bytes.Buffer
+	if _, err := t.WriteTo(&tagData); err != nil {
+		return fmt.Errorf("failed to encode ID3 tag: %w", err)
+	}
+
+	return replaceFile(path, func(tmp *os.File) error {
+		return writeChunks(tmp, src, header, chunks, isID3Chunk, []newChunk{{id: "ID3 ", data: tagData.Bytes()}}, binary.BigEndian)
+	})
+}
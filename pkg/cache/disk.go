@@ -0,0 +1,299 @@
+// pkg/cache/disk.go - On-disk cache of lookup results
+
+// Package cache persists enrichment lookup results between runs so that
+// repeated runs (and offline runs) don't need to hit provider APIs.
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/enricher"
+	"github.com/cerberussg/tagger/pkg/normalize"
+)
+
+// Entry is the JSON document stored for each key
+type Entry struct {
+	Key       string                  `json:"key"`
+	Metadata  *enricher.TrackMetadata `json:"metadata,omitempty"`
+	NotFound  bool                    `json:"not_found,omitempty"`
+	CreatedAt time.Time               `json:"created_at"`
+	ExpiresAt time.Time               `json:"expires_at"`
+}
+
+// Expired reports whether the entry had expired at now
+func (e Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// DiskCache stores one JSON file per key inside a directory. Hits and
+// misses are counted in memory until SaveStats adds them to the totals
+// kept alongside the entries.
+type DiskCache struct {
+	dir    string
+	hits   int64
+	misses int64
+}
+
+// NewDiskCache creates a cache rooted at dir, creating it if necessary
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if dir == "" {
+		return nil, errors.New("cache directory not configured")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// DiskCache can back an enricher directly
+var _ enricher.Cache = (*DiskCache)(nil)
+
+// Key builds a cache key from an artist and title, reduced by
+// normalize.MatchKey so that spellings the matcher treats as the same
+// ("L.T.J. Bukem", "ltj bukem") share an entry. Any non-empty hints (album,
+// label, year, ...) are included, since they can change the result; they
+// are only lowercased, as some are IDs and ranges.
+func Key(artist, title string, hints ...string) string {
+	key := normalize.MatchKey(artist) + "|" + normalize.MatchKey(title)
+	for _, hint := range hints {
+		key += "|" + strings.Join(strings.Fields(strings.ToLower(hint)), " ")
+	}
+	return strings.TrimRight(key, "|")
+}
+
+// Get returns the cached metadata for key. The boolean reports a cache hit;
+// a hit with nil metadata is a cached "not found" result. Hits and misses
+// are counted towards the hit rate.
+func (c *DiskCache) Get(key string) (*enricher.TrackMetadata, bool) {
+	metadata, hit := c.read(key)
+	if hit {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+	}
+	return metadata, hit
+}
+
+// Contains reports whether key has a live entry, without counting towards
+// the hit rate
+func (c *DiskCache) Contains(key string) bool {
+	_, hit := c.read(key)
+	return hit
+}
+
+// read returns the live entry for key
+func (c *DiskCache) read(key string) (*enricher.TrackMetadata, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		return nil, false
+	}
+
+	if e.Expired(time.Now()) {
+		return nil, false
+	}
+
+	if e.NotFound {
+		return nil, true
+	}
+	return e.Metadata, true
+}
+
+// Set stores metadata for key, expiring after ttl (zero means never)
+func (c *DiskCache) Set(key string, metadata *enricher.TrackMetadata, ttl time.Duration) error {
+	return c.write(Entry{Key: key, Metadata: metadata}, ttl)
+}
+
+// SetNegative records that key was looked up and nothing was found
+func (c *DiskCache) SetNegative(key string, ttl time.Duration) error {
+	return c.write(Entry{Key: key, NotFound: true}, ttl)
+}
+
+// write atomically stores an entry
+func (c *DiskCache) write(e Entry, ttl time.Duration) error {
+	e.CreatedAt = time.Now()
+	if ttl > 0 {
+		e.ExpiresAt = e.CreatedAt.Add(ttl)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(e.Key))
+}
+
+// path returns the file used for key
+func (c *DiskCache) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// statsFile holds the hit and miss totals across runs
+const statsFile = "stats.json"
+
+// hitCounts is the JSON document stored in statsFile
+type hitCounts struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// Stats describes the cache contents and its hit rate across runs
+type Stats struct {
+	Entries  int   // Entries stored, expired ones included
+	NotFound int   // Entries recording that nothing was found
+	Expired  int   // Entries past their expiry, removed on the next clear
+	Bytes    int64 // Size of all entries on disk
+	Hits     int64 // Lookups answered from the cache
+	Misses   int64 // Lookups the cache couldn't answer
+}
+
+// HitRate returns the fraction of lookups answered from the cache, and
+// false when no lookups have been counted
+func (s Stats) HitRate() (float64, bool) {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0, false
+	}
+	return float64(s.Hits) / float64(total), true
+}
+
+// Entries returns every entry in the cache, expired ones included, in no
+// particular order. Unreadable files are skipped.
+func (c *DiskCache) Entries() ([]Entry, error) {
+	entries, _, err := c.scan()
+	return entries, err
+}
+
+// scan reads every entry file, returning the entries and their total size
+func (c *DiskCache) scan() ([]Entry, int64, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []Entry
+	var size int64
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil || e.Key == "" {
+			continue // Not an entry, e.g. the stats file
+		}
+		entries = append(entries, e)
+		size += int64(len(data))
+	}
+	return entries, size, nil
+}
+
+// Stats reports the entries in the cache and the saved hit and miss
+// totals, plus any counted since the last SaveStats
+func (c *DiskCache) Stats() (Stats, error) {
+	entries, size, err := c.scan()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{Entries: len(entries), Bytes: size}
+	now := time.Now()
+	for _, e := range entries {
+		if e.NotFound {
+			stats.NotFound++
+		}
+		if e.Expired(now) {
+			stats.Expired++
+		}
+	}
+
+	saved := c.savedCounts()
+	stats.Hits = saved.Hits + atomic.LoadInt64(&c.hits)
+	stats.Misses = saved.Misses + atomic.LoadInt64(&c.misses)
+	return stats, nil
+}
+
+// savedCounts reads the hit and miss totals, which are zero if never saved
+func (c *DiskCache) savedCounts() hitCounts {
+	var counts hitCounts
+	if data, err := os.ReadFile(filepath.Join(c.dir, statsFile)); err == nil {
+		json.Unmarshal(data, &counts)
+	}
+	return counts
+}
+
+// SaveStats adds the hits and misses counted since the last call to the
+// totals on disk. It is safe to call on a nil cache.
+func (c *DiskCache) SaveStats() error {
+	if c == nil {
+		return nil
+	}
+
+	hits, misses := atomic.SwapInt64(&c.hits, 0), atomic.SwapInt64(&c.misses, 0)
+	if hits == 0 && misses == 0 {
+		return nil
+	}
+
+	counts := c.savedCounts()
+	counts.Hits += hits
+	counts.Misses += misses
+	data, err := json.Marshal(counts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.dir, statsFile), data, 0644)
+}
+
+// Clear removes every entry and the hit and miss totals, returning how
+// many entries were removed
+func (c *DiskCache) Clear() (int, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, path := range paths {
+		if filepath.Base(path) == statsFile {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	atomic.StoreInt64(&c.hits, 0)
+	atomic.StoreInt64(&c.misses, 0)
+	if err := os.Remove(filepath.Join(c.dir, statsFile)); err != nil && !os.IsNotExist(err) {
+		return removed, err
+	}
+	return removed, nil
+}
@@ -0,0 +1,139 @@
+// pkg/cache/disk_test.go
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestDiskCache_SetGet(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDiskCache returned error: %v", err)
+	}
+
+	key := Key("LTJ Bukem", "Music")
+	if _, hit := c.Get(key); hit {
+		t.Fatal("Expected miss on empty cache")
+	}
+
+	metadata := &enricher.TrackMetadata{Artist: "LTJ Bukem", Title: "Music", Label: "Good Looking Records"}
+	if err := c.Set(key, metadata, time.Hour); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	got, hit := c.Get(key)
+	if !hit || got == nil {
+		t.Fatal("Expected cache hit after Set")
+	}
+	if got.Label != "Good Looking Records" {
+		t.Errorf("Expected label 'Good Looking Records', got '%s'", got.Label)
+	}
+}
+
+func TestDiskCache_Negative(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := Key("Unknown", "Track")
+	if err := c.SetNegative(key, time.Hour); err != nil {
+		t.Fatalf("SetNegative returned error: %v", err)
+	}
+
+	got, hit := c.Get(key)
+	if !hit {
+		t.Error("Expected negative entry to count as a hit")
+	}
+	if got != nil {
+		t.Error("Expected nil metadata for negative entry")
+	}
+}
+
+func TestDiskCache_Expiry(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := Key("Artist", "Title")
+	if err := c.Set(key, &enricher.TrackMetadata{}, time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, hit := c.Get(key); hit {
+		t.Error("Expected expired entry to miss")
+	}
+}
+
+func TestKey_NormalizesCaseAndWhitespace(t *testing.T) {
+	if Key("LTJ  Bukem", "Music ") != Key("ltj bukem", "music") {
+		t.Error("Expected keys to ignore case and extra whitespace")
+	}
+	if Key("L.T.J. Bukem", "Music") != Key("ltj bukem", "music") || Key("Björk", "Jóga") != Key("Bjork", "Joga") {
+		t.Error("Expected keys to ignore punctuation and accents")
+	}
+	if Key("Goldie", "Timeless", "a1b2-c3d4") == Key("Goldie", "Timeless", "a1b2c3d4") {
+		t.Error("Expected hints to keep their punctuation")
+	}
+	if Key("Goldie", "Saint Angel", "Timeless") == Key("Goldie", "Saint Angel") {
+		t.Error("Expected hints to change the key")
+	}
+	if Key("Goldie", "Saint Angel", "", "") != Key("Goldie", "Saint Angel") {
+		t.Error("Expected empty hints not to change the key")
+	}
+}
+
+func TestDiskCache_StatsAndClear(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := Key("Goldie", "Inner City Life")
+	c.Set(found, &enricher.TrackMetadata{Label: "FFRR"}, time.Hour)
+	c.SetNegative(Key("Unknown", "Track"), time.Hour)
+	c.Set(Key("Old", "Track"), &enricher.TrackMetadata{}, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	c.Get(found)
+	c.Get(Key("Missing", "Track"))
+	if !c.Contains(found) {
+		t.Error("Expected Contains to find the entry")
+	}
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Stats returned error: %v", err)
+	}
+	if stats.Entries != 3 || stats.NotFound != 1 || stats.Expired != 1 || stats.Bytes == 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if rate, ok := stats.HitRate(); !ok || rate != 0.5 {
+		t.Errorf("Expected a 50%% hit rate from Get alone, got %v (%v)", rate, ok)
+	}
+
+	// Saved totals carry over to the next run's cache
+	if err := c.SaveStats(); err != nil {
+		t.Fatalf("SaveStats returned error: %v", err)
+	}
+	reopened, _ := NewDiskCache(dir)
+	reopened.Get(found)
+	if stats, _ := reopened.Stats(); stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 3 {
+		t.Errorf("Expected saved totals plus this run's hit, got %+v", stats)
+	}
+
+	removed, err := reopened.Clear()
+	if err != nil || removed != 3 {
+		t.Fatalf("Clear removed %d entries, err %v; expected 3", removed, err)
+	}
+	if stats, _ := reopened.Stats(); stats.Entries != 0 || stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("Expected an empty cache after Clear, got %+v", stats)
+	}
+}
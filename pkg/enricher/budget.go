@@ -0,0 +1,71 @@
+// pkg/enricher/budget.go - Per-run cap on provider API calls
+
+package enricher
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBudgetExhausted is returned once a CallBudget has no calls left
+var ErrBudgetExhausted = errors.New("API call budget exhausted")
+
+// CallBudget caps how many HTTP requests providers may issue in a run.
+// Providers call Take before each request. A nil budget is unlimited, and
+// all methods are safe for concurrent use.
+type CallBudget struct {
+	mu   sync.Mutex
+	max  int
+	used int
+}
+
+// NewCallBudget creates a budget allowing max calls; max <= 0 is unlimited
+func NewCallBudget(max int) *CallBudget {
+	return &CallBudget{max: max}
+}
+
+// Take reserves one call, returning ErrBudgetExhausted if none are left
+func (b *CallBudget) Take() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.max > 0 && b.used >= b.max {
+		return ErrBudgetExhausted
+	}
+	b.used++
+	return nil
+}
+
+// Exhausted reports whether no further calls may be made
+func (b *CallBudget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.max > 0 && b.used >= b.max
+}
+
+// Used returns the number of calls made so far
+func (b *CallBudget) Used() int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Max returns the configured limit, or 0 when unlimited
+func (b *CallBudget) Max() int {
+	if b == nil {
+		return 0
+	}
+	return b.max
+}
@@ -0,0 +1,45 @@
+// pkg/enricher/budget_test.go
+
+package enricher
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCallBudget(t *testing.T) {
+	budget := NewCallBudget(2)
+
+	for i := 0; i < 2; i++ {
+		if err := budget.Take(); err != nil {
+			t.Fatalf("Take %d returned error: %v", i+1, err)
+		}
+	}
+
+	if !budget.Exhausted() {
+		t.Error("Expected budget to be exhausted after 2 calls")
+	}
+	if err := budget.Take(); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted, got %v", err)
+	}
+	if budget.Used() != 2 {
+		t.Errorf("Expected 2 calls used, got %d", budget.Used())
+	}
+}
+
+func TestCallBudget_Unlimited(t *testing.T) {
+	var nilBudget *CallBudget
+	if err := nilBudget.Take(); err != nil || nilBudget.Exhausted() {
+		t.Error("Expected a nil budget to be unlimited")
+	}
+
+	budget := NewCallBudget(0)
+	for i := 0; i < 100; i++ {
+		if err := budget.Take(); err != nil {
+			t.Fatalf("Expected zero budget to be unlimited, got %v", err)
+		}
+	}
+	if budget.Used() != 100 {
+		t.Errorf("Expected 100 calls counted, got %d", budget.Used())
+	}
+}
@@ -0,0 +1,139 @@
+// pkg/enricher/cache.go - Lookup result caching
+
+package enricher
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/normalize"
+)
+
+// Cache stores lookup results by key. Implementations must be safe for
+// concurrent use. A disk cache, an in-memory one or a shared store such as
+// Redis can all sit behind it.
+type Cache interface {
+	// Get returns the cached metadata for key. The boolean reports a hit;
+	// a hit with nil metadata is a cached "not found" result.
+	Get(key string) (*TrackMetadata, bool)
+
+	// Set stores metadata for key, expiring after ttl (zero means never)
+	Set(key string, metadata *TrackMetadata, ttl time.Duration) error
+
+	// SetNegative records that key was looked up and nothing was found
+	SetNegative(key string, ttl time.Duration) error
+}
+
+// NopCache is a Cache that stores nothing, so every lookup misses
+type NopCache struct{}
+
+// Get always misses
+func (NopCache) Get(key string) (*TrackMetadata, bool) { return nil, false }
+
+// Set discards metadata
+func (NopCache) Set(key string, metadata *TrackMetadata, ttl time.Duration) error { return nil }
+
+// SetNegative discards the result
+func (NopCache) SetNegative(key string, ttl time.Duration) error { return nil }
+
+// memoryEntry is one MemoryCache entry; nil metadata is "not found"
+type memoryEntry struct {
+	metadata  *TrackMetadata
+	expiresAt time.Time
+}
+
+// MemoryCache is a Cache held in memory for the life of the process.
+// Expired entries are dropped when next read. Metadata is copied in and
+// out, so callers may modify what they get.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryCache creates an empty in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Get returns the live entry for key
+func (c *MemoryCache) Get(key string) (*TrackMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expiresAt.IsZero() && c.now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	if entry.metadata == nil {
+		return nil, true
+	}
+	copied := *entry.metadata
+	return &copied, true
+}
+
+// Set stores a copy of metadata for key
+func (c *MemoryCache) Set(key string, metadata *TrackMetadata, ttl time.Duration) error {
+	var copied *TrackMetadata
+	if metadata != nil {
+		value := *metadata
+		copied = &value
+	}
+	c.store(key, copied, ttl)
+	return nil
+}
+
+// SetNegative records that key was looked up and nothing was found
+func (c *MemoryCache) SetNegative(key string, ttl time.Duration) error {
+	c.store(key, nil, ttl)
+	return nil
+}
+
+// Len returns how many entries are held, including expired ones not yet
+// read
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *MemoryCache) store(key string, metadata *TrackMetadata, ttl time.Duration) {
+	entry := memoryEntry{metadata: metadata}
+	if ttl > 0 {
+		entry.expiresAt = c.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// DurationBucket rounds a file's length down to 5 seconds, for cache keys:
+// the same file read twice keys alike, while an edit and the full-length
+// version of a title, which can match different recordings, do not
+func DurationBucket(d time.Duration) int {
+	return int(d.Seconds()) / 5
+}
+
+// CacheKey builds the key the enricher caches a request under. Artist and
+// title are reduced by normalize.MatchKey, as when matching, so "L.T.J.
+// Bukem" and "ltj bukem" share an entry; every other hint that can change
+// the result is included, lowercased, with the length as a DurationBucket.
+func CacheKey(req *SearchRequest) string {
+	lower := func(s string) string {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%s|%d|%s|%s|%s|%s|%d-%d|%t|%d|%d",
+		normalize.MatchKey(req.Artist), normalize.MatchKey(req.Title), lower(req.Album),
+		lower(req.Label), lower(req.Genre), req.Year, DurationBucket(req.Duration),
+		lower(req.PreferredFormat), lower(req.CatalogNumber),
+		req.RecordingID, req.ISRC, req.MinYear, req.MaxYear, req.AllowBootleg,
+		req.MinRecordingScore, req.AbandonBelowScore)
+}
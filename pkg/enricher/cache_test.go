@@ -0,0 +1,181 @@
+// pkg/enricher/cache_test.go
+
+package enricher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_SetGetNegativeExpiry(t *testing.T) {
+	c := NewMemoryCache()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	if _, hit := c.Get("missing"); hit {
+		t.Error("Expected a miss for an unknown key")
+	}
+
+	c.Set("found", &TrackMetadata{Label: "Metalheadz"}, time.Hour)
+	metadata, hit := c.Get("found")
+	if !hit || metadata == nil || metadata.Label != "Metalheadz" {
+		t.Fatalf("Expected the cached metadata, got %v %v", metadata, hit)
+	}
+	metadata.Label = "Changed"
+	if again, _ := c.Get("found"); again.Label != "Metalheadz" {
+		t.Error("Expected the cached copy to be unaffected by callers")
+	}
+
+	c.SetNegative("not found", time.Hour)
+	if metadata, hit := c.Get("not found"); !hit || metadata != nil {
+		t.Errorf("Expected a negative hit, got %v %v", metadata, hit)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, hit := c.Get("found"); hit {
+		t.Error("Expected the entry to have expired")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Expected the expired entry to be dropped, %d left", c.Len())
+	}
+}
+
+func TestMemoryCache_Concurrent(t *testing.T) {
+	c := NewMemoryCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("%d-%d", i, j%10)
+				c.Set(key, &TrackMetadata{Year: j}, 0)
+				c.Get(key)
+				c.SetNegative(key+"-none", 0)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Len() != 8*20 {
+		t.Errorf("Expected %d entries, got %d", 8*20, c.Len())
+	}
+}
+
+func TestEnricher_UsesCache(t *testing.T) {
+	found := NewFakeProvider("Found", FakeResponse{Result: &TrackMetadata{Label: "Metalheadz", Confidence: 0.9}})
+	e := NewEnricher([]MetadataProvider{found}, &EnricherConfig{
+		Strategy:       StrategyFirst,
+		MinConfidence:  0.7,
+		RequestTimeout: time.Second,
+		CacheEnabled:   true,
+		Cache:          NewMemoryCache(),
+	})
+
+	for i := 0; i < 2; i++ {
+		result, err := e.Lookup(context.Background(), "Goldie", "Inner City Life")
+		if err != nil || result.Label != "Metalheadz" {
+			t.Fatalf("Lookup %d: expected the match, got %v %v", i, result, err)
+		}
+	}
+	if found.Calls() != 1 {
+		t.Errorf("Expected the second lookup to be served from the cache, got %d calls", found.Calls())
+	}
+
+	// Spacing and case don't change the key
+	if _, err := e.Lookup(context.Background(), " goldie", "INNER  CITY LIFE"); err != nil || found.Calls() != 1 {
+		t.Errorf("Expected a cache hit for the same track, got %v after %d calls", err, found.Calls())
+	}
+}
+
+func TestCacheKey_Normalization(t *testing.T) {
+	key := CacheKey(&SearchRequest{Artist: "LTJ Bukem", Title: "Music"})
+	for _, req := range []*SearchRequest{
+		{Artist: "ltj bukem", Title: "music"},
+		{Artist: "L.T.J. Bukem", Title: "Music"},
+		{Artist: " L.T.J.  BUKEM ", Title: "Music."},
+	} {
+		if got := CacheKey(req); got != key {
+			t.Errorf("CacheKey(%q, %q) = %q, expected %q", req.Artist, req.Title, got, key)
+		}
+	}
+	if CacheKey(&SearchRequest{Artist: "Björk", Title: "Jóga"}) != CacheKey(&SearchRequest{Artist: "Bjork", Title: "Joga"}) {
+		t.Error("Expected accents to be folded")
+	}
+	if CacheKey(&SearchRequest{Artist: "Goldie", Title: "Timeless", CatalogNumber: "FX-240"}) == CacheKey(&SearchRequest{Artist: "Goldie", Title: "Timeless", CatalogNumber: "FX 240"}) {
+		t.Error("Expected hints other than artist and title to keep their punctuation")
+	}
+}
+
+func TestEnricher_CachesNotFoundButNotFailures(t *testing.T) {
+	missing := NewFakeProvider("Missing")
+	failing := NewFakeProvider("Failing", FakeResponse{Err: ErrRateLimit})
+	for _, tc := range []struct {
+		provider *FakeProvider
+		calls    int
+	}{
+		{missing, 1},
+		{failing, 2},
+	} {
+		e := NewEnricher([]MetadataProvider{tc.provider}, &EnricherConfig{
+			Strategy:       StrategyFirst,
+			RequestTimeout: time.Second,
+			CacheEnabled:   true,
+			Cache:          NewMemoryCache(),
+		})
+		e.Lookup(context.Background(), "Artist", "Title")
+		_, err := e.Lookup(context.Background(), "Artist", "Title")
+
+		if tc.provider.Calls() != tc.calls {
+			t.Errorf("%s: expected %d provider calls, got %d", tc.provider.Name(), tc.calls, tc.provider.Calls())
+		}
+		if tc.provider == missing {
+			var notFound *NotFoundError
+			if !errors.As(err, &notFound) || notFound.Reason != "cached as not found" {
+				t.Errorf("Expected a cached not found, got %v", err)
+			}
+		}
+	}
+}
+
+func TestEnricher_DoesNotCacheMissJoinedWithFailure(t *testing.T) {
+	rejected := NewFakeProvider("MusicBrainz", FakeResponse{Result: &TrackMetadata{Confidence: 0.45}})
+	failing := NewFakeProvider("Deezer", FakeResponse{Err: ErrNetwork})
+	e := NewEnricher([]MetadataProvider{rejected, failing}, &EnricherConfig{
+		Strategy:       StrategyBest,
+		MinConfidence:  0.7,
+		RequestTimeout: time.Second,
+		CacheEnabled:   true,
+		Cache:          NewMemoryCache(),
+	})
+
+	_, err := e.Lookup(context.Background(), "Artist", "Title")
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrNetwork) {
+		t.Fatalf("Expected the miss and the failure joined, got %v", err)
+	}
+	if IsNotFound(err) {
+		t.Error("Expected IsNotFound to be false while a provider failed")
+	}
+	e.Lookup(context.Background(), "Artist", "Title")
+	if failing.Calls() != 2 {
+		t.Errorf("Expected the lookup retried rather than cached as not found, got %d calls", failing.Calls())
+	}
+}
+
+func TestEnricher_CacheDisabled(t *testing.T) {
+	provider := NewFakeProvider("Found", FakeResponse{Result: &TrackMetadata{Confidence: 0.9}})
+	e := NewEnricher([]MetadataProvider{provider}, &EnricherConfig{
+		Strategy:       StrategyFirst,
+		RequestTimeout: time.Second,
+		Cache:          NewMemoryCache(),
+	})
+	e.Lookup(context.Background(), "Artist", "Title")
+	e.Lookup(context.Background(), "Artist", "Title")
+	if provider.Calls() != 2 {
+		t.Errorf("Expected the cache to be ignored unless CacheEnabled, got %d calls", provider.Calls())
+	}
+}
@@ -0,0 +1,472 @@
+// pkg/enricher/deezer/deezer.go
+
+// Package deezer looks tracks up in Deezer's public catalogue. Its search
+// needs no API key and has good coverage of recent electronic releases,
+// with reliable release dates, so it supplements MusicBrainz for tracks
+// that aren't there yet. Deezer has no catalog numbers.
+package deezer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/enricher"
+	"github.com/cerberussg/tagger/pkg/normalize"
+)
+
+const (
+	defaultBaseURL = "https://api.deezer.com"
+
+	// Deezer allows 50 requests every 5 seconds per client
+	requestsPerInterval = 50
+	rateInterval        = 5 * time.Second
+
+	// defaultMaxResults is how many search results are ranked when the
+	// request doesn't say
+	defaultMaxResults = 10
+
+	// maxAlbumLookups bounds the album requests LookupCandidates makes
+	maxAlbumLookups = 3
+
+	maxRetries     = 2
+	initialBackoff = time.Second
+
+	// closeMatchSimilarity is the minimum normalize.Similarity for an
+	// artist or title that isn't exact to still count as close
+	closeMatchSimilarity = 0.8
+
+	// durationTolerance is how far a track's length may be from the file's
+	// and still count as the same recording
+	durationTolerance = 5 * time.Second
+)
+
+// Deezer error codes, returned in the body of a 200 response
+const (
+	errorQuota        = 4
+	errorDataNotFound = 800
+)
+
+// DeezerProvider implements the MetadataProvider interface for Deezer
+type DeezerProvider struct {
+	client      *http.Client
+	baseURL     string
+	budget      *enricher.CallBudget
+	lastRequest time.Time
+}
+
+// Option configures optional DeezerProvider settings
+type Option func(*DeezerProvider)
+
+// WithBaseURL points the provider at a different API root, such as a test
+// server
+func WithBaseURL(u string) Option {
+	return func(d *DeezerProvider) {
+		if u != "" {
+			d.baseURL = strings.TrimRight(u, "/")
+		}
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, e.g. to route requests
+// through a specific proxy
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *DeezerProvider) {
+		if client != nil {
+			d.client = client
+		}
+	}
+}
+
+// WithCallBudget charges every request (including retries) against budget,
+// failing with enricher.ErrBudgetExhausted once it runs out
+func WithCallBudget(budget *enricher.CallBudget) Option {
+	return func(d *DeezerProvider) {
+		d.budget = budget
+	}
+}
+
+// NewDeezerProvider creates a new Deezer metadata provider
+func NewDeezerProvider(opts ...Option) *DeezerProvider {
+	d := &DeezerProvider{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: defaultBaseURL,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Name returns the provider's display name
+func (d *DeezerProvider) Name() string {
+	return "Deezer"
+}
+
+// Lookup searches for track metadata by artist and title
+func (d *DeezerProvider) Lookup(ctx context.Context, artist, title string) (*enricher.TrackMetadata, error) {
+	return d.LookupWithHints(ctx, &enricher.SearchRequest{Artist: artist, Title: title})
+}
+
+// LookupWithHints returns the best matching track with its album's label
+// and release date
+func (d *DeezerProvider) LookupWithHints(ctx context.Context, req *enricher.SearchRequest) (*enricher.TrackMetadata, error) {
+	tracks, err := d.search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := d.trackMetadata(ctx, &tracks[0], req)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Candidates = len(tracks)
+	return metadata, nil
+}
+
+// LookupCandidates returns the best few matches, best first. Each needs an
+// album request, so only the top maxAlbumLookups are returned.
+func (d *DeezerProvider) LookupCandidates(ctx context.Context, req *enricher.SearchRequest) ([]*enricher.TrackMetadata, error) {
+	tracks, err := d.search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) > maxAlbumLookups {
+		tracks = tracks[:maxAlbumLookups]
+	}
+
+	var candidates []*enricher.TrackMetadata
+	for i := range tracks {
+		candidate, err := d.trackMetadata(ctx, &tracks[i], req)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates, nil
+}
+
+// search finds tracks by artist and title, ranked best first. Tracks whose
+// artist and title are both unlike the request are dropped.
+func (d *DeezerProvider) search(ctx context.Context, req *enricher.SearchRequest) ([]Track, error) {
+	limit := req.MaxResults
+	if limit <= 0 {
+		limit = defaultMaxResults
+	}
+
+	params := url.Values{}
+	params.Set("q", searchQuery(req.Artist, req.Title))
+	params.Set("limit", strconv.Itoa(limit))
+
+	var result SearchResult
+	if err := d.getJSON(ctx, fmt.Sprintf("%s/search?%s", d.baseURL, params.Encode()), &result); err != nil {
+		return nil, d.failure(ctx, "track search", err)
+	}
+
+	if len(result.Data) == 0 {
+		return nil, enricher.ErrNotFound
+	}
+	tracks := rankTracks(result.Data, req.Artist, req.Title, req.Duration)
+	if len(tracks) == 0 {
+		return nil, &enricher.NotFoundError{Candidates: len(result.Data), Reason: "none matched the artist and title"}
+	}
+	return tracks, nil
+}
+
+// searchQuery builds an advanced search on the core title, so "Music
+// (Original Mix)" still finds "Music"
+func searchQuery(artist, title string) string {
+	core, _ := normalize.CleanTitle(title)
+	if core == "" {
+		core = title
+	}
+	return fmt.Sprintf(`artist:"%s" track:"%s"`, strings.ReplaceAll(artist, `"`, ""), strings.ReplaceAll(core, `"`, ""))
+}
+
+// rankTracks orders tracks by match quality, then closeness to the file's
+// length, then Deezer's popularity rank, dropping those that don't match
+func rankTracks(tracks []Track, artist, title string, duration time.Duration) []Track {
+	var ranked []Track
+	for _, track := range tracks {
+		if matchQuality(&track, artist, title) != enricher.MatchNone {
+			ranked = append(ranked, track)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		qi, qj := matchQuality(&ranked[i], artist, title), matchQuality(&ranked[j], artist, title)
+		if qi != qj {
+			return qi > qj
+		}
+		if duration > 0 {
+			ci, cj := lengthMatches(&ranked[i], duration), lengthMatches(&ranked[j], duration)
+			if ci != cj {
+				return ci
+			}
+		}
+		return ranked[i].Rank > ranked[j].Rank
+	})
+	return ranked
+}
+
+// lengthMatches reports whether track is within durationTolerance of length
+func lengthMatches(track *Track, length time.Duration) bool {
+	diff := time.Duration(track.Duration)*time.Second - length
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= durationTolerance
+}
+
+// matchQuality grades a track against the requested artist and title. It
+// is exact when both match once folded, comparing core titles so mix
+// names on either side don't count against it.
+func matchQuality(track *Track, artist, title string) enricher.MatchQuality {
+	exactArtist := sameText(track.Artist.Name, artist)
+	exactTitle := sameText(coreTitle(track), coreOf(title))
+	if exactArtist && exactTitle {
+		return enricher.MatchExact
+	}
+
+	closeArtist := exactArtist || normalize.Similarity(track.Artist.Name, artist) >= closeMatchSimilarity
+	closeTitle := exactTitle || normalize.Similarity(coreTitle(track), coreOf(title)) >= closeMatchSimilarity
+	if closeArtist && closeTitle {
+		return enricher.MatchClose
+	}
+	return enricher.MatchNone
+}
+
+// coreTitle is a track's title without its version ("Original Mix")
+func coreTitle(track *Track) string {
+	if track.TitleShort != "" {
+		return coreOf(track.TitleShort)
+	}
+	return coreOf(track.Title)
+}
+
+func coreOf(title string) string {
+	if core, _ := normalize.CleanTitle(title); core != "" {
+		return core
+	}
+	return title
+}
+
+func sameText(a, b string) bool {
+	return normalize.MatchKey(a) == normalize.MatchKey(b)
+}
+
+// trackMetadata fetches track's album for its label, release date and
+// genre, and converts the two to TrackMetadata
+func (d *DeezerProvider) trackMetadata(ctx context.Context, track *Track, req *enricher.SearchRequest) (*enricher.TrackMetadata, error) {
+	// Without the album the match still has its album title
+	album := track.Album
+	if track.Album.ID != 0 {
+		var detail Album
+		err := d.getJSON(ctx, fmt.Sprintf("%s/album/%d", d.baseURL, track.Album.ID), &detail)
+		switch {
+		case err == nil:
+			album = detail
+		case !errors.Is(err, enricher.ErrNotFound):
+			return nil, d.failure(ctx, "album lookup", err)
+		}
+	}
+
+	metadata := &enricher.TrackMetadata{
+		Artist:       req.Artist,
+		Title:        req.Title,
+		Album:        album.Title,
+		Label:        strings.TrimSpace(album.Label),
+		ReleaseDate:  album.ReleaseDate,
+		ProviderID:   strconv.FormatInt(track.ID, 10),
+		ProviderName: d.Name(),
+		Extra: map[string]interface{}{
+			"deezer_track_id": track.ID,
+			"deezer_album_id": album.ID,
+		},
+	}
+	if len(album.ReleaseDate) >= 4 {
+		metadata.Year, _ = strconv.Atoi(album.ReleaseDate[:4])
+	}
+	if len(album.Genres.Data) > 0 {
+		metadata.Genre = album.Genres.Data[0].Name
+	}
+	if album.UPC != "" {
+		metadata.Extra["deezer_upc"] = album.UPC
+	}
+
+	metadata.Confidence = enricher.CalculateConfidence(metadata, matchQuality(track, req.Artist, req.Title))
+	return metadata, nil
+}
+
+// failure reports a failed request as a ProviderError, leaving context,
+// budget and not found errors as they are
+func (d *DeezerProvider) failure(ctx context.Context, op string, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, enricher.ErrNotFound) || errors.Is(err, enricher.ErrBudgetExhausted) {
+		return err
+	}
+	return d.providerError(op, err)
+}
+
+// SupportsGenre reports whether Deezer has good coverage for a genre. Its
+// catalogue is strongest for commercially released music of any genre.
+func (d *DeezerProvider) SupportsGenre(genre string) bool {
+	return true
+}
+
+// RateLimit returns the provider's rate limiting info: 50 requests every
+// 5 seconds, no key or user agent needed
+func (d *DeezerProvider) RateLimit() enricher.RateLimitInfo {
+	return enricher.RateLimitInfo{
+		RequestsPerSecond: float64(requestsPerInterval) / rateInterval.Seconds(),
+		BurstAllowed:      requestsPerInterval,
+		RequiresUserAgent: false,
+		RequiresAPIKey:    false,
+	}
+}
+
+// Close cleans up any resources
+func (d *DeezerProvider) Close() error {
+	return nil
+}
+
+// waitForRateLimit spaces requests evenly at the allowed rate, which never
+// exceeds the 5-second allowance
+func (d *DeezerProvider) waitForRateLimit(ctx context.Context) error {
+	interval := rateInterval / requestsPerInterval
+	if elapsed := time.Since(d.lastRequest); elapsed < interval {
+		select {
+		case <-time.After(interval - elapsed):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	d.lastRequest = time.Now()
+	return nil
+}
+
+// getJSON performs a rate-limited, budgeted GET and decodes the response
+// into v. Rate limiting (including Deezer's quota error) and network
+// failures are retried with exponential backoff while ctx allows; other
+// failures are returned at once.
+func (d *DeezerProvider) getJSON(ctx context.Context, requestURL string, v interface{}) error {
+	backoff := initialBackoff
+
+	for attempt := 0; ; attempt++ {
+		if err := d.budget.Take(); err != nil {
+			return err
+		}
+		if err := d.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Accept", "application/json")
+
+		resp, err := d.client.Do(httpReq)
+		if err == nil {
+			err = decodeResponse(resp, v)
+		} else {
+			err = fmt.Errorf("%w: http request failed: %w", enricher.ErrNetwork, err)
+		}
+		if err == nil {
+			return nil
+		}
+		// Preserve context errors without wrapping
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if enricher.KindOf(err).Retryable() && attempt < maxRetries {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return err
+	}
+}
+
+// decodeResponse decodes a response into v and closes its body. Deezer
+// reports most failures as an error object with status 200, so that is
+// checked for before decoding.
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return enricher.ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp.StatusCode)
+	}
+
+	body, err := enricher.ReadBody(resp.Body, enricher.MaxResponseSize)
+	if err != nil {
+		return err
+	}
+
+	var failure struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &failure); err == nil && failure.Error != nil {
+		return apiError(failure.Error)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: failed to parse JSON response: %w", enricher.ErrAPIError, err)
+	}
+	return nil
+}
+
+// statusError describes an unexpected HTTP status, wrapping the common
+// error that classifies it
+func statusError(status int) error {
+	kind := enricher.ErrAPIError
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		kind = enricher.ErrRateLimit
+	case http.StatusUnauthorized, http.StatusForbidden:
+		kind = enricher.ErrAuth
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		kind = enricher.ErrNetwork
+	}
+	return fmt.Errorf("%w: deezer API returned status %d", kind, status)
+}
+
+// apiError classifies an error object from a response body
+func apiError(apiErr *APIError) error {
+	switch apiErr.Code {
+	case errorDataNotFound:
+		return enricher.ErrNotFound
+	case errorQuota:
+		return fmt.Errorf("%w: deezer API: %s", enricher.ErrRateLimit, apiErr.Message)
+	}
+	return fmt.Errorf("%w: deezer API %s (code %d): %s", enricher.ErrAPIError, apiErr.Type, apiErr.Code, apiErr.Message)
+}
+
+// providerError reports a failed operation as an enricher.ProviderError,
+// classified so callers needn't match on its text
+func (d *DeezerProvider) providerError(op string, err error) error {
+	return &enricher.ProviderError{Provider: d.Name(), Op: op, Kind: enricher.KindOf(err), Err: err}
+}
@@ -0,0 +1,147 @@
+// pkg/enricher/deezer/deezer_test.go
+
+package deezer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/enricher"
+)
+
+// searchResponse has a popular cover ranked above the original
+const searchResponse = `{
+	"total": 3,
+	"data": [
+		{"id": 3, "title": "Inner City Life (Cover)", "title_short": "Inner City Life", "duration": 240, "rank": 900000,
+		 "artist": {"id": 30, "name": "Some Cover Band"}, "album": {"id": 300, "title": "Covers"}},
+		{"id": 2, "title": "Inner City Life (Radio Edit)", "title_short": "Inner City Life", "duration": 225, "rank": 500000,
+		 "artist": {"id": 20, "name": "Goldie"}, "album": {"id": 200, "title": "Inner City Life"}},
+		{"id": 1, "title": "Inner City Life", "title_short": "Inner City Life", "duration": 412, "rank": 400000,
+		 "artist": {"id": 20, "name": "Goldie"}, "album": {"id": 100, "title": "Timeless"}}
+	]
+}`
+
+func newTestServer(t *testing.T, albumCalls *int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/search":
+			if q := r.URL.Query().Get("q"); q != `artist:"Goldie" track:"Inner City Life"` {
+				t.Errorf("Unexpected search query %q", q)
+			}
+			fmt.Fprint(w, searchResponse)
+		case strings.HasPrefix(r.URL.Path, "/album/"):
+			if albumCalls != nil {
+				*albumCalls++
+			}
+			id := strings.TrimPrefix(r.URL.Path, "/album/")
+			fmt.Fprintf(w, `{"id": %s, "title": "Album %s", "label": "Label %s", "release_date": "1995-07-24", "upc": "0000%s",
+				"genres": {"data": [{"id": 106, "name": "Electro"}]}}`, id, id, id, id)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestDeezerProvider_LookupWithHints(t *testing.T) {
+	server := newTestServer(t, nil)
+	defer server.Close()
+
+	provider := NewDeezerProvider(WithBaseURL(server.URL))
+	result, err := provider.LookupWithHints(context.Background(), &enricher.SearchRequest{
+		Artist:   "Goldie",
+		Title:    "Inner City Life (Original Mix)",
+		Duration: 411 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("LookupWithHints returned error: %v", err)
+	}
+
+	// The cover is dropped and the full-length original beats the edit
+	if result.ProviderID != "1" {
+		t.Fatalf("Expected the original (track 1), got %s", result.ProviderID)
+	}
+	if result.Album != "Album 100" || result.Label != "Label 100" || result.ReleaseDate != "1995-07-24" || result.Year != 1995 || result.Genre != "Electro" {
+		t.Errorf("Unexpected metadata: %+v", result)
+	}
+	if result.ProviderName != "Deezer" || result.Extra["deezer_album_id"] != int64(100) {
+		t.Errorf("Expected Deezer IDs in the result, got %s %v", result.ProviderName, result.Extra)
+	}
+	if result.Confidence < 0.8 {
+		t.Errorf("Expected high confidence for an exact match with label and date, got %.2f", result.Confidence)
+	}
+}
+
+func TestDeezerProvider_LookupCandidates(t *testing.T) {
+	albumCalls := 0
+	server := newTestServer(t, &albumCalls)
+	defer server.Close()
+
+	provider := NewDeezerProvider(WithBaseURL(server.URL))
+	candidates, err := provider.LookupCandidates(context.Background(), &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life"})
+	if err != nil {
+		t.Fatalf("LookupCandidates returned error: %v", err)
+	}
+	if len(candidates) != 2 || albumCalls != 2 {
+		t.Errorf("Expected the two Goldie tracks with an album lookup each, got %d candidates and %d lookups", len(candidates), albumCalls)
+	}
+}
+
+func TestDeezerProvider_Errors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response string
+		status   int
+		expected error
+	}{
+		{"no results", `{"data": [], "total": 0}`, http.StatusOK, enricher.ErrNotFound},
+		{"data not found", `{"error": {"type": "DataException", "message": "no data", "code": 800}}`, http.StatusOK, enricher.ErrNotFound},
+		{"bad parameter", `{"error": {"type": "ParameterException", "message": "Wrong parameter", "code": 500}}`, http.StatusOK, enricher.ErrAPIError},
+		{"forbidden", ``, http.StatusForbidden, enricher.ErrAuth},
+		{"too large", `{"data": [], "padding": "` + strings.Repeat("x", enricher.MaxResponseSize) + `"}`, http.StatusOK, enricher.ErrAPIError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.response)
+			}))
+			defer server.Close()
+
+			provider := NewDeezerProvider(WithBaseURL(server.URL))
+			_, err := provider.Lookup(context.Background(), "Goldie", "Inner City Life")
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+			if tc.expected != enricher.ErrNotFound {
+				var providerErr *enricher.ProviderError
+				if !errors.As(err, &providerErr) || providerErr.Provider != "Deezer" {
+					t.Errorf("Expected a Deezer ProviderError, got %T", err)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIError_Quota(t *testing.T) {
+	err := apiError(&APIError{Type: "Exception", Message: "Quota limit exceeded", Code: errorQuota})
+	if !errors.Is(err, enricher.ErrRateLimit) || !enricher.KindOf(err).Retryable() {
+		t.Errorf("Expected a retryable rate limit error, got %v", err)
+	}
+}
+
+func TestDeezerProvider_RateLimit(t *testing.T) {
+	info := NewDeezerProvider().RateLimit()
+	if info.RequestsPerSecond != 10 || info.BurstAllowed != 50 || info.RequiresAPIKey {
+		t.Errorf("Expected 50 requests per 5s without a key, got %+v", info)
+	}
+}
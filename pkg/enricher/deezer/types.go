@@ -0,0 +1,57 @@
+// pkg/enricher/deezer/types.go
+
+package deezer
+
+// SearchResult is the response from the track search
+type SearchResult struct {
+	Data  []Track `json:"data"`
+	Total int     `json:"total"`
+}
+
+// Track is a Deezer track as returned by the search
+type Track struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	TitleShort   string `json:"title_short"`
+	TitleVersion string `json:"title_version,omitempty"`
+	Duration     int    `json:"duration"` // seconds
+	Rank         int    `json:"rank"`
+	Artist       Artist `json:"artist"`
+	Album        Album  `json:"album"`
+}
+
+// Artist is a track's main artist
+type Artist struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Album is a release; the search only fills in ID and title, the album
+// lookup the rest
+type Album struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Label       string    `json:"label,omitempty"`
+	ReleaseDate string    `json:"release_date,omitempty"` // YYYY-MM-DD
+	UPC         string    `json:"upc,omitempty"`
+	RecordType  string    `json:"record_type,omitempty"` // album, ep, single, compile
+	Genres      GenreList `json:"genres,omitempty"`
+}
+
+// GenreList wraps an album's genres
+type GenreList struct {
+	Data []Genre `json:"data"`
+}
+
+// Genre is a Deezer genre
+type Genre struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// APIError is the error object Deezer returns with status 200
+type APIError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
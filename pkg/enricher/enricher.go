@@ -5,6 +5,9 @@ package enricher
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -13,6 +16,8 @@ var (
 	ErrNotFound    = errors.New("no metadata found")
 	ErrRateLimit   = errors.New("rate limit exceeded")
 	ErrAPIError    = errors.New("API error")
+	ErrNetwork     = errors.New("network error")
+	ErrAuth        = errors.New("authentication failed")
 	ErrNoProvider  = errors.New("no providers available")
 )
 
@@ -27,6 +32,10 @@ type MetadataProvider interface {
 	// LookupWithHints allows passing additional search hints
 	LookupWithHints(ctx context.Context, req *SearchRequest) (*TrackMetadata, error)
 	
+	// LookupCandidates returns ranked matches with their confidences, best
+	// first. Providers that only ever find one match can use SingleCandidate.
+	LookupCandidates(ctx context.Context, req *SearchRequest) ([]*TrackMetadata, error)
+	
 	// SupportsGenre indicates if this provider has good coverage for a genre
 	SupportsGenre(genre string) bool
 	
@@ -37,6 +46,20 @@ type MetadataProvider interface {
 	Close() error
 }
 
+// ArtworkProvider is implemented by providers that can supply cover art
+// for a result they returned
+type ArtworkProvider interface {
+	// FetchArtwork downloads the front cover for previously returned metadata.
+	// Returns ErrNotFound when no artwork exists.
+	FetchArtwork(ctx context.Context, metadata *TrackMetadata) (*Artwork, error)
+}
+
+// Artwork is an image fetched from a provider
+type Artwork struct {
+	MIMEType string
+	Data     []byte
+}
+
 // TrackMetadata represents the enriched metadata from any provider
 type TrackMetadata struct {
 	Artist        string            `json:"artist"`
@@ -53,6 +76,11 @@ type TrackMetadata struct {
 	ProviderName  string            `json:"provider_name"`  // e.g., "MusicBrainz"
 	Confidence    float64           `json:"confidence"`     // 0.0 - 1.0
 	
+	// Candidates is how many search results the provider picked this
+	// match from, or 0 if it doesn't say. It explains a rejected match
+	// and isn't cached.
+	Candidates    int               `json:"-"`
+	
 	// Extensible fields for provider-specific data
 	Extra         map[string]interface{} `json:"extra,omitempty"`
 }
@@ -62,6 +90,7 @@ type SearchRequest struct {
 	Artist      string
 	Title       string
 	Album       string
+	Label       string
 	Genre       string
 	Year        string
 	Duration    time.Duration
@@ -69,6 +98,43 @@ type SearchRequest struct {
 	// Search preferences
 	PreferOriginalRelease bool
 	MaxResults           int
+	
+	// PreferredFormat tie-breaks between releases toward ones whose media
+	// format contains this string (e.g. "Vinyl", "12\"", "Digital Media")
+	PreferredFormat string
+	
+	// AbandonBelowScore treats the lookup as not found when the best
+	// candidate's provider relevance score (0-100) is below this value.
+	// Zero disables the check.
+	AbandonBelowScore int
+	
+	// MinRecordingScore discards candidates whose provider relevance score
+	// (0-100) is below this value before match scoring. Zero disables it.
+	MinRecordingScore int
+	
+	// CatalogNumber is a catalog number known for the track, e.g. from the
+	// filename. Releases and labels carrying it are preferred.
+	CatalogNumber string
+	
+	// RecordingID is a MusicBrainz recording ID already stored in the file.
+	// Providers that can fetch by ID skip the fuzzy search when it is set.
+	RecordingID string
+	
+	// ISRC is the recording's International Standard Recording Code, read
+	// from the file. Providers that can look codes up try it before the
+	// fuzzy search.
+	ISRC string
+	
+	// MinYear and MaxYear bound when the wanted release came out. Releases
+	// dated outside the range only win when none fall inside it. Zero
+	// leaves that end open.
+	MinYear int
+	MaxYear int
+	
+	// AllowBootleg lets bootleg, promotional and other unofficial releases
+	// compete with official ones. By default an official release wins
+	// whenever the recording has one.
+	AllowBootleg bool
 }
 
 // RateLimitInfo describes the provider's rate limiting
@@ -96,19 +162,27 @@ type EnricherConfig struct {
 	// Quality thresholds
 	MinConfidence     float64       `yaml:"min_confidence"`
 	RequireLabel      bool          `yaml:"require_label"`
+	MinRecordingScore int           `yaml:"min_recording_score"`
 	
-	// Timeouts
+	// RequestTimeout bounds a whole lookup: every provider request,
+	// retry and rate-limit wait. It is separate from a provider's HTTP
+	// client timeout, which caps each single request and so should be
+	// no longer than this.
 	RequestTimeout    time.Duration `yaml:"request_timeout"`
 	
-	// For future use
+	// CacheEnabled serves repeated requests from Cache, which keeps
+	// results and "not found" answers for CacheTTL (zero means forever).
+	// A nil Cache stores nothing.
 	CacheEnabled      bool          `yaml:"cache_enabled"`
 	CacheTTL          time.Duration `yaml:"cache_ttl"`
+	Cache             Cache         `yaml:"-"`
 }
 
 // Enricher orchestrates multiple metadata providers
 type Enricher struct {
 	providers []MetadataProvider
 	config    *EnricherConfig
+	cache     Cache
 }
 
 // NewEnricher creates an enricher with the specified providers
@@ -116,17 +190,24 @@ func NewEnricher(providers []MetadataProvider, config *EnricherConfig) *Enricher
 	if config == nil {
 		config = &EnricherConfig{
 			Strategy:       StrategyFirst,
-			MinConfidence:  0.7,
-			RequireLabel:   false,
-			RequestTimeout: 30 * time.Second,
-			CacheEnabled:   true,
-			CacheTTL:       24 * time.Hour,
+			MinConfidence:     0.7,
+			RequireLabel:      false,
+			MinRecordingScore: 50,
+			RequestTimeout:    30 * time.Second,
+			CacheEnabled:      true,
+			CacheTTL:          24 * time.Hour,
 		}
 	}
 	
+	cache := config.Cache
+	if cache == nil || !config.CacheEnabled {
+		cache = NopCache{}
+	}
+	
 	return &Enricher{
 		providers: providers,
 		config:    config,
+		cache:     cache,
 	}
 }
 
@@ -146,12 +227,43 @@ func (e *Enricher) Lookup(ctx context.Context, artist, title string) (*TrackMeta
 	return e.LookupWithRequest(ctx, req)
 }
 
-// LookupWithRequest performs lookup with full search parameters
+// LookupWithRequest performs lookup with full search parameters, answering
+// from the cache when it can. Matches and "not found" results are cached;
+// other failures, including a miss joined with another provider's failure,
+// are retried next time.
 func (e *Enricher) LookupWithRequest(ctx context.Context, req *SearchRequest) (*TrackMetadata, error) {
+	key := CacheKey(req)
+	if metadata, hit := e.cache.Get(key); hit {
+		if metadata == nil {
+			return nil, &NotFoundError{Reason: "cached as not found"}
+		}
+		return metadata, nil
+	}
+	
+	metadata, err := e.lookup(ctx, req)
+	// A cache that can't store only costs a repeat lookup
+	switch {
+	case err == nil:
+		e.cache.Set(key, metadata, e.config.CacheTTL)
+	case IsNotFound(err):
+		e.cache.SetNegative(key, e.config.CacheTTL)
+	}
+	return metadata, err
+}
+
+// lookup runs the configured strategy for req
+func (e *Enricher) lookup(ctx context.Context, req *SearchRequest) (*TrackMetadata, error) {
 	// Apply request timeout
 	ctx, cancel := context.WithTimeout(ctx, e.config.RequestTimeout)
 	defer cancel()
 	
+	// Apply configured defaults without mutating the caller's request
+	if req.MinRecordingScore == 0 && e.config.MinRecordingScore > 0 {
+		withDefaults := *req
+		withDefaults.MinRecordingScore = e.config.MinRecordingScore
+		req = &withDefaults
+	}
+	
 	switch e.config.Strategy {
 	case StrategyFirst:
 		return e.lookupFirst(ctx, req)
@@ -164,47 +276,78 @@ func (e *Enricher) LookupWithRequest(ctx context.Context, req *SearchRequest) (*
 	}
 }
 
-// lookupFirst tries providers in order, returns first successful result
+// lookupFirst tries providers in order, returns first successful result.
+// If nothing is found, every provider error is returned joined together.
 func (e *Enricher) lookupFirst(ctx context.Context, req *SearchRequest) (*TrackMetadata, error) {
-	var lastErr error
+	var errs []error
 	
 	for _, provider := range e.providers {
 		result, err := provider.LookupWithHints(ctx, req)
+		if err == nil {
+			err = e.rejected(result)
+		}
 		if err != nil {
-			lastErr = err
+			errs = append(errs, wrapProviderError(provider, "lookup", err))
 			continue
 		}
-		
-		if result != nil && result.Confidence >= e.config.MinConfidence {
-			if !e.config.RequireLabel || result.Label != "" {
-				return result, nil
-			}
-		}
+		return result, nil
 	}
 	
-	if lastErr != nil {
-		return nil, lastErr
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 	return nil, ErrNotFound
 }
 
-// lookupBest tries all providers and returns the best result by confidence
+// rejected reports a result that fell short of the enricher's thresholds
+// as a NotFoundError, or nil if it is good enough
+func (e *Enricher) rejected(result *TrackMetadata) error {
+	switch {
+	case result == nil:
+		return ErrNotFound
+	case result.Confidence < e.config.MinConfidence:
+		return &NotFoundError{
+			Candidates: result.Candidates,
+			Best:       result,
+			Reason:     fmt.Sprintf("best scored %.2f (below %.2f)", result.Confidence, e.config.MinConfidence),
+		}
+	case e.config.RequireLabel && result.Label == "":
+		return &NotFoundError{Candidates: result.Candidates, Best: result, Reason: "best match has no label"}
+	}
+	return nil
+}
+
+// lookupBest asks every provider at once and returns the best result by
+// confidence, ties going to the earlier provider. Providers have their own
+// rate limiters, so a track costs the slowest lookup rather than the sum of
+// them. A perfect match ends the search early, cancelling providers still
+// running. If nothing is found, every provider error is returned joined
+// together.
 func (e *Enricher) lookupBest(ctx context.Context, req *SearchRequest) (*TrackMetadata, error) {
-	var bestResult *TrackMetadata
-	var lastErr error
-	
-	for _, provider := range e.providers {
+	query := func(ctx context.Context, provider MetadataProvider) ([]*TrackMetadata, error) {
 		result, err := provider.LookupWithHints(ctx, req)
+		if err == nil {
+			err = e.rejected(result)
+		}
 		if err != nil {
-			lastErr = err
+			return nil, wrapProviderError(provider, "lookup", err)
+		}
+		return []*TrackMetadata{result}, nil
+	}
+	perfect := func(results []*TrackMetadata) bool {
+		return results[0].Confidence >= 1
+	}
+	
+	var bestResult *TrackMetadata
+	var errs []error
+	for _, answer := range e.queryProviders(ctx, query, perfect) {
+		if answer.err != nil {
+			errs = append(errs, answer.err)
 			continue
 		}
-		
-		if result != nil && result.Confidence >= e.config.MinConfidence {
-			if !e.config.RequireLabel || result.Label != "" {
-				if bestResult == nil || result.Confidence > bestResult.Confidence {
-					bestResult = result
-				}
+		for _, result := range answer.results {
+			if bestResult == nil || result.Confidence > bestResult.Confidence {
+				bestResult = result
 			}
 		}
 	}
@@ -213,17 +356,75 @@ func (e *Enricher) lookupBest(ctx context.Context, req *SearchRequest) (*TrackMe
 		return bestResult, nil
 	}
 	
-	if lastErr != nil {
-		return nil, lastErr
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 	return nil, ErrNotFound
 }
 
+// providerAnswer is one provider's reply to queryProviders
+type providerAnswer struct {
+	results []*TrackMetadata
+	err     error
+}
+
+// queryProviders runs query against every provider concurrently and
+// returns the answers in provider order. Once an answer without error
+// satisfies enough, if given, the providers still running are cancelled
+// and left out of the answers. The caller's context deadline applies to
+// all of them.
+//
+// Every query has returned by the time queryProviders does, cancelled ones
+// included, so a provider is never still busy (say, waiting on its rate
+// limiter) when the next lookup reaches it.
+//
+// Plain goroutines are used rather than errgroup: one provider failing
+// must not cancel the others, and this keeps the dependency list short.
+func (e *Enricher) queryProviders(ctx context.Context, query func(context.Context, MetadataProvider) ([]*TrackMetadata, error), enough func([]*TrackMetadata) bool) []providerAnswer {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	
+	type reply struct {
+		index int
+		providerAnswer
+	}
+	replies := make(chan reply, len(e.providers))
+	var wg sync.WaitGroup
+	for i, provider := range e.providers {
+		wg.Add(1)
+		go func(i int, provider MetadataProvider) {
+			defer wg.Done()
+			results, err := query(ctx, provider)
+			replies <- reply{i, providerAnswer{results: results, err: err}}
+		}(i, provider)
+	}
+	
+	answered := make([]*providerAnswer, len(e.providers))
+	for range e.providers {
+		r := <-replies
+		answered[r.index] = &r.providerAnswer
+		if enough != nil && r.err == nil && len(r.results) > 0 && enough(r.results) {
+			break
+		}
+	}
+	// Cancelled queries return quickly; their answers are dropped
+	cancel()
+	wg.Wait()
+	
+	var answers []providerAnswer
+	for _, answer := range answered {
+		if answer != nil {
+			answers = append(answers, *answer)
+		}
+	}
+	return answers
+}
+
 // lookupFallback tries providers in order with more aggressive fallback
 func (e *Enricher) lookupFallback(ctx context.Context, req *SearchRequest) (*TrackMetadata, error) {
 	// First pass: try with all hints
-	result, err := e.lookupFirst(ctx, req)
-	if err == nil && result != nil {
+	result, firstErr := e.lookupFirst(ctx, req)
+	if firstErr == nil && result != nil {
 		return result, nil
 	}
 	
@@ -235,7 +436,97 @@ func (e *Enricher) lookupFallback(ctx context.Context, req *SearchRequest) (*Tra
 		MaxResults:           req.MaxResults,
 	}
 	
-	return e.lookupFirst(ctx, simplifiedReq)
+	result, secondErr := e.lookupFirst(ctx, simplifiedReq)
+	if secondErr == nil {
+		return result, nil
+	}
+	
+	// Report failures from both passes
+	return nil, errors.Join(firstErr, secondErr)
+}
+
+// LookupCandidatesWithRequest asks every provider for candidates and merges
+// them, highest confidence first, up to req.MaxResults. Confidence and label
+// requirements are not applied, so weak matches stay visible for review.
+func (e *Enricher) LookupCandidatesWithRequest(ctx context.Context, req *SearchRequest) ([]*TrackMetadata, error) {
+	if len(e.providers) == 0 {
+		return nil, ErrNoProvider
+	}
+	
+	ctx, cancel := context.WithTimeout(ctx, e.config.RequestTimeout)
+	defer cancel()
+	
+	if req.MinRecordingScore == 0 && e.config.MinRecordingScore > 0 {
+		withDefaults := *req
+		withDefaults.MinRecordingScore = e.config.MinRecordingScore
+		req = &withDefaults
+	}
+	
+	query := func(ctx context.Context, provider MetadataProvider) ([]*TrackMetadata, error) {
+		results, err := provider.LookupCandidates(ctx, req)
+		if err != nil {
+			return nil, wrapProviderError(provider, "lookup candidates", err)
+		}
+		return results, nil
+	}
+	
+	// Providers are asked concurrently; answers keep provider order
+	var candidates []*TrackMetadata
+	var errs []error
+	for _, answer := range e.queryProviders(ctx, query, nil) {
+		if answer.err != nil {
+			errs = append(errs, answer.err)
+			continue
+		}
+		candidates = append(candidates, answer.results...)
+	}
+	
+	if len(candidates) == 0 {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, ErrNotFound
+	}
+	
+	// Stable, so each provider's own ranking breaks confidence ties
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	if req.MaxResults > 0 && len(candidates) > req.MaxResults {
+		candidates = candidates[:req.MaxResults]
+	}
+	return candidates, nil
+}
+
+// SingleCandidate implements LookupCandidates for providers that return a
+// single match: the LookupWithHints result, if any, is the only candidate
+func SingleCandidate(ctx context.Context, provider MetadataProvider, req *SearchRequest) ([]*TrackMetadata, error) {
+	result, err := provider.LookupWithHints(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrNotFound
+	}
+	return []*TrackMetadata{result}, nil
+}
+
+// FetchArtwork fetches cover art from the provider that produced metadata
+func (e *Enricher) FetchArtwork(ctx context.Context, metadata *TrackMetadata) (*Artwork, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.config.RequestTimeout)
+	defer cancel()
+
+	for _, provider := range e.providers {
+		if provider.Name() != metadata.ProviderName {
+			continue
+		}
+		if artworkProvider, ok := provider.(ArtworkProvider); ok {
+			artwork, err := artworkProvider.FetchArtwork(ctx, metadata)
+			return artwork, wrapProviderError(provider, "fetch artwork", err)
+		}
+	}
+
+	return nil, ErrNotFound
 }
 
 // AddProvider adds a new provider to the enricher
@@ -259,17 +550,36 @@ func (e *Enricher) Close() error {
 	return lastErr
 }
 
-// Helper function to calculate confidence score based on completeness
-func CalculateConfidence(metadata *TrackMetadata, exactMatch bool) float64 {
+// MatchQuality describes how closely a provider's result matched the
+// requested artist and title
+type MatchQuality int
+
+const (
+	// MatchNone means neither artist nor title is even a close match
+	MatchNone MatchQuality = iota
+	// MatchClose means the artist or title is a close fuzzy match
+	MatchClose
+	// MatchExact means both artist and title match exactly
+	MatchExact
+)
+
+// maxMismatchConfidence caps the confidence of a MatchNone result. A
+// complete release (label, date, catalog number) for the wrong recording
+// must never look trustworthy enough to write.
+const maxMismatchConfidence = 0.4
+
+// CalculateConfidence scores a result by match quality and completeness
+func CalculateConfidence(metadata *TrackMetadata, quality MatchQuality) float64 {
 	confidence := 0.0
 	
 	// Base score for finding anything
 	confidence += 0.2
 	
 	// Exact vs fuzzy match bonus
-	if exactMatch {
+	switch quality {
+	case MatchExact:
 		confidence += 0.4
-	} else {
+	case MatchClose:
 		confidence += 0.2
 	}
 	
@@ -284,10 +594,13 @@ func CalculateConfidence(metadata *TrackMetadata, exactMatch bool) float64 {
 		confidence += 0.1
 	}
 	
-	// Cap at 1.0
+	// Cap at 1.0, or much lower when nothing actually matched
 	if confidence > 1.0 {
 		confidence = 1.0
 	}
+	if quality == MatchNone && confidence > maxMismatchConfidence {
+		confidence = maxMismatchConfidence
+	}
 	
 	return confidence
 }
\ No newline at end of file
@@ -0,0 +1,307 @@
+// pkg/enricher/enricher_test.go
+
+package enricher
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLookupBest_JoinsProviderErrors(t *testing.T) {
+	providers := []MetadataProvider{
+		NewFakeProvider("MusicBrainz", FakeResponse{Err: ErrRateLimit}),
+		NewFakeProvider("Discogs", FakeResponse{Err: ErrNotFound}),
+	}
+	e := NewEnricher(providers, &EnricherConfig{
+		Strategy:       StrategyBest,
+		MinConfidence:  0.7,
+		RequestTimeout: time.Second,
+	})
+
+	_, err := e.Lookup(context.Background(), "Artist", "Title")
+	if err == nil {
+		t.Fatal("Expected an error when every provider fails")
+	}
+
+	if !errors.Is(err, ErrRateLimit) {
+		t.Error("Expected the MusicBrainz rate-limit error to survive")
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("Expected the Discogs not-found error to survive")
+	}
+
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Error("Expected errors.As to find a ProviderError")
+	}
+}
+
+func TestLookupFirst_LowConfidenceReturnsNotFound(t *testing.T) {
+	providers := []MetadataProvider{
+		NewFakeProvider("MusicBrainz", FakeResponse{Result: &TrackMetadata{Confidence: 0.2, Candidates: 3}}),
+	}
+	e := NewEnricher(providers, nil)
+
+	_, err := e.Lookup(context.Background(), "Artist", "Title")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a low-confidence result, got %v", err)
+	}
+
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || notFound.Best == nil || notFound.Best.Confidence != 0.2 {
+		t.Fatalf("Expected the rejected match in a NotFoundError, got %v", err)
+	}
+	if got := notFound.Summary(); got != "3 candidates, best scored 0.20 (below 0.70)" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+}
+
+// newTestEnricher builds an enricher over providers with a short timeout
+func newTestEnricher(strategy ProviderStrategy, requireLabel bool, providers ...MetadataProvider) *Enricher {
+	return NewEnricher(providers, &EnricherConfig{
+		Strategy:       strategy,
+		MinConfidence:  0.7,
+		RequireLabel:   requireLabel,
+		RequestTimeout: time.Second,
+	})
+}
+
+func TestLookupFirst_StopsAtFirstAcceptableResult(t *testing.T) {
+	weak := NewFakeProvider("Weak", FakeResponse{Result: &TrackMetadata{Label: "Weak", Confidence: 0.5}})
+	good := NewFakeProvider("Good", FakeResponse{Result: &TrackMetadata{Label: "Good", Confidence: 0.8}})
+	better := NewFakeProvider("Better", FakeResponse{Result: &TrackMetadata{Label: "Better", Confidence: 0.95}})
+
+	result, err := newTestEnricher(StrategyFirst, false, weak, good, better).Lookup(context.Background(), "Artist", "Title")
+	if err != nil {
+		t.Fatalf("Lookup returned error: %v", err)
+	}
+	if result.ProviderName != "Good" {
+		t.Errorf("Expected the first result above MinConfidence, got %q", result.ProviderName)
+	}
+	if better.Calls() != 0 {
+		t.Errorf("Expected providers after a match not to be queried, got %d calls", better.Calls())
+	}
+}
+
+func TestLookupBest_PicksHighestConfidence(t *testing.T) {
+	providers := []MetadataProvider{
+		NewFakeProvider("Good", FakeResponse{Result: &TrackMetadata{Label: "Good", Confidence: 0.8}}),
+		NewFakeProvider("Failing", FakeResponse{Err: ErrRateLimit}),
+		NewFakeProvider("Better", FakeResponse{Result: &TrackMetadata{Label: "Better", Confidence: 0.95}}),
+	}
+
+	result, err := newTestEnricher(StrategyBest, false, providers...).Lookup(context.Background(), "Artist", "Title")
+	if err != nil {
+		t.Fatalf("Lookup returned error: %v", err)
+	}
+	if result.ProviderName != "Better" {
+		t.Errorf("Expected the highest-confidence result, got %q", result.ProviderName)
+	}
+}
+
+func TestLookupBest_QueriesProvidersConcurrently(t *testing.T) {
+	// Each provider waits for the other, so a sequential lookup would time out
+	started := make(chan struct{}, 2)
+	waitForBoth := func(result *TrackMetadata) func(*SearchRequest) (*TrackMetadata, error) {
+		return func(*SearchRequest) (*TrackMetadata, error) {
+			started <- struct{}{}
+			for len(started) < 2 {
+				time.Sleep(time.Millisecond)
+			}
+			return result, nil
+		}
+	}
+	first := &FakeProvider{ProviderName: "First", Respond: waitForBoth(&TrackMetadata{Label: "First", Confidence: 0.8})}
+	second := &FakeProvider{ProviderName: "Second", Respond: waitForBoth(&TrackMetadata{Label: "Second", Confidence: 0.9})}
+
+	result, err := newTestEnricher(StrategyBest, false, first, second).Lookup(context.Background(), "Artist", "Title")
+	if err != nil {
+		t.Fatalf("Lookup returned error: %v", err)
+	}
+	if result.ProviderName != "Second" {
+		t.Errorf("Expected the highest-confidence result, got %q", result.ProviderName)
+	}
+}
+
+// blockingProvider answers only when its context is done. Like a real
+// provider's rate limiter state, its fields aren't locked: a lookup still
+// running after Lookup returns shows up as a data race under -race.
+type blockingProvider struct {
+	*FakeProvider
+	returned int
+	lastErr  error
+}
+
+func (b *blockingProvider) LookupWithHints(ctx context.Context, req *SearchRequest) (*TrackMetadata, error) {
+	<-ctx.Done()
+	b.returned++
+	b.lastErr = ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestLookupBest_PerfectMatchCancelsOthers(t *testing.T) {
+	slow := &blockingProvider{FakeProvider: NewFakeProvider("Slow")}
+	perfect := NewFakeProvider("Perfect", FakeResponse{Result: &TrackMetadata{Label: "Perfect", Confidence: 1}})
+	e := newTestEnricher(StrategyBest, false, slow, perfect)
+
+	// Back to back, as for consecutive files in a batch
+	for i := 1; i <= 2; i++ {
+		start := time.Now()
+		result, err := e.Lookup(context.Background(), "Artist", "Title")
+		if err != nil {
+			t.Fatalf("Lookup returned error: %v", err)
+		}
+		if result.ProviderName != "Perfect" {
+			t.Errorf("Expected the perfect match, got %q", result.ProviderName)
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("Expected the lookup not to wait for the timeout, took %v", elapsed)
+		}
+		if slow.returned != i || !errors.Is(slow.lastErr, context.Canceled) {
+			t.Errorf("Expected the slow provider to be cancelled and finished before Lookup returned, got %d returns, %v", slow.returned, slow.lastErr)
+		}
+	}
+}
+
+func TestLookup_RequireLabel(t *testing.T) {
+	for _, strategy := range []ProviderStrategy{StrategyFirst, StrategyBest} {
+		t.Run(string(strategy), func(t *testing.T) {
+			unlabelled := NewFakeProvider("Unlabelled", FakeResponse{Result: &TrackMetadata{Confidence: 1}})
+			labelled := NewFakeProvider("Labelled", FakeResponse{Result: &TrackMetadata{Label: "Metalheadz", Confidence: 0.75}})
+
+			result, err := newTestEnricher(strategy, true, unlabelled, labelled).Lookup(context.Background(), "Artist", "Title")
+			if err != nil {
+				t.Fatalf("Lookup returned error: %v", err)
+			}
+			if result.ProviderName != "Labelled" {
+				t.Errorf("Expected results without a label to be skipped, got %q", result.ProviderName)
+			}
+		})
+	}
+}
+
+func TestLookup_MinConfidenceGate(t *testing.T) {
+	provider := NewFakeProvider("MusicBrainz", FakeResponse{Result: &TrackMetadata{Label: "Metalheadz", Confidence: 0.69}})
+
+	for _, strategy := range []ProviderStrategy{StrategyFirst, StrategyBest} {
+		_, err := newTestEnricher(strategy, false, provider).Lookup(context.Background(), "Artist", "Title")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound below MinConfidence, got %v", strategy, err)
+		}
+	}
+}
+
+func TestLookupFallback_SimplifiedSecondPass(t *testing.T) {
+	provider := NewFakeProvider("MusicBrainz",
+		FakeResponse{Err: ErrNotFound},
+		FakeResponse{Result: &TrackMetadata{Label: "Metalheadz", Confidence: 0.9}},
+	)
+	e := newTestEnricher(StrategyFallback, false, provider)
+
+	req := &SearchRequest{
+		Artist:                "Goldie",
+		Title:                 "Inner City Life",
+		Album:                 "Timeless",
+		Label:                 "FFRR",
+		Year:                  "1995",
+		PreferOriginalRelease: true,
+		MaxResults:            5,
+	}
+	result, err := e.LookupWithRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("LookupWithRequest returned error: %v", err)
+	}
+	if result.Label != "Metalheadz" {
+		t.Errorf("Expected the second-pass result, got %+v", result)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 lookups, got %d", len(requests))
+	}
+	if requests[0].Album != "Timeless" || requests[0].Label != "FFRR" {
+		t.Errorf("Expected the first pass to carry every hint, got %+v", requests[0])
+	}
+	second := requests[1]
+	if second.Artist != "Goldie" || second.Title != "Inner City Life" || !second.PreferOriginalRelease || second.MaxResults != 5 {
+		t.Errorf("Expected the second pass to keep artist, title and preferences, got %+v", second)
+	}
+	if second.Album != "" || second.Label != "" || second.Year != "" {
+		t.Errorf("Expected the second pass to drop hints, got %+v", second)
+	}
+}
+
+func TestLookupFallback_JoinsErrorsFromBothPasses(t *testing.T) {
+	provider := NewFakeProvider("MusicBrainz", FakeResponse{Err: ErrRateLimit}, FakeResponse{Err: ErrAPIError})
+
+	_, err := newTestEnricher(StrategyFallback, false, provider).Lookup(context.Background(), "Artist", "Title")
+	if !errors.Is(err, ErrRateLimit) || !errors.Is(err, ErrAPIError) {
+		t.Errorf("Expected errors from both passes, got %v", err)
+	}
+}
+
+func TestClose_ClosesEveryProvider(t *testing.T) {
+	first, second := NewFakeProvider("First"), NewFakeProvider("Second")
+	if err := newTestEnricher(StrategyFirst, false, first, second).Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if !first.Closed() || !second.Closed() {
+		t.Error("Expected every provider to be closed")
+	}
+}
+
+func TestLookupCandidatesWithRequest_MergesByConfidence(t *testing.T) {
+	providers := []MetadataProvider{
+		NewFakeProvider("Weak", FakeResponse{Result: &TrackMetadata{Label: "Weak", Confidence: 0.4}}),
+		NewFakeProvider("Failing", FakeResponse{Err: ErrRateLimit}),
+		NewFakeProvider("Strong", FakeResponse{Result: &TrackMetadata{Label: "Strong", Confidence: 0.9}}),
+		NewFakeProvider("Middling", FakeResponse{Result: &TrackMetadata{Label: "Middling", Confidence: 0.6}}),
+	}
+	e := newTestEnricher(StrategyFirst, true, providers...)
+
+	candidates, err := e.LookupCandidatesWithRequest(context.Background(), &SearchRequest{Artist: "Artist", Title: "Title", MaxResults: 2})
+	if err != nil {
+		t.Fatalf("LookupCandidatesWithRequest returned error: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("Expected MaxResults to cap candidates at 2, got %d", len(candidates))
+	}
+	if candidates[0].ProviderName != "Strong" || candidates[1].ProviderName != "Middling" {
+		t.Errorf("Expected candidates ordered by confidence, got %s then %s", candidates[0].ProviderName, candidates[1].ProviderName)
+	}
+}
+
+func TestLookupCandidatesWithRequest_AllFail(t *testing.T) {
+	e := newTestEnricher(StrategyFirst, false, NewFakeProvider("Failing", FakeResponse{Err: ErrRateLimit}), NewFakeProvider("Empty"))
+
+	_, err := e.LookupCandidatesWithRequest(context.Background(), &SearchRequest{Artist: "Artist", Title: "Title"})
+	if !errors.Is(err, ErrRateLimit) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected every provider error to be joined, got %v", err)
+	}
+}
+
+func TestCalculateConfidence_MismatchCap(t *testing.T) {
+	complete := &TrackMetadata{Label: "Metalheadz", ReleaseDate: "1994-11-21", CatalogNumber: "METH 001"}
+
+	testCases := []struct {
+		quality  MatchQuality
+		expected float64
+	}{
+		{MatchExact, 1.0},
+		{MatchClose, 0.8},
+		{MatchNone, maxMismatchConfidence},
+	}
+
+	for _, tc := range testCases {
+		if got := CalculateConfidence(complete, tc.quality); math.Abs(got-tc.expected) > 1e-9 {
+			t.Errorf("CalculateConfidence(quality %d) = %.2f, expected %.2f", tc.quality, got, tc.expected)
+		}
+	}
+
+	if got := CalculateConfidence(&TrackMetadata{}, MatchNone); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("Expected bare mismatch to score 0.2, got %.2f", got)
+	}
+}
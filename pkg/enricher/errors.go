@@ -0,0 +1,165 @@
+// pkg/enricher/errors.go - Provider error types
+
+package enricher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrorKind classifies why a provider call failed, so callers can react
+// without matching on error strings
+type ErrorKind int
+
+const (
+	// KindUnknown is any failure not covered below
+	KindUnknown ErrorKind = iota
+	// KindNotFound means the provider answered but had no match
+	KindNotFound
+	// KindRateLimited means the provider refused the request for now
+	// (HTTP 429, or 503 from MusicBrainz)
+	KindRateLimited
+	// KindNetwork is a failure to reach the provider or read its answer
+	KindNetwork
+	// KindBadResponse is an error status or a response that couldn't be
+	// parsed
+	KindBadResponse
+	// KindAuth means the provider rejected the credentials or user agent
+	KindAuth
+)
+
+// String returns the kind's name as used in reports
+func (k ErrorKind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindRateLimited:
+		return "rate_limited"
+	case KindNetwork:
+		return "network"
+	case KindBadResponse:
+		return "bad_response"
+	case KindAuth:
+		return "auth"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable reports whether a failure of this kind may succeed if the same
+// request is tried again shortly
+func (k ErrorKind) Retryable() bool {
+	return k == KindRateLimited || k == KindNetwork
+}
+
+// KindOf classifies err. A ProviderError's own Kind wins; otherwise the
+// common errors and network errors are recognised wherever they are
+// wrapped. Context cancellation and deadlines are the caller's doing, not
+// the provider's, and stay KindUnknown.
+func KindOf(err error) ErrorKind {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.Kind != KindUnknown {
+		return providerErr.Kind
+	}
+
+	var netErr net.Error
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return KindUnknown
+	case errors.Is(err, ErrNotFound):
+		return KindNotFound
+	case errors.Is(err, ErrRateLimit):
+		return KindRateLimited
+	case errors.Is(err, ErrNetwork), errors.As(err, &netErr):
+		return KindNetwork
+	case errors.Is(err, ErrAuth):
+		return KindAuth
+	case errors.Is(err, ErrAPIError):
+		return KindBadResponse
+	}
+	return KindUnknown
+}
+
+// ProviderError records which provider and operation produced an error.
+// Use errors.As to recover it from an enricher error, and errors.Is to test
+// the underlying cause (e.g. ErrNotFound).
+type ProviderError struct {
+	Provider string    // Provider display name, e.g. "MusicBrainz"
+	Op       string    // Operation that failed, e.g. "lookup"
+	Kind     ErrorKind // Why it failed; see KindOf
+	Err      error     // Underlying error
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Provider, e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// wrapProviderError wraps err with the provider's name and the operation.
+// An error the provider already reported as a ProviderError is returned
+// as it is, since it names a more specific operation.
+func wrapProviderError(provider MetadataProvider, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if providerErr, ok := err.(*ProviderError); ok && providerErr.Provider == provider.Name() {
+		return err
+	}
+	return &ProviderError{Provider: provider.Name(), Op: op, Kind: KindOf(err), Err: err}
+}
+
+// NotFoundError is ErrNotFound with what the lookup saw: how many
+// candidates the search returned and why the best of them was rejected.
+// errors.Is(err, ErrNotFound) holds for it; use errors.As to read it. A
+// plain ErrNotFound means the search returned nothing at all.
+type NotFoundError struct {
+	Candidates int            // Search results considered; 0 if unknown
+	Best       *TrackMetadata // Best rejected match, or nil if none got that far
+	Reason     string         // Why it was rejected, e.g. "best scored 0.45 (below 0.70)"
+}
+
+// Error implements the error interface
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrNotFound, e.Summary())
+}
+
+// Is makes errors.Is(err, ErrNotFound) hold
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// Summary describes the miss, e.g. "3 candidates, best scored 0.45 (below
+// 0.70)"
+func (e *NotFoundError) Summary() string {
+	switch e.Candidates {
+	case 0:
+		return e.Reason
+	case 1:
+		return "1 candidate, " + e.Reason
+	}
+	return fmt.Sprintf("%d candidates, %s", e.Candidates, e.Reason)
+}
+
+// IsNotFound reports whether err means nothing was found and nothing else
+// went wrong: errors.Is(err, ErrNotFound) holds for every error joined into
+// it. A miss from one provider alongside a network failure from another
+// may well succeed next time, so it mustn't be cached as not found.
+func IsNotFound(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		for _, e := range errs {
+			if !IsNotFound(e) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+	return errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,108 @@
+// pkg/enricher/errors_test.go
+
+package enricher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestProviderError_Unwrap(t *testing.T) {
+	var err error = &ProviderError{Provider: "MusicBrainz", Op: "lookup", Err: ErrRateLimit}
+
+	if !errors.Is(err, ErrRateLimit) {
+		t.Error("Expected errors.Is to find the wrapped ErrRateLimit")
+	}
+
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatal("Expected errors.As to find the ProviderError")
+	}
+	if providerErr.Provider != "MusicBrainz" {
+		t.Errorf("Expected provider 'MusicBrainz', got '%s'", providerErr.Provider)
+	}
+
+	if err.Error() != "MusicBrainz lookup: rate limit exceeded" {
+		t.Errorf("Unexpected error string: %s", err.Error())
+	}
+}
+
+func TestProviderError_ContextErrorsStillMatch(t *testing.T) {
+	err := &ProviderError{Provider: "MusicBrainz", Op: "lookup", Err: context.DeadlineExceeded}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Expected context.DeadlineExceeded to remain detectable")
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected ErrorKind
+	}{
+		{"nil", nil, KindUnknown},
+		{"not found", ErrNotFound, KindNotFound},
+		{"wrapped rate limit", fmt.Errorf("%w: status 503", ErrRateLimit), KindRateLimited},
+		{"network sentinel", fmt.Errorf("%w: connection reset", ErrNetwork), KindNetwork},
+		{"net error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, KindNetwork},
+		{"auth", ErrAuth, KindAuth},
+		{"bad response", fmt.Errorf("%w: invalid JSON", ErrAPIError), KindBadResponse},
+		{"deadline", context.DeadlineExceeded, KindUnknown},
+		{"provider kind wins", &ProviderError{Provider: "MusicBrainz", Kind: KindAuth, Err: ErrAPIError}, KindAuth},
+		{"joined", errors.Join(errors.New("external: exit 1"), ErrNotFound), KindNotFound},
+		{"other", errors.New("boom"), KindUnknown},
+	}
+
+	for _, tc := range testCases {
+		if got := KindOf(tc.err); got != tc.expected {
+			t.Errorf("%s: KindOf() = %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+
+	if !KindRateLimited.Retryable() || !KindNetwork.Retryable() || KindNotFound.Retryable() || KindBadResponse.Retryable() {
+		t.Error("Expected only rate limiting and network failures to be retryable")
+	}
+}
+
+func TestWrapProviderError_Classifies(t *testing.T) {
+	provider := NewFakeProvider("MusicBrainz")
+
+	err := wrapProviderError(provider, "lookup", fmt.Errorf("%w: status 429", ErrRateLimit))
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Kind != KindRateLimited {
+		t.Fatalf("Expected a rate limited ProviderError, got %#v", err)
+	}
+
+	// A provider's own ProviderError keeps its more specific operation
+	inner := &ProviderError{Provider: "MusicBrainz", Op: "recording search", Kind: KindNetwork, Err: ErrNetwork}
+	if got := wrapProviderError(provider, "lookup", inner); got != inner {
+		t.Errorf("Expected the provider's error unchanged, got %v", got)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	miss := &ProviderError{Provider: "MusicBrainz", Op: "lookup", Kind: KindNotFound, Err: &NotFoundError{Reason: "best scored 0.45 (below 0.70)"}}
+	failure := &ProviderError{Provider: "Deezer", Op: "lookup", Kind: KindNetwork, Err: ErrNetwork}
+
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{ErrNotFound, true},
+		{miss, true},
+		{errors.Join(miss, ErrNotFound), true},
+		{errors.Join(errors.Join(miss), ErrNotFound), true},
+		{errors.Join(miss, failure), false},
+		{failure, false},
+		{nil, false},
+	}
+	for _, tc := range testCases {
+		if got := IsNotFound(tc.err); got != tc.expected {
+			t.Errorf("IsNotFound(%v) = %t, expected %t", tc.err, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// pkg/enricher/external.go - Provider backed by an external program
+
+package enricher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ExternalProvider runs an external program for each lookup, so providers
+// can be added without recompiling.
+//
+// The protocol: the program receives an ExternalRequest as JSON on stdin and
+// writes JSON to stdout, then exits 0. The reply is a TrackMetadata object,
+// an array of them (best first) for candidate lookups, or null or nothing
+// when there is no match. A non-zero exit is a failure; its stderr is
+// included in the error. The program is killed if the lookup's context
+// ends first.
+type ExternalProvider struct {
+	name    string
+	command string
+	args    []string
+}
+
+// ExternalRequest is the JSON an ExternalProvider writes to the program's
+// stdin
+type ExternalRequest struct {
+	Artist                string `json:"artist"`
+	Title                 string `json:"title"`
+	Album                 string `json:"album,omitempty"`
+	Label                 string `json:"label,omitempty"`
+	Genre                 string `json:"genre,omitempty"`
+	Year                  string `json:"year,omitempty"`
+	DurationMS            int64  `json:"duration_ms,omitempty"`
+	PreferOriginalRelease bool   `json:"prefer_original_release,omitempty"`
+	PreferredFormat       string `json:"preferred_format,omitempty"`
+	MaxResults            int    `json:"max_results,omitempty"`
+	RecordingID           string `json:"recording_id,omitempty"`
+	ISRC                  string `json:"isrc,omitempty"`
+}
+
+// externalWaitDelay bounds how long a killed program's output pipes may
+// stay open, e.g. when it has started children of its own
+const externalWaitDelay = time.Second
+
+// NewExternalProvider creates a provider that runs command with args for
+// each lookup. name is used in results and errors.
+func NewExternalProvider(name, command string, args ...string) *ExternalProvider {
+	return &ExternalProvider{name: name, command: command, args: args}
+}
+
+// Name returns the configured display name
+func (p *ExternalProvider) Name() string {
+	return p.name
+}
+
+// Lookup searches for track metadata by artist and title
+func (p *ExternalProvider) Lookup(ctx context.Context, artist, title string) (*TrackMetadata, error) {
+	return p.LookupWithHints(ctx, &SearchRequest{Artist: artist, Title: title})
+}
+
+// LookupWithHints runs the program and returns its best match
+func (p *ExternalProvider) LookupWithHints(ctx context.Context, req *SearchRequest) (*TrackMetadata, error) {
+	candidates, err := p.LookupCandidates(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return candidates[0], nil
+}
+
+// LookupCandidates runs the program and returns every match it reports
+func (p *ExternalProvider) LookupCandidates(ctx context.Context, req *SearchRequest) ([]*TrackMetadata, error) {
+	input, err := json.Marshal(ExternalRequest{
+		Artist:                req.Artist,
+		Title:                 req.Title,
+		Album:                 req.Album,
+		Label:                 req.Label,
+		Genre:                 req.Genre,
+		Year:                  req.Year,
+		DurationMS:            req.Duration.Milliseconds(),
+		PreferOriginalRelease: req.PreferOriginalRelease,
+		PreferredFormat:       req.PreferredFormat,
+		MaxResults:            req.MaxResults,
+		RecordingID:           req.RecordingID,
+		ISRC:                  req.ISRC,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := p.run(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := decodeExternalReply(output)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s returned invalid JSON: %v", ErrAPIError, p.command, err)
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+
+	for _, candidate := range candidates {
+		if candidate.ProviderName == "" {
+			candidate.ProviderName = p.name
+		}
+	}
+	return candidates, nil
+}
+
+// run executes the program with input on stdin and returns its stdout
+func (p *ExternalProvider) run(ctx context.Context, input []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, p.command, p.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = externalWaitDelay
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// Report a timeout or cancellation rather than "signal: killed"
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("%w: %s exited with code %d: %s", ErrAPIError, p.command, exitErr.ExitCode(), msg)
+			}
+			return nil, fmt.Errorf("%w: %s exited with code %d", ErrAPIError, p.command, exitErr.ExitCode())
+		}
+		return nil, fmt.Errorf("failed to run %s: %w", p.command, err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// decodeExternalReply accepts a single object, an array of objects, null,
+// or empty output
+func decodeExternalReply(output []byte) ([]*TrackMetadata, error) {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 || bytes.Equal(output, []byte("null")) {
+		return nil, nil
+	}
+
+	if output[0] == '[' {
+		var candidates []*TrackMetadata
+		if err := json.Unmarshal(output, &candidates); err != nil {
+			return nil, err
+		}
+
+		// Drop null entries
+		kept := candidates[:0]
+		for _, candidate := range candidates {
+			if candidate != nil {
+				kept = append(kept, candidate)
+			}
+		}
+		return kept, nil
+	}
+
+	var metadata TrackMetadata
+	if err := json.Unmarshal(output, &metadata); err != nil {
+		return nil, err
+	}
+	return []*TrackMetadata{&metadata}, nil
+}
+
+// SupportsGenre reports true; the program decides what it can answer
+func (p *ExternalProvider) SupportsGenre(genre string) bool {
+	return true
+}
+
+// RateLimit reports no rate limit, since lookups run locally. Programs that
+// call remote services must pace themselves.
+func (p *ExternalProvider) RateLimit() RateLimitInfo {
+	return RateLimitInfo{}
+}
+
+// Close has nothing to clean up; each lookup is its own process
+func (p *ExternalProvider) Close() error {
+	return nil
+}
@@ -0,0 +1,115 @@
+// pkg/enricher/external_test.go
+
+package enricher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExternalHelperProcess is the external program used by the tests
+// below. It only runs when invoked by newHelperProvider.
+func TestExternalHelperProcess(t *testing.T) {
+	mode := os.Getenv("TAGGER_EXTERNAL_HELPER")
+	if mode == "" {
+		return
+	}
+
+	var req ExternalRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintf(os.Stderr, "bad request: %v", err)
+		os.Exit(2)
+	}
+
+	switch mode {
+	case "match":
+		fmt.Printf(`{"artist": %q, "title": %q, "label": "Metalheadz", "year": 1995, "confidence": 0.9}`, req.Artist, req.Title)
+	case "candidates":
+		fmt.Print(`[{"label": "First", "confidence": 0.9}, null, {"label": "Second", "confidence": 0.6}]`)
+	case "echo":
+		json.NewEncoder(os.Stdout).Encode(TrackMetadata{Album: req.Album, Year: int(req.DurationMS), Confidence: 1})
+	case "none":
+		fmt.Print("null")
+	case "fail":
+		fmt.Fprint(os.Stderr, "database offline")
+		os.Exit(3)
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+// newHelperProvider returns an ExternalProvider running
+// TestExternalHelperProcess in the given mode
+func newHelperProvider(t *testing.T, mode string) *ExternalProvider {
+	t.Setenv("TAGGER_EXTERNAL_HELPER", mode)
+	return NewExternalProvider("Helper", os.Args[0], "-test.run=^TestExternalHelperProcess$")
+}
+
+func TestExternalProvider_Match(t *testing.T) {
+	result, err := newHelperProvider(t, "match").Lookup(context.Background(), "Goldie", "Inner City Life")
+	if err != nil {
+		t.Fatalf("Lookup returned error: %v", err)
+	}
+	if result.Artist != "Goldie" || result.Label != "Metalheadz" || result.Year != 1995 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if result.ProviderName != "Helper" {
+		t.Errorf("Expected ProviderName to default to 'Helper', got '%s'", result.ProviderName)
+	}
+}
+
+func TestExternalProvider_SendsHints(t *testing.T) {
+	req := &SearchRequest{Artist: "Goldie", Title: "Angel", Album: "Timeless", Duration: 1500 * time.Millisecond}
+	result, err := newHelperProvider(t, "echo").LookupWithHints(context.Background(), req)
+	if err != nil {
+		t.Fatalf("LookupWithHints returned error: %v", err)
+	}
+	if result.Album != "Timeless" || result.Year != 1500 {
+		t.Errorf("Expected album and duration to reach the program, got %+v", result)
+	}
+}
+
+func TestExternalProvider_Candidates(t *testing.T) {
+	candidates, err := newHelperProvider(t, "candidates").LookupCandidates(context.Background(), &SearchRequest{Artist: "A", Title: "T"})
+	if err != nil {
+		t.Fatalf("LookupCandidates returned error: %v", err)
+	}
+	if len(candidates) != 2 || candidates[0].Label != "First" || candidates[1].Label != "Second" {
+		t.Errorf("Expected two candidates in order, got %+v", candidates)
+	}
+}
+
+func TestExternalProvider_NotFound(t *testing.T) {
+	_, err := newHelperProvider(t, "none").Lookup(context.Background(), "A", "T")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a null reply, got %v", err)
+	}
+}
+
+func TestExternalProvider_Failure(t *testing.T) {
+	_, err := newHelperProvider(t, "fail").Lookup(context.Background(), "A", "T")
+	if !errors.Is(err, ErrAPIError) || !strings.Contains(err.Error(), "database offline") {
+		t.Errorf("Expected ErrAPIError carrying stderr, got %v", err)
+	}
+}
+
+func TestExternalProvider_KilledOnTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := newHelperProvider(t, "hang").Lookup(ctx, "A", "T")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the program to be killed promptly, took %s", elapsed)
+	}
+}
@@ -0,0 +1,124 @@
+// pkg/enricher/fake.go - Scriptable provider for tests
+
+package enricher
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeResponse is one scripted reply from a FakeProvider
+type FakeResponse struct {
+	Result *TrackMetadata
+	Err    error
+}
+
+// FakeProvider is a MetadataProvider with scripted replies, for testing
+// code built on the enricher without touching the network.
+//
+// Each lookup is answered by Respond when set. Otherwise replies are taken
+// from Responses in order, with the last one repeated once the script runs
+// out; an empty script answers ErrNotFound. Returned metadata is a copy
+// with ProviderName filled in, so callers may modify it freely.
+type FakeProvider struct {
+	ProviderName string
+	Responses    []FakeResponse
+	Respond      func(req *SearchRequest) (*TrackMetadata, error)
+
+	mu       sync.Mutex
+	requests []SearchRequest
+	closed   bool
+}
+
+// NewFakeProvider creates a FakeProvider answering with responses in order
+func NewFakeProvider(name string, responses ...FakeResponse) *FakeProvider {
+	return &FakeProvider{ProviderName: name, Responses: responses}
+}
+
+// Name returns the configured provider name
+func (f *FakeProvider) Name() string {
+	return f.ProviderName
+}
+
+// Lookup answers a plain artist/title lookup from the script
+func (f *FakeProvider) Lookup(ctx context.Context, artist, title string) (*TrackMetadata, error) {
+	return f.LookupWithHints(ctx, &SearchRequest{Artist: artist, Title: title})
+}
+
+// LookupWithHints records req and answers from the script. A cancelled
+// context is reported before the script is consulted.
+func (f *FakeProvider) LookupWithHints(ctx context.Context, req *SearchRequest) (*TrackMetadata, error) {
+	f.mu.Lock()
+	call := len(f.requests)
+	f.requests = append(f.requests, *req)
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var result *TrackMetadata
+	var err error
+	switch {
+	case f.Respond != nil:
+		result, err = f.Respond(req)
+	case len(f.Responses) == 0:
+		err = ErrNotFound
+	default:
+		reply := f.Responses[min(call, len(f.Responses)-1)]
+		result, err = reply.Result, reply.Err
+	}
+
+	if result != nil {
+		copied := *result
+		if copied.ProviderName == "" {
+			copied.ProviderName = f.ProviderName
+		}
+		result = &copied
+	}
+	return result, err
+}
+
+// LookupCandidates answers with the single scripted result
+func (f *FakeProvider) LookupCandidates(ctx context.Context, req *SearchRequest) ([]*TrackMetadata, error) {
+	return SingleCandidate(ctx, f, req)
+}
+
+// Requests returns a copy of every request received so far, in order
+func (f *FakeProvider) Requests() []SearchRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SearchRequest(nil), f.requests...)
+}
+
+// Calls returns how many lookups have been made
+func (f *FakeProvider) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+// Closed reports whether Close has been called
+func (f *FakeProvider) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// SupportsGenre always reports true
+func (f *FakeProvider) SupportsGenre(genre string) bool {
+	return true
+}
+
+// RateLimit reports no rate limit
+func (f *FakeProvider) RateLimit() RateLimitInfo {
+	return RateLimitInfo{}
+}
+
+// Close marks the provider closed
+func (f *FakeProvider) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
@@ -4,38 +4,210 @@ package musicbrainz
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/cerberussg/tagger/pkg/enricher"
+	"github.com/cerberussg/tagger/pkg/normalize"
 )
 
 const (
-	baseURL     = "https://musicbrainz.org/ws/2"
+	defaultBaseURL = "https://musicbrainz.org/ws/2"
+	coverArtURL = "https://coverartarchive.org"
 	userAgent   = "tagger/0.1.0 (https://github.com/cerberussg/tagger)"
-	rateLimit   = time.Second // 1 request per second
+
+	// defaultRequestsPerSecond is the public web service's limit
+	defaultRequestsPerSecond = 1.0
+
+	// maxArtworkSize caps a cover art download. The Cover Art Archive serves
+	// the original upload, which for a high-resolution scan can run to
+	// several megabytes.
+	maxArtworkSize = 32 << 20
 )
 
 // MusicBrainzProvider implements the MetadataProvider interface for MusicBrainz
 type MusicBrainzProvider struct {
 	client      *http.Client
+	baseURL     string
 	userAgent   string
+	budget      *enricher.CallBudget
 	lastRequest time.Time
+
+	// requestsPerSecond paces requests; mirrors and authenticated clients
+	// may be allowed more than the public default
+	requestsPerSecond float64
+
+	// Per-phase limits; the recording search also leaves lookupTimeout
+	// on the caller's deadline for the release lookup
+	searchTimeout time.Duration
+	lookupTimeout time.Duration
+
+	// weights rank recordings on top of their search score
+	weights MatchWeights
+
+	// query builds the recording search; nil uses DefaultQueryTemplate
+	query *template.Template
+
+	// country is the ISO 3166-1 code whose release events date a release
+	// with no date of its own, or "" for the earliest anywhere
+	country string
+
+	// yearSource picks the date a match's year is taken from
+	yearSource YearSource
+
+	// logger reports suspect responses, such as recordings with no
+	// artist credit; it discards output unless WithLogger is used
+	logger *log.Logger
+}
+
+// Option configures optional MusicBrainzProvider settings
+type Option func(*MusicBrainzProvider)
+
+// WithUserAgent overrides the User-Agent header sent with every request.
+// MusicBrainz asks that clients identify themselves with contact details.
+func WithUserAgent(ua string) Option {
+	return func(m *MusicBrainzProvider) {
+		if ua != "" {
+			m.userAgent = ua
+		}
+	}
+}
+
+// WithBaseURL points the provider at a different web service root, such as
+// a local MusicBrainz mirror or a test server
+func WithBaseURL(u string) Option {
+	return func(m *MusicBrainzProvider) {
+		if u != "" {
+			m.baseURL = strings.TrimRight(u, "/")
+		}
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, e.g. to route requests
+// through a specific proxy or tune timeouts
+func WithHTTPClient(client *http.Client) Option {
+	return func(m *MusicBrainzProvider) {
+		if client != nil {
+			m.client = client
+		}
+	}
+}
+
+// WithPhaseTimeouts sets how long the recording search and the follow-up
+// release lookup may each take. Zero keeps the default for that phase.
+func WithPhaseTimeouts(search, lookup time.Duration) Option {
+	return func(m *MusicBrainzProvider) {
+		if search > 0 {
+			m.searchTimeout = search
+		}
+		if lookup > 0 {
+			m.lookupTimeout = lookup
+		}
+	}
+}
+
+// WithLogger sends notes about suspect MusicBrainz data to logger
+func WithLogger(logger *log.Logger) Option {
+	return func(m *MusicBrainzProvider) {
+		if logger != nil {
+			m.logger = logger
+		}
+	}
+}
+
+// WithCallBudget charges every search, release lookup and artwork request
+// (including retries) against budget, failing with
+// enricher.ErrBudgetExhausted once it runs out
+func WithCallBudget(budget *enricher.CallBudget) Option {
+	return func(m *MusicBrainzProvider) {
+		m.budget = budget
+	}
+}
+
+// WithRateLimit sets how many requests per second the provider may make.
+// The public web service allows 1; raise it only for a mirror or an
+// arrangement that permits more. Rates that aren't positive are ignored.
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(m *MusicBrainzProvider) {
+		if requestsPerSecond > 0 {
+			m.requestsPerSecond = requestsPerSecond
+		}
+	}
+}
+
+// WithPreferredCountry dates releases that have no date of their own by
+// their earliest release event in country, an ISO 3166-1 code such as "GB",
+// before falling back to their earliest event anywhere
+func WithPreferredCountry(country string) Option {
+	return func(m *MusicBrainzProvider) {
+		m.country = strings.ToUpper(strings.TrimSpace(country))
+	}
+}
+
+// YearSource is where a match's year comes from
+type YearSource string
+
+const (
+	// YearFromReleaseGroup uses the first release date of the matched
+	// release's release group, which a reissue or a promo dated by
+	// mistake doesn't change, falling back to the release's own date
+	YearFromReleaseGroup YearSource = "release_group"
+
+	// YearFromRelease uses the matched release's own date
+	YearFromRelease YearSource = "release"
+)
+
+// WithYearSource sets where a match's year comes from; the default is
+// YearFromReleaseGroup. Unknown sources are ignored.
+func WithYearSource(source YearSource) Option {
+	return func(m *MusicBrainzProvider) {
+		if source == YearFromReleaseGroup || source == YearFromRelease {
+			m.yearSource = source
+		}
+	}
 }
 
 // NewMusicBrainzProvider creates a new MusicBrainz metadata provider
-func NewMusicBrainzProvider() *MusicBrainzProvider {
-	return &MusicBrainzProvider{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		userAgent: userAgent,
+func NewMusicBrainzProvider(opts ...Option) *MusicBrainzProvider {
+	m := &MusicBrainzProvider{
+		client:            newDefaultHTTPClient(),
+		baseURL:           defaultBaseURL,
+		userAgent:         userAgent,
+		requestsPerSecond: defaultRequestsPerSecond,
+		searchTimeout:     defaultSearchTimeout,
+		lookupTimeout:     defaultLookupTimeout,
+		weights:           DefaultMatchWeights(),
+		yearSource:        YearFromReleaseGroup,
+		logger:            log.New(io.Discard, "", 0),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// newDefaultHTTPClient returns a client with a 30s timeout that honours
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func newDefaultHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
 	}
 }
 
@@ -57,42 +229,319 @@ func (m *MusicBrainzProvider) Lookup(ctx context.Context, artist, title string)
 
 // LookupWithHints performs advanced search with additional parameters
 func (m *MusicBrainzProvider) LookupWithHints(ctx context.Context, req *enricher.SearchRequest) (*enricher.TrackMetadata, error) {
-	// Rate limiting - ensure we don't exceed 1 req/sec
-	if err := m.waitForRateLimit(ctx); err != nil {
-		return nil, err
+	// A recording ID from a previous run skips the search. IDs MusicBrainz
+	// no longer knows (merged or deleted recordings) fall back to it.
+	if req.RecordingID != "" && mbidPattern.MatchString(strings.TrimSpace(req.RecordingID)) {
+		metadata, err := m.lookupByID(ctx, req.RecordingID, req.PreferredFormat, req.AllowBootleg)
+		if err == nil && metadata.Artist == "" {
+			metadata.Artist = req.Artist // Uncredited recording; keep the file's artist
+		}
+		if !errors.Is(err, enricher.ErrNotFound) {
+			return metadata, err
+		}
 	}
 
-	// Search for recordings with release information included
-	recordings, err := m.searchRecordings(ctx, req)
-	if err != nil {
-		// Preserve context errors without wrapping
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
+	// An ISRC names the recording too. Codes MusicBrainz doesn't know, and
+	// malformed ones, fall back to the search.
+	if isrc := normalizeISRC(req.ISRC); isrc != "" {
+		metadata, err := m.lookupByISRC(ctx, isrc, req)
+		if !errors.Is(err, enricher.ErrNotFound) {
+			return metadata, err
 		}
-		return nil, fmt.Errorf("musicbrainz recording search failed: %w", err)
 	}
 
-	if len(recordings) == 0 {
-		return nil, enricher.ErrNotFound
+	recordings, err := m.searchCandidates(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get the best recording match
-	bestRecording := m.findBestRecordingMatch(recordings, req.Artist, req.Title)
+	bestRecording := m.findBestRecordingMatch(recordings, req.Artist, req.Title, req.Duration)
 	if bestRecording == nil {
-		return nil, enricher.ErrNotFound
+		return nil, &enricher.NotFoundError{Candidates: len(recordings), Reason: "none matched the artist and title"}
+	}
+
+	// A low-scoring best match is more likely wrong than right
+	if req.AbandonBelowScore > 0 && bestRecording.Score < req.AbandonBelowScore {
+		return nil, &enricher.NotFoundError{
+			Candidates: len(recordings),
+			Reason:     fmt.Sprintf("best search score %d (below %d)", bestRecording.Score, req.AbandonBelowScore),
+		}
 	}
 
 	// Find the best release from the recording's releases
-	bestRelease := m.findBestRelease(bestRecording.Releases, req.PreferOriginalRelease)
+	releases := preferReleasesWithTrack(bestRecording.Releases, bestRecording)
+	releases = preferYearRange(releases, req.MinYear, req.MaxYear)
+	releases = preferHintedReleases(preferCatalogNumber(releases, req.CatalogNumber), req.Label, req.Year)
+	releases = preferOfficialReleases(releases, req.AllowBootleg)
+	bestRelease := m.findBestRelease(releases, req.PreferOriginalRelease, req.PreferredFormat)
 	if bestRelease == nil {
-		return nil, enricher.ErrNotFound
+		return nil, &enricher.NotFoundError{Candidates: len(recordings), Reason: "best match has no releases"}
+	}
+
+	// Search results often omit label info. Fetch it in its own phase; if
+	// that fails, the search result is still worth returning.
+	if !hasLabel(*bestRelease) && bestRelease.ID != "" {
+		lookupCtx, cancel := context.WithTimeout(ctx, phaseTimeout(ctx, m.lookupTimeout, 0))
+		if detail, err := m.lookupRelease(lookupCtx, bestRelease.ID); err == nil {
+			withDetail := *bestRelease
+			withDetail.LabelInfo = detail.LabelInfo
+			if len(withDetail.Media) == 0 {
+				withDetail.Media = detail.Media
+			}
+			if releaseDate(withDetail, "") == "" {
+				withDetail.Date, withDetail.ReleaseEvents = detail.Date, detail.ReleaseEvents
+			}
+			if withDetail.ReleaseGroup.FirstReleaseDate == "" {
+				withDetail.ReleaseGroup.FirstReleaseDate = detail.ReleaseGroup.FirstReleaseDate
+			}
+			bestRelease = &withDetail
+		}
+		cancel()
 	}
 
 	// Convert to our standard format
 	metadata := m.convertToTrackMetadata(bestRecording, bestRelease, req.Artist, req.Title)
+	metadata.Label, metadata.CatalogNumber = releaseLabel(*bestRelease, req.Label, req.CatalogNumber)
+	metadata.Candidates = len(recordings)
+	return metadata, nil
+}
+
+// LookupCandidates returns up to req.MaxResults matching recordings as
+// metadata, best match first. Releases are chosen as in LookupWithHints but
+// from search data only: no follow-up release lookups are made, so labels
+// may be missing and confidences lower than LookupWithHints would report.
+func (m *MusicBrainzProvider) LookupCandidates(ctx context.Context, req *enricher.SearchRequest) ([]*enricher.TrackMetadata, error) {
+	recordings, err := m.searchCandidates(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*enricher.TrackMetadata
+	for _, recording := range rankRecordings(recordings, req.Artist, req.Title, req.Duration, m.weights) {
+		if req.AbandonBelowScore > 0 && recording.Score < req.AbandonBelowScore {
+			continue
+		}
+
+		releases := preferReleasesWithTrack(recording.Releases, recording)
+		releases = preferYearRange(releases, req.MinYear, req.MaxYear)
+		releases = preferHintedReleases(preferCatalogNumber(releases, req.CatalogNumber), req.Label, req.Year)
+		releases = preferOfficialReleases(releases, req.AllowBootleg)
+		release := m.findBestRelease(releases, req.PreferOriginalRelease, req.PreferredFormat)
+		if release == nil {
+			continue
+		}
+		candidate := m.convertToTrackMetadata(recording, release, req.Artist, req.Title)
+		candidate.Label, candidate.CatalogNumber = releaseLabel(*release, req.Label, req.CatalogNumber)
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) == 0 {
+		return nil, enricher.ErrNotFound
+	}
+	return candidates, nil
+}
+
+// mbidPattern matches a MusicBrainz identifier (a UUID)
+var mbidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ErrInvalidMBID is returned by LookupByID for malformed identifiers
+var ErrInvalidMBID = errors.New("invalid MusicBrainz ID")
+
+// LookupByID fetches a recording by its MusicBrainz ID, with no fuzzy
+// search. The original release is chosen as in LookupWithHints and its
+// label info fetched. Artist and title come from MusicBrainz, and the
+// confidence is 1.0 since the recording is known.
+func (m *MusicBrainzProvider) LookupByID(ctx context.Context, recordingID string) (*enricher.TrackMetadata, error) {
+	return m.lookupByID(ctx, recordingID, "", false)
+}
+
+// lookupByID is LookupByID with a preferred release format, optionally
+// letting unofficial releases compete
+func (m *MusicBrainzProvider) lookupByID(ctx context.Context, recordingID, preferredFormat string, allowBootleg bool) (*enricher.TrackMetadata, error) {
+	recordingID = strings.TrimSpace(recordingID)
+	if !mbidPattern.MatchString(recordingID) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMBID, recordingID)
+	}
+
+	detail, err := m.lookupRecording(ctx, recordingID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, enricher.ErrNotFound) || errors.Is(err, enricher.ErrBudgetExhausted) {
+			return nil, err
+		}
+		return nil, m.providerError("recording lookup", err)
+	}
+
+	recording := &Recording{
+		ID:             detail.ID,
+		Title:          detail.Title,
+		Length:         detail.Length,
+		Score:          100,
+		ArtistCredit:   detail.ArtistCredit,
+		Releases:       detail.Releases,
+		Disambiguation: detail.Disambiguation,
+	}
+
+	releases := preferOfficialReleases(preferReleasesWithTrack(recording.Releases, recording), allowBootleg)
+	release := m.findBestRelease(releases, true, preferredFormat)
+	if release == nil {
+		release = &Release{} // A standalone recording still has artist and title
+	} else if release.ID != "" {
+		if withLabels, err := m.lookupRelease(ctx, release.ID); err == nil {
+			release = withLabels
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	artist := creditedArtist(recording.ArtistCredit)
+	if artist == "" {
+		m.logger.Printf("musicbrainz: recording %s (%q) has no artist credit", recording.ID, recording.Title)
+	}
+	metadata := m.convertToTrackMetadata(recording, release, artist, recording.Title)
+	metadata.Confidence = 1.0
+	return metadata, nil
+}
+
+// isrcPattern matches an ISRC: country, registrant, year and designation
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+
+// isrcConfidence is the confidence of a match found by ISRC. Codes are
+// occasionally reused or mistyped, so it stays just short of an ID match.
+const isrcConfidence = 0.99
+
+// normalizeISRC uppercases an ISRC and drops its hyphens, returning "" if
+// the result isn't a valid code
+func normalizeISRC(isrc string) string {
+	isrc = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(isrc), "-", ""))
+	if !isrcPattern.MatchString(isrc) {
+		return ""
+	}
+	return isrc
+}
+
+// lookupByISRC fetches the recording an ISRC was assigned to, with no
+// fuzzy search. A code shared by several recordings (remasters, duplicates
+// awaiting a merge) resolves to the one closest to the file.
+func (m *MusicBrainzProvider) lookupByISRC(ctx context.Context, isrc string, req *enricher.SearchRequest) (*enricher.TrackMetadata, error) {
+	result, err := m.lookupISRC(ctx, isrc)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, enricher.ErrNotFound) || errors.Is(err, enricher.ErrBudgetExhausted) {
+			return nil, err
+		}
+		return nil, m.providerError("isrc lookup", err)
+	}
+	if len(result.Recordings) == 0 {
+		return nil, enricher.ErrNotFound
+	}
+
+	recording := m.findBestRecordingMatch(result.Recordings, req.Artist, req.Title, req.Duration)
+	if recording == nil {
+		recording = &result.Recordings[0]
+	}
+
+	metadata, err := m.lookupByID(ctx, recording.ID, req.PreferredFormat, req.AllowBootleg)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Artist == "" {
+		metadata.Artist = req.Artist // Uncredited recording; keep the file's artist
+	}
+	metadata.Confidence = isrcConfidence
+	metadata.Extra["isrc"] = isrc
 	return metadata, nil
 }
 
+// hasArtistCredit reports whether a recording credits at least one named
+// artist. MusicBrainz occasionally returns an empty credit array.
+func hasArtistCredit(recording *Recording) bool {
+	return creditedArtist(recording.ArtistCredit) != ""
+}
+
+// creditedArtist renders an artist credit as displayed, e.g. "Goldie
+// presents Metalheadz"
+func creditedArtist(credits []ArtistCredit) string {
+	var b strings.Builder
+	for _, credit := range credits {
+		name := credit.Name
+		if name == "" {
+			name = credit.Artist.Name
+		}
+		b.WriteString(name)
+		b.WriteString(credit.Joinphrase)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// searchCandidates runs the recording search and drops low-relevance
+// results. It returns ErrNotFound when nothing is left.
+func (m *MusicBrainzProvider) searchCandidates(ctx context.Context, req *enricher.SearchRequest) ([]Recording, error) {
+	recordings, err := m.searchPass(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// An album hint from a file's tags may name a compilation or be
+	// misspelt; rather than miss the track, search again without it
+	withoutAlbum := *req
+	withoutAlbum.Album = ""
+	if len(recordings) == 0 && req.Album != "" {
+		if recordings, err = m.searchPass(ctx, &withoutAlbum, false); err != nil {
+			return nil, err
+		}
+	}
+
+	// Phrase quoting misses "Ni-Ten-Ichi-Ryu" for "Ni Ten Ichi Ryu" and
+	// any title with a word out of place. As a last resort, search the
+	// words unquoted and let ranking pick out the track.
+	if len(recordings) == 0 && looseQueryDiffers(req) {
+		if recordings, err = m.searchPass(ctx, &withoutAlbum, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(recordings) == 0 {
+		return nil, enricher.ErrNotFound
+	}
+
+	for _, recording := range recordings {
+		if !hasArtistCredit(&recording) {
+			m.logger.Printf("musicbrainz: recording %s (%q) has no artist credit; matching it on title alone", recording.ID, recording.Title)
+		}
+	}
+
+	return recordings, nil
+}
+
+// searchPass runs one recording search, strict or loose, and drops
+// results scoring below the request's minimum
+func (m *MusicBrainzProvider) searchPass(ctx context.Context, req *enricher.SearchRequest, loose bool) ([]Recording, error) {
+	// Search for recordings with release information included, leaving
+	// time on the caller's deadline for a follow-up release lookup
+	searchCtx, cancel := context.WithTimeout(ctx, phaseTimeout(ctx, m.searchTimeout, m.lookupTimeout))
+	recordings, err := m.searchRecordings(searchCtx, req, loose)
+	cancel()
+	if err != nil {
+		// Preserve context and budget errors without wrapping
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, enricher.ErrBudgetExhausted) {
+			return nil, err
+		}
+		return nil, m.providerError("recording search", err)
+	}
+
+	// Drop low-relevance candidates before they can win on bonuses
+	return filterByMinScore(recordings, req.MinRecordingScore), nil
+}
+
 // SupportsGenre indicates if MusicBrainz has good coverage for a genre
 func (m *MusicBrainzProvider) SupportsGenre(genre string) bool {
 	// MusicBrainz has good coverage for most genres, especially established ones
@@ -112,7 +561,7 @@ func (m *MusicBrainzProvider) SupportsGenre(genre string) bool {
 // RateLimit returns the provider's rate limiting info
 func (m *MusicBrainzProvider) RateLimit() enricher.RateLimitInfo {
 	return enricher.RateLimitInfo{
-		RequestsPerSecond: 1.0,
+		RequestsPerSecond: m.requestsPerSecond,
 		BurstAllowed:      1,
 		RequiresUserAgent: true,
 		RequiresAPIKey:    false,
@@ -125,11 +574,95 @@ func (m *MusicBrainzProvider) Close() error {
 	return nil
 }
 
-// waitForRateLimit enforces the 1 req/sec rate limit
+// FetchArtwork downloads the front cover for the release behind metadata
+// from the Cover Art Archive
+func (m *MusicBrainzProvider) FetchArtwork(ctx context.Context, metadata *enricher.TrackMetadata) (*enricher.Artwork, error) {
+	releaseID, _ := metadata.Extra["musicbrainz_release_id"].(string)
+	if releaseID == "" {
+		return nil, enricher.ErrNotFound
+	}
+
+	if err := m.budget.Take(); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/release/%s/front", coverArtURL, releaseID), nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("User-Agent", m.userAgent)
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, m.providerError("artwork download", fmt.Errorf("%w: http request failed: %w", enricher.ErrNetwork, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, enricher.ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, m.providerError("artwork download", statusError("cover art archive", resp.StatusCode))
+	}
+
+	data, err := enricher.ReadBody(resp.Body, maxArtworkSize)
+	if err != nil {
+		return nil, m.providerError("artwork download", err)
+	}
+
+	mimeType := resp.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	return &enricher.Artwork{MIMEType: mimeType, Data: data}, nil
+}
+
+// Ping performs a single lightweight request against the API to verify
+// connectivity. It respects the provider's rate limit.
+func (m *MusicBrainzProvider) Ping(ctx context.Context) error {
+	if err := m.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("query", "recording:ping")
+	params.Set("limit", "1")
+	params.Set("fmt", "json")
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/recording?%s", m.baseURL, params.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	httpReq.Header.Set("User-Agent", m.userAgent)
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return m.providerError("ping", fmt.Errorf("%w: http request failed: %w", enricher.ErrNetwork, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return m.providerError("ping", statusError("musicbrainz API", resp.StatusCode))
+	}
+
+	return nil
+}
+
+// waitForRateLimit spaces requests to the configured rate
 func (m *MusicBrainzProvider) waitForRateLimit(ctx context.Context) error {
+	interval := time.Duration(float64(time.Second) / m.requestsPerSecond)
 	elapsed := time.Since(m.lastRequest)
-	if elapsed < rateLimit {
-		waitTime := rateLimit - elapsed
+	if elapsed < interval {
+		waitTime := interval - elapsed
 		
 		select {
 		case <-time.After(waitTime):
@@ -144,172 +677,819 @@ func (m *MusicBrainzProvider) waitForRateLimit(ctx context.Context) error {
 }
 
 // searchRecordings searches MusicBrainz for recordings matching the criteria
-func (m *MusicBrainzProvider) searchRecordings(ctx context.Context, req *enricher.SearchRequest) ([]Recording, error) {
-	// Build search query
-	query := fmt.Sprintf(`artist:"%s" AND recording:"%s"`, req.Artist, req.Title)
-	
-	// Add additional hints if available
-	if req.Album != "" {
-		query += fmt.Sprintf(` AND release:"%s"`, req.Album)
-	}
-
+func (m *MusicBrainzProvider) searchRecordings(ctx context.Context, req *enricher.SearchRequest, loose bool) ([]Recording, error) {
 	// Prepare URL with release information included
 	params := url.Values{}
-	params.Set("query", query)
+	params.Set("query", m.recordingQuery(req, loose))
 	params.Set("limit", strconv.Itoa(req.MaxResults))
 	params.Set("fmt", "json")
-	params.Set("inc", "releases+labels") // Include release and label info in the response
+	params.Set("inc", "releases+labels+aliases") // Include release, label and artist alias info in the response
 	
-	searchURL := fmt.Sprintf("%s/recording?%s", baseURL, params.Encode())
+	searchURL := fmt.Sprintf("%s/recording?%s", m.baseURL, params.Encode())
 
-	// Make HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
-	if err != nil {
+	var searchResult RecordingSearchResult
+	if err := m.getJSON(ctx, searchURL, &searchResult); err != nil {
 		return nil, err
 	}
-	
-	httpReq.Header.Set("User-Agent", m.userAgent)
-	httpReq.Header.Set("Accept", "application/json")
 
-	resp, err := m.client.Do(httpReq)
+	return searchResult.Recordings, nil
+}
+
+// recordingQuery builds the search query for req from the provider's query
+// template. A query the template fails to produce for this request is
+// logged and replaced by the default.
+func (m *MusicBrainzProvider) recordingQuery(req *enricher.SearchRequest, loose bool) string {
+	if loose || m.query == nil {
+		return recordingQuery(req, loose)
+	}
+	query, err := executeQuery(m.query, req)
 	if err != nil {
-		// Preserve context errors without wrapping
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
+		m.logger.Printf("musicbrainz: query template failed for %q - %q (%v); using the default query", req.Artist, req.Title, err)
+		return recordingQuery(req, loose)
+	}
+	return query
+}
+
+// recordingQuery builds the default search query for req. The strict query
+// (DefaultQueryTemplate) quotes artist and title as phrases; the loose one
+// lists their words unquoted, so word order, punctuation and extra words no
+// longer rule a recording out.
+func recordingQuery(req *enricher.SearchRequest, loose bool) string {
+	if loose {
+		// Search on the core title: a phrase query for "Music (Original
+		// Mix)" misses recordings titled plain "Music"
+		title, _ := normalize.CleanTitle(req.Title)
+		return fmt.Sprintf(`artist:(%s) AND recording:(%s)`, looseTerms(looseArtist(req.Artist)), looseTerms(title)) + featuredClause(req.Title)
+	}
+
+	query, _ := executeQuery(defaultQuery, req)
+	return query
+}
+
+// featuredClause returns optional artist terms for the guests named in a
+// title ("feat. X"). Without AND they only raise the score of recordings
+// crediting the guest, so a release that leaves the guest off still
+// matches.
+func featuredClause(title string) string {
+	_, featured := normalize.SplitFeatured(title)
+	clause := ""
+	for _, name := range featured {
+		clause += fmt.Sprintf(` artist:"%s"`, luceneTerm(name))
+	}
+	return clause
+}
+
+// durationClause returns an optional dur term, in milliseconds, covering
+// length give or take lengthTolerance, or "" for an unknown length. Like
+// featuredClause it only raises the score of recordings that fit, so a
+// file cut differently from its release still matches.
+func durationClause(length time.Duration) string {
+	if length <= 0 {
+		return ""
+	}
+	low := max(length-lengthTolerance, 0)
+	return fmt.Sprintf(" dur:[%d TO %d]", low.Milliseconds(), (length + lengthTolerance).Milliseconds())
+}
+
+// sameTitle reports whether a recording's title matches the target's
+// exactly (as normalize.MatchKey), with or without the target's guest credits.
+// MusicBrainz credits guests as artists, so "State Of Mind" is an exact
+// match for "State Of Mind (feat. Diane Charlemagne)".
+func sameTitle(recordingTitle, targetTitle string) bool {
+	recordingTitle = normalize.MatchKey(recordingTitle)
+	if recordingTitle == normalize.MatchKey(targetTitle) {
+		return true
+	}
+	title, featured := normalize.SplitFeatured(targetTitle)
+	return featured != nil && recordingTitle == normalize.MatchKey(title)
+}
+
+// looseTerms folds s and escapes each of its words as a separate term
+func looseTerms(s string) string {
+	words := strings.Fields(normalize.Fold(s))
+	for i, word := range words {
+		words[i] = normalize.EscapeLucene(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// looseQueryDiffers reports whether the loose query could find anything
+// the strict one didn't. A one-word artist and title search the same
+// either way, so the extra request would be wasted.
+func looseQueryDiffers(req *enricher.SearchRequest) bool {
+	title, _ := normalize.CleanTitle(req.Title)
+	return len(strings.Fields(looseArtist(req.Artist))) > 1 || len(strings.Fields(title)) > 1
+}
+
+// looseArtist spaces out the artists of a slash-joined name so each is a
+// separate loose term
+func looseArtist(artist string) string {
+	if parts := compositeArtists(artist); parts != nil {
+		return strings.Join(parts, " ")
+	}
+	return artist
+}
+
+// artistClause builds the artist part of a recording query. A name with a
+// leading "The " or trailing ", The" is searched both as given and without
+// it, so "The Photek" still finds "Photek". The reverse needs nothing extra:
+// the phrase "Prototypes" already matches "The Prototypes". "The The"
+// isn't widened to a search for any artist named "The". A slash-joined
+// name is also searched as its artists credited together (see
+// compositeArtists), never as one of them alone: "AC/DC" mustn't find
+// recordings by "AC".
+func artistClause(artist string) string {
+	names := artistSearchNames(artist)
+	terms := make([]string, len(names))
+	for i, name := range names {
+		terms[i] = fmt.Sprintf(`artist:"%s"`, normalize.EscapeLucene(name))
+	}
+	if parts := compositeArtists(artist); parts != nil {
+		together := make([]string, len(parts))
+		for i, part := range parts {
+			together[i] = fmt.Sprintf(`artist:"%s"`, luceneTerm(part))
 		}
-		return nil, fmt.Errorf("http request failed: %w", err)
+		terms = append(terms, "("+strings.Join(together, " AND ")+")")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("musicbrainz API returned status %d", resp.StatusCode)
+	if len(terms) == 1 {
+		return terms[0]
 	}
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
 
-	// Parse response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+// artistSearchNames returns artist folded, and again without a leading
+// "The " or trailing ", The" when it has one
+func artistSearchNames(artist string) []string {
+	folded := normalize.Fold(artist)
+	stripped := normalize.StripArtistThe(folded)
+	if strings.EqualFold(stripped, strings.TrimSpace(folded)) || strings.EqualFold(stripped, "the") {
+		return []string{folded}
 	}
+	return []string{folded, stripped}
+}
 
-	var searchResult RecordingSearchResult
-	if err := json.Unmarshal(body, &searchResult); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+// compositeArtists splits a slash-joined artist such as "Photek/Goldie",
+// which the four-hyphen filename layout produces, into its artists.
+// MusicBrainz credits collaborators separately, so the joined name never
+// matches a credit. It returns nil for a name without two slash-separated
+// parts of at least two characters each. The parts only count together:
+// "AC/DC" is one band, not "AC" and "DC", so a name is never matched by one
+// of its parts alone.
+func compositeArtists(artist string) []string {
+	if !strings.Contains(artist, "/") {
+		return nil
 	}
 
-	return searchResult.Recordings, nil
+	var parts []string
+	for _, part := range strings.Split(artist, "/") {
+		part = strings.TrimSpace(part)
+		if utf8.RuneCountInString(part) < 2 {
+			return nil
+		}
+		parts = append(parts, part)
+	}
+	return parts
 }
 
-// findBestRecordingMatch finds the recording that best matches the search criteria
-func (m *MusicBrainzProvider) findBestRecordingMatch(recordings []Recording, targetArtist, targetTitle string) *Recording {
-	if len(recordings) == 0 {
+// luceneTerm folds s and escapes it for a quoted search phrase
+func luceneTerm(s string) string {
+	return normalize.EscapeLucene(normalize.Fold(s))
+}
+
+// filterByMinScore returns the recordings whose search score is at least minScore
+func filterByMinScore(recordings []Recording, minScore int) []Recording {
+	if minScore <= 0 {
+		return recordings
+	}
+
+	filtered := make([]Recording, 0, len(recordings))
+	for _, recording := range recordings {
+		if recording.Score >= minScore {
+			filtered = append(filtered, recording)
+		}
+	}
+	return filtered
+}
+
+// findBestRecordingMatch finds the recording that best matches the search
+// criteria. targetLength, when known, favours recordings of the same length.
+func (m *MusicBrainzProvider) findBestRecordingMatch(recordings []Recording, targetArtist, targetTitle string, targetLength time.Duration) *Recording {
+	ranked := rankRecordings(recordings, targetArtist, targetTitle, targetLength, m.weights)
+	if len(ranked) == 0 {
 		return nil
 	}
+	return ranked[0]
+}
 
-	// Simple scoring: prefer exact matches, then by score
-	bestScore := 0
-	var bestRecording *Recording
+// rankRecordings orders recordings by match score, best first, dropping any
+// that score 0 or less. Ties keep search order.
+func rankRecordings(recordings []Recording, targetArtist, targetTitle string, targetLength time.Duration, weights MatchWeights) []*Recording {
+	ranked := make([]*Recording, 0, len(recordings))
+	scores := make(map[*Recording]int, len(recordings))
+	for i := range recordings {
+		score := recordingMatchScore(&recordings[i], targetArtist, targetTitle, targetLength, weights)
+		if score > 0 {
+			ranked = append(ranked, &recordings[i])
+			scores[&recordings[i]] = score
+		}
+	}
 
-	for i, recording := range recordings {
-		score := recording.Score
-		
-		// Bonus for exact title match
-		if strings.EqualFold(recording.Title, targetTitle) {
-			score += 10
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i]] > scores[ranked[j]]
+	})
+	return ranked
+}
+
+// uncreditedMinScore is the search score a recording with no artist credit
+// needs, on top of an exact title, before it can match at all
+const uncreditedMinScore = 95
+
+// recordingMatchScore is the search score plus weighted bonuses for
+// matching title, artist and length. Recordings with no artist credit
+// score 0 unless the title match is near-certain.
+func recordingMatchScore(recording *Recording, targetArtist, targetTitle string, targetLength time.Duration, weights MatchWeights) int {
+	score := recording.Score
+	exactTitle := sameTitle(recording.Title, targetTitle)
+
+	// Without a credit nothing confirms the artist, so a title that's
+	// merely similar is as likely another artist's track
+	if !hasArtistCredit(recording) && (!exactTitle || recording.Score < uncreditedMinScore) {
+		return 0
+	}
+
+	// Bonus for exact title match, or a smaller one when only the core
+	// titles agree (e.g. "Music" vs "Music (Original Mix)")
+	if exactTitle {
+		score += weights.Title
+	} else if sameCoreTitle(recording.Title, targetTitle) {
+		score += weights.CoreTitle
+	}
+
+	// Bonus for exact artist match, the best across all credits
+	score += weights.artistBonus(recording.ArtistCredit, targetArtist)
+
+	// Guests are credited as artists rather than named in the title
+	if _, featured := normalize.SplitFeatured(targetTitle); featured != nil {
+		score += weights.featuredBonus(recording.ArtistCredit, featured)
+	}
+
+	// A remix is usually its own recording, titled or disambiguated with
+	// the remixer's name, and often on another label than the original
+	score += weights.remixBonus(recording, normalize.Remixer(normalize.MixDescriptor(targetTitle)))
+
+	return score + weights.lengthBonus(recording.Length, targetLength)
+}
+
+// Recording length tolerances. Rips and encoders shift a track's length by a
+// second or two; a difference beyond lengthMismatch is usually another edit
+// or mix of the same title.
+const (
+	lengthTolerance = 3 * time.Second
+	lengthMismatch  = 30 * time.Second
+)
+
+// sameCoreTitle reports whether two titles match once trailing
+// qualifiers like "(Original Mix)" are removed
+func sameCoreTitle(a, b string) bool {
+	coreA, _ := normalize.CleanTitle(a)
+	coreB, _ := normalize.CleanTitle(b)
+	return coreA != "" && normalize.MatchKey(coreA) == normalize.MatchKey(coreB)
+}
+
+// normalizeArtistName drops a leading "The " or trailing ", The" from name
+// so "The Prodigy" and "Prodigy, The" compare equal, and reduces it to its
+// normalize.MatchKey
+func normalizeArtistName(name string) string {
+	return normalize.MatchKey(normalize.StripArtistThe(normalize.Fold(name)))
+}
+
+// artistMatch is how a target artist name matched a credit
+type artistMatch int
+
+const (
+	artistNoMatch artistMatch = iota
+	artistNameMatch
+	artistAliasMatch
+)
+
+// matchArtist reports whether target is the credited, canonical or sort
+// name of one of the credited artists, one of their aliases, or neither. A
+// slash-joined target that isn't credited as a whole matches only when
+// each of its artists is credited, so a recording by "AC" alone doesn't
+// match "AC/DC".
+func matchArtist(credits []ArtistCredit, target string) artistMatch {
+	if match := matchAnyCredit(credits, target); match != artistNoMatch {
+		return match
+	}
+
+	parts := compositeArtists(target)
+	if parts == nil {
+		return artistNoMatch
+	}
+	match := artistNameMatch
+	for _, part := range parts {
+		switch matchAnyCredit(credits, part) {
+		case artistNoMatch:
+			return artistNoMatch
+		case artistAliasMatch:
+			match = artistAliasMatch
 		}
-		
-		// Bonus for exact artist match
-		for _, credit := range recording.ArtistCredit {
-			if strings.EqualFold(credit.Artist.Name, targetArtist) {
-				score += 10
-				break
+	}
+	return match
+}
+
+// matchAnyCredit is the best matchArtistName across credits, a name match
+// beating an alias match
+func matchAnyCredit(credits []ArtistCredit, target string) artistMatch {
+	match := artistNoMatch
+	for _, credit := range credits {
+		switch matchArtistName(credit, target) {
+		case artistNameMatch:
+			return artistNameMatch
+		case artistAliasMatch:
+			match = artistAliasMatch
+		}
+	}
+	return match
+}
+
+// matchArtistName is matchArtist for a single artist name
+func matchArtistName(credit ArtistCredit, target string) artistMatch {
+	target = normalizeArtistName(target)
+	if target == "" {
+		return artistNoMatch
+	}
+
+	for _, name := range []string{credit.Name, credit.Artist.Name, credit.Artist.SortName} {
+		if name != "" && normalizeArtistName(name) == target {
+			return artistNameMatch
+		}
+	}
+
+	for _, alias := range credit.Artist.Aliases {
+		for _, name := range []string{alias.Name, alias.SortName} {
+			if name != "" && normalizeArtistName(name) == target {
+				return artistAliasMatch
+			}
+		}
+	}
+
+	return artistNoMatch
+}
+
+// findReleaseTrack returns the track on release that is recording, and
+// the position of the medium (disc) it is on. Tracks are matched by
+// recording ID when the response includes it, otherwise by title. It
+// returns nil when the release's track listing isn't known or lacks the
+// recording.
+func findReleaseTrack(release Release, recording *Recording) (*Track, int) {
+	for _, media := range release.Media {
+		tracks := media.AllTracks()
+		for i := range tracks {
+			track := &tracks[i]
+			if track.Recording.ID != "" {
+				if track.Recording.ID == recording.ID {
+					return track, media.Position
+				}
+				continue
+			}
+			if strings.EqualFold(track.Title, recording.Title) || sameCoreTitle(track.Title, recording.Title) {
+				return track, media.Position
+			}
+		}
+	}
+	return nil, 0
+}
+
+// hasTrackListing reports whether any of the release's media list tracks
+func hasTrackListing(release Release) bool {
+	for _, media := range release.Media {
+		if len(media.AllTracks()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// preferReleasesWithTrack drops releases whose track listing is known and
+// doesn't include the recording, such as a single carrying another mix
+// when the track is on a compilation. Releases without a listing are kept,
+// and if every release would be dropped they all are.
+func preferReleasesWithTrack(releases []Release, recording *Recording) []Release {
+	var kept []Release
+	for _, release := range releases {
+		if track, _ := findReleaseTrack(release, recording); track != nil || !hasTrackListing(release) {
+			kept = append(kept, release)
+		}
+	}
+	if len(kept) == 0 {
+		return releases
+	}
+	return kept
+}
+
+// preferYearRange narrows releases to those dated within minYear to maxYear
+// (either may be 0 for an open end). Releases without a date count as
+// outside. If none are inside, all are kept.
+func preferYearRange(releases []Release, minYear, maxYear int) []Release {
+	if minYear == 0 && maxYear == 0 {
+		return releases
+	}
+
+	var matched []Release
+	for _, release := range releases {
+		year := releaseYear(release)
+		if year == 0 || (minYear > 0 && year < minYear) || (maxYear > 0 && year > maxYear) {
+			continue
+		}
+		matched = append(matched, release)
+	}
+	if len(matched) == 0 {
+		return releases
+	}
+	return matched
+}
+
+// preferOfficialReleases narrows releases to those with "Official" status,
+// so a bootleg or promo's label and catalog number don't win just for
+// coming out first. It runs after the catalog number and label hints, so a
+// file hinting at a bootleg still gets it. If none are official, or
+// allowBootleg is set, all are kept.
+func preferOfficialReleases(releases []Release, allowBootleg bool) []Release {
+	if allowBootleg {
+		return releases
+	}
+
+	var official []Release
+	for _, release := range releases {
+		if strings.EqualFold(release.Status, "Official") {
+			official = append(official, release)
+		}
+	}
+	if len(official) == 0 {
+		return releases
+	}
+	return official
+}
+
+// releaseDate returns when a release came out: its own date, or else the
+// earliest date among its release events, which MusicBrainz often fills in
+// instead. Events in country (an ISO 3166-1 code, "" for any) win when one
+// is dated. It is "" for an undated release.
+func releaseDate(release Release, country string) string {
+	if release.Date != "" {
+		return release.Date
+	}
+
+	earliest, earliestInCountry := "", ""
+	for _, event := range release.ReleaseEvents {
+		if event.Date == "" {
+			continue
+		}
+		if earliest == "" || event.Date < earliest {
+			earliest = event.Date
+		}
+		if country != "" && eventInCountry(event, country) && (earliestInCountry == "" || event.Date < earliestInCountry) {
+			earliestInCountry = event.Date
+		}
+	}
+	if earliestInCountry != "" {
+		return earliestInCountry
+	}
+	return earliest
+}
+
+// eventInCountry reports whether a release event took place in country
+func eventInCountry(event ReleaseEvent, country string) bool {
+	for _, code := range event.Area.ISO31661Codes {
+		if strings.EqualFold(code, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// dateYear returns the year of a MusicBrainz date (YYYY, YYYY-MM or
+// YYYY-MM-DD), or 0 if there is none
+func dateYear(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+// releaseYear returns the year a release is dated, or 0 if it has no date
+func releaseYear(release Release) int {
+	return dateYear(releaseDate(release, ""))
+}
+
+// preferHintedReleases narrows releases to those matching the label and
+// year hints. Each hint is ignored if no release matches it.
+func preferHintedReleases(releases []Release, label, year string) []Release {
+	if label != "" {
+		var matched []Release
+		for _, release := range releases {
+			for _, info := range release.LabelInfo {
+				if strings.EqualFold(info.Label.Name, label) {
+					matched = append(matched, release)
+					break
+				}
 			}
 		}
+		if len(matched) > 0 {
+			releases = matched
+		}
+	}
 
-		if score > bestScore {
-			bestScore = score
-			bestRecording = &recordings[i]
+	if year != "" {
+		var matched []Release
+		for _, release := range releases {
+			if strings.HasPrefix(releaseDate(release, ""), year) {
+				matched = append(matched, release)
+			}
+		}
+		if len(matched) > 0 {
+			releases = matched
 		}
 	}
 
-	return bestRecording
+	return releases
 }
 
-// findBestRelease finds the best release from a list, preferring original releases
-func (m *MusicBrainzProvider) findBestRelease(releases []Release, preferOriginal bool) *Release {
+// findBestRelease finds the best release from a list, preferring original
+// releases. Releases whose media match preferredFormat win ties: the first
+// such release when not preferring originals, or on equal dates otherwise.
+// Remaining ties are broken by releaseOutranks, so the pick doesn't depend
+// on the order MusicBrainz happened to list releases in.
+func (m *MusicBrainzProvider) findBestRelease(releases []Release, preferOriginal bool, preferredFormat string) *Release {
 	if len(releases) == 0 {
 		return nil
 	}
 
 	if !preferOriginal {
+		for i := range releases {
+			if releaseHasFormat(releases[i], preferredFormat) {
+				return &releases[i]
+			}
+		}
 		return &releases[0] // Just return the first one
 	}
 
 	// Prefer releases with earlier dates (likely originals)
-	var bestRelease *Release
-	var earliestDate string
-
-	for i, release := range releases {
-		if release.Date != "" {
-			if earliestDate == "" || release.Date < earliestDate {
-				earliestDate = release.Date
-				bestRelease = &releases[i]
-			}
-		} else if bestRelease == nil {
-			bestRelease = &releases[i] // Fallback to first release with no date
+	bestRelease := &releases[0]
+	for i := 1; i < len(releases); i++ {
+		if releaseOutranks(releases[i], *bestRelease, preferredFormat, m.country) {
+			bestRelease = &releases[i]
 		}
 	}
 
-	if bestRelease == nil && len(releases) > 0 {
-		bestRelease = &releases[0] // Ultimate fallback
+	return bestRelease
+}
+
+// releaseOutranks reports whether a is a better original-release pick than
+// b. In order: a dated release beats an undated one and earlier beats
+// later, dating releases as releaseDate does for country; then the
+// preferred format; then "Official" status; then having label info; then
+// more complete media data.
+func releaseOutranks(a, b Release, preferredFormat, country string) bool {
+	aDate, bDate := releaseDate(a, country), releaseDate(b, country)
+	if (aDate == "") != (bDate == "") {
+		return aDate != ""
+	}
+	if aDate != bDate {
+		return aDate < bDate
 	}
 
-	return bestRelease
+	if aFormat, bFormat := releaseHasFormat(a, preferredFormat), releaseHasFormat(b, preferredFormat); aFormat != bFormat {
+		return aFormat
+	}
+
+	if aOfficial, bOfficial := strings.EqualFold(a.Status, "Official"), strings.EqualFold(b.Status, "Official"); aOfficial != bOfficial {
+		return aOfficial
+	}
+
+	if aLabel, bLabel := hasLabel(a), hasLabel(b); aLabel != bLabel {
+		return aLabel
+	}
+
+	return mediaCompleteness(a) > mediaCompleteness(b)
+}
+
+// mediaCompleteness counts the known format and track count fields across
+// a release's media
+func mediaCompleteness(release Release) int {
+	complete := 0
+	for _, media := range release.Media {
+		if media.Format != "" {
+			complete++
+		}
+		if media.TrackCount > 0 {
+			complete++
+		}
+	}
+	return complete
 }
 
-// convertToTrackMetadata converts MusicBrainz data to our standard format
+// releaseHasFormat reports whether any of the release's media match format,
+// case-insensitively and by substring so "vinyl" matches "12\" Vinyl"
+func releaseHasFormat(release Release, format string) bool {
+	if format == "" {
+		return false
+	}
+
+	format = strings.ToLower(format)
+	for _, media := range release.Media {
+		if strings.Contains(strings.ToLower(media.Format), format) {
+			return true
+		}
+	}
+	return false
+}
+
+// noLabel is the MusicBrainz placeholder label for self-released records
+const noLabel = "[no label]"
+
+// releaseLabel picks the most relevant label on a release, which may list
+// several (co-releases, licensees, sub-labels), and returns it with its
+// catalog number. In order it prefers the entry carrying catalogHint, the
+// one named labelHint, the first with a catalog number, then the first
+// named one. Entries with only a catalog number or the "[no label]"
+// placeholder never win; if no entry names a label the first catalog
+// number is still returned.
+func releaseLabel(release Release, labelHint, catalogHint string) (label, catalogNumber string) {
+	var best *LabelInfo
+	bestRank := 0
+	for i, info := range release.LabelInfo {
+		if catalogNumber == "" {
+			catalogNumber = info.CatalogNumber
+		}
+		if info.Label.Name == "" || strings.EqualFold(info.Label.Name, noLabel) {
+			continue
+		}
+
+		rank := 1
+		switch {
+		case catalogHint != "" && sameCatalogNumber(info.CatalogNumber, catalogHint):
+			rank = 4
+		case labelHint != "" && strings.EqualFold(info.Label.Name, labelHint):
+			rank = 3
+		case info.CatalogNumber != "":
+			rank = 2
+		}
+		if rank > bestRank {
+			best, bestRank = &release.LabelInfo[i], rank
+		}
+	}
+
+	if best == nil {
+		return "", catalogNumber
+	}
+	return best.Label.Name, best.CatalogNumber
+}
+
+// releaseLabels lists the distinct label names on a release, in order
+func releaseLabels(release Release) []string {
+	var labels []string
+	seen := make(map[string]bool)
+	for _, info := range release.LabelInfo {
+		name := info.Label.Name
+		key := strings.ToLower(name)
+		if name == "" || strings.EqualFold(name, noLabel) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		labels = append(labels, name)
+	}
+	return labels
+}
+
+// hasLabel reports whether a release names a label
+func hasLabel(release Release) bool {
+	label, _ := releaseLabel(release, "", "")
+	return label != ""
+}
+
+// sameCatalogNumber compares catalog numbers ignoring case, spaces and
+// dashes, so "FX 240", "fx-240" and "FX240" are equal
+func sameCatalogNumber(a, b string) bool {
+	key := func(s string) string {
+		return strings.ToLower(strings.NewReplacer(" ", "", "-", "", ".", "").Replace(s))
+	}
+	return a != "" && key(a) == key(b)
+}
+
+// preferCatalogNumber narrows releases to those listing the catalog number,
+// unless none do
+func preferCatalogNumber(releases []Release, catalogNumber string) []Release {
+	if catalogNumber == "" {
+		return releases
+	}
+
+	var matched []Release
+	for _, release := range releases {
+		for _, info := range release.LabelInfo {
+			if sameCatalogNumber(info.CatalogNumber, catalogNumber) {
+				matched = append(matched, release)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return releases
+	}
+	return matched
+}
+
+// matchYear returns the year to tag a match with from release, taken from
+// its release group's first release date or its own date as yearSource
+// says. Search results don't always carry the release group's date.
+func (m *MusicBrainzProvider) matchYear(release Release) int {
+	if m.yearSource == YearFromReleaseGroup {
+		if year := dateYear(release.ReleaseGroup.FirstReleaseDate); year > 0 {
+			return year
+		}
+	}
+	return dateYear(releaseDate(release, m.country))
+}
+
+// convertToTrackMetadata converts MusicBrainz data to our standard format.
+// Sparse responses are expected: a nil release or one without a date or
+// label info simply leaves those fields empty.
 func (m *MusicBrainzProvider) convertToTrackMetadata(recording *Recording, release *Release, originalArtist, originalTitle string) *enricher.TrackMetadata {
+	if release == nil {
+		release = &Release{}
+	}
 	metadata := &enricher.TrackMetadata{
 		Artist:       originalArtist, // Use the original parsed artist
 		Title:        originalTitle,  // Use the original parsed title
 		Album:        release.Title,
-		ReleaseDate:  release.Date,
+		ReleaseDate:  releaseDate(*release, m.country),
 		ProviderID:   recording.ID,
 		ProviderName: "MusicBrainz",
 		Extra:        make(map[string]interface{}),
 	}
 
-	// Extract year from date
-	if release.Date != "" && len(release.Date) >= 4 {
-		if year, err := strconv.Atoi(release.Date[:4]); err == nil {
-			metadata.Year = year
-		}
-	}
+	metadata.Year = m.matchYear(*release)
 
-	// Extract label information
-	if len(release.LabelInfo) > 0 {
-		metadata.Label = release.LabelInfo[0].Label.Name
-		if release.LabelInfo[0].CatalogNumber != "" {
-			metadata.CatalogNumber = release.LabelInfo[0].CatalogNumber
-		}
+	// Extract label information, keeping every label for co-releases
+	metadata.Label, metadata.CatalogNumber = releaseLabel(*release, "", "")
+	if labels := releaseLabels(*release); len(labels) > 1 {
+		metadata.Extra["musicbrainz_labels"] = labels
 	}
 
-	// Calculate confidence based on match quality and completeness
-	exactArtistMatch := false
-	exactTitleMatch := strings.EqualFold(recording.Title, originalTitle)
-	
-	for _, credit := range recording.ArtistCredit {
-		if strings.EqualFold(credit.Artist.Name, originalArtist) {
-			exactArtistMatch = true
-			break
+	// Record where the track sits on multi-disc releases and box sets
+	if track, disc := findReleaseTrack(*release, recording); track != nil {
+		metadata.Extra["musicbrainz_track_number"] = track.Number
+		if len(release.Media) > 1 {
+			metadata.Extra["musicbrainz_disc"] = disc
+			metadata.Extra["musicbrainz_disc_count"] = len(release.Media)
 		}
 	}
 
-	metadata.Confidence = enricher.CalculateConfidence(metadata, exactArtistMatch && exactTitleMatch)
+	// Calculate confidence based on match quality and completeness
+	metadata.Confidence = enricher.CalculateConfidence(metadata, matchQuality(recording, originalArtist, originalTitle))
 
 	// Store additional MusicBrainz-specific data
 	metadata.Extra["musicbrainz_recording_id"] = recording.ID
 	metadata.Extra["musicbrainz_release_id"] = release.ID
 	metadata.Extra["musicbrainz_score"] = recording.Score
+	if remix := normalize.MixDescriptor(originalTitle); remix != "" {
+		metadata.Extra["remix"] = remix
+	}
 
 	return metadata
-}
\ No newline at end of file
+}
+
+// closeMatchSimilarity is the minimum normalize.Similarity for a title or
+// artist that isn't an exact match to still count as a close one
+const closeMatchSimilarity = 0.8
+
+// matchQuality grades a recording against the requested artist and title.
+// It is exact when the title matches exactly and the artist is a credited
+// artist under any of their names, aliases included, and close when either
+// one matches by core title or small typo.
+func matchQuality(recording *Recording, artist, title string) enricher.MatchQuality {
+	exactTitle := sameTitle(recording.Title, title)
+	exactArtist := matchArtist(recording.ArtistCredit, artist) != artistNoMatch
+	closeArtist := false
+	for _, credit := range recording.ArtistCredit {
+		if normalize.Similarity(credit.Artist.Name, artist) >= closeMatchSimilarity {
+			closeArtist = true
+		}
+	}
+
+	if exactArtist && exactTitle {
+		return enricher.MatchExact
+	}
+
+	closeTitle := exactTitle || sameCoreTitle(recording.Title, title) || normalize.Similarity(recording.Title, title) >= closeMatchSimilarity
+	if closeTitle || closeArtist || exactArtist {
+		return enricher.MatchClose
+	}
+	return enricher.MatchNone
+}
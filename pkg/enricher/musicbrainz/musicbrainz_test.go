@@ -5,7 +5,10 @@ package musicbrainz
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -18,6 +21,16 @@ import (
 func TestMusicBrainzProvider_Interface(t *testing.T) {
 	// Ensure MusicBrainzProvider implements MetadataProvider interface
 	var _ enricher.MetadataProvider = (*MusicBrainzProvider)(nil)
+	var _ enricher.ArtworkProvider = (*MusicBrainzProvider)(nil)
+}
+
+func TestMusicBrainzProvider_FetchArtwork_NoReleaseID(t *testing.T) {
+	provider := NewMusicBrainzProvider()
+
+	_, err := provider.FetchArtwork(context.Background(), &enricher.TrackMetadata{})
+	if err != enricher.ErrNotFound {
+		t.Errorf("Expected ErrNotFound without a release ID, got %v", err)
+	}
 }
 
 func TestNewMusicBrainzProvider(t *testing.T) {
@@ -41,6 +54,44 @@ func TestNewMusicBrainzProvider(t *testing.T) {
 	}
 }
 
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestMusicBrainzProvider_WithHTTPClient(t *testing.T) {
+	var seen *http.Request
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		seen = r
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"count": 0, "recordings": []}`)),
+		}, nil
+	})}
+
+	provider := NewMusicBrainzProvider(WithHTTPClient(client))
+	if _, err := provider.Lookup(context.Background(), "LTJ Bukem", "Music"); err != enricher.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if seen == nil {
+		t.Fatal("Expected the injected client to be used")
+	}
+	if seen.URL.Host != "musicbrainz.org" {
+		t.Errorf("Expected request to musicbrainz.org, got %s", seen.URL.Host)
+	}
+}
+
+func TestNewDefaultHTTPClient_UsesEnvironmentProxy(t *testing.T) {
+	transport, ok := newDefaultHTTPClient().Transport.(*http.Transport)
+	if !ok || transport.Proxy == nil {
+		t.Fatal("Expected default transport to have a proxy function")
+	}
+}
+
 func TestMusicBrainzProvider_SupportsGenre(t *testing.T) {
 	provider := NewMusicBrainzProvider()
 	
@@ -99,6 +150,30 @@ func TestMusicBrainzProvider_RateLimit(t *testing.T) {
 	}
 }
 
+func TestMusicBrainzProvider_WithRateLimit(t *testing.T) {
+	provider := NewMusicBrainzProvider(WithRateLimit(20))
+	if got := provider.RateLimit().RequestsPerSecond; got != 20 {
+		t.Errorf("Expected the configured 20 requests per second, got %f", got)
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := provider.waitForRateLimit(ctx); err != nil {
+			t.Fatalf("Rate limit wait failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("Expected three requests at 20/s to take about 100ms, took %v", elapsed)
+	}
+
+	for _, rate := range []float64{0, -1} {
+		if got := NewMusicBrainzProvider(WithRateLimit(rate)).RateLimit().RequestsPerSecond; got != 1.0 {
+			t.Errorf("Expected rate %v ignored in favour of the default, got %f", rate, got)
+		}
+	}
+}
+
 func TestMusicBrainzProvider_SearchRecordings_MockResponse(t *testing.T) {
 	// Test the JSON parsing logic with a mock response
 	mockJSON := `{
@@ -325,7 +400,7 @@ func TestMusicBrainzProvider_FindBestRecordingMatch(t *testing.T) {
 		},
 	}
 	
-	best := provider.findBestRecordingMatch(recordings, "LTJ Bukem", "Music")
+	best := provider.findBestRecordingMatch(recordings, "LTJ Bukem", "Music", 0)
 	
 	if best == nil {
 		t.Fatal("findBestRecordingMatch returned nil")
@@ -336,6 +411,129 @@ func TestMusicBrainzProvider_FindBestRecordingMatch(t *testing.T) {
 	}
 }
 
+func TestMusicBrainzProvider_FindBestRecordingMatch_ThePrefix(t *testing.T) {
+	provider := NewMusicBrainzProvider()
+
+	recordings := []Recording{
+		{
+			ID:    "other-artist",
+			Title: "Firestarter",
+			Score: 85,
+			ArtistCredit: []ArtistCredit{
+				{Artist: Artist{Name: "Firestarter Tribute Band"}},
+			},
+		},
+		{
+			ID:    "prodigy",
+			Title: "Firestarter",
+			Score: 80,
+			ArtistCredit: []ArtistCredit{
+				{Name: "The Prodigy", Artist: Artist{Name: "The Prodigy", SortName: "Prodigy, The"}},
+			},
+		},
+	}
+
+	for _, artist := range []string{"The Prodigy", "Prodigy, The", "Prodigy", "the prodigy"} {
+		t.Run(artist, func(t *testing.T) {
+			best := provider.findBestRecordingMatch(recordings, artist, "Firestarter", 0)
+			if best == nil || best.ID != "prodigy" {
+				t.Errorf("Expected 'prodigy' for artist %q, got %+v", artist, best)
+			}
+		})
+	}
+}
+
+func TestMusicBrainzProvider_FindBestRecordingMatch_Length(t *testing.T) {
+	provider := NewMusicBrainzProvider()
+
+	credit := []ArtistCredit{{Artist: Artist{Name: "Goldie"}}}
+	recordings := []Recording{
+		{ID: "radio-edit", Title: "Inner City Life", Length: 223000, Score: 100, ArtistCredit: credit},
+		{ID: "album-version", Title: "Inner City Life", Length: 1281000, Score: 98, ArtistCredit: credit},
+	}
+
+	// A 21:22 rip should pick the album version despite its lower score
+	best := provider.findBestRecordingMatch(recordings, "Goldie", "Inner City Life", 21*time.Minute+22*time.Second)
+	if best == nil || best.ID != "album-version" {
+		t.Errorf("Expected length to pick the album version, got %+v", best)
+	}
+
+	// Without a length, search score decides
+	best = provider.findBestRecordingMatch(recordings, "Goldie", "Inner City Life", 0)
+	if best == nil || best.ID != "radio-edit" {
+		t.Errorf("Expected the higher-scoring recording without a length, got %+v", best)
+	}
+
+	if got := DefaultMatchWeights().lengthBonus(0, time.Minute); got != 0 {
+		t.Errorf("Expected no bonus for an unknown recording length, got %d", got)
+	}
+}
+
+func TestMusicBrainzProvider_FindBestRecordingMatch_Aliases(t *testing.T) {
+	provider := NewMusicBrainzProvider()
+
+	aliased := ArtistCredit{Artist: Artist{
+		Name:    "Photek",
+		Aliases: []Alias{{Name: "Studio Pressure"}, {Name: "Aquarius"}},
+	}}
+
+	recordings := []Recording{
+		{ID: "via-alias", Title: "Jump", Score: 80, ArtistCredit: []ArtistCredit{aliased}},
+		{ID: "unrelated", Title: "Jump", Score: 85, ArtistCredit: []ArtistCredit{{Artist: Artist{Name: "Someone Else"}}}},
+	}
+
+	best := provider.findBestRecordingMatch(recordings, "Studio Pressure", "Jump", 0)
+	if best == nil || best.ID != "via-alias" {
+		t.Errorf("Expected alias match to win, got %+v", best)
+	}
+
+	weights := DefaultMatchWeights()
+	if got := weights.artistBonus([]ArtistCredit{aliased}, "Aquarius"); got != weights.Artist {
+		t.Errorf("Expected the full artist bonus %d for an alias, got %d", weights.Artist, got)
+	}
+	if got := weights.artistBonus([]ArtistCredit{aliased}, "Photek"); got != weights.Artist {
+		t.Errorf("Expected primary name bonus %d, got %d", weights.Artist, got)
+	}
+}
+
+func TestNormalizeArtistName(t *testing.T) {
+	testCases := map[string]string{
+		"The Prodigy":  "prodigy",
+		"Prodigy, The": "prodigy",
+		" THE Orb ":    "orb",
+		"Theo Parrish": "theo parrish",
+		"The The":      "the",
+		"Bukem, LTJ":   "bukem ltj",
+		"L.T.J. Bukem": "ltj bukem",
+		"Björk":        "bjork",
+	}
+
+	for input, expected := range testCases {
+		if got := normalizeArtistName(input); got != expected {
+			t.Errorf("normalizeArtistName(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestArtistClause(t *testing.T) {
+	testCases := map[string]string{
+		"Photek":       `artist:"Photek"`,
+		"The Photek":   `(artist:"The Photek" OR artist:"Photek")`,
+		"Prodigy, The": `(artist:"Prodigy, The" OR artist:"Prodigy")`,
+		"The The":      `artist:"The The"`,
+		"Theo Parrish": `artist:"Theo Parrish"`,
+		"Photek/Goldie": `(artist:"Photek\/Goldie" OR (artist:"Photek" AND artist:"Goldie"))`,
+		"AC/DC":         `(artist:"AC\/DC" OR (artist:"AC" AND artist:"DC"))`,
+		"A/B":           `artist:"A\/B"`,
+	}
+
+	for input, expected := range testCases {
+		if got := artistClause(input); got != expected {
+			t.Errorf("artistClause(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
 func TestMusicBrainzProvider_FindBestRelease(t *testing.T) {
 	provider := NewMusicBrainzProvider()
 	
@@ -358,7 +556,7 @@ func TestMusicBrainzProvider_FindBestRelease(t *testing.T) {
 	}
 	
 	// Test preferring original (earliest date)
-	best := provider.findBestRelease(releases, true)
+	best := provider.findBestRelease(releases, true, "")
 	
 	if best == nil {
 		t.Fatal("findBestRelease returned nil")
@@ -369,7 +567,7 @@ func TestMusicBrainzProvider_FindBestRelease(t *testing.T) {
 	}
 	
 	// Test not preferring original (first release)
-	first := provider.findBestRelease(releases, false)
+	first := provider.findBestRelease(releases, false, "")
 	
 	if first == nil {
 		t.Fatal("findBestRelease returned nil")
@@ -394,18 +592,379 @@ func TestMusicBrainzProvider_ErrorHandling(t *testing.T) {
 	// For now, we'll test error scenarios that don't require network calls
 	
 	// Test empty recordings
-	best := provider.findBestRecordingMatch([]Recording{}, "Artist", "Title")
+	best := provider.findBestRecordingMatch([]Recording{}, "Artist", "Title", 0)
 	if best != nil {
 		t.Error("Expected nil for empty recordings slice")
 	}
 	
 	// Test empty releases
-	bestRelease := provider.findBestRelease([]Release{}, true)
+	bestRelease := provider.findBestRelease([]Release{}, true, "")
 	if bestRelease != nil {
 		t.Error("Expected nil for empty releases slice")
 	}
 }
 
+func TestMusicBrainzProvider_AbandonBelowScore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"count": 1,
+			"recordings": [
+				{
+					"id": "weak-match",
+					"title": "Music",
+					"score": 40,
+					"artist-credit": [{"name": "LTJ Bukem", "artist": {"name": "LTJ Bukem"}}],
+					"releases": [{"id": "release-id", "title": "Music", "date": "1993"}]
+				}
+			]
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	req := &enricher.SearchRequest{
+		Artist:            "LTJ Bukem",
+		Title:             "Music",
+		MaxResults:        5,
+		AbandonBelowScore: 50,
+	}
+
+	_, err := provider.LookupWithHints(ctx, req)
+	var notFound *enricher.NotFoundError
+	if !errors.As(err, &notFound) || !errors.Is(err, enricher.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for score below threshold, got %v", err)
+	}
+	if got := notFound.Summary(); got != "1 candidate, best search score 40 (below 50)" {
+		t.Errorf("Expected the rejected score in the diagnostics, got %q", got)
+	}
+
+	req.AbandonBelowScore = 0
+	result, err := provider.LookupWithHints(ctx, req)
+	if err != nil {
+		t.Fatalf("Expected match with threshold disabled, got %v", err)
+	}
+	if result.ProviderID != "weak-match" {
+		t.Errorf("Expected provider ID 'weak-match', got '%s'", result.ProviderID)
+	}
+}
+
+func TestMusicBrainzProvider_LookupCandidates(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"count": 3,
+			"recordings": [
+				{
+					"id": "cover",
+					"title": "Music",
+					"score": 100,
+					"artist-credit": [{"name": "Someone Else", "artist": {"name": "Someone Else"}}],
+					"releases": [{"id": "cover-release", "title": "Covers", "date": "2010"}]
+				},
+				{
+					"id": "original",
+					"title": "Music",
+					"score": 95,
+					"artist-credit": [{"name": "LTJ Bukem", "artist": {"name": "LTJ Bukem"}}],
+					"releases": [{"id": "original-release", "title": "Music", "date": "1993",
+						"label-info": [{"catalog-number": "GLR002", "label": {"name": "Good Looking Records"}}]}]
+				},
+				{
+					"id": "no-releases",
+					"title": "Music",
+					"score": 90,
+					"artist-credit": [{"name": "LTJ Bukem", "artist": {"name": "LTJ Bukem"}}]
+				}
+			]
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	req := &enricher.SearchRequest{Artist: "LTJ Bukem", Title: "Music", MaxResults: 5}
+
+	candidates, err := provider.LookupCandidates(context.Background(), req)
+	if err != nil {
+		t.Fatalf("LookupCandidates returned error: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("Expected 2 candidates (recordings without releases dropped), got %d", len(candidates))
+	}
+	if candidates[0].ProviderID != "original" || candidates[1].ProviderID != "cover" {
+		t.Errorf("Expected artist match to rank first, got %s then %s", candidates[0].ProviderID, candidates[1].ProviderID)
+	}
+	if candidates[0].Label != "Good Looking Records" || candidates[0].Confidence <= candidates[1].Confidence {
+		t.Errorf("Expected the original to carry its label and a higher confidence, got %+v", candidates[0])
+	}
+	if requests != 1 {
+		t.Errorf("Expected a single search request, got %d", requests)
+	}
+}
+
+func TestMusicBrainzProvider_CallBudget(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count": 0, "recordings": []}`)
+	}))
+	defer server.Close()
+
+	// A miss costs two searches: the strict query, then the loose retry
+	budget := enricher.NewCallBudget(2)
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL), WithCallBudget(budget))
+	ctx := context.Background()
+
+	if _, err := provider.Lookup(ctx, "LTJ Bukem", "Music"); err != enricher.ErrNotFound {
+		t.Errorf("Expected ErrNotFound from first lookup, got %v", err)
+	}
+	if _, err := provider.Lookup(ctx, "Goldie", "Inner City Life"); err != enricher.ErrBudgetExhausted {
+		t.Errorf("Expected ErrBudgetExhausted from second lookup, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 HTTP requests, got %d", requests)
+	}
+}
+
+func TestFilterByMinScore(t *testing.T) {
+	recordings := []Recording{
+		{ID: "exact-but-weak", Title: "Music", Score: 12},
+		{ID: "solid", Title: "Music (VIP)", Score: 85},
+		{ID: "borderline", Title: "Music", Score: 50},
+	}
+
+	filtered := filterByMinScore(recordings, 50)
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 recordings at or above 50, got %d", len(filtered))
+	}
+	for _, recording := range filtered {
+		if recording.ID == "exact-but-weak" {
+			t.Error("Expected low-scoring recording to be discarded")
+		}
+	}
+
+	if len(filterByMinScore(recordings, 0)) != len(recordings) {
+		t.Error("Expected a zero threshold to keep every recording")
+	}
+}
+
+func TestMusicBrainzProvider_FindBestRelease_PreferredFormat(t *testing.T) {
+	provider := NewMusicBrainzProvider()
+
+	releases := []Release{
+		{ID: "digital", Date: "1995-07-01", Media: []Media{{Format: "Digital Media"}}},
+		{ID: "vinyl", Date: "1995-07-01", Media: []Media{{Format: "12\" Vinyl"}}},
+		{ID: "cd-reissue", Date: "2008-01-01", Media: []Media{{Format: "CD"}}},
+	}
+
+	if best := provider.findBestRelease(releases, true, "vinyl"); best.ID != "vinyl" {
+		t.Errorf("Expected vinyl to win the same-date tie, got '%s'", best.ID)
+	}
+	if best := provider.findBestRelease(releases, true, ""); best.ID != "digital" {
+		t.Errorf("Expected first same-date release without a preference, got '%s'", best.ID)
+	}
+	if best := provider.findBestRelease(releases, true, "CD"); best.ID != "digital" {
+		t.Errorf("Expected format to only break ties, not override an earlier date, got '%s'", best.ID)
+	}
+	if best := provider.findBestRelease(releases, false, "vinyl"); best.ID != "vinyl" {
+		t.Errorf("Expected first vinyl release when not preferring originals, got '%s'", best.ID)
+	}
+}
+
+func TestMusicBrainzProvider_FindBestRelease_TieBreaks(t *testing.T) {
+	provider := NewMusicBrainzProvider()
+
+	bootleg := Release{ID: "bootleg", Date: "1996", Status: "Bootleg"}
+	official := Release{ID: "official", Date: "1996", Status: "Official"}
+	labelled := Release{ID: "labelled", Date: "1996", Status: "Official",
+		LabelInfo: []LabelInfo{{CatalogNumber: "MET 001", Label: Label{Name: "Metalheadz"}}}}
+	complete := Release{ID: "complete", Date: "1996", Status: "Official", LabelInfo: labelled.LabelInfo,
+		Media: []Media{{Format: "12\" Vinyl", TrackCount: 2}}}
+
+	testCases := []struct {
+		name     string
+		releases []Release
+		expected string
+	}{
+		{"official beats bootleg", []Release{bootleg, official}, "official"},
+		{"label info beats none", []Release{official, labelled}, "labelled"},
+		{"complete media beats none", []Release{labelled, complete}, "complete"},
+		{"earlier date still wins", []Release{complete, {ID: "earlier", Date: "1995"}}, "earlier"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Both orders must give the same pick
+			for _, releases := range [][]Release{tc.releases, {tc.releases[1], tc.releases[0]}} {
+				if best := provider.findBestRelease(releases, true, ""); best.ID != tc.expected {
+					t.Errorf("Expected '%s', got '%s'", tc.expected, best.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestPreferHintedReleases(t *testing.T) {
+	releases := []Release{
+		{ID: "reissue", Date: "2008-03-01", LabelInfo: []LabelInfo{{Label: Label{Name: "Reinforced Records"}}}},
+		{ID: "original", Date: "1994-06-01", LabelInfo: []LabelInfo{{Label: Label{Name: "Metalheadz"}}}},
+		{ID: "repress", Date: "1997-01-01", LabelInfo: []LabelInfo{{Label: Label{Name: "Metalheadz"}}}},
+	}
+
+	got := preferHintedReleases(releases, "metalheadz", "1997")
+	if len(got) != 1 || got[0].ID != "repress" {
+		t.Errorf("Expected only the 1997 Metalheadz release, got %+v", got)
+	}
+
+	if got := preferHintedReleases(releases, "Unknown Label", ""); len(got) != len(releases) {
+		t.Error("Expected an unmatched label hint to be ignored")
+	}
+}
+
+func TestPreferYearRange(t *testing.T) {
+	releases := []Release{
+		{ID: "reissue", Date: "2008-03-01"},
+		{ID: "original", Date: "1994-06-01"},
+		{ID: "undated"},
+		{ID: "repress", Date: "1997"},
+	}
+
+	testCases := []struct {
+		minYear, maxYear int
+		expected         []string
+	}{
+		{0, 0, []string{"reissue", "original", "undated", "repress"}},
+		{1993, 1997, []string{"original", "repress"}},
+		{1995, 0, []string{"reissue", "repress"}},
+		{0, 1995, []string{"original"}},
+		{1980, 1989, []string{"reissue", "original", "undated", "repress"}}, // Nothing inside; keep all
+	}
+
+	for _, tc := range testCases {
+		var got []string
+		for _, release := range preferYearRange(releases, tc.minYear, tc.maxYear) {
+			got = append(got, release.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("preferYearRange(%d, %d) = %v, expected %v", tc.minYear, tc.maxYear, got, tc.expected)
+		}
+	}
+}
+
+func TestReleaseDate_ReleaseEvents(t *testing.T) {
+	event := func(date string, codes ...string) ReleaseEvent {
+		return ReleaseEvent{Date: date, Area: Area{ISO31661Codes: codes}}
+	}
+	eventsOnly := Release{ID: "events", ReleaseEvents: []ReleaseEvent{
+		event("1996-02-01", "US"),
+		event(""),
+		event("1995-07-24", "GB"),
+		event("1995-09-01", "DE"),
+	}}
+
+	testCases := []struct {
+		name     string
+		release  Release
+		country  string
+		expected string
+	}{
+		{"top-level date wins", Release{Date: "1995", ReleaseEvents: []ReleaseEvent{event("1994-01-01", "GB")}}, "", "1995"},
+		{"earliest event", eventsOnly, "", "1995-07-24"},
+		{"preferred country", eventsOnly, "DE", "1995-09-01"},
+		{"country without an event", eventsOnly, "JP", "1995-07-24"},
+		{"undated", Release{ReleaseEvents: []ReleaseEvent{event("")}}, "", ""},
+	}
+	for _, tc := range testCases {
+		if got := releaseDate(tc.release, tc.country); got != tc.expected {
+			t.Errorf("%s: releaseDate = %q, expected %q", tc.name, got, tc.expected)
+		}
+	}
+
+	// Dated only by its events, the 1995 release is now the original
+	reissue := Release{ID: "reissue", Date: "2008-03-01"}
+	provider := NewMusicBrainzProvider()
+	if best := provider.findBestRelease([]Release{reissue, eventsOnly}, true, ""); best.ID != "events" {
+		t.Errorf("Expected the release dated by its events to win, got %s", best.ID)
+	}
+	if got := preferYearRange([]Release{reissue, eventsOnly}, 1990, 1999); len(got) != 1 || got[0].ID != "events" {
+		t.Errorf("Expected the event date to count for the year range, got %+v", got)
+	}
+
+	metadata := NewMusicBrainzProvider(WithPreferredCountry("de")).convertToTrackMetadata(&Recording{ID: "r"}, &eventsOnly, "Goldie", "Inner City Life")
+	if metadata.ReleaseDate != "1995-09-01" || metadata.Year != 1995 {
+		t.Errorf("Expected the German release date, got %q (%d)", metadata.ReleaseDate, metadata.Year)
+	}
+}
+
+func TestMatchYear_ReleaseGroup(t *testing.T) {
+	reissue := Release{ID: "reissue", Date: "2008-03-01", ReleaseGroup: ReleaseGroup{FirstReleaseDate: "1995-07-24"}}
+	undatedGroup := Release{ID: "single", Date: "1994-11-01"}
+
+	testCases := []struct {
+		name     string
+		source   YearSource
+		release  Release
+		expected int
+	}{
+		{"release group date by default", "", reissue, 1995},
+		{"release date when configured", YearFromRelease, reissue, 2008},
+		{"release date when the group has none", YearFromReleaseGroup, undatedGroup, 1994},
+		{"unknown source keeps the default", "bogus", reissue, 1995},
+	}
+	for _, tc := range testCases {
+		provider := NewMusicBrainzProvider(WithYearSource(tc.source))
+		metadata := provider.convertToTrackMetadata(&Recording{ID: "r"}, &tc.release, "Goldie", "Inner City Life")
+		if metadata.Year != tc.expected {
+			t.Errorf("%s: Year = %d, expected %d", tc.name, metadata.Year, tc.expected)
+		}
+		if metadata.ReleaseDate != tc.release.Date {
+			t.Errorf("%s: expected the release's own date kept, got %q", tc.name, metadata.ReleaseDate)
+		}
+	}
+}
+
+func TestPreferOfficialReleases(t *testing.T) {
+	bootleg := Release{ID: "bootleg", Status: "Bootleg", Date: "1994-01-01", LabelInfo: []LabelInfo{{CatalogNumber: "WHITE001"}}}
+	promo := Release{ID: "promo", Status: "Promotion", Date: "1994-03-01"}
+	official := Release{ID: "official", Status: "Official", Date: "1994-06-01", LabelInfo: []LabelInfo{{CatalogNumber: "METH 001"}}}
+
+	testCases := []struct {
+		name         string
+		releases     []Release
+		allowBootleg bool
+		expected     []string
+	}{
+		{"official preferred", []Release{bootleg, promo, official}, false, []string{"official"}},
+		{"allow bootleg", []Release{bootleg, promo, official}, true, []string{"bootleg", "promo", "official"}},
+		{"nothing official", []Release{bootleg, promo}, false, []string{"bootleg", "promo"}},
+	}
+
+	for _, tc := range testCases {
+		var got []string
+		for _, release := range preferOfficialReleases(tc.releases, tc.allowBootleg) {
+			got = append(got, release.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: got %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+
+	// The earlier white label would otherwise win on date
+	provider := NewMusicBrainzProvider()
+	releases := preferOfficialReleases([]Release{bootleg, official}, false)
+	if best := provider.findBestRelease(releases, true, ""); best == nil || best.ID != "official" {
+		t.Errorf("Expected the official release picked over an earlier bootleg, got %+v", best)
+	}
+	if best := provider.findBestRelease(preferOfficialReleases([]Release{bootleg, official}, true), true, ""); best == nil || best.ID != "bootleg" {
+		t.Errorf("Expected the earlier bootleg with bootlegs allowed, got %+v", best)
+	}
+}
+
 func TestMusicBrainzProvider_Close(t *testing.T) {
 	provider := NewMusicBrainzProvider()
 	
@@ -485,6 +1044,624 @@ func BenchmarkMusicBrainzProvider_FindBestRecordingMatch(b *testing.B) {
 	b.ResetTimer()
 	
 	for i := 0; i < b.N; i++ {
-		provider.findBestRecordingMatch(recordings, "LTJ Bukem", "Music")
+		provider.findBestRecordingMatch(recordings, "LTJ Bukem", "Music", 0)
+	}
+}
+func TestMusicBrainzProvider_LookupByID(t *testing.T) {
+	const recordingID = "b1a9c0e9-d987-4042-ae91-78d6a3267d69"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/recording/" + recordingID:
+			fmt.Fprint(w, `{
+				"id": "b1a9c0e9-d987-4042-ae91-78d6a3267d69",
+				"title": "Inner City Life",
+				"length": 458000,
+				"artist-credit": [
+					{"name": "Goldie", "joinphrase": " presents ", "artist": {"name": "Goldie"}},
+					{"name": "Metalheadz", "artist": {"name": "Metalheadz"}}
+				],
+				"releases": [
+					{"id": "reissue", "title": "Timeless", "date": "2008-05-19", "status": "Official"},
+					{"id": "original", "title": "Inner City Life", "date": "1994-11-21", "status": "Official"}
+				]
+			}`)
+		case "/release/original":
+			fmt.Fprint(w, `{
+				"id": "original",
+				"title": "Inner City Life",
+				"date": "1994-11-21",
+				"label-info": [{"catalog-number": "FFRR 240", "label": {"name": "FFRR"}}]
+			}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	metadata, err := provider.LookupByID(ctx, recordingID)
+	if err != nil {
+		t.Fatalf("LookupByID failed: %v", err)
+	}
+	if metadata.Artist != "Goldie presents Metalheadz" {
+		t.Errorf("Expected credited artist, got '%s'", metadata.Artist)
+	}
+	if metadata.Album != "Inner City Life" || metadata.Year != 1994 {
+		t.Errorf("Expected original 1994 release, got '%s' (%d)", metadata.Album, metadata.Year)
+	}
+	if metadata.Label != "FFRR" || metadata.CatalogNumber != "FFRR 240" {
+		t.Errorf("Expected label info from release lookup, got '%s' / '%s'", metadata.Label, metadata.CatalogNumber)
+	}
+	if metadata.Confidence != 1.0 {
+		t.Errorf("Expected confidence 1.0, got %f", metadata.Confidence)
+	}
+
+	if _, err := provider.LookupByID(ctx, "00000000-0000-0000-0000-000000000000"); !errors.Is(err, enricher.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown ID, got %v", err)
+	}
+	if _, err := provider.LookupByID(ctx, "not-an-mbid"); !errors.Is(err, ErrInvalidMBID) {
+		t.Errorf("Expected ErrInvalidMBID, got %v", err)
+	}
+}
+
+func TestMatchQuality(t *testing.T) {
+	recording := &Recording{
+		Title:        "Inner City Life",
+		ArtistCredit: []ArtistCredit{{Name: "Goldie", Artist: Artist{Name: "Goldie", Aliases: []Alias{{Name: "Rufige Kru"}}}}},
+	}
+
+	testCases := []struct {
+		artist, title string
+		expected      enricher.MatchQuality
+	}{
+		{"Goldie", "Inner City Life", enricher.MatchExact},
+		{"Rufige Kru", "Inner City Life", enricher.MatchExact},
+		{"Rufige Kru", "Inner City Life (Original Mix)", enricher.MatchClose},
+		{"Goldie", "Inner City Life (Original Mix)", enricher.MatchClose},
+		{"Goldi", "Terminator", enricher.MatchClose},
+		{"Photek", "Inner City Lfie", enricher.MatchClose},
+		{"Photek", "The Hidden Camera", enricher.MatchNone},
+	}
+
+	for _, tc := range testCases {
+		if got := matchQuality(recording, tc.artist, tc.title); got != tc.expected {
+			t.Errorf("matchQuality(%q, %q) = %d, expected %d", tc.artist, tc.title, got, tc.expected)
+		}
+	}
+
+	// A complete release for the wrong recording stays below the default 0.7
+	release := &Release{
+		Title:     "Timeless",
+		Date:      "1995-07-24",
+		LabelInfo: []LabelInfo{{CatalogNumber: "828 646-2", Label: Label{Name: "FFRR"}}},
+	}
+	provider := NewMusicBrainzProvider()
+	if metadata := provider.convertToTrackMetadata(recording, release, "Photek", "The Hidden Camera"); metadata.Confidence > 0.4 {
+		t.Errorf("Expected mismatched recording capped at 0.4, got %.2f", metadata.Confidence)
+	}
+}
+
+func TestMusicBrainzProvider_LookupWithHints_RecordingID(t *testing.T) {
+	const knownID = "b1a9c0e9-d987-4042-ae91-78d6a3267d69"
+	const mergedID = "00000000-0000-0000-0000-000000000000"
+
+	var searched bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/recording/" + knownID:
+			fmt.Fprint(w, `{
+				"id": "b1a9c0e9-d987-4042-ae91-78d6a3267d69",
+				"title": "Inner City Life",
+				"artist-credit": [{"name": "Goldie", "artist": {"name": "Goldie"}}],
+				"releases": [{"id": "release-id", "title": "Inner City Life", "date": "1994"}]
+			}`)
+		case "/recording":
+			searched = true
+			fmt.Fprint(w, `{"count": 0, "recordings": []}`)
+		case "/release/release-id":
+			fmt.Fprint(w, `{"id": "release-id", "label-info": [{"label": {"name": "FFRR"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	req := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life", RecordingID: knownID}
+	metadata, err := provider.LookupWithHints(ctx, req)
+	if err != nil {
+		t.Fatalf("LookupWithHints failed: %v", err)
+	}
+	if searched {
+		t.Error("Expected the stored recording ID to skip the search")
+	}
+	if metadata.ProviderID != knownID || metadata.Label != "FFRR" {
+		t.Errorf("Expected recording %s on FFRR, got %s on '%s'", knownID, metadata.ProviderID, metadata.Label)
+	}
+
+	req.RecordingID = mergedID
+	if _, err := provider.LookupWithHints(ctx, req); !errors.Is(err, enricher.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from the fallback search, got %v", err)
+	}
+	if !searched {
+		t.Error("Expected an unknown recording ID to fall back to searching")
+	}
+}
+
+func TestMusicBrainzProvider_LookupWithHints_ISRC(t *testing.T) {
+	const recordingID = "b1a9c0e9-d987-4042-ae91-78d6a3267d69"
+
+	var searched bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/isrc/GBAAA9400123":
+			fmt.Fprint(w, `{
+				"isrc": "GBAAA9400123",
+				"recordings": [
+					{"id": "11111111-1111-1111-1111-111111111111", "title": "Inner City Life (Remastered)", "artist-credit": [{"name": "Goldie", "artist": {"name": "Goldie"}}]},
+					{"id": "b1a9c0e9-d987-4042-ae91-78d6a3267d69", "title": "Inner City Life", "artist-credit": [{"name": "Goldie", "artist": {"name": "Goldie"}}]}
+				]
+			}`)
+		case "/recording/" + recordingID:
+			fmt.Fprint(w, `{
+				"id": "b1a9c0e9-d987-4042-ae91-78d6a3267d69",
+				"title": "Inner City Life",
+				"artist-credit": [{"name": "Goldie", "artist": {"name": "Goldie"}}],
+				"releases": [{"id": "release-id", "title": "Inner City Life", "date": "1994"}]
+			}`)
+		case "/release/release-id":
+			fmt.Fprint(w, `{"id": "release-id", "label-info": [{"label": {"name": "FFRR"}}]}`)
+		case "/recording":
+			searched = true
+			fmt.Fprint(w, `{"count": 0, "recordings": []}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL), WithRateLimit(50))
+	ctx := context.Background()
+
+	// The file's artist is misspelt; the code finds the recording anyway
+	req := &enricher.SearchRequest{Artist: "Goldy", Title: "Inner City Life", ISRC: "gb-aaa-94-00123"}
+	metadata, err := provider.LookupWithHints(ctx, req)
+	if err != nil {
+		t.Fatalf("LookupWithHints failed: %v", err)
+	}
+	if searched {
+		t.Error("Expected the ISRC to skip the search")
+	}
+	if metadata.ProviderID != recordingID || metadata.Label != "FFRR" || metadata.Artist != "Goldie" {
+		t.Errorf("Expected Goldie's recording %s on FFRR, got %s's %s on '%s'", recordingID, metadata.Artist, metadata.ProviderID, metadata.Label)
+	}
+	if metadata.Confidence != isrcConfidence || metadata.Extra["isrc"] != "GBAAA9400123" {
+		t.Errorf("Expected confidence %.2f and the ISRC in Extra, got %.2f and %v", isrcConfidence, metadata.Confidence, metadata.Extra["isrc"])
+	}
+
+	for _, isrc := range []string{"GBAAA9400999", "not an isrc"} {
+		searched = false
+		req.ISRC = isrc
+		if _, err := provider.LookupWithHints(ctx, req); !errors.Is(err, enricher.ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound from the fallback search, got %v", isrc, err)
+		}
+		if !searched {
+			t.Errorf("%s: expected a fall back to searching", isrc)
+		}
+	}
+}
+
+func TestMusicBrainzProvider_FindBestRecordingMatch_NoArtistCredit(t *testing.T) {
+	provider := NewMusicBrainzProvider()
+
+	// Without a credit only an exact title on a near-certain hit matches
+	recordings := []Recording{
+		{ID: "uncredited", Title: "Inner City Life (Remix)", Score: 100},
+		{ID: "empty-credit", Title: "Inner City Life", Score: 90, ArtistCredit: []ArtistCredit{}},
+	}
+	if best := provider.findBestRecordingMatch(recordings, "Goldie", "Inner City Life", 0); best != nil {
+		t.Errorf("Expected uncredited recordings to be rejected, got %+v", best)
+	}
+
+	recordings = append(recordings, Recording{ID: "strong", Title: "Inner City Life", Score: 100, ArtistCredit: []ArtistCredit{{}}})
+	best := provider.findBestRecordingMatch(recordings, "Goldie", "Inner City Life", 0)
+	if best == nil || best.ID != "strong" {
+		t.Errorf("Expected an exact title with a top score to match, got %+v", best)
+	}
+
+	// A credited recording still beats an uncredited one
+	recordings = append(recordings, Recording{ID: "credited", Title: "Inner City Life", Score: 98, ArtistCredit: []ArtistCredit{{Artist: Artist{Name: "Goldie"}}}})
+	best = provider.findBestRecordingMatch(recordings, "Goldie", "Inner City Life", 0)
+	if best == nil || best.ID != "credited" {
+		t.Errorf("Expected the credited recording to win, got %+v", best)
+	}
+
+	if quality := matchQuality(&recordings[2], "Goldie", "Inner City Life"); quality == enricher.MatchExact {
+		t.Error("Expected an uncredited recording never to be an exact match")
+	}
+}
+
+func TestMusicBrainzProvider_LogsMissingArtistCredit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count": 1, "recordings": [{"id": "recording-id", "title": "Inner City Life", "score": 80, "artist-credit": []}]}`)
+	}))
+	defer server.Close()
+
+	var logged strings.Builder
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL), WithLogger(log.New(&logged, "", 0)))
+
+	_, err := provider.LookupWithHints(context.Background(), &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life", MaxResults: 5})
+	if !errors.Is(err, enricher.ErrNotFound) {
+		t.Errorf("Expected an uncredited, moderate-scoring recording not to match, got %v", err)
+	}
+	if !strings.Contains(logged.String(), "recording-id") || !strings.Contains(logged.String(), "no artist credit") {
+		t.Errorf("Expected the missing credit to be logged, got %q", logged.String())
+	}
+}
+
+func TestMusicBrainzProvider_SparseResponses(t *testing.T) {
+	const recordingID = "b1a9c0e9-d987-4042-ae91-78d6a3267d69"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/recording":
+			fmt.Fprint(w, `{"recordings": [{"id": "sparse", "title": "Inner City Life", "score": 100, "releases": [{"id": "release-id", "label-info": []}]}]}`)
+		case "/release/release-id":
+			fmt.Fprint(w, `{"id": "release-id", "label-info": [{"catalog-number": "FX 240", "label": null}]}`)
+		case "/recording/" + recordingID:
+			fmt.Fprint(w, `{"id": "b1a9c0e9-d987-4042-ae91-78d6a3267d69", "title": "Inner City Life"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	metadata, err := provider.LookupWithHints(ctx, &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life", MaxResults: 5})
+	if err != nil {
+		t.Fatalf("Expected a sparse recording to still match on title, got %v", err)
+	}
+	if metadata.Label != "" || metadata.CatalogNumber != "FX 240" || metadata.Year != 0 {
+		t.Errorf("Expected only the catalog number from the sparse release, got %+v", metadata)
+	}
+	if metadata.Confidence >= 0.7 {
+		t.Errorf("Expected an uncredited, unlabelled match to stay below the default threshold, got %.2f", metadata.Confidence)
+	}
+
+	metadata, err = provider.LookupByID(ctx, recordingID)
+	if err != nil {
+		t.Fatalf("Expected a recording with no credit or releases to be returned, got %v", err)
+	}
+	if metadata.Title != "Inner City Life" || metadata.Artist != "" || metadata.Album != "" {
+		t.Errorf("Expected title only from the bare recording, got %+v", metadata)
+	}
+
+	if converted := provider.convertToTrackMetadata(&Recording{ID: "bare"}, nil, "Goldie", "Angel"); converted.ProviderID != "bare" {
+		t.Errorf("Expected a nil release to be tolerated, got %+v", converted)
 	}
-}
\ No newline at end of file
+}
+
+func TestReleaseLabel(t *testing.T) {
+	release := Release{LabelInfo: []LabelInfo{
+		{CatalogNumber: "SELF 1", Label: Label{Name: "[no label]"}},
+		{CatalogNumber: "FX 240", Label: Label{Name: "FFRR"}},
+	}}
+	if label, catalog := releaseLabel(release, "", ""); label != "FFRR" || catalog != "FX 240" {
+		t.Errorf("Expected FFRR / FX 240, got %q / %q", label, catalog)
+	}
+
+	if label, catalog := releaseLabel(Release{}, "", ""); label != "" || catalog != "" {
+		t.Errorf("Expected nothing from a release without label info, got %q / %q", label, catalog)
+	}
+	if hasLabel(Release{LabelInfo: []LabelInfo{{CatalogNumber: "X"}}}) {
+		t.Error("Expected label info without a label name not to count as a label")
+	}
+}
+
+func TestReleaseLabel_CoRelease(t *testing.T) {
+	release := Release{LabelInfo: []LabelInfo{
+		{Label: Label{Name: "London Records"}},
+		{CatalogNumber: "FX 240", Label: Label{Name: "FFRR"}},
+		{CatalogNumber: "SUB 12", Label: Label{Name: "Sub Label"}},
+	}}
+
+	tests := []struct {
+		labelHint, catalogHint string
+		label, catalog         string
+	}{
+		{"", "", "FFRR", "FX 240"},               // First entry with a catalog number
+		{"sub label", "", "Sub Label", "SUB 12"}, // Label hint
+		{"", "sub-12", "Sub Label", "SUB 12"},    // Catalog hint, loosely compared
+		{"FFRR", "SUB12", "Sub Label", "SUB 12"}, // Catalog hint beats label hint
+		{"London Records", "", "London Records", ""},
+	}
+	for _, tt := range tests {
+		label, catalog := releaseLabel(release, tt.labelHint, tt.catalogHint)
+		if label != tt.label || catalog != tt.catalog {
+			t.Errorf("releaseLabel(%q, %q) = %q / %q, expected %q / %q", tt.labelHint, tt.catalogHint, label, catalog, tt.label, tt.catalog)
+		}
+	}
+
+	provider := NewMusicBrainzProvider()
+	metadata := provider.convertToTrackMetadata(&Recording{ID: "recording-id", Title: "Angel"}, &release, "Goldie", "Angel")
+	labels, _ := metadata.Extra["musicbrainz_labels"].([]string)
+	if len(labels) != 3 || labels[0] != "London Records" {
+		t.Errorf("Expected all three labels kept in Extra, got %v", metadata.Extra["musicbrainz_labels"])
+	}
+
+	other := Release{ID: "other", LabelInfo: []LabelInfo{{CatalogNumber: "FX 999", Label: Label{Name: "FFRR"}}}}
+	if got := preferCatalogNumber([]Release{other, release}, "fx240"); len(got) != 1 || got[0].ID != "" {
+		t.Errorf("Expected the release with the catalog number, got %+v", got)
+	}
+	if got := preferCatalogNumber([]Release{other, release}, "NOPE 1"); len(got) != 2 {
+		t.Errorf("Expected an unknown catalog number to be ignored, got %d releases", len(got))
+	}
+}
+
+func TestPreferReleasesWithTrack(t *testing.T) {
+	// Search results list each release's tracks under "track"
+	var recording Recording
+	err := json.Unmarshal([]byte(`{
+		"id": "recording-id", "title": "Inner City Life",
+		"releases": [
+			{"id": "single", "date": "1994-11-01", "media": [{"position": 1, "track": [{"number": "A", "title": "Saint Angel"}]}]},
+			{"id": "box", "date": "1995-07-01", "media": [
+				{"position": 1, "track-count": 10},
+				{"position": 2, "track": [{"number": "3", "title": "Inner City Life"}]}
+			]},
+			{"id": "unlisted", "date": "1996-01-01"}
+		]
+	}`), &recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	releases := preferReleasesWithTrack(recording.Releases, &recording)
+	if len(releases) != 2 || releases[0].ID != "box" || releases[1].ID != "unlisted" {
+		t.Fatalf("Expected the single without the track dropped, got %+v", releases)
+	}
+
+	provider := NewMusicBrainzProvider()
+	best := provider.findBestRelease(releases, true, "")
+	if best.ID != "box" {
+		t.Errorf("Expected the box set, got %s", best.ID)
+	}
+	metadata := provider.convertToTrackMetadata(&recording, best, "Goldie", "Inner City Life")
+	if metadata.Extra["musicbrainz_disc"] != 2 || metadata.Extra["musicbrainz_disc_count"] != 2 || metadata.Extra["musicbrainz_track_number"] != "3" {
+		t.Errorf("Expected disc 2 of 2, track 3, got %v", metadata.Extra)
+	}
+
+	// A lookup's track listing is matched by recording ID, not title
+	byID := Release{ID: "comp", Media: []Media{{Position: 1, Tracks: []Track{
+		{Number: "1", Title: "Inner City Life", Recording: Recording{ID: "other-mix"}},
+	}}}}
+	if got := preferReleasesWithTrack([]Release{byID, recording.Releases[0]}, &recording); len(got) != 2 {
+		t.Errorf("Expected every release kept when none lists the recording, got %d", len(got))
+	}
+}
+
+func TestMusicBrainzProvider_AlbumHintFallback(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+		if strings.Contains(query, "release:") {
+			fmt.Fprint(w, `{"count": 0, "recordings": []}`)
+			return
+		}
+		fmt.Fprint(w, `{
+			"count": 1,
+			"recordings": [{
+				"id": "recording-id",
+				"title": "Inner City Life",
+				"score": 100,
+				"artist-credit": [{"name": "Goldie", "artist": {"name": "Goldie"}}],
+				"releases": [{"id": "release-id", "title": "Timeless", "date": "1995", "label-info": [{"label": {"name": "FFRR"}}]}]
+			}]
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	req := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life", Album: "Now That's What I Call Jungle", MaxResults: 5}
+
+	metadata, err := provider.LookupWithHints(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected the search without the album hint to match, got %v", err)
+	}
+	if metadata.ProviderID != "recording-id" {
+		t.Errorf("Expected recording-id, got %s", metadata.ProviderID)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], `release:"Now That's What I Call Jungle"`) || strings.Contains(queries[1], "release:") {
+		t.Errorf("Expected an album-constrained search then an unconstrained one, got %q", queries)
+	}
+}
+
+func TestMusicBrainzProvider_LooseQueryFallback(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query().Get("query")
+		if strings.HasSuffix(r.URL.Path, "/recording") {
+			queries = append(queries, query)
+		}
+		if strings.Contains(query, `"`) {
+			fmt.Fprint(w, `{"count": 0, "recordings": []}`)
+			return
+		}
+		fmt.Fprint(w, `{
+			"count": 1,
+			"recordings": [{
+				"id": "recording-id",
+				"title": "Ni-Ten-Ichi-Ryu",
+				"score": 90,
+				"artist-credit": [{"name": "Photek", "artist": {"name": "Photek"}}],
+				"releases": [{"id": "release-id", "title": "Ni-Ten-Ichi-Ryu", "date": "1997"}]
+			}]
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	req := &enricher.SearchRequest{Artist: "Photek", Title: "Ni Ten Ichi Ryu", Album: "Modus Operandi", MaxResults: 5}
+
+	metadata, err := provider.LookupWithHints(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected the loose search to match, got %v", err)
+	}
+	if metadata.ProviderID != "recording-id" {
+		t.Errorf("Expected recording-id, got %s", metadata.ProviderID)
+	}
+	if len(queries) != 3 || queries[2] != `artist:(Photek) AND recording:(Ni Ten Ichi Ryu)` {
+		t.Errorf("Expected two strict searches then a loose one without the album, got %q", queries)
+	}
+
+	// A one-word artist and title search the same either way
+	queries = nil
+	if _, err := provider.Lookup(context.Background(), "Photek", "Rings"); err != enricher.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if len(queries) != 1 {
+		t.Errorf("Expected no loose search for a one-word title, got %q", queries)
+	}
+}
+
+func TestRecordingQuery(t *testing.T) {
+	req := &enricher.SearchRequest{Artist: "Dillinja & Lemon D", Title: "Killer Bees (VIP)", Album: "Valve"}
+
+	if got, want := recordingQuery(req, false), `artist:"Dillinja \& Lemon D" AND recording:"Killer Bees" AND release:"Valve"`; got != want {
+		t.Errorf("strict query = %q, expected %q", got, want)
+	}
+	if got, want := recordingQuery(req, true), `artist:(Dillinja \& Lemon D) AND recording:(Killer Bees)`; got != want {
+		t.Errorf("loose query = %q, expected %q", got, want)
+	}
+}
+
+func TestRecordingQuery_Featured(t *testing.T) {
+	req := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life (feat. Diane Charlemagne)"}
+	if got, want := recordingQuery(req, false), `artist:"Goldie" AND recording:"Inner City Life" artist:"Diane Charlemagne"`; got != want {
+		t.Errorf("strict query = %q, expected %q", got, want)
+	}
+
+	req = &enricher.SearchRequest{Artist: "High Contrast", Title: "Return Of Forever ft. Dynamite MC & Tali"}
+	if got, want := recordingQuery(req, true), `artist:(High Contrast) AND recording:(Return Of Forever) artist:"Dynamite MC" artist:"Tali"`; got != want {
+		t.Errorf("loose query = %q, expected %q", got, want)
+	}
+}
+
+func TestRecordingQuery_Duration(t *testing.T) {
+	req := &enricher.SearchRequest{Artist: "Goldie", Title: "Inner City Life", Duration: 6*time.Minute + 12*time.Second}
+	if got, want := recordingQuery(req, false), `artist:"Goldie" AND recording:"Inner City Life" dur:[369000 TO 375000]`; got != want {
+		t.Errorf("strict query = %q, expected %q", got, want)
+	}
+	if got := recordingQuery(req, true); strings.Contains(got, "dur:") {
+		t.Errorf("Expected the loose query to leave out the length, got %q", got)
+	}
+}
+
+func TestRankRecordings_Featured(t *testing.T) {
+	goldie := ArtistCredit{Name: "Goldie", Artist: Artist{Name: "Goldie"}}
+	diane := ArtistCredit{Name: "Diane Charlemagne", Artist: Artist{Name: "Diane Charlemagne"}}
+	recordings := []Recording{
+		{ID: "instrumental", Title: "Inner City Life", Score: 100, ArtistCredit: []ArtistCredit{goldie}},
+		{ID: "vocal", Title: "Inner City Life", Score: 100, ArtistCredit: []ArtistCredit{goldie, diane}},
+	}
+
+	ranked := rankRecordings(recordings, "Goldie", "Inner City Life (feat. Diane Charlemagne)", 0, DefaultMatchWeights())
+	if len(ranked) != 2 || ranked[0].ID != "vocal" {
+		t.Fatalf("Expected the recording crediting the featured vocalist first, got %+v", ranked)
+	}
+
+	// The guest credit stands in for the title's "feat.", so it's an exact match
+	if got := matchQuality(ranked[0], "Goldie", "Inner City Life (feat. Diane Charlemagne)"); got != enricher.MatchExact {
+		t.Errorf("Expected an exact match, got %v", got)
+	}
+}
+
+func TestRankRecordings_Remix(t *testing.T) {
+	goldie := []ArtistCredit{{Name: "Goldie", Artist: Artist{Name: "Goldie"}}}
+	recordings := []Recording{
+		{ID: "original", Title: "Inner City Life", Score: 100, ArtistCredit: goldie},
+		{ID: "other-remix", Title: "Inner City Life (Photek remix)", Score: 100, ArtistCredit: goldie},
+		{ID: "remix", Title: "Inner City Life", Disambiguation: "Roni Size remix", Score: 95, ArtistCredit: goldie},
+	}
+
+	ranked := rankRecordings(recordings, "Goldie", "Inner City Life (Roni Size Remix)", 0, DefaultMatchWeights())
+	if len(ranked) != 3 || ranked[0].ID != "remix" {
+		t.Fatalf("Expected the recording disambiguated as the Roni Size remix first, got %+v", ranked)
+	}
+
+	// A mix that names no one is no signal
+	ranked = rankRecordings(recordings, "Goldie", "Inner City Life (Original Mix)", 0, DefaultMatchWeights())
+	if ranked[0].ID != "original" {
+		t.Errorf("Expected search order for an original mix, got %s first", ranked[0].ID)
+	}
+
+	provider := NewMusicBrainzProvider()
+	metadata := provider.convertToTrackMetadata(ranked[0], nil, "Goldie", "Inner City Life (Roni Size Remix)")
+	if metadata.Extra["remix"] != "Roni Size Remix" {
+		t.Errorf("Expected the remix kept in Extra, got %v", metadata.Extra["remix"])
+	}
+}
+
+func TestMatchArtist_Composite(t *testing.T) {
+	photek := ArtistCredit{Artist: Artist{Name: "Photek"}}
+	goldie := ArtistCredit{Artist: Artist{Name: "Goldie", Aliases: []Alias{{Name: "Rufige Kru"}}}}
+
+	if got := matchArtist([]ArtistCredit{photek, goldie}, "Photek/Goldie"); got != artistNameMatch {
+		t.Errorf("Expected a slash-joined artist to match when both artists are credited, got %v", got)
+	}
+	if got := matchArtist([]ArtistCredit{photek, goldie}, "Photek / Rufige Kru"); got != artistAliasMatch {
+		t.Errorf("Expected an alias match through a slash-joined artist, got %v", got)
+	}
+	if got := matchArtist([]ArtistCredit{goldie}, "Photek/Goldie"); got != artistNoMatch {
+		t.Errorf("Expected no match when only one of the artists is credited, got %v", got)
+	}
+
+	ac := ArtistCredit{Artist: Artist{Name: "AC"}}
+	acdc := ArtistCredit{Artist: Artist{Name: "AC/DC"}}
+	if got := matchArtist([]ArtistCredit{ac}, "AC/DC"); got != artistNoMatch {
+		t.Errorf("Expected a credit for part of a slash-joined name not to match, got %v", got)
+	}
+	if got := matchArtist([]ArtistCredit{acdc}, "AC/DC"); got != artistNameMatch {
+		t.Errorf("Expected the whole name to match its credit, got %v", got)
+	}
+
+	req := &enricher.SearchRequest{Artist: "Photek/Goldie", Title: "Angel"}
+	if got, want := recordingQuery(req, true), `artist:(Photek Goldie) AND recording:(Angel)`; got != want {
+		t.Errorf("loose query = %q, expected %q", got, want)
+	}
+}
+
+func TestMusicBrainzProvider_MatchWeights(t *testing.T) {
+	recordings := []Recording{
+		{ID: "title-only", Title: "Inner City Life", Score: 90, ArtistCredit: []ArtistCredit{{Artist: Artist{Name: "Burial"}}}},
+		{ID: "artist-only", Title: "Inner City Life (VIP)", Score: 85, ArtistCredit: []ArtistCredit{{Artist: Artist{Name: "Goldie"}}}},
+	}
+
+	// With the defaults, title and artist count the same
+	best := NewMusicBrainzProvider().findBestRecordingMatch(recordings, "Goldie", "Inner City Life", 0)
+	if best == nil || best.ID != "title-only" {
+		t.Errorf("Expected the higher-scoring title match by default, got %+v", best)
+	}
+
+	weights := DefaultMatchWeights()
+	weights.Artist = 30
+	best = NewMusicBrainzProvider(WithMatchWeights(weights)).findBestRecordingMatch(recordings, "Goldie", "Inner City Life", 0)
+	if best == nil || best.ID != "artist-only" {
+		t.Errorf("Expected a heavier artist weight to pick the artist match, got %+v", best)
+	}
+}
@@ -0,0 +1,145 @@
+// pkg/enricher/musicbrainz/query.go - Recording search query templates
+
+package musicbrainz
+
+import (
+	"errors"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/enricher"
+	"github.com/cerberussg/tagger/pkg/normalize"
+)
+
+// DefaultQueryTemplate builds the recording search used unless
+// WithQueryTemplate is given: artist and title as phrases, narrowed to the
+// album when there is one, with optional terms for featured guests and the
+// file's length
+const DefaultQueryTemplate = `{{.ArtistClause}} AND recording:"{{.Title}}"{{if .Album}} AND release:"{{.Album}}"{{end}}{{.Featured}}{{.Duration}}`
+
+// defaultQuery is DefaultQueryTemplate, parsed
+var defaultQuery = template.Must(parseQueryTemplate(DefaultQueryTemplate))
+
+// QueryFields are the values a query template is executed with. Artist,
+// Title, Album, Year and CatNo are escaped for use inside a quoted phrase;
+// Title has qualifiers such as "(Original Mix)" removed.
+type QueryFields struct {
+	Artist string
+	Title  string
+	Album  string
+	Year   string
+	CatNo  string
+
+	// ArtistClause is the default's artist term: artist:"..." or, for a
+	// name with "The" or a slash-joined one, several of them ORed together
+	ArtistClause string
+
+	// Featured holds an optional artist:"..." term, with a leading space,
+	// for each guest named in the title ("feat. X"), or ""
+	Featured string
+
+	// Duration holds an optional dur:[...] term, with a leading space, for
+	// recordings within lengthTolerance of the file's length, or "" when
+	// the length is unknown
+	Duration string
+}
+
+// queryFields fills the template fields for req
+func queryFields(req *enricher.SearchRequest) QueryFields {
+	title, _ := normalize.CleanTitle(req.Title)
+	return QueryFields{
+		Artist:       luceneTerm(req.Artist),
+		Title:        luceneTerm(title),
+		Album:        luceneTerm(req.Album),
+		Year:         luceneTerm(req.Year),
+		CatNo:        luceneTerm(req.CatalogNumber),
+		ArtistClause: artistClause(req.Artist),
+		Featured:     featuredClause(req.Title),
+		Duration:     durationClause(req.Duration),
+	}
+}
+
+// sampleQueryRequests are run through a template when it is parsed, with
+// and without the optional fields, to catch mistakes before any search
+var sampleQueryRequests = []*enricher.SearchRequest{
+	{Artist: "Goldie", Title: "Inner City Life (feat. Diane Charlemagne)", Album: "Timeless", Year: "1995", CatalogNumber: "828 614-2", Duration: 351 * time.Second},
+	{Artist: "Photek", Title: "Ni Ten Ichi Ryu"},
+}
+
+// ParseQueryTemplate parses a Go text/template for the recording search
+// (see QueryFields) and checks it produces a usable query: not empty, with
+// balanced quotes and parentheses. Queries are tried against sample
+// requests, so a template that only breaks for some files can still pass.
+func ParseQueryTemplate(text string) (*template.Template, error) {
+	tmpl, err := parseQueryTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range sampleQueryRequests {
+		if _, err := executeQuery(tmpl, req); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+func parseQueryTemplate(text string) (*template.Template, error) {
+	return template.New("query").Option("missingkey=error").Parse(text)
+}
+
+// WithQueryTemplate replaces the recording search query with one built
+// from tmpl, e.g. to add "AND status:official". Use ParseQueryTemplate to
+// build it. The loose fallback search, tried when nothing is found, is not
+// affected.
+func WithQueryTemplate(tmpl *template.Template) Option {
+	return func(m *MusicBrainzProvider) {
+		if tmpl != nil {
+			m.query = tmpl
+		}
+	}
+}
+
+// executeQuery runs tmpl for req and checks the result
+func executeQuery(tmpl *template.Template, req *enricher.SearchRequest) (string, error) {
+	var query strings.Builder
+	if err := tmpl.Execute(&query, queryFields(req)); err != nil {
+		return "", err
+	}
+	q := strings.TrimSpace(query.String())
+	return q, validateQuery(q)
+}
+
+// validateQuery catches the Lucene syntax errors a template is likely to
+// introduce. MusicBrainz answers those with an error or, worse, nothing.
+func validateQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return errors.New("query is empty")
+	}
+
+	depth, quoted, escaped := 0, false, false
+	for _, r := range query {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth--; depth < 0 {
+				return errors.New("query has an unmatched ')'")
+			}
+		}
+	}
+	if quoted {
+		return errors.New("query has an unclosed quote")
+	}
+	if depth > 0 {
+		return errors.New("query has an unclosed '('")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// pkg/enricher/musicbrainz/query_test.go
+
+package musicbrainz
+
+import (
+	"testing"
+
+	"github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestParseQueryTemplate(t *testing.T) {
+	for _, text := range []string{
+		`{{.ArtistClause}} AND recording:"{{.Title}}`,
+		`artist:({{.Artist}} AND recording:"{{.Title}}"`,
+		`artist:"{{.Artist}}") AND recording:"{{.Title}}"`,
+		`{{.Label}}`,
+		`{{.Artist`,
+		`{{if .Album}}release:"{{.Album}}"{{end}}`,
+	} {
+		if _, err := ParseQueryTemplate(text); err == nil {
+			t.Errorf("Expected %q to be rejected", text)
+		}
+	}
+
+	tmpl, err := ParseQueryTemplate(DefaultQueryTemplate + " AND status:official")
+	if err != nil {
+		t.Fatalf("ParseQueryTemplate returned error: %v", err)
+	}
+	m := NewMusicBrainzProvider(WithQueryTemplate(tmpl))
+	req := &enricher.SearchRequest{Artist: "Dillinja & Lemon D", Title: "Killer Bees (VIP)", Album: "Valve"}
+	if got, want := m.recordingQuery(req, false), `artist:"Dillinja \& Lemon D" AND recording:"Killer Bees" AND release:"Valve" AND status:official`; got != want {
+		t.Errorf("query = %q, expected %q", got, want)
+	}
+	if got, want := m.recordingQuery(req, true), recordingQuery(req, true); got != want {
+		t.Errorf("Expected the loose query unchanged, got %q", got)
+	}
+}
+
+func TestRecordingQuery_TemplateFallback(t *testing.T) {
+	// Valid for the sample requests, but not for this artist
+	tmpl, err := ParseQueryTemplate(`{{if eq .Artist "Dillinja"}}"{{end}}artist:"{{.Artist}}" AND recording:"{{.Title}}" AND date:{{.Year}}`)
+	if err != nil {
+		t.Fatalf("ParseQueryTemplate returned error: %v", err)
+	}
+	m := NewMusicBrainzProvider(WithQueryTemplate(tmpl))
+
+	req := &enricher.SearchRequest{Artist: "Photek", Title: "The Hidden Camera", Year: "1996"}
+	if got, want := m.recordingQuery(req, false), `artist:"Photek" AND recording:"The Hidden Camera" AND date:1996`; got != want {
+		t.Errorf("query = %q, expected %q", got, want)
+	}
+	req = &enricher.SearchRequest{Artist: "Dillinja", Title: "The Angels Fell"}
+	if got, want := m.recordingQuery(req, false), recordingQuery(req, false); got != want {
+		t.Errorf("Expected the default query when the template's is invalid, got %q", got)
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	testCases := map[string]bool{
+		`artist:"Goldie" AND recording:"Inner City Life"`:                     true,
+		`(artist:"Goldie" OR artist:"Rufige Kru") AND recording:"Terminator"`: true,
+		`artist:"Shy FX \"Original\" Nuttah"`:                                 true,
+		`recording:"(Sound of the Future"`:                                    true,
+		`artist:"Goldie`:                                                      false,
+		`(artist:"Goldie"`:                                                    false,
+		`artist:"Goldie")`:                                                    false,
+		`   `:                                                                 false,
+	}
+
+	for query, valid := range testCases {
+		if err := validateQuery(query); (err == nil) != valid {
+			t.Errorf("validateQuery(%q) = %v, expected valid: %t", query, err, valid)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// pkg/enricher/musicbrainz/request.go
+
+package musicbrainz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/enricher"
+)
+
+const (
+	defaultSearchTimeout = 15 * time.Second
+	defaultLookupTimeout = 10 * time.Second
+
+	maxRetries     = 2
+	initialBackoff = time.Second
+)
+
+// phaseTimeout returns how long a phase may run: its own limit, trimmed so
+// that reserve is left on ctx's deadline for the phases after it. The
+// reserve never takes more than half of the remaining time.
+func phaseTimeout(ctx context.Context, limit, reserve time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return limit
+	}
+
+	remaining := time.Until(deadline)
+	if reserve > remaining/2 {
+		reserve = remaining / 2
+	}
+	if available := remaining - reserve; available < limit {
+		return available
+	}
+	return limit
+}
+
+// getJSON performs a rate-limited, budgeted GET against the web service and
+// decodes the response into v. Rate limiting (503 and 429) and network
+// failures are retried with exponential backoff while ctx allows; other
+// failures are returned at once.
+func (m *MusicBrainzProvider) getJSON(ctx context.Context, requestURL string, v interface{}) error {
+	backoff := initialBackoff
+
+	for attempt := 0; ; attempt++ {
+		if err := m.budget.Take(); err != nil {
+			return err
+		}
+		if err := m.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("User-Agent", m.userAgent)
+		httpReq.Header.Set("Accept", "application/json")
+
+		resp, err := m.client.Do(httpReq)
+		if err == nil {
+			err = decodeResponse(resp, v)
+		} else {
+			err = fmt.Errorf("%w: http request failed: %w", enricher.ErrNetwork, err)
+		}
+		if err == nil {
+			return nil
+		}
+		// Preserve context errors without wrapping
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if enricher.KindOf(err).Retryable() && attempt < maxRetries {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return err
+	}
+}
+
+// decodeResponse decodes a web service response into v and closes its body
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return enricher.ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError("musicbrainz API", resp.StatusCode)
+	}
+
+	body, err := enricher.ReadBody(resp.Body, enricher.MaxResponseSize)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: failed to parse JSON response: %w", enricher.ErrAPIError, err)
+	}
+	return nil
+}
+
+// statusError describes an unexpected HTTP status from service, wrapping
+// the common error that classifies it. MusicBrainz answers 503 when a
+// client goes over its rate limit.
+func statusError(service string, status int) error {
+	kind := enricher.ErrAPIError
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		kind = enricher.ErrRateLimit
+	case http.StatusUnauthorized, http.StatusForbidden:
+		kind = enricher.ErrAuth
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		kind = enricher.ErrNetwork
+	}
+	return fmt.Errorf("%w: %s returned status %d", kind, service, status)
+}
+
+// providerError reports a failed operation as an enricher.ProviderError,
+// classified so callers needn't match on its text
+func (m *MusicBrainzProvider) providerError(op string, err error) error {
+	return &enricher.ProviderError{Provider: m.Name(), Op: op, Kind: enricher.KindOf(err), Err: err}
+}
+
+// lookupRecording fetches a recording with its artists and releases,
+// including each release's media so a preferred format can be picked and
+// its release group for the first release date
+func (m *MusicBrainzProvider) lookupRecording(ctx context.Context, recordingID string) (*RecordingDetail, error) {
+	var recording RecordingDetail
+	requestURL := fmt.Sprintf("%s/recording/%s?inc=artists+releases+release-groups+media&fmt=json", m.baseURL, recordingID)
+	if err := m.getJSON(ctx, requestURL, &recording); err != nil {
+		return nil, err
+	}
+	return &recording, nil
+}
+
+// lookupISRC fetches the recordings an ISRC is assigned to, with their
+// artists so the closest can be picked
+func (m *MusicBrainzProvider) lookupISRC(ctx context.Context, isrc string) (*ISRCResult, error) {
+	var result ISRCResult
+	requestURL := fmt.Sprintf("%s/isrc/%s?inc=artists&fmt=json", m.baseURL, isrc)
+	if err := m.getJSON(ctx, requestURL, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// lookupRelease fetches a release with its labels, media and release
+// group, for when the search results didn't include them
+func (m *MusicBrainzProvider) lookupRelease(ctx context.Context, releaseID string) (*Release, error) {
+	var release Release
+	requestURL := fmt.Sprintf("%s/release/%s?inc=labels+media+release-groups&fmt=json", m.baseURL, releaseID)
+	if err := m.getJSON(ctx, requestURL, &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
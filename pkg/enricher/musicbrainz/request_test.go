@@ -0,0 +1,140 @@
+// pkg/enricher/musicbrainz/request_test.go
+
+package musicbrainz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/enricher"
+)
+
+func TestPhaseTimeout(t *testing.T) {
+	if got := phaseTimeout(context.Background(), 15*time.Second, 10*time.Second); got != 15*time.Second {
+		t.Errorf("Expected full limit without a deadline, got %v", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	// 20s remaining, 10s reserved for the lookup: search gets ~10s
+	if got := phaseTimeout(ctx, 15*time.Second, 10*time.Second); got > 10*time.Second || got < 9*time.Second {
+		t.Errorf("Expected ~10s for search, got %v", got)
+	}
+
+	// A reserve larger than half the remaining time is capped at half
+	if got := phaseTimeout(ctx, 15*time.Second, 30*time.Second); got > 10*time.Second || got < 9*time.Second {
+		t.Errorf("Expected ~10s when reserve exceeds half, got %v", got)
+	}
+}
+
+func TestMusicBrainzProvider_LookupFetchesMissingLabel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(r.URL.Path, "/release/") {
+			fmt.Fprint(w, `{"id": "release-id", "label-info": [{"catalog-number": "MPRLP01", "label": {"name": "Metalheadz"}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{
+			"count": 1,
+			"recordings": [{
+				"id": "recording-id",
+				"title": "Inner City Life",
+				"score": 100,
+				"artist-credit": [{"name": "Goldie", "artist": {"name": "Goldie"}}],
+				"releases": [{"id": "release-id", "title": "Timeless", "date": "1995"}]
+			}]
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	result, err := provider.Lookup(context.Background(), "Goldie", "Inner City Life")
+	if err != nil {
+		t.Fatalf("Lookup returned error: %v", err)
+	}
+
+	if result.Label != "Metalheadz" || result.CatalogNumber != "MPRLP01" {
+		t.Errorf("Expected label from release lookup, got label=%q catalog=%q", result.Label, result.CatalogNumber)
+	}
+}
+
+func TestMusicBrainzProvider_RetriesServiceUnavailable(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"count": 0, "recordings": []}`)
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	if _, err := provider.Lookup(context.Background(), "Goldie", "Inner City Life"); err != enricher.ErrNotFound {
+		t.Errorf("Expected ErrNotFound after retry, got %v", err)
+	}
+	// The failed attempt and its retry, then the loose search after the miss
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestMusicBrainzProvider_ClassifiesFailures(t *testing.T) {
+	testCases := []struct {
+		status   int
+		kind     enricher.ErrorKind
+		attempts int
+	}{
+		{http.StatusInternalServerError, enricher.KindBadResponse, 1},
+		{http.StatusForbidden, enricher.KindAuth, 1},
+		{http.StatusTooManyRequests, enricher.KindRateLimited, maxRetries + 1},
+	}
+
+	for _, tc := range testCases {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(tc.status)
+		}))
+
+		provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+		_, err := provider.Lookup(context.Background(), "Goldie", "Angel")
+		server.Close()
+
+		var providerErr *enricher.ProviderError
+		if !errors.As(err, &providerErr) {
+			t.Errorf("status %d: expected a ProviderError, got %v", tc.status, err)
+			continue
+		}
+		if providerErr.Kind != tc.kind || providerErr.Op != "recording search" {
+			t.Errorf("status %d: expected a %v recording search failure, got %v %q", tc.status, tc.kind, providerErr.Kind, providerErr.Op)
+		}
+		if attempts != tc.attempts {
+			t.Errorf("status %d: expected %d attempts, got %d", tc.status, tc.attempts, attempts)
+		}
+	}
+}
+
+func TestDecodeResponse_TooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count": 1, "recordings": [], "padding": "`)
+		w.Write([]byte(strings.Repeat("x", enricher.MaxResponseSize)))
+		fmt.Fprint(w, `"}`)
+	}))
+	defer server.Close()
+
+	provider := NewMusicBrainzProvider(WithBaseURL(server.URL))
+	_, err := provider.Lookup(context.Background(), "Goldie", "Inner City Life")
+	if !errors.Is(err, enricher.ErrAPIError) || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Expected an oversized response to be refused, got %v", err)
+	}
+}
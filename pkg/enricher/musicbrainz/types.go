@@ -18,15 +18,26 @@ type Recording struct {
 	Score        int            `json:"score"` // Search relevance score
 	ArtistCredit []ArtistCredit `json:"artist-credit"`
 	Releases     []Release      `json:"releases,omitempty"`
+	
+	// Disambiguation tells apart recordings sharing a title, often by
+	// naming the mix ("Roni Size remix")
+	Disambiguation string `json:"disambiguation,omitempty"`
 }
 
 // RecordingDetail represents detailed recording info with releases
 type RecordingDetail struct {
-	ID           string         `json:"id"`
-	Title        string         `json:"title"`
-	Length       int            `json:"length,omitempty"`
-	ArtistCredit []ArtistCredit `json:"artist-credit"`
-	Releases     []Release      `json:"releases"`
+	ID             string         `json:"id"`
+	Title          string         `json:"title"`
+	Length         int            `json:"length,omitempty"`
+	ArtistCredit   []ArtistCredit `json:"artist-credit"`
+	Releases       []Release      `json:"releases"`
+	Disambiguation string         `json:"disambiguation,omitempty"`
+}
+
+// ISRCResult represents the recordings an ISRC is assigned to
+type ISRCResult struct {
+	ISRC       string      `json:"isrc"`
+	Recordings []Recording `json:"recordings"`
 }
 
 // ArtistCredit represents artist credit information
@@ -139,6 +150,19 @@ type Media struct {
 	Position   int     `json:"position"`
 	TrackCount int     `json:"track-count"`
 	Tracks     []Track `json:"tracks,omitempty"`
+
+	// SearchTracks holds the media's tracks in search results, which list
+	// them under "track" rather than "tracks"
+	SearchTracks []Track `json:"track,omitempty"`
+}
+
+// AllTracks returns the tracks listed for the media, from either a lookup
+// or a search response
+func (m Media) AllTracks() []Track {
+	if len(m.Tracks) > 0 {
+		return m.Tracks
+	}
+	return m.SearchTracks
 }
 
 // Track represents an individual track on media
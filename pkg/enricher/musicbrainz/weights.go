@@ -0,0 +1,102 @@
+// pkg/enricher/musicbrainz/weights.go - Recording match scoring weights
+
+package musicbrainz
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cerberussg/tagger/pkg/normalize"
+)
+
+// MatchWeights are the bonuses added to a recording's MusicBrainz search
+// score (0-100) when ranking candidates. Raise Artist relative to Title
+// when titles in a collection are often mangled but artists are reliable.
+type MatchWeights struct {
+	Title          int // Title matches exactly (after folding)
+	CoreTitle      int // Titles match once qualifiers like "(Original Mix)" are removed
+	Artist         int // A credited, canonical or sort name, or one of the artist's aliases, matches
+	Featured       int // A guest named in the title ("feat. X") is credited
+	Remix          int // The remixer named in the title ("Roni Size Remix") is in the recording's title or disambiguation
+	Length         int // Length within lengthTolerance of the file's
+	LengthMismatch int // Length differs by more than lengthMismatch; usually negative
+}
+
+// DefaultMatchWeights returns the weights used unless WithMatchWeights is
+// given
+func DefaultMatchWeights() MatchWeights {
+	return MatchWeights{
+		Title:          10,
+		CoreTitle:      5,
+		Artist:         10,
+		Featured:       5,
+		Remix:          10,
+		Length:         5,
+		LengthMismatch: -10,
+	}
+}
+
+// WithMatchWeights replaces the bonuses used to rank recordings
+func WithMatchWeights(weights MatchWeights) Option {
+	return func(m *MusicBrainzProvider) {
+		m.weights = weights
+	}
+}
+
+// artistBonus scores target naming the credited artists. An alias
+// (including a former name) earns the same bonus as the primary name.
+func (w MatchWeights) artistBonus(credits []ArtistCredit, target string) int {
+	if matchArtist(credits, target) == artistNoMatch {
+		return 0
+	}
+	return w.Artist
+}
+
+// featuredBonus scores a recording crediting any of the guests named in
+// the title, once however many are credited
+func (w MatchWeights) featuredBonus(credits []ArtistCredit, featured []string) int {
+	for _, name := range featured {
+		if matchArtist(credits, name) != artistNoMatch {
+			return w.Featured
+		}
+	}
+	return 0
+}
+
+// remixBonus scores a recording whose title or disambiguation names
+// remixer. An empty remixer (no remix, or one such as "Original Mix" that
+// names no one) scores 0.
+func (w MatchWeights) remixBonus(recording *Recording, remixer string) int {
+	if remixer == "" {
+		return 0
+	}
+	remixer = strings.ToLower(remixer)
+	for _, text := range []string{recording.Title, recording.Disambiguation} {
+		if strings.Contains(strings.ToLower(normalize.Fold(text)), remixer) {
+			return w.Remix
+		}
+	}
+	return 0
+}
+
+// lengthBonus scores a recording's length in milliseconds against the
+// file's. Unknown lengths on either side score 0.
+func (w MatchWeights) lengthBonus(lengthMS int, target time.Duration) int {
+	if lengthMS <= 0 || target <= 0 {
+		return 0
+	}
+
+	diff := time.Duration(lengthMS)*time.Millisecond - target
+	if diff < 0 {
+		diff = -diff
+	}
+
+	switch {
+	case diff <= lengthTolerance:
+		return w.Length
+	case diff > lengthMismatch:
+		return w.LengthMismatch
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,25 @@
+// pkg/enricher/response.go - Bounded reads of provider responses
+
+package enricher
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxResponseSize caps a web service response. Real ones are well under a
+// megabyte; a broken proxy or endpoint could send far more.
+const MaxResponseSize = 10 << 20
+
+// ReadBody reads all of r, failing with ErrAPIError rather than buffering
+// more than limit bytes. A failed read is ErrNetwork.
+func ReadBody(r io.Reader, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read response body: %w", ErrNetwork, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w: response body exceeds %d bytes", ErrAPIError, limit)
+	}
+	return body, nil
+}
@@ -0,0 +1,19 @@
+// pkg/enricher/response_test.go
+
+package enricher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadBody(t *testing.T) {
+	body, err := ReadBody(strings.NewReader("12345"), 5)
+	if err != nil || string(body) != "12345" {
+		t.Errorf("Expected a body at the limit to be read, got %q, %v", body, err)
+	}
+	if _, err := ReadBody(strings.NewReader("123456"), 5); !errors.Is(err, ErrAPIError) {
+		t.Errorf("Expected ErrAPIError past the limit, got %v", err)
+	}
+}
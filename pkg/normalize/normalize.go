@@ -0,0 +1,346 @@
+// pkg/normalize/normalize.go - Shared fuzzy string handling
+
+// Package normalize holds the string normalization used when parsing
+// filenames and matching against provider data.
+package normalize
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// quoteFolder maps typographic quotes to their ASCII equivalents
+var quoteFolder = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+)
+
+// Fold composes Unicode (NFC) so combining accents compare equal to
+// precomposed ones, folds smart quotes to ASCII and collapses whitespace.
+// Case is preserved.
+func Fold(s string) string {
+	s = quoteFolder.Replace(norm.NFC.String(s))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// MatchKey reduces s to the form names and titles are compared in, both
+// when matching provider results and when keying cached lookups, so the two
+// agree on what counts as the same: Fold, then lowercase, with accents
+// removed ("Amélie" is "amelie"), apostrophes and periods dropped ("L.T.J."
+// is "ltj", "Don't" is "dont") and other punctuation treated as a space,
+// whitespace collapsed. A name that is nothing but punctuation, such as
+// "!!!", is kept as it is, lowercased.
+func MatchKey(s string) string {
+	folded := strings.ToLower(Fold(s))
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(folded) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '.':
+			// dropped
+		case unicode.IsPunct(r):
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	key := strings.Join(strings.Fields(norm.NFC.String(b.String())), " ")
+	if key == "" {
+		return folded
+	}
+	return key
+}
+
+// delimiterDashPattern matches a figure/en/em dash or horizontal bar with
+// whitespace or underscores on both sides
+var delimiterDashPattern = regexp.MustCompile(`[\s_]+[\x{2012}\x{2013}\x{2014}\x{2015}][\s_]+`)
+
+// DelimiterDashes turns dashes used as delimiters ("Artist — Title") into
+// " - ". Unspaced dashes ("Timeless—Inner City Life") are left alone since
+// they belong to the text.
+func DelimiterDashes(s string) string {
+	return delimiterDashPattern.ReplaceAllString(s, " - ")
+}
+
+// StripArtistThe removes a leading "The " or trailing ", The" so "The
+// Prodigy" and "Prodigy, The" both become "Prodigy". A bare "The" is kept.
+func StripArtistThe(s string) string {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+
+	switch {
+	case strings.HasPrefix(lower, "the ") && len(s) > len("the "):
+		return strings.TrimSpace(s[len("the "):])
+	case strings.HasSuffix(lower, ", the"):
+		return strings.TrimSpace(s[:len(s)-len(", the")])
+	}
+	return s
+}
+
+var (
+	// trailingQualifierPattern matches a trailing "(...)" or "[...]" group
+	trailingQualifierPattern = regexp.MustCompile(`\s*[(\[]([^()\[\]]*)[)\]]\s*$`)
+
+	// trailingFeaturePattern matches an unbracketed trailing "feat. X"
+	trailingFeaturePattern = regexp.MustCompile(`(?i)\s+((?:feat\.?|ft\.?|featuring)\s+.+)$`)
+)
+
+// CleanTitle splits a title into its core and any trailing qualifiers such
+// as "(Original Mix)", "[VIP]" or "feat. X", returned in reading order.
+// "Inner City Life (Roni Size Remix) [Remastered]" gives "Inner City Life"
+// and ["Roni Size Remix", "Remastered"].
+func CleanTitle(s string) (core string, qualifiers []string) {
+	core = Fold(s)
+
+	for {
+		m := trailingQualifierPattern.FindStringSubmatchIndex(core)
+		if m == nil || m[0] == 0 {
+			break
+		}
+		if q := strings.TrimSpace(core[m[2]:m[3]]); q != "" {
+			qualifiers = append([]string{q}, qualifiers...)
+		}
+		core = core[:m[0]]
+	}
+
+	if m := trailingFeaturePattern.FindStringSubmatchIndex(core); m != nil {
+		qualifiers = append([]string{core[m[2]:m[3]]}, qualifiers...)
+		core = core[:m[0]]
+	}
+
+	return strings.TrimSpace(core), qualifiers
+}
+
+var (
+	// featurePrefixPattern matches the "feat." that opens a guest credit
+	featurePrefixPattern = regexp.MustCompile(`(?i)^(?:feat\.?|ft\.?|featuring)\s+`)
+
+	// featuredSeparatorPattern splits a guest credit naming several artists
+	featuredSeparatorPattern = regexp.MustCompile(`\s*(?:,|&)\s*`)
+)
+
+// SplitFeatured takes the guest credits out of a title, so "State Of Mind
+// (feat. Diane Charlemagne)" gives "State Of Mind" and ["Diane
+// Charlemagne"]. Other qualifiers stay in the title in brackets. A title
+// without a guest credit is returned unchanged.
+func SplitFeatured(s string) (title string, featured []string) {
+	core, qualifiers := CleanTitle(s)
+	title = core
+	for _, q := range qualifiers {
+		loc := featurePrefixPattern.FindStringIndex(q)
+		if loc == nil {
+			title += " (" + q + ")"
+			continue
+		}
+		for _, name := range featuredSeparatorPattern.Split(q[loc[1]:], -1) {
+			if name != "" {
+				featured = append(featured, name)
+			}
+		}
+	}
+
+	if featured == nil {
+		return s, nil
+	}
+	return title, featured
+}
+
+// luceneEscaper escapes characters with special meaning in Lucene queries
+var luceneEscaper = strings.NewReplacer(
+	`\`, `\\`, `+`, `\+`, `-`, `\-`, `&`, `\&`, `|`, `\|`, `!`, `\!`,
+	`(`, `\(`, `)`, `\)`, `{`, `\{`, `}`, `\}`, `[`, `\[`, `]`, `\]`,
+	`^`, `\^`, `"`, `\"`, `~`, `\~`, `*`, `\*`, `?`, `\?`, `:`, `\:`,
+	`/`, `\/`,
+)
+
+// EscapeLucene escapes s for use as a term or inside a quoted phrase in a
+// Lucene query, such as a MusicBrainz search
+func EscapeLucene(s string) string {
+	return luceneEscaper.Replace(s)
+}
+
+// genreAliases maps lowercase genre shorthand to the canonical name written
+// to tags. Keys have "&", "'n'" and " n " folded to "and" (see genreKey).
+var genreAliases = map[string]string{
+	"dnb":           "Drum and Bass",
+	"dandb":         "Drum and Bass",
+	"drum and bass": "Drum and Bass",
+	"drumandbass":   "Drum and Bass",
+	"jungle":        "Jungle",
+	"house":         "House",
+	"deep house":    "Deep House",
+	"tech house":    "Tech House",
+	"techno":        "Techno",
+	"breakbeat":     "Breakbeat",
+	"breaks":        "Breakbeat",
+	"dubstep":       "Dubstep",
+	"garage":        "UK Garage",
+	"ukg":           "UK Garage",
+	"uk garage":     "UK Garage",
+	"trance":        "Trance",
+	"ambient":       "Ambient",
+	"electro":       "Electro",
+	"idm":           "IDM",
+	"hardcore":      "Hardcore",
+	"edm":           "Electronic",
+	"electronic":    "Electronic",
+}
+
+// genreAndPattern matches the ways "and" gets written in genre shorthand
+var genreAndPattern = regexp.MustCompile(`\s*(&|'n'|’n’|\bn\b)\s*`)
+
+// genreKey lowercases a genre and folds its "and" spellings
+func genreKey(s string) string {
+	s = strings.ToLower(Fold(s))
+	return strings.TrimSpace(genreAndPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.TrimSpace(m) == m {
+			return "and" // unspaced, as in "d&b"
+		}
+		return " and "
+	}))
+}
+
+// GenreMap maps genre keys (see genreKey) to canonical genre names
+type GenreMap map[string]string
+
+// defaultGenres is the built-in map used by Genre
+var defaultGenres = NewGenreMap(nil)
+
+// NewGenreMap returns the built-in genre aliases plus extra, which maps
+// alias spellings to canonical names and wins over the built-ins. An extra
+// alias of a built-in genre renames it, so {"dnb": "Drum & Bass"} applies
+// to every DnB spelling. Every canonical name also maps to itself, so
+// "Liquid DnB" isn't re-cased.
+func NewGenreMap(extra map[string]string) GenreMap {
+	m := make(GenreMap, len(genreAliases)+len(extra))
+	for alias, canonical := range genreAliases {
+		m.add(alias, canonical)
+	}
+
+	aliases := make([]string, 0, len(extra))
+	for alias := range extra {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		canonical := strings.TrimSpace(extra[alias])
+		if previous, ok := m[genreKey(alias)]; ok && canonical != "" {
+			for key, name := range m {
+				if name == previous {
+					m[key] = canonical
+				}
+			}
+		}
+		m.add(alias, canonical)
+	}
+	return m
+}
+
+// add maps alias to canonical, and canonical to itself unless it is
+// already an alias
+func (m GenreMap) add(alias, canonical string) {
+	canonical = strings.TrimSpace(canonical)
+	if key := genreKey(alias); key != "" && canonical != "" {
+		m[key] = canonical
+		if _, ok := m[genreKey(canonical)]; !ok {
+			m[genreKey(canonical)] = canonical
+		}
+	}
+}
+
+// Canonical returns the canonical name for a genre in any known spelling.
+// Unknown genres are returned title-cased, except for words given all in
+// capitals, such as "EBM", which are kept as they are.
+func (m GenreMap) Canonical(s string) string {
+	key := genreKey(s)
+	if key == "" {
+		return ""
+	}
+	if canonical, ok := m[key]; ok {
+		return canonical
+	}
+
+	acronyms := make(map[string]string)
+	for _, word := range strings.Fields(Fold(s)) {
+		if isUpperWord(word) {
+			acronyms[strings.ToLower(word)] = word
+		}
+	}
+
+	words := strings.Fields(key)
+	for i, word := range words {
+		if acronym, ok := acronyms[word]; ok {
+			words[i] = acronym
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToTitle(r)) + word[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// isUpperWord reports whether word has letters and they are all capitals
+func isUpperWord(word string) bool {
+	letters := false
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters = true
+		}
+	}
+	return letters
+}
+
+// Genre returns the canonical name for a genre given as shorthand, using
+// the built-in aliases, so "dnb", "D&B" and "drum n bass" all become "Drum
+// and Bass". Unknown genres are returned title-cased.
+func Genre(s string) string {
+	return defaultGenres.Canonical(s)
+}
+
+// Similarity scores how alike two strings are, from 0 (nothing in common)
+// to 1 (equal as MatchKeys), using edit distance relative to the longer
+// string
+func Similarity(a, b string) float64 {
+	ra := []rune(MatchKey(a))
+	rb := []rune(MatchKey(b))
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
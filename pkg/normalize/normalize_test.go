@@ -0,0 +1,223 @@
+// pkg/normalize/normalize_test.go
+
+package normalize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	testCases := map[string]string{
+		"Don’t Stop":            "Don't Stop",
+		"“Quoted”":              `"Quoted"`,
+		"Ame\u0301lie":          "Am\u00e9lie",
+		"  Inner   City  Life ": "Inner City Life",
+	}
+
+	for input, expected := range testCases {
+		if got := Fold(input); got != expected {
+			t.Errorf("Fold(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	testCases := map[string]string{
+		"LTJ Bukem":                 "ltj bukem",
+		"ltj  bukem":                "ltj bukem",
+		"L.T.J. Bukem":              "ltj bukem",
+		"Amélie":                    "amelie",
+		"Ame\u0301lie":              "amelie",
+		"Björk":                     "bjork",
+		"Don’t Stop":                "dont stop",
+		"Inner City Life (VIP Mix)": "inner city life vip mix",
+		"Photek/Goldie":             "photek goldie",
+		"Roni Size & Reprazent":     "roni size reprazent",
+		"Jay-Z":                     "jay z",
+		"!!!":                       "!!!",
+		"  ":                        "",
+	}
+
+	for input, expected := range testCases {
+		if got := MatchKey(input); got != expected {
+			t.Errorf("MatchKey(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestDelimiterDashes(t *testing.T) {
+	testCases := map[string]string{
+		"Goldie — Inner City Life":   "Goldie - Inner City Life",
+		"Goldie_–_Inner_City_Life":   "Goldie - Inner_City_Life",
+		"Goldie - Timeless—Part Two": "Goldie - Timeless—Part Two",
+	}
+
+	for input, expected := range testCases {
+		if got := DelimiterDashes(input); got != expected {
+			t.Errorf("DelimiterDashes(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestStripArtistThe(t *testing.T) {
+	testCases := map[string]string{
+		"The Prodigy":  "Prodigy",
+		"Prodigy, The": "Prodigy",
+		"the orb":      "orb",
+		"Theo Parrish": "Theo Parrish",
+		"The":          "The",
+		"The The":      "The",
+	}
+
+	for input, expected := range testCases {
+		if got := StripArtistThe(input); got != expected {
+			t.Errorf("StripArtistThe(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	testCases := []struct {
+		input      string
+		core       string
+		qualifiers []string
+	}{
+		{"Inner City Life", "Inner City Life", nil},
+		{"Inner City Life (Original Mix)", "Inner City Life", []string{"Original Mix"}},
+		{"Inner City Life (Roni Size Remix) [Remastered]", "Inner City Life", []string{"Roni Size Remix", "Remastered"}},
+		{"State Of Mind feat. Diane Charlemagne", "State Of Mind", []string{"feat. Diane Charlemagne"}},
+		{"Music (feat. MC Conrad) [VIP]", "Music", []string{"feat. MC Conrad", "VIP"}},
+		{"(Untitled)", "(Untitled)", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			core, qualifiers := CleanTitle(tc.input)
+			if core != tc.core {
+				t.Errorf("Expected core %q, got %q", tc.core, core)
+			}
+			if !reflect.DeepEqual(qualifiers, tc.qualifiers) {
+				t.Errorf("Expected qualifiers %q, got %q", tc.qualifiers, qualifiers)
+			}
+		})
+	}
+}
+
+func TestSplitFeatured(t *testing.T) {
+	testCases := []struct {
+		input    string
+		title    string
+		featured []string
+	}{
+		{"Inner City Life", "Inner City Life", nil},
+		{"Inner City Life (Original Mix)", "Inner City Life (Original Mix)", nil},
+		{"State Of Mind feat. Diane Charlemagne", "State Of Mind", []string{"Diane Charlemagne"}},
+		{"Watercolour (ft. Kirsty Hawkshaw)", "Watercolour", []string{"Kirsty Hawkshaw"}},
+		{"Blind Faith (featuring Liz Horsman, Tali & Dynamite MC)", "Blind Faith", []string{"Liz Horsman", "Tali", "Dynamite MC"}},
+		{"Music (feat. MC Conrad) [VIP]", "Music (VIP)", []string{"MC Conrad"}},
+		{"Featherweight", "Featherweight", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			title, featured := SplitFeatured(tc.input)
+			if title != tc.title {
+				t.Errorf("Expected title %q, got %q", tc.title, title)
+			}
+			if !reflect.DeepEqual(featured, tc.featured) {
+				t.Errorf("Expected featured %q, got %q", tc.featured, featured)
+			}
+		})
+	}
+}
+
+func TestEscapeLucene(t *testing.T) {
+	testCases := map[string]string{
+		"Inner City Life":      "Inner City Life",
+		`12" Edit`:             `12\" Edit`,
+		"Who? (VIP)":           `Who\? \(VIP\)`,
+		"AC/DC":                `AC\/DC`,
+		`back\slash`:           `back\\slash`,
+		"Drum & Bass: Vol. 1!": `Drum \& Bass\: Vol. 1\!`,
+	}
+
+	for input, expected := range testCases {
+		if got := EscapeLucene(input); got != expected {
+			t.Errorf("EscapeLucene(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestGenre(t *testing.T) {
+	testCases := map[string]string{
+		"dnb":          "Drum and Bass",
+		"DnB":          "Drum and Bass",
+		"d&b":          "Drum and Bass",
+		"D'n'B":        "Drum and Bass",
+		"drum & bass":  "Drum and Bass",
+		"drum n bass":  "Drum and Bass",
+		"breaks":       "Breakbeat",
+		"ukg":          "UK Garage",
+		"minimal wave": "Minimal Wave",
+		"":             "",
+	}
+
+	for input, expected := range testCases {
+		if got := Genre(input); got != expected {
+			t.Errorf("Genre(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"Inner City Life", "inner city life", 1, 1},
+		{"L.T.J. Bukem", "LTJ Bukem", 1, 1},
+		{"Inner City Life", "Inner City Lfie", 0.8, 0.9},
+		{"Inner City Life", "Terminator", 0, 0.3},
+		{"", "", 1, 1},
+		{"Goldie", "", 0, 0},
+	}
+
+	for _, tc := range testCases {
+		if got := Similarity(tc.a, tc.b); got < tc.min || got > tc.max {
+			t.Errorf("Similarity(%q, %q) = %.2f, expected between %.2f and %.2f", tc.a, tc.b, got, tc.min, tc.max)
+		}
+	}
+}
+
+func TestGenreMap(t *testing.T) {
+	m := NewGenreMap(map[string]string{
+		"jungle":    "Drum and Bass",
+		"liquid":    "Liquid DnB",
+		"Drum&Bass": "Drum & Bass",
+	})
+
+	testCases := map[string]string{
+		"jungle":       "Drum and Bass",
+		"Liquid":       "Liquid DnB",
+		"liquid dnb":   "Liquid DnB",
+		"drum n bass":  "Drum & Bass",
+		"DnB":          "Drum & Bass",
+		"Drum & Bass":  "Drum & Bass",
+		"breaks":       "Breakbeat",
+		"neurofunk":    "Neurofunk",
+		"électronique": "Électronique",
+		"EBM":          "EBM",
+		"dark EBM":     "Dark EBM",
+	}
+
+	for input, expected := range testCases {
+		if got := m.Canonical(input); got != expected {
+			t.Errorf("Canonical(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+
+	if got := Genre("jungle"); got != "Jungle" {
+		t.Errorf("Expected custom aliases not to affect Genre, got %q", got)
+	}
+}
@@ -0,0 +1,189 @@
+// pkg/normalize/qualifier.go - Classifying bracketed title qualifiers
+
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+// QualifierKind is what a bracketed title qualifier appears to hold
+type QualifierKind int
+
+const (
+	// QualifierUnknown is left in the title
+	QualifierUnknown QualifierKind = iota
+	// QualifierMix names a version of the track ("Roni Size Remix", "VIP")
+	// and belongs to the title
+	QualifierMix
+	// QualifierFeature credits a guest artist ("feat. Diane Charlemagne")
+	QualifierFeature
+	// QualifierYear is a release year ("1995")
+	QualifierYear
+	// QualifierCatalog is a catalog number ("FX240")
+	QualifierCatalog
+	// QualifierLabel is a record label ("Metalheadz Records")
+	QualifierLabel
+	// QualifierAlbum is taken to be the release the track appeared on
+	QualifierAlbum
+)
+
+// String returns the kind's name as used in output
+func (k QualifierKind) String() string {
+	switch k {
+	case QualifierMix:
+		return "mix"
+	case QualifierFeature:
+		return "feature"
+	case QualifierYear:
+		return "year"
+	case QualifierCatalog:
+		return "catalog number"
+	case QualifierLabel:
+		return "label"
+	case QualifierAlbum:
+		return "album"
+	default:
+		return "unknown"
+	}
+}
+
+// QualifierRules tunes ClassifyQualifier
+type QualifierRules struct {
+	// Labels are names always classified as labels, compared ignoring case
+	Labels []string
+
+	// MaxLabelWords caps how many words a qualifier ending in a label word
+	// such as "Records" may have and still count as a label
+	MaxLabelWords int
+
+	// MinAlbumLength is the fewest characters a qualifier needs to be taken
+	// as an album; shorter unrecognised ones stay unknown. 0 disables album
+	// classification.
+	MinAlbumLength int
+
+	// MaxAlbumWords caps how many words an album may have; longer text is
+	// more likely a comment than a release title
+	MaxAlbumWords int
+}
+
+// DefaultQualifierRules returns the rules used unless configured otherwise
+func DefaultQualifierRules() QualifierRules {
+	return QualifierRules{MaxLabelWords: 4, MinAlbumLength: 4, MaxAlbumWords: 6}
+}
+
+var (
+	// mixWords mark a qualifier as a version of the track
+	mixWords = map[string]bool{
+		"remix": true, "mix": true, "vip": true, "edit": true, "dub": true,
+		"version": true, "rework": true, "refix": true, "bootleg": true,
+		"flip": true, "instrumental": true, "remaster": true, "remastered": true,
+		"extended": true, "radio": true, "original": true, "live": true,
+		"acoustic": true, "remake": true, "rmx": true, "re-edit": true,
+	}
+
+	// labelWords end a label name, as in "FFRR Records"
+	labelWords = map[string]bool{
+		"records": true, "recordings": true, "recs": true, "music": true,
+		"audio": true, "label": true, "ltd": true, "ltd.": true,
+		"limited": true, "productions": true,
+	}
+
+	qualifierYearPattern    = regexp.MustCompile(`^(19|20)\d{2}$`)
+	qualifierCatalogPattern = regexp.MustCompile(`^[A-Z]{2,8}[ -]?\d{1,5}[A-Z]?$`)
+	qualifierFeaturePattern = regexp.MustCompile(`(?i)^(feat\.?|ft\.?|featuring)\s`)
+)
+
+// ClassifyQualifier guesses what a bracketed qualifier such as one returned
+// by CleanTitle holds. Versions and guest credits are checked first, so
+// "Metalheadz Dub" is a mix, not a label.
+func ClassifyQualifier(q string, rules QualifierRules) QualifierKind {
+	q = Fold(q)
+	if q == "" {
+		return QualifierUnknown
+	}
+
+	words := strings.Fields(strings.ToLower(q))
+	switch {
+	case qualifierFeaturePattern.MatchString(q):
+		return QualifierFeature
+	case containsAny(words, mixWords):
+		return QualifierMix
+	case qualifierYearPattern.MatchString(q):
+		return QualifierYear
+	case qualifierCatalogPattern.MatchString(q):
+		return QualifierCatalog
+	case isKnownLabel(q, rules.Labels):
+		return QualifierLabel
+	case labelWords[words[len(words)-1]] && len(words) > 1 && len(words) <= rules.MaxLabelWords:
+		return QualifierLabel
+	case rules.MinAlbumLength > 0 && len(q) >= rules.MinAlbumLength && (rules.MaxAlbumWords <= 0 || len(words) <= rules.MaxAlbumWords):
+		return QualifierAlbum
+	}
+	return QualifierUnknown
+}
+
+// containsAny reports whether any of words is in set
+func containsAny(words []string, set map[string]bool) bool {
+	for _, word := range words {
+		if set[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnownLabel reports whether q is one of labels, ignoring case
+func isKnownLabel(q string, labels []string) bool {
+	for _, label := range labels {
+		if strings.EqualFold(q, Fold(label)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mixStyleWords describe a mix rather than name who made it, as in "Full
+// Vocal Mix"
+var mixStyleWords = map[string]bool{
+	"full": true, "vocal": true, "club": true, "short": true, "long": true,
+	"main": true, "album": true, "single": true, "clean": true, "dirty": true,
+	"alternate": true, "alternative": true, "special": true,
+}
+
+// MixDescriptor returns the first bracketed qualifier of title that names a
+// version of the track, such as "Roni Size Remix", or "" if there is none
+func MixDescriptor(title string) string {
+	_, qualifiers := CleanTitle(title)
+	rules := DefaultQualifierRules()
+	for _, q := range qualifiers {
+		if ClassifyQualifier(q, rules) == QualifierMix {
+			return q
+		}
+	}
+	return ""
+}
+
+// Remixer returns who a mix descriptor credits: "Roni Size" for "Roni Size
+// Remix", "Roni Size's Full Vocal Mix" or "Remix by Roni Size". Descriptors
+// that name no one, such as "Original Mix", "Radio Edit" or "VIP", give "".
+func Remixer(descriptor string) string {
+	words := strings.Fields(Fold(descriptor))
+	for i, word := range words {
+		if i > 0 && i+1 < len(words) && strings.EqualFold(word, "by") {
+			return strings.Join(words[i+1:], " ")
+		}
+	}
+
+	name := words
+	for i, word := range words {
+		if mixWords[strings.ToLower(word)] {
+			name = words[:i]
+			break
+		}
+	}
+	for len(name) > 0 && mixStyleWords[strings.ToLower(name[len(name)-1])] {
+		name = name[:len(name)-1]
+	}
+	return strings.TrimSuffix(strings.Join(name, " "), "'s")
+}
@@ -0,0 +1,67 @@
+// pkg/normalize/qualifier_test.go
+
+package normalize
+
+import "testing"
+
+func TestClassifyQualifier(t *testing.T) {
+	rules := DefaultQualifierRules()
+	rules.Labels = []string{"FFRR"}
+
+	testCases := []struct {
+		qualifier string
+		expected  QualifierKind
+	}{
+		{"Roni Size Remix", QualifierMix},
+		{"VIP", QualifierMix},
+		{"Original Mix", QualifierMix},
+		{"Metalheadz Dub", QualifierMix},
+		{"feat. Diane Charlemagne", QualifierFeature},
+		{"1995", QualifierYear},
+		{"FX240", QualifierCatalog},
+		{"METH 001", QualifierCatalog},
+		{"ffrr", QualifierLabel},
+		{"Moving Shadow Records", QualifierLabel},
+		{"Records", QualifierAlbum},                       // A lone label word isn't a label
+		{"The Long Name Of Some Records", QualifierAlbum}, // Too many words for a label
+		{"Timeless", QualifierAlbum},
+		{"Bad", QualifierUnknown},                                       // Shorter than MinAlbumLength
+		{"a note someone left about this track once", QualifierUnknown}, // Too many words for an album
+		{"", QualifierUnknown},
+	}
+
+	for _, tc := range testCases {
+		if got := ClassifyQualifier(tc.qualifier, rules); got != tc.expected {
+			t.Errorf("ClassifyQualifier(%q) = %v, expected %v", tc.qualifier, got, tc.expected)
+		}
+	}
+
+	// Album classification can be turned off
+	rules.MinAlbumLength = 0
+	if got := ClassifyQualifier("Timeless", rules); got != QualifierUnknown {
+		t.Errorf("Expected no album with MinAlbumLength 0, got %v", got)
+	}
+}
+
+func TestRemixer(t *testing.T) {
+	testCases := []struct {
+		title    string
+		expected string
+	}{
+		{"Inner City Life (Roni Size Remix)", "Roni Size"},
+		{"Inner City Life (Roni Size's Full Vocal Mix) [Remastered]", "Roni Size"},
+		{"Inner City Life [Remix by Roni Size]", "Roni Size"},
+		{"Super Sharp Shooter (Dillinja VIP)", "Dillinja"},
+		{"Music (Original Mix)", ""},
+		{"Music (Radio Edit)", ""},
+		{"Music (VIP)", ""},
+		{"Music (Timeless)", ""}, // Not a mix
+		{"Music", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := Remixer(MixDescriptor(tc.title)); got != tc.expected {
+			t.Errorf("Remixer(MixDescriptor(%q)) = %q, expected %q", tc.title, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// pkg/tagger/enrich.go - Enriching a single file
+
+// Package tagger reads, looks up and tags individual audio files without
+// the command line around it, for programs that embed the enrichment:
+//
+//	provider := musicbrainz.NewMusicBrainzProvider(musicbrainz.WithUserAgent("my-app/1.0 (me@example.com)"))
+//	e := enricher.NewEnricher([]enricher.MetadataProvider{provider}, nil)
+//	result, err := tagger.EnrichFile(ctx, path, tagger.Options{Enricher: e, DryRun: true})
+package tagger
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cerberussg/tagger/pkg/audiotag"
+	"github.com/cerberussg/tagger/pkg/enricher"
+)
+
+var (
+	// ErrNoTrackInfo means neither the tags nor the filename gave an
+	// artist and title to look up
+	ErrNoTrackInfo = errors.New("no artist and title in tags or filename")
+
+	// ErrLowConfidence means a match was found but fell below
+	// Options.MinConfidence, so nothing was written
+	ErrLowConfidence = errors.New("match below minimum confidence")
+
+	// ErrNoEnricher means Options.Enricher was not set
+	ErrNoEnricher = errors.New("no enricher configured")
+)
+
+// Fields that Options.Overwrite can name
+const (
+	FieldLabel   = "label"
+	FieldCatalog = "catalog"
+	FieldAlbum   = "album"
+	FieldYear    = "year"
+	FieldGenre   = "genre"
+)
+
+// Fields lists every field Options.Overwrite can name
+var Fields = []string{FieldLabel, FieldCatalog, FieldAlbum, FieldYear, FieldGenre}
+
+// Options controls EnrichFile
+type Options struct {
+	// Enricher looks the track up; required
+	Enricher *enricher.Enricher
+
+	// Parser reads artist and title from filenames of untagged files; nil
+	// uses the built-in rules
+	Parser *FilenameParser
+
+	// Prepare, if set, may adjust the search request before the lookup,
+	// e.g. to add a label hint or a preferred release format
+	Prepare func(req *enricher.SearchRequest)
+
+	// MinConfidence is the confidence a match needs to be written. Zero
+	// writes any match the enricher returns.
+	MinConfidence float64
+
+	// DryRun looks the track up and builds the update without writing it
+	DryRun bool
+
+	// OverwriteGenre replaces an existing genre tag; by default only
+	// missing fields are filled
+	OverwriteGenre bool
+
+	// AppendGenre adds the match's genre to the file's existing genres
+	// rather than replacing them, e.g. a subgenre after a broad genre. An
+	// existing genre then no longer keeps the match's from being written.
+	AppendGenre bool
+
+	// Overwrite names the fields (FieldLabel, FieldCatalog, ...) a match
+	// replaces even when the file already has them; the rest are only
+	// filled when empty
+	Overwrite []string
+
+	// LabelOnly writes nothing but label and catalog number
+	LabelOnly bool
+
+	// Genre picks the genre to write for a match, e.g. to map it to a
+	// canonical name; nil writes the provider's as it is
+	Genre func(match *enricher.TrackMetadata) string
+}
+
+// FileResult is the outcome of EnrichFile
+type FileResult struct {
+	Path string
+
+	// Info is what the file said before the lookup
+	Info *TrackInfo
+
+	// Match is the metadata found, if any, even when it wasn't written
+	Match *enricher.TrackMetadata
+
+	// Update is the change made to the file's tags, or that would be made
+	// with DryRun. It is nil when there was nothing to write.
+	Update *audiotag.Update
+
+	// Written is set when Update was applied to the file
+	Written bool
+}
+
+// EnrichFile reads the file at path, looks it up and fills in the tags it
+// is missing. The result is returned along with any error, so a caller can
+// see what was read or matched before things went wrong; lookup errors
+// wrap the enricher's, e.g. enricher.ErrNotFound.
+func EnrichFile(ctx context.Context, path string, opts Options) (*FileResult, error) {
+	if opts.Enricher == nil {
+		return nil, ErrNoEnricher
+	}
+
+	result := &FileResult{Path: path}
+	info, err := ReadTrackInfo(path, opts.Parser)
+	if err != nil {
+		return result, err
+	}
+	result.Info = info
+	if info.Artist == "" || info.Title == "" {
+		return result, ErrNoTrackInfo
+	}
+
+	req := NewSearchRequest(path, info)
+	if opts.Prepare != nil {
+		opts.Prepare(req)
+	}
+	match, err := opts.Enricher.LookupWithRequest(ctx, req)
+	if err != nil {
+		return result, err
+	}
+	result.Match = match
+	if match.Confidence < opts.MinConfidence {
+		return result, ErrLowConfidence
+	}
+
+	update := BuildUpdate(info, match, opts)
+	if update.IsEmpty() {
+		return result, nil
+	}
+	result.Update = update
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := audiotag.WriteFile(path, update); err != nil {
+		return result, err
+	}
+	result.Written = true
+	return result, nil
+}
+
+// overwrites reports whether field is replaced when the file already has it
+func (o Options) overwrites(field string) bool {
+	if field == FieldGenre && o.OverwriteGenre {
+		return true
+	}
+	for _, f := range o.Overwrite {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGenre reports whether genre is already among the values of a genre
+// tag as read, which are "/"-joined in ID3v2.3
+func hasGenre(tagged, genre string) bool {
+	for _, value := range strings.Split(tagged, "/") {
+		if strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(genre)) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildUpdate maps a match onto tag frames, filling only the fields the
+// file doesn't already have, or replacing those named in Options.Overwrite
+// when the match differs.
+func BuildUpdate(info *TrackInfo, match *enricher.TrackMetadata, opts Options) *audiotag.Update {
+	update := &audiotag.Update{}
+	if info.CatalogNumber == "" || (opts.overwrites(FieldCatalog) && match.CatalogNumber != info.CatalogNumber) {
+		update.CatalogNumber = match.CatalogNumber
+	}
+	if info.Label == "" || (opts.overwrites(FieldLabel) && match.Label != info.Label) {
+		update.Label = match.Label
+	}
+	if opts.LabelOnly {
+		return update
+	}
+	if info.Album == "" || (opts.overwrites(FieldAlbum) && match.Album != info.Album) {
+		update.Album = match.Album
+	}
+	if info.Year == 0 || (opts.overwrites(FieldYear) && match.Year != info.Year) {
+		update.Year = match.Year
+	}
+	// Curated genres are never replaced unless explicitly requested
+	if info.Genre == "" || opts.overwrites(FieldGenre) || opts.AppendGenre {
+		update.Genre = match.Genre
+		if opts.Genre != nil {
+			update.Genre = opts.Genre(match)
+		}
+		if opts.AppendGenre && hasGenre(info.Genre, update.Genre) {
+			update.Genre = ""
+		}
+		update.AppendGenre = opts.AppendGenre && update.Genre != ""
+	}
+	// Storing the recording ID lets later runs skip the search
+	if id, _ := match.Extra["musicbrainz_recording_id"].(string); id != info.RecordingID {
+		update.RecordingID = id
+	}
+	return update
+}
@@ -0,0 +1,155 @@
+// pkg/tagger/enrich_test.go
+
+package tagger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cerberussg/tagger/pkg/audiotag"
+	"github.com/cerberussg/tagger/pkg/enricher"
+)
+
+// writeUntaggedAIFF writes a minimal AIFF with no tags: a FORM holding only
+// an 18-byte COMM chunk
+func writeUntaggedAIFF(t *testing.T, name string) string {
+	t.Helper()
+	aiff := append([]byte("FORM\x00\x00\x00\x1eAIFFCOMM\x00\x00\x00\x12"), make([]byte, 18)...)
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, aiff, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newFakeEnricher(responses ...enricher.FakeResponse) (*enricher.Enricher, *enricher.FakeProvider) {
+	provider := enricher.NewFakeProvider("Fake", responses...)
+	return enricher.NewEnricher([]enricher.MetadataProvider{provider}, nil), provider
+}
+
+func TestEnrichFile_WritesMissingTags(t *testing.T) {
+	path := writeUntaggedAIFF(t, "[METH 001] Goldie - Inner City Life.aiff")
+	e, provider := newFakeEnricher(enricher.FakeResponse{Result: &enricher.TrackMetadata{
+		Label:         "FFRR",
+		CatalogNumber: "METH 001",
+		Year:          1994,
+		Confidence:    0.9,
+	}})
+
+	result, err := EnrichFile(context.Background(), path, Options{Enricher: e, MinConfidence: 0.5})
+	if err != nil {
+		t.Fatalf("EnrichFile returned error: %v", err)
+	}
+	if !result.Info.FromFilename || result.Info.Artist != "Goldie" || result.Info.Title != "Inner City Life" {
+		t.Errorf("Expected artist and title parsed from the filename, got %+v", result.Info)
+	}
+	if !result.Written || result.Update == nil || result.Update.Label != "FFRR" {
+		t.Fatalf("Expected the label written, got %+v", result)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 1 || requests[0].CatalogNumber != "METH 001" {
+		t.Errorf("Expected one lookup hinted with the filename's catalog number, got %+v", requests)
+	}
+
+	metadata, err := audiotag.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read tagged file: %v", err)
+	}
+	if got := audiotag.Label(metadata); got != "FFRR" {
+		t.Errorf("Expected label FFRR in the file, got %q", got)
+	}
+}
+
+func TestEnrichFile_DryRun(t *testing.T) {
+	path := writeUntaggedAIFF(t, "Goldie - Angel.aiff")
+	e, _ := newFakeEnricher(enricher.FakeResponse{Result: &enricher.TrackMetadata{Label: "FFRR", Confidence: 0.9}})
+
+	result, err := EnrichFile(context.Background(), path, Options{Enricher: e, DryRun: true})
+	if err != nil {
+		t.Fatalf("EnrichFile returned error: %v", err)
+	}
+	if result.Written || result.Update == nil || result.Update.Label != "FFRR" {
+		t.Errorf("Expected an unwritten update with the label, got %+v", result)
+	}
+	if metadata, err := audiotag.ReadFile(path); err == nil && audiotag.Label(metadata) != "" {
+		t.Errorf("Expected nothing written on a dry run, got label %q", audiotag.Label(metadata))
+	}
+}
+
+func TestEnrichFile_Errors(t *testing.T) {
+	path := writeUntaggedAIFF(t, "Goldie - Angel.aiff")
+
+	if _, err := EnrichFile(context.Background(), path, Options{}); !errors.Is(err, ErrNoEnricher) {
+		t.Errorf("Expected ErrNoEnricher, got %v", err)
+	}
+
+	e, _ := newFakeEnricher(enricher.FakeResponse{Result: &enricher.TrackMetadata{Label: "FFRR", Confidence: 0.75}})
+	result, err := EnrichFile(context.Background(), path, Options{Enricher: e, MinConfidence: 0.8})
+	if !errors.Is(err, ErrLowConfidence) || result.Match == nil || result.Written {
+		t.Errorf("Expected ErrLowConfidence with the match reported, got %v / %+v", err, result)
+	}
+
+	e, _ = newFakeEnricher()
+	if _, err := EnrichFile(context.Background(), path, Options{Enricher: e}); !errors.Is(err, enricher.ErrNotFound) {
+		t.Errorf("Expected enricher.ErrNotFound, got %v", err)
+	}
+
+	unnamed := writeUntaggedAIFF(t, "untitled.aiff")
+	if _, err := EnrichFile(context.Background(), unnamed, Options{Enricher: e}); !errors.Is(err, ErrNoTrackInfo) {
+		t.Errorf("Expected ErrNoTrackInfo, got %v", err)
+	}
+}
+
+func TestBuildUpdate_Overwrite(t *testing.T) {
+	info := &TrackInfo{Label: "Reinforced", CatalogNumber: "RIVET 1", Album: "Promo", Year: 2010, Genre: "Jungle"}
+	match := &enricher.TrackMetadata{Label: "FFRR", CatalogNumber: "FX 240", Album: "Timeless", Year: 1995, Genre: "Drum and Bass"}
+
+	testCases := []struct {
+		name      string
+		overwrite []string
+		expected  audiotag.Update
+	}{
+		{"fills only empty fields by default", nil, audiotag.Update{}},
+		{"replaces named fields", []string{FieldLabel, FieldCatalog}, audiotag.Update{Label: "FFRR", CatalogNumber: "FX 240"}},
+		{"year and genre", []string{FieldYear, FieldGenre}, audiotag.Update{Year: 1995, Genre: "Drum and Bass"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			update := BuildUpdate(info, match, Options{Overwrite: tc.overwrite})
+			if *update != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, *update)
+			}
+		})
+	}
+
+	// Matching values aren't rewritten
+	same := &enricher.TrackMetadata{Label: "Reinforced", CatalogNumber: "RIVET 1"}
+	if update := BuildUpdate(info, same, Options{Overwrite: []string{FieldLabel, FieldCatalog}}); !update.IsEmpty() {
+		t.Errorf("Expected nothing to write when the match agrees, got %+v", *update)
+	}
+
+	// A missing catalog number is still filled
+	if update := BuildUpdate(&TrackInfo{Label: "FFRR"}, match, Options{}); update.CatalogNumber != "FX 240" || update.Label != "" {
+		t.Errorf("Expected only the empty catalog number filled, got %+v", *update)
+	}
+}
+
+func TestBuildUpdate_AppendGenre(t *testing.T) {
+	match := &enricher.TrackMetadata{Genre: "Jungle"}
+
+	update := BuildUpdate(&TrackInfo{Genre: "Drum and Bass"}, match, Options{AppendGenre: true})
+	if update.Genre != "Jungle" || !update.AppendGenre {
+		t.Errorf("Expected Jungle appended to the existing genre, got %+v", *update)
+	}
+	if update := BuildUpdate(&TrackInfo{Genre: "Drum and Bass/jungle"}, match, Options{AppendGenre: true}); !update.IsEmpty() {
+		t.Errorf("Expected nothing to write when the genre is already there, got %+v", *update)
+	}
+	if update := BuildUpdate(&TrackInfo{Genre: "Drum and Bass"}, match, Options{}); update.Genre != "" {
+		t.Errorf("Expected the existing genre kept by default, got %+v", *update)
+	}
+}
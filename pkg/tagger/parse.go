@@ -0,0 +1,338 @@
+// pkg/tagger/parse.go - Filename parsing
+
+package tagger
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cerberussg/tagger/pkg/normalize"
+)
+
+// Edge cases reported by FilenameParser.Parse. A parse that isn't an edge
+// case returns "".
+const (
+	EdgeNoHyphens    = "no_hyphens"
+	EdgeThreeHyphens = "three_hyphens"
+	EdgeManyHyphens  = "many_hyphens"
+	EdgeProfile      = "profile_edge_case" // Declared by a HyphenLayout
+)
+
+// FilenameParser extracts artist and title from filenames such as
+// "Goldie - Inner City Life.aiff". The zero value uses the built-in rules.
+type FilenameParser struct {
+	// Layouts override the built-in rules for the hyphen counts they name.
+	// A nil layout declares that count an edge case.
+	Layouts map[int]HyphenLayout
+
+	// Logger receives a line for each guess the parser makes; nil
+	// discards them
+	Logger *log.Logger
+}
+
+var discardLogger = log.New(io.Discard, "", 0)
+
+// logger returns p's logger, or one that discards everything
+func (p *FilenameParser) logger() *log.Logger {
+	if p == nil || p.Logger == nil {
+		return discardLogger
+	}
+	return p.Logger
+}
+
+// ParseFilename parses path with the built-in rules
+func ParseFilename(path string) (artist, title, edgeCase string) {
+	return (*FilenameParser)(nil).Parse(path)
+}
+
+// Parse extracts artist and title from path's filename. Filenames the rules
+// can't split reliably are returned with a best guess, possibly empty, and
+// the edge case they hit.
+func (p *FilenameParser) Parse(path string) (artist, title, edgeCase string) {
+	filename := filepath.Base(path)
+
+	// Remove file extension
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	// Fold Unicode variants before any hyphen counting
+	name = normalize.DelimiterDashes(normalize.Fold(name))
+
+	// Clean up common prefixes first (track numbers, etc.)
+	name = CleanTrackPrefix(name)
+
+	// Count hyphens to determine parsing strategy
+	hyphenCount := strings.Count(name, "-")
+	p.logger().Printf("Parsing filename: %s (hyphens: %d)", name, hyphenCount)
+
+	// A parse profile overrides the built-in rules for the counts it names
+	if p != nil {
+		if layout, ok := p.Layouts[hyphenCount]; ok {
+			if layout == nil {
+				return "", "", EdgeProfile
+			}
+			artist, title = layout.apply(name)
+			return artist, title, ""
+		}
+	}
+
+	switch hyphenCount {
+	case 0:
+		// No hyphens - can't reliably parse
+		return p.edgeCase(name, EdgeNoHyphens)
+	case 1:
+		// Artist - Title
+		artist, title = parseOneHyphen(name)
+	case 2:
+		// Artist - Album - Title
+		artist, title = parseTwoHyphens(name)
+	case 3:
+		// Edge case - needs manual review or special handling
+		return p.edgeCase(name, EdgeThreeHyphens)
+	case 4:
+		// Artist/Part - Album/Part - Title
+		artist, title = p.parseFourHyphens(name)
+	default:
+		// 5+ hyphens - likely very complex, needs edge case handling
+		return p.edgeCase(name, EdgeManyHyphens)
+	}
+	return artist, title, ""
+}
+
+func parseOneHyphen(name string) (artist, title string) {
+	parts := strings.SplitN(name, "-", 2)
+	if len(parts) == 2 {
+		artist = CleanFilename(strings.TrimSpace(parts[0]))
+		title = CleanFilename(strings.TrimSpace(parts[1]))
+		return artist, title
+	}
+	return "", ""
+}
+
+func parseTwoHyphens(name string) (artist, title string) {
+	parts := strings.SplitN(name, "-", 3)
+	if len(parts) == 3 {
+		artist = CleanFilename(strings.TrimSpace(parts[0]))
+		// Skip album (parts[1]) for now - we just want artist/title
+		title = CleanFilename(strings.TrimSpace(parts[2]))
+		return artist, title
+	}
+	return "", ""
+}
+
+// parseFourHyphens reads "Artist - Part - Album - Part - Title", joining
+// the two artist parts with a slash
+func (p *FilenameParser) parseFourHyphens(name string) (artist, title string) {
+	parts := strings.SplitN(name, "-", 5)
+	if len(parts) != 5 {
+		return "", ""
+	}
+
+	artist = CleanFilename(strings.TrimSpace(parts[0])) + "/" + CleanFilename(strings.TrimSpace(parts[1]))
+	title = CleanFilename(strings.TrimSpace(parts[4]))
+
+	// Skip album parts[2]/parts[3]
+	album := CleanFilename(strings.TrimSpace(parts[2])) + "/" + CleanFilename(strings.TrimSpace(parts[3]))
+	p.logger().Printf("Detected album: %s", album)
+
+	return artist, title
+}
+
+// edgeCase makes a best guess at a filename the rules can't split reliably
+func (p *FilenameParser) edgeCase(name, caseType string) (artist, title, edgeCase string) {
+	p.logger().Printf("Edge case (%s): %s", caseType, name)
+
+	// For edge cases, try some fallback strategies
+	switch caseType {
+	case EdgeNoHyphens:
+		// Maybe it's "Artist Title" with spaces?
+		artist, title = trySpaceSeparated(name)
+	case EdgeThreeHyphens:
+		// Try treating as Artist - Album - Extra - Title
+		artist, title = tryThreeHyphenFallback(name)
+		p.logger().Printf("Guessing: Artist=%s, Title=%s", artist, title)
+	case EdgeManyHyphens:
+		// Try to find the most likely artist-title split
+		artist, title = tryManyHyphenFallback(name)
+		p.logger().Printf("Best guess: Artist=%s, Title=%s", artist, title)
+	}
+
+	// Apply final cleaning to results from edge case handling
+	return CleanFilename(artist), CleanFilename(title), caseType
+}
+
+func trySpaceSeparated(name string) (artist, title string) {
+	// Look for patterns like "ArtistName SongTitle"
+	// This is tricky without more context, so be conservative
+	words := strings.Fields(name)
+	if len(words) == 2 {
+		return words[0], words[1]
+	}
+	return "", ""
+}
+
+func tryThreeHyphenFallback(name string) (artist, title string) {
+	parts := strings.SplitN(name, "-", 4)
+	if len(parts) == 4 {
+		// Artist - Album Part 1 - Album Part 2 - Title
+		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[3])
+	}
+	return "", ""
+}
+
+func tryManyHyphenFallback(name string) (artist, title string) {
+	// For 5+ hyphens, take the first part as artist and the last as title
+	parts := strings.Split(name, "-")
+	if len(parts) >= 3 {
+		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[len(parts)-1])
+	}
+	return "", ""
+}
+
+// catalogPattern matches a bracketed, upper-case catalog number such as
+// "[FX240]" or "[METH 001]" in a filename
+var catalogPattern = regexp.MustCompile(`\[([A-Z]{2,8}[ -]?\d{1,5}[A-Z]?)\]`)
+
+// CatalogFromFilename returns a bracketed catalog number from path's
+// filename, or "". Parse drops it from the artist and title.
+func CatalogFromFilename(path string) string {
+	if m := catalogPattern.FindStringSubmatch(filepath.Base(path)); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// filenameNumberPatterns match track numbering left on a parsed artist or
+// title, such as "01 Title", "A1 Title", "Title 01" or "Title (01)"
+var filenameNumberPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\d{2}\.?\s+`),     // "01 " or "01. " at start
+	regexp.MustCompile(`^\d{1,3}\.\s+`),    // "1. " at start
+	regexp.MustCompile(`^\d{1,3}\s*-\s*`),  // "01-" or "1 - " at start
+	regexp.MustCompile(`^[A-Z]\d+\s+`),     // "A1 " or "B2 " at start
+	regexp.MustCompile(`^[A-Z]\d+\s*-\s*`), // "A1-" or "B2 - " at start
+	regexp.MustCompile(`\s+\d+$`),          // " 01" at end
+	regexp.MustCompile(`\s*\(\d+\)$`),      // " (01)" at end
+	regexp.MustCompile(`\s*-\s*\d+$`),      // " - 01" at end
+}
+
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// CleanFilename tidies a parsed artist or title: it drops a bracketed
+// catalog number and track numbering, turns underscores into spaces and
+// "+" into "&", and collapses whitespace
+func CleanFilename(text string) string {
+	if text == "" {
+		return text
+	}
+
+	// Drop a bracketed catalog number; CatalogFromFilename keeps it as a hint
+	text = catalogPattern.ReplaceAllString(text, "")
+
+	// Replace underscores with spaces
+	text = strings.ReplaceAll(text, "_", " ")
+
+	// Replace + with & (common in artist collaborations)
+	text = strings.ReplaceAll(text, "+", " & ")
+
+	for _, re := range filenameNumberPatterns {
+		text = re.ReplaceAllString(text, "")
+	}
+
+	// Clean up multiple spaces and trim
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+// trackPrefixPatterns match track numbering at the start of a filename.
+// Bare numbers need two digits (or a trailing dot) so artists like
+// "4hero", "2 Bad Mice" and "808 State" survive. Hyphenated forms are
+// listed before their bare counterparts so "B2 - " isn't reduced to "- ".
+var trackPrefixPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\d{1,2}-\d{1,3}\.?\s+`), // "1-04 " disc-track
+	regexp.MustCompile(`^\(\d{1,3}\)\s*`),        // "(01) "
+	regexp.MustCompile(`^\[[A-Z]?\d{1,3}\]\s*`),  // "[A1] " or "[01] "
+	regexp.MustCompile(`^#\d{1,3}\s*[-.]?\s*`),   // "#3 " or "#3 - "
+	regexp.MustCompile(`^[IVX]{1,4}\.\s+`),       // "IV. " roman numerals
+	regexp.MustCompile(`^\d{1,3}\s*-\s*`),        // "01-" or "1 - "
+	regexp.MustCompile(`^\d{2}\.?\s+`),           // "01 " or "01. "
+	regexp.MustCompile(`^\d{1,3}\.\s+`),          // "1. "
+	regexp.MustCompile(`^[A-Z]\d+\s*-\s*`),       // "A1-" or "B2 - "
+	regexp.MustCompile(`^[A-Z]\d+\s+`),           // "A1 " or "B2 "
+}
+
+// CleanTrackPrefix removes track numbering such as "01 ", "1. ", "A1 ",
+// "(01) " or "1-04 " from the start of a filename
+func CleanTrackPrefix(name string) string {
+	// First replace underscores with spaces for better pattern matching
+	name = strings.ReplaceAll(name, "_", " ")
+
+	for _, re := range trackPrefixPatterns {
+		name = re.ReplaceAllString(name, "")
+	}
+
+	return strings.TrimSpace(name)
+}
+
+// HyphenLayout names each hyphen-separated part of a filename. Repeated
+// artist parts are joined with "/" and repeated title parts with " - ";
+// album, catno and skip parts are ignored for now.
+type HyphenLayout []string
+
+// layoutFields are the part names a layout may use
+var layoutFields = map[string]bool{"artist": true, "title": true, "album": true, "catno": true, "skip": true}
+
+// edgeLayout declares a hyphen count to be an edge case
+const edgeLayout = "edge"
+
+// ParseHyphenLayout parses a layout like "artist - album - catno - title",
+// which must have one part per hyphen plus one and name an artist and a
+// title. "edge" returns a nil layout.
+func ParseHyphenLayout(spec string, hyphens int) (HyphenLayout, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == edgeLayout {
+		return nil, nil
+	}
+
+	var layout HyphenLayout
+	hasArtist, hasTitle := false, false
+	for _, part := range strings.Split(spec, "-") {
+		part = strings.TrimSpace(part)
+		if !layoutFields[part] {
+			return nil, fmt.Errorf("unknown part %q (use artist, title, album, catno or skip)", part)
+		}
+		hasArtist = hasArtist || part == "artist"
+		hasTitle = hasTitle || part == "title"
+		layout = append(layout, part)
+	}
+
+	if len(layout) != hyphens+1 {
+		return nil, fmt.Errorf("%q has %d parts, expected %d", spec, len(layout), hyphens+1)
+	}
+	if !hasArtist || !hasTitle {
+		return nil, fmt.Errorf("%q must include artist and title", spec)
+	}
+	return layout, nil
+}
+
+// apply splits name on its hyphens and assembles artist and title
+func (l HyphenLayout) apply(name string) (artist, title string) {
+	parts := strings.Split(name, "-")
+	if len(parts) != len(l) {
+		return "", ""
+	}
+
+	var artists, titles []string
+	for i, field := range l {
+		part := CleanFilename(strings.TrimSpace(parts[i]))
+		switch field {
+		case "artist":
+			artists = append(artists, part)
+		case "title":
+			titles = append(titles, part)
+		}
+	}
+
+	return strings.Join(artists, "/"), strings.Join(titles, " - ")
+}
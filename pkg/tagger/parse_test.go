@@ -0,0 +1,115 @@
+// pkg/tagger/parse_test.go
+
+package tagger
+
+import "testing"
+
+func TestCleanTrackPrefix(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		// Existing patterns
+		{"01 Artist - Title", "Artist - Title"},
+		{"01. Artist - Title", "Artist - Title"},
+		{"1. Artist - Title", "Artist - Title"},
+		{"01-Artist - Title", "Artist - Title"},
+		{"A1 Artist - Title", "Artist - Title"},
+		{"B2 - Artist - Title", "Artist - Title"},
+		{"01_Artist_-_Title", "Artist - Title"},
+
+		// Bracketed and prefixed track numbers
+		{"(01) Artist - Title", "Artist - Title"},
+		{"[A1] Artist - Title", "Artist - Title"},
+		{"[07] Artist - Title", "Artist - Title"},
+		{"#3 Artist - Title", "Artist - Title"},
+		{"#12 - Artist - Title", "Artist - Title"},
+
+		// Disc-track numbering
+		{"1-04 Artist - Title", "Artist - Title"},
+		{"2-11. Artist - Title", "Artist - Title"},
+
+		// Roman numerals
+		{"IV. Artist - Title", "Artist - Title"},
+
+		// Leading numbers that belong to the artist
+		{"4hero - Mr Kirk's Nightmare", "4hero - Mr Kirk's Nightmare"},
+		{"2 Bad Mice - Bombscare", "2 Bad Mice - Bombscare"},
+		{"808 State - Pacific", "808 State - Pacific"},
+		{"01 4hero - Star Chasers", "4hero - Star Chasers"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := CleanTrackPrefix(tc.input); got != tc.expected {
+				t.Errorf("CleanTrackPrefix(%q) = %q, expected %q", tc.input, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseFilename_KeepsNumericArtists(t *testing.T) {
+	artist, title, _ := ParseFilename("/music/2 Bad Mice - Bombscare.aiff")
+	if artist != "2 Bad Mice" || title != "Bombscare" {
+		t.Errorf("Expected '2 Bad Mice' / 'Bombscare', got %q / %q", artist, title)
+	}
+}
+
+func TestParseFilename_UnicodeNormalization(t *testing.T) {
+	testCases := []struct {
+		name           string
+		path           string
+		expectedArtist string
+		expectedTitle  string
+	}{
+		{"em-dash delimiter", "/music/Goldie — Inner City Life.aiff", "Goldie", "Inner City Life"},
+		{"en-dash delimiter with underscores", "/music/Goldie_–_Inner_City_Life.aiff", "Goldie", "Inner City Life"},
+		{"em-dash inside title kept", "/music/Goldie - Timeless—Inner City Life.aiff", "Goldie", "Timeless—Inner City Life"},
+		{"smart quotes folded", "/music/DJ Hype - Don’t Stop.aiff", "DJ Hype", "Don't Stop"},
+		{"combining accent composed", "/music/Ame\u0301lie - Noir.aiff", "Am\u00e9lie", "Noir"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			artist, title, _ := ParseFilename(tc.path)
+			if artist != tc.expectedArtist || title != tc.expectedTitle {
+				t.Errorf("Expected %q / %q, got %q / %q", tc.expectedArtist, tc.expectedTitle, artist, title)
+			}
+		})
+	}
+}
+
+func TestParseHyphenLayout_Invalid(t *testing.T) {
+	testCases := map[string]int{
+		"artist - title - album": 1, // Wrong part count
+		"artist - album":         1, // No title
+		"artist - tune":          1, // Unknown part
+	}
+
+	for spec, hyphens := range testCases {
+		if _, err := ParseHyphenLayout(spec, hyphens); err == nil {
+			t.Errorf("Expected an error for %q with %d hyphens", spec, hyphens)
+		}
+	}
+}
+
+func TestCatalogFromFilename(t *testing.T) {
+	tests := []struct {
+		path, catalog string
+	}{
+		{"/music/[FX240] Goldie - Inner City Life.aiff", "FX240"},
+		{"/music/Goldie - Angel [METH 001].aiff", "METH 001"},
+		{"/music/[1995] Goldie - Angel.aiff", ""},
+		{"/music/Goldie - Angel [Remastered].aiff", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CatalogFromFilename(tt.path); got != tt.catalog {
+			t.Errorf("CatalogFromFilename(%q) = %q, expected %q", tt.path, got, tt.catalog)
+		}
+	}
+
+	if artist, title, _ := ParseFilename("/music/[FX240] Goldie - Inner City Life [FX240].aiff"); artist != "Goldie" || title != "Inner City Life" {
+		t.Errorf("Expected the catalog number stripped from artist and title, got %q / %q", artist, title)
+	}
+}
@@ -0,0 +1,112 @@
+// pkg/tagger/track.go - Reading what a file already says about itself
+
+package tagger
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cerberussg/tagger/pkg/audiotag"
+	"github.com/cerberussg/tagger/pkg/enricher"
+)
+
+// TrackInfo is what can be read about a file before any enrichment
+type TrackInfo struct {
+	Artist     string
+	Title      string
+	Album      string
+	Genre      string
+	Label      string
+	Year       int
+	HasArtwork bool
+
+	// CatalogNumber is the file's TXXX:CATALOGNUMBER tag; one found in the
+	// filename is only a lookup hint
+	CatalogNumber string
+
+	// EdgeCase is set when artist and title were guessed from a filename
+	// the parser couldn't split reliably (see FilenameParser.Parse)
+	EdgeCase string
+
+	// FromFilename is set when the file had no tags, so artist and title
+	// come from its name
+	FromFilename bool
+
+	// RecordingID is a MusicBrainz recording ID written by an earlier run
+	// (or Picard); it lets the lookup skip the fuzzy search
+	RecordingID string
+
+	// ISRC is the recording's code from the TSRC frame, which MusicBrainz
+	// can look up directly
+	ISRC string
+}
+
+// ReadTrackInfo reads a file's embedded tags, falling back to parsing its
+// filename with parser (nil for the built-in rules) when it has none. A
+// damaged file returns an error wrapping audiotag.ErrUnreadable rather
+// than a guess from its name.
+func ReadTrackInfo(path string, parser *FilenameParser) (*TrackInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	metadata, err := audiotag.ReadFrom(file)
+	if errors.Is(err, audiotag.ErrUnreadable) {
+		return nil, err
+	}
+	if err != nil {
+		// No embedded tags - try filename parsing
+		parser.logger().Printf("No embedded tags found - parsing filename")
+		info := &TrackInfo{FromFilename: true}
+		info.Artist, info.Title, info.EdgeCase = parser.Parse(path)
+		return info, nil
+	}
+
+	info := &TrackInfo{
+		Title:       strings.TrimSpace(metadata.Title()),
+		Artist:      strings.TrimSpace(metadata.Artist()),
+		Album:       strings.TrimSpace(metadata.Album()),
+		Genre:       strings.TrimSpace(metadata.Genre()),
+		Year:        metadata.Year(),
+		HasArtwork:  metadata.Picture() != nil,
+		RecordingID: audiotag.RecordingID(metadata),
+		ISRC:        audiotag.ISRC(metadata),
+	}
+
+	// Check for label info: TPUB, falling back to a user-defined LABEL frame
+	info.Label = audiotag.Label(metadata)
+	if info.Label == "" {
+		info.Label = audiotag.UserText(metadata, "LABEL")
+	}
+	info.CatalogNumber = audiotag.UserText(metadata, audiotag.CatalogNumberDescription)
+	return info, nil
+}
+
+// NewSearchRequest builds the lookup for a file from what it already says:
+// artist and title, album and year to pick the right release, the catalog
+// number in its name, its recording ID and ISRC, and its length
+func NewSearchRequest(path string, info *TrackInfo) *enricher.SearchRequest {
+	req := &enricher.SearchRequest{
+		Artist:                info.Artist,
+		Title:                 info.Title,
+		Album:                 info.Album,
+		PreferOriginalRelease: true,
+		MaxResults:            5,
+		RecordingID:           info.RecordingID,
+		ISRC:                  info.ISRC,
+		CatalogNumber:         CatalogFromFilename(path),
+	}
+	if info.Year > 0 {
+		req.Year = strconv.Itoa(info.Year)
+	}
+
+	// Duration only disambiguates, so files without one are looked up anyway
+	if duration, err := audiotag.Duration(path); err == nil {
+		req.Duration = duration
+	}
+	return req
+}